@@ -2,10 +2,17 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,19 +24,23 @@ func fetchWhoAmI(token string) (user, error) {
 }
 
 func fetchProjects(token string) ([]project, error) {
-	var payload projectsResponse
-	err := doJSONRequest(http.MethodGet, apiHost+"/api/v1/projects", token, nil, &payload)
-	return payload.Projects, err
+	return fetchProjectsWithOrg(token, "")
 }
 
+// fetchProjectsWithOrg fetches every page of the projects listing.
 func fetchProjectsWithOrg(token, orgID string) ([]project, error) {
-	var payload projectsResponse
+	projects, _, err := fetchProjectPages(token, orgID, "", 0)
+	return projects, err
+}
+
+// fetchProjectPages fetches up to limit pages of projects from the page
+// query selects; see fetchPages.
+func fetchProjectPages(token, orgID, query string, limit int) ([]project, string, error) {
 	requestURL := apiHost + "/api/v1/projects"
 	if orgID != "" {
 		requestURL += "?organizationId=" + url.QueryEscape(orgID)
 	}
-	err := doJSONRequest(http.MethodGet, requestURL, token, nil, &payload)
-	return payload.Projects, err
+	return fetchPages[project](token, requestURL, query, limit)
 }
 
 func fetchRegions(token string) ([]region, error) {
@@ -56,6 +67,9 @@ func createProjectContainer(
 		req,
 		&payload,
 	)
+	if err == nil {
+		recordAudit("container.create", stringValue(payload["id"]), "project="+projectID)
+	}
 	return payload, err
 }
 
@@ -71,17 +85,24 @@ func patchProjectContainerConfig(
 		req,
 		&payload,
 	)
+	if err == nil {
+		recordAudit("container.update", containerID, "project="+projectID+" fields="+auditFields(req))
+	}
 	return payload, err
 }
 
 func removeProjectContainer(token, projectID, containerID string) error {
-	return doJSONRequest(
+	err := doJSONRequest(
 		http.MethodPost,
 		apiHost+"/api/v1/projects/"+projectID+"/containers/"+containerID+"/remove",
 		token,
 		map[string]any{},
 		nil,
 	)
+	if err == nil {
+		recordAudit("container.remove", containerID, "project="+projectID)
+	}
+	return err
 }
 
 func createProjectVolume(
@@ -96,17 +117,24 @@ func createProjectVolume(
 		req,
 		&payload,
 	)
+	if err == nil {
+		recordAudit("volume.create", stringValue(payload["id"]), "project="+projectID)
+	}
 	return payload, err
 }
 
 func removeProjectVolume(token, projectID, volumeID string) error {
-	return doJSONRequest(
+	err := doJSONRequest(
 		http.MethodPost,
 		apiHost+"/api/v1/projects/"+projectID+"/volumes/"+volumeID+"/remove",
 		token,
 		map[string]any{},
 		nil,
 	)
+	if err == nil {
+		recordAudit("volume.remove", volumeID, "project="+projectID)
+	}
+	return err
 }
 
 func createProjectForDeploy(token, name, regionID, orgID string) (project, error) {
@@ -119,24 +147,40 @@ func createProjectForDeploy(token, name, regionID, orgID string) (project, error
 		body["organizationId"] = orgID
 	}
 	err := doJSONRequest(http.MethodPost, apiHost+"/api/v1/projects/create", token, body, &payload)
+	if err == nil {
+		recordAudit("project.create", payload.ID, "name="+name+" region="+regionID)
+	}
 	return payload, err
 }
 
 func fetchTunnels(token, projectID string) ([]tunnel, error) {
-	var payload []tunnel
-	err := doJSONRequest(http.MethodGet, apiHost+"/api/v1/projects/"+projectID+"/tunnels", token, nil, &payload)
-	return payload, err
+	tunnels, _, err := fetchPages[tunnel](token, apiHost+"/api/v1/projects/"+projectID+"/tunnels", "", 0)
+	return tunnels, err
 }
 
 func createTunnel(token, projectID string, req createTunnelRequest) (tunnel, error) {
 	var t tunnel
 	err := doJSONRequest(http.MethodPost, apiHost+"/api/v1/projects/"+projectID+"/tunnels/create", token, req, &t)
+	if err == nil {
+		recordAudit("tunnel.create", t.TunnelID, fmt.Sprintf("project=%s container=%s port=%d", projectID, req.ContainerID, req.TargetPort))
+	}
 	return t, err
 }
 
+func deleteTunnel(token, tunnelID string) error {
+	err := doJSONRequest(http.MethodDelete, apiHost+"/api/v1/tunnels/"+url.PathEscape(tunnelID), token, nil, nil)
+	if err == nil {
+		recordAudit("tunnel.delete", tunnelID, "")
+	}
+	return err
+}
+
 func createDeploySession(token string, req createDeploySessionRequest) (deploySessionResponse, error) {
 	var payload deploySessionResponse
 	err := doJSONRequest(http.MethodPost, apiHost+"/api/v1/cli/deploy/sessions", token, req, &payload)
+	if err == nil {
+		recordAudit("deploy.start", payload.BuildID, "project="+payload.ProjectID)
+	}
 	return payload, err
 }
 
@@ -196,6 +240,26 @@ func fetchOrganizations(token string) ([]organization, error) {
 	return payload, err
 }
 
+// containerMatch is one result of the container search, with the project
+// the container belongs to.
+type containerMatch struct {
+	container
+	ProjectID   string `json:"projectId"`
+	ProjectName string `json:"projectName"`
+}
+
+type containerSearchResponse struct {
+	Matches []containerMatch `json:"items"`
+}
+
+// searchContainers asks the API for containers whose id or name matches
+// query, across every project the token can see.
+func searchContainers(token, query string) ([]containerMatch, error) {
+	var payload containerSearchResponse
+	err := doJSONRequest(http.MethodGet, apiHost+"/api/v1/containers?query="+url.QueryEscape(query), token, nil, &payload)
+	return payload.Matches, err
+}
+
 type containerLogsOutput struct {
 	Stdout string `json:"stdout"`
 	Stderr string `json:"stderr"`
@@ -207,30 +271,129 @@ func fetchContainerLogs(token, projectID, containerID string) (containerLogsOutp
 	return payload, err
 }
 
+// Container lifecycle actions accepted by runContainerAction.
+const (
+	containerActionStart   = "start"
+	containerActionStop    = "stop"
+	containerActionRestart = "restart"
+)
+
+func runContainerAction(token, projectID, containerID, action string) error {
+	err := doJSONRequest(http.MethodPost, apiHost+"/api/v1/projects/"+projectID+"/containers/"+containerID+"/"+action, token, nil, nil)
+	if err == nil {
+		recordAudit("container."+action, containerID, "project="+projectID)
+	}
+	return err
+}
+
 func doJSONRequest(method, url, token string, body any, out any) error {
 	return doJSONRequestWithTimeout(method, url, token, body, out, 20*time.Second)
 }
 
 func doJSONRequestWithTimeout(method, url, token string, body any, out any, timeout time.Duration) error {
+	return doJSONRequestContext(context.Background(), method, url, token, body, out, timeout)
+}
+
+// apiRetryAttempts is how many times an idempotent request is tried before
+// its error is returned; apiRetryBackoff is the delay before the first retry,
+// doubled for each one after it.
+var (
+	apiRetryAttempts = 3
+	apiRetryBackoff  = 250 * time.Millisecond
+)
+
+// apiRetryMaxDelay caps the backoff and any Retry-After the API asks for.
+const apiRetryMaxDelay = 5 * time.Second
+
+// errAPIInterrupted is returned when Ctrl+C cancels a request in flight.
+var errAPIInterrupted = errors.New("interrupted")
+
+// doJSONRequestContext sends an API request and decodes its response into
+// out. An interrupt cancels it, as does ctx. GET and HEAD requests are
+// retried with jittered backoff after connection errors and 5xx responses;
+// anything else is sent once, since it may have taken effect.
+func doJSONRequestContext(ctx context.Context, method, url, token string, body any, out any, timeout time.Duration) error {
 	var requestBytes []byte
-	var reqBody io.Reader
 	if body != nil {
 		payload, err := json.Marshal(body)
 		if err != nil {
 			return err
 		}
 		requestBytes = payload
-		reqBody = bytes.NewBuffer(payload)
 	}
 
-	req, err := http.NewRequest(method, url, reqBody)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	attempts := 1
+	if method == http.MethodGet || method == http.MethodHead {
+		attempts = max(apiRetryAttempts, 1)
+	}
+	for attempt := 1; ; attempt++ {
+		err := doJSONAttempt(ctx, method, url, token, requestBytes, out, timeout)
+		if ctx.Err() != nil && err != nil {
+			if errors.Is(context.Cause(ctx), context.Canceled) {
+				return fmt.Errorf("%s %s: %w", method, url, errAPIInterrupted)
+			}
+			return err
+		}
+		delay, retry := apiRetryDelay(err, attempt)
+		if !retry || attempt >= attempts {
+			return err
+		}
+		debugf("retrying %s %s in %s (attempt %d of %d): %v", method, url, delay.Round(time.Millisecond), attempt+1, attempts, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s %s: %w", method, url, errAPIInterrupted)
+		case <-timer.C:
+		}
+	}
+}
+
+// apiRetryDelay reports whether err is worth another attempt and how long to
+// wait first: full jitter over an exponential backoff, or the API's
+// Retry-After when it sent one.
+func apiRetryDelay(err error, attempt int) (time.Duration, bool) {
+	var apiErr *apiError
+	var urlErr *url.Error
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.As(err, &apiErr):
+		if apiErr.Status < 500 || apiErr.Status == http.StatusNotImplemented {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			return min(apiErr.RetryAfter, apiRetryMaxDelay), true
+		}
+	case errors.As(err, &urlErr):
+		// A timeout already took the whole client timeout; repeating it
+		// would only make the command hang longer.
+		if urlErr.Timeout() {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	ceiling := min(apiRetryBackoff<<(attempt-1), apiRetryMaxDelay)
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1), true
+}
+
+func doJSONAttempt(ctx context.Context, method, url, token string, requestBytes []byte, out any, timeout time.Duration) error {
+	var reqBody io.Reader
+	if requestBytes != nil {
+		reqBody = bytes.NewReader(requestBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return err
 	}
 	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
-	if body != nil {
+	if requestBytes != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
@@ -243,19 +406,28 @@ func doJSONRequestWithTimeout(method, url, token string, body any, out any, time
 	}
 
 	client := &http.Client{Timeout: timeout}
+	started := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
 		debugf("HTTP transport error: %v", err)
+		recordAPI(method, url, 0, time.Since(started), requestBytes, nil, err)
 		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
+	observeServerDate(resp.Header, started, time.Now())
 
 	respBytes, readErr := io.ReadAll(resp.Body)
+	recordAPI(method, url, resp.StatusCode, time.Since(started), requestBytes, respBytes, readErr)
 	if readErr != nil {
 		return readErr
 	}
 
-	debugf("HTTP response status: %d", resp.StatusCode)
+	requestID := responseRequestID(resp.Header)
+	if requestID != "" {
+		debugf("HTTP response status: %d (request id %s)", resp.StatusCode, requestID)
+	} else {
+		debugf("HTTP response status: %d", resp.StatusCode)
+	}
 	if len(respBytes) > 0 {
 		debugf("Response body: %s", string(respBytes))
 	}
@@ -263,8 +435,8 @@ func doJSONRequestWithTimeout(method, url, token string, body any, out any, time
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		msg := strings.TrimSpace(string(respBytes))
 		code := ""
-		requestID := ""
 		errorID := ""
+		var details map[string]any
 		if len(respBytes) > 0 {
 			var apiPayload struct {
 				Error   any    `json:"error"`
@@ -274,7 +446,9 @@ func doJSONRequestWithTimeout(method, url, token string, body any, out any, time
 				} `json:"meta"`
 			}
 			if err := json.Unmarshal(respBytes, &apiPayload); err == nil {
-				requestID = strings.TrimSpace(apiPayload.Meta.RequestID)
+				if requestID == "" {
+					requestID = strings.TrimSpace(apiPayload.Meta.RequestID)
+				}
 				if apiErrorMessage, ok := apiPayload.Error.(string); ok && strings.TrimSpace(apiErrorMessage) != "" {
 					msg = strings.TrimSpace(apiErrorMessage)
 				} else if errorObject, ok := apiPayload.Error.(map[string]any); ok {
@@ -287,6 +461,7 @@ func doJSONRequestWithTimeout(method, url, token string, body any, out any, time
 					if value, ok := errorObject["errorId"].(string); ok {
 						errorID = strings.TrimSpace(value)
 					}
+					details, _ = errorObject["details"].(map[string]any)
 				} else if strings.TrimSpace(apiPayload.Message) != "" {
 					msg = strings.TrimSpace(apiPayload.Message)
 				}
@@ -296,11 +471,13 @@ func doJSONRequestWithTimeout(method, url, token string, body any, out any, time
 			msg = "request failed"
 		}
 		return &apiError{
-			Status:    resp.StatusCode,
-			Code:      code,
-			Message:   msg,
-			RequestID: requestID,
-			ErrorID:   errorID,
+			Status:     resp.StatusCode,
+			Code:       code,
+			Message:    msg,
+			RequestID:  requestID,
+			ErrorID:    errorID,
+			Details:    details,
+			RetryAfter: retryAfter(resp.Header),
 		}
 	}
 
@@ -314,22 +491,48 @@ func doJSONRequestWithTimeout(method, url, token string, body any, out any, time
 		Error *struct {
 			Code    string `json:"code"`
 			Message string `json:"message"`
+			Details any    `json:"details"`
 		} `json:"error"`
 	}
 
 	if err := json.Unmarshal(respBytes, &env); err == nil && (env.OK || env.Error != nil) {
 		if !env.OK {
 			if env.Error != nil {
+				details, _ := env.Error.Details.(map[string]any)
 				return &apiError{
-					Status:  resp.StatusCode,
-					Code:    env.Error.Code,
-					Message: env.Error.Message,
+					Status:    resp.StatusCode,
+					Code:      env.Error.Code,
+					Message:   env.Error.Message,
+					RequestID: requestID,
+					Details:   details,
 				}
 			}
-			return &apiError{Status: resp.StatusCode, Message: "request failed"}
+			return &apiError{Status: resp.StatusCode, Message: "request failed", RequestID: requestID}
 		}
-		return json.Unmarshal(env.Data, out)
+		return decodeAPIResponse(env.Data, out)
 	}
 
-	return json.Unmarshal(respBytes, out)
+	return decodeAPIResponse(respBytes, out)
+}
+
+// retryAfter reads a Retry-After header given in seconds or as a date.
+func retryAfter(header http.Header) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && time.Until(at) > 0 {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// responseRequestID is the ID the API tagged the request with in its logs.
+func responseRequestID(header http.Header) string {
+	for _, name := range []string{"X-Request-Id", "X-Correlation-Id"} {
+		if value := strings.TrimSpace(header.Get(name)); value != "" {
+			return value
+		}
+	}
+	return ""
 }
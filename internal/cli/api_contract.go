@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The structs in types.go are the v1 API contract: their JSON names are the
+// ones /api/v1 documents, and tickets on disk use the same names. Responses
+// are translated onto them by decodeAPIResponse, so a field the API renames
+// to another casing or to snake_case (sshUser, ssh_user, SSHUser) still
+// lands in the same place.
+
+// apiModel is implemented by response models with fields the CLI cannot
+// work without. missingAPIFields names the ones a decoded value lacks.
+type apiModel interface {
+	missingAPIFields() []string
+}
+
+func (u user) missingAPIFields() []string {
+	return missingFields(map[string]string{"id": u.ID})
+}
+
+func (p project) missingAPIFields() []string {
+	return missingFields(map[string]string{"id": p.ID})
+}
+
+func (c container) missingAPIFields() []string {
+	return missingFields(map[string]string{"id": c.ID})
+}
+
+func (m containerMatch) missingAPIFields() []string {
+	return missingFields(map[string]string{"id": m.ID, "projectId": m.ProjectID})
+}
+
+func (t tunnel) missingAPIFields() []string {
+	id := t.TunnelID
+	if id == "" {
+		id = t.ID
+	}
+	return missingFields(map[string]string{"tunnelId": id})
+}
+
+func missingFields(values map[string]string) []string {
+	var missing []string
+	for name, value := range values {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// apiNormalizer is implemented by response models that tidy themselves up
+// after decoding, such as trimming stray whitespace the API left in values.
+type apiNormalizer interface {
+	normalizeAPIRecord()
+}
+
+// apiContractError reports a response that decoded without the fields its
+// model requires, which usually means the API renamed them.
+type apiContractError struct {
+	Model    string
+	Missing  []string
+	Received []string
+}
+
+func (e *apiContractError) Error() string {
+	return fmt.Sprintf("API response for %s is missing %s (fields received: %s); the API may have changed, so update hubfly or report this with --debug output",
+		e.Model, strings.Join(e.Missing, ", "), strings.Join(e.Received, ", "))
+}
+
+// decodeAPIResponse unmarshals an API response into out after renaming keys
+// that match one of out's JSON names only when case, underscores and dashes
+// are ignored. It then normalizes and checks every model in the result.
+func decodeAPIResponse(data []byte, out any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	normalized, err := json.Marshal(normalizeAPIKeys(raw, reflect.TypeOf(out)))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(normalized, out); err != nil {
+		return err
+	}
+	return checkAPIContract(reflect.ValueOf(out), raw)
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// normalizeAPIKeys walks a decoded JSON value alongside the Go type it will
+// be unmarshalled into and renames object keys to the type's JSON names.
+func normalizeAPIKeys(v any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t == rawMessageType {
+		return v
+	}
+	switch value := v.(type) {
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return v
+		}
+		for i := range value {
+			value[i] = normalizeAPIKeys(value[i], t.Elem())
+		}
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, item := range value {
+				value[key] = normalizeAPIKeys(item, t.Elem())
+			}
+		case reflect.Struct:
+			fields := jsonFields(t)
+			byFold := make(map[string]string, len(fields))
+			for name := range fields {
+				byFold[foldAPIKey(name)] = name
+			}
+			for key, item := range value {
+				name := key
+				if _, ok := fields[key]; !ok {
+					canonical, ok := byFold[foldAPIKey(key)]
+					if !ok {
+						continue
+					}
+					delete(value, key)
+					if _, taken := value[canonical]; taken {
+						debugf("API field %q ignored in favour of %q", key, canonical)
+						continue
+					}
+					debugf("API field %q read as %q", key, canonical)
+					name = canonical
+				}
+				value[name] = normalizeAPIKeys(item, fields[name])
+			}
+		}
+	}
+	return v
+}
+
+// jsonFields maps the JSON names of t's fields, including those promoted
+// from embedded structs, to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for embedded, typ := range jsonFields(field.Type) {
+				fields[embedded] = typ
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func foldAPIKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// checkAPIContract normalizes every apiNormalizer in v and returns an
+// apiContractError for the first apiModel that is missing required fields.
+func checkAPIContract(v reflect.Value, raw any) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() {
+		if normalizer, ok := v.Addr().Interface().(apiNormalizer); ok {
+			normalizer.normalizeAPIRecord()
+		}
+	}
+	if model, ok := v.Interface().(apiModel); ok {
+		if missing := model.missingAPIFields(); len(missing) > 0 {
+			var received []string
+			if object, ok := raw.(map[string]any); ok {
+				for key := range object {
+					received = append(received, key)
+				}
+				sort.Strings(received)
+			}
+			return &apiContractError{Model: v.Type().Name(), Missing: missing, Received: received}
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items, _ := raw.([]any)
+		for i := 0; i < v.Len(); i++ {
+			var item any
+			if i < len(items) {
+				item = items[i]
+			}
+			if err := checkAPIContract(v.Index(i), item); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		object, _ := raw.(map[string]any)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if err := checkAPIContract(v.Field(i), object[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fetchFixture serves a response recorded from the API, or a variant of one
+// with renamed fields, and decodes it the way every API call does.
+func fetchFixture(t *testing.T, name string, out any) error {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "api", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	return doJSONRequest(http.MethodGet, srv.URL, "", nil, out)
+}
+
+func TestAPIContractFixtures(t *testing.T) {
+	var want []tunnel
+	if err := fetchFixture(t, "tunnels.v1.json", &want); err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 1 || want[0].TunnelID != "tun_8f2c1a" || want[0].Targets[0].LocalPort != 15432 || want[0].Limits.MaxStreams != 64 {
+		t.Fatalf("v1 fixture decoded as %+v", want)
+	}
+	for _, name := range []string{"tunnels.snake.json", "tunnels.recased.json"} {
+		var got []tunnel
+		if err := fetchFixture(t, name, &got); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s decoded as %+v, want %+v", name, got, want)
+		}
+	}
+
+	var renamed []tunnel
+	err := fetchFixture(t, "tunnels.renamed.json", &renamed)
+	var contractErr *apiContractError
+	if !errors.As(err, &contractErr) || contractErr.Model != "tunnel" || !strings.Contains(err.Error(), "tunnelId") || !strings.Contains(err.Error(), "uuid") {
+		t.Fatalf("renamed id: err = %v, want an apiContractError naming tunnelId and the fields received", err)
+	}
+
+	var me, meSnake user
+	if err := fetchFixture(t, "me.v1.json", &me); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetchFixture(t, "me.snake.json", &meSnake); err != nil {
+		t.Fatal(err)
+	}
+	if meSnake.ID != me.ID || meSnake.Email != me.Email || meSnake.Name != me.Name {
+		t.Errorf("me.snake.json decoded as %+v, want %+v", meSnake, me)
+	}
+}
+
+func TestDecodedTunnelsAreTrimmedAndChecked(t *testing.T) {
+	var got []tunnel
+	if err := fetchFixture(t, "tunnels.suspect.json", &got); err != nil {
+		t.Fatal(err)
+	}
+	tun := got[0]
+	if tun.TunnelID != "tun_8f2c1a" || tun.TargetContainer != "postgres" || tun.Targets[0].TargetID != "tgt_1" {
+		t.Fatalf("fields not trimmed: %+v", tun)
+	}
+	if problems := normalizeTunnel(&tun); len(problems) != 2 {
+		t.Fatalf("problems = %q, want the out-of-range port and the unparseable expiry", problems)
+	}
+	if err := checkTunnelConnectable(tun, tun.Targets[0]); err != nil {
+		t.Fatalf("valid target refused: %v", err)
+	}
+	if err := checkTunnelConnectable(tun, tun.Targets[1]); err == nil || !strings.Contains(err.Error(), "70000") {
+		t.Fatalf("target with port 70000: err = %v", err)
+	}
+	tun.ConnectURL = "gw-eu1.hubfly.space:443"
+	if err := checkTunnelConnectable(tun, tun.Targets[0]); err == nil {
+		t.Fatal("connect url without a scheme was accepted")
+	}
+}
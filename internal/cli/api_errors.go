@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Kinds of API failure. An apiError matches its kind with errors.Is, by the
+// code the API sent or, for older endpoints that send none, by status.
+var (
+	errAPIUnauthorized  = errors.New("not logged in")
+	errAPIForbidden     = errors.New("not allowed")
+	errAPINotFound      = errors.New("not found")
+	errAPIConflict      = errors.New("conflict")
+	errAPIInvalid       = errors.New("invalid request")
+	errAPIQuotaExceeded = errors.New("quota exceeded")
+	errAPIRateLimited   = errors.New("rate limited")
+)
+
+var apiErrorKinds = map[string]error{
+	"UNAUTHORIZED":           errAPIUnauthorized,
+	"TOKEN_EXPIRED":          errAPIUnauthorized,
+	"FORBIDDEN":              errAPIForbidden,
+	"NOT_FOUND":              errAPINotFound,
+	"PROJECT_NOT_FOUND":      errAPINotFound,
+	"CONTAINER_NOT_FOUND":    errAPINotFound,
+	"TUNNEL_NOT_FOUND":       errAPINotFound,
+	"TARGET_NOT_FOUND":       errAPINotFound,
+	"CONFLICT":               errAPIConflict,
+	"ALREADY_EXISTS":         errAPIConflict,
+	"BAD_REQUEST":            errAPIInvalid,
+	"VALIDATION_FAILED":      errAPIInvalid,
+	"QUOTA_EXCEEDED":         errAPIQuotaExceeded,
+	"TUNNEL_QUOTA_EXCEEDED":  errAPIQuotaExceeded,
+	"PROJECT_QUOTA_EXCEEDED": errAPIQuotaExceeded,
+	"RATE_LIMITED":           errAPIRateLimited,
+}
+
+var apiStatusKinds = map[int]error{
+	http.StatusUnauthorized:        errAPIUnauthorized,
+	http.StatusForbidden:           errAPIForbidden,
+	http.StatusNotFound:            errAPINotFound,
+	http.StatusConflict:            errAPIConflict,
+	http.StatusBadRequest:          errAPIInvalid,
+	http.StatusUnprocessableEntity: errAPIInvalid,
+	http.StatusPaymentRequired:     errAPIQuotaExceeded,
+	http.StatusTooManyRequests:     errAPIRateLimited,
+}
+
+func (e *apiError) kind() error {
+	if kind, ok := apiErrorKinds[e.Code]; ok {
+		return kind
+	}
+	return apiStatusKinds[e.Status]
+}
+
+func (e *apiError) Is(target error) bool {
+	kind := e.kind()
+	return kind != nil && kind == target
+}
+
+// apiCodeHints are hints for codes that say more than their kind does.
+var apiCodeHints = map[string]string{
+	"TUNNEL_QUOTA_EXCEEDED":  "delete unused tunnels with `hubfly tunnel delete <tunnelId>`; `hubfly tunnel list --all-projects` shows them",
+	"PROJECT_QUOTA_EXCEEDED": "remove a project you no longer use in the dashboard, or upgrade the plan",
+	"TOKEN_EXPIRED":          "your session expired; run `hubfly login` again",
+	"TUNNEL_NOT_FOUND":       "the tunnel may have expired; `hubfly tunnel list` shows the ones that are left",
+	"CONTAINER_NOT_FOUND":    "`hubfly containers` lists the containers you can reach",
+	"PROJECT_NOT_FOUND":      "`hubfly projects` lists your projects; `hubfly use project` changes the default",
+}
+
+var apiKindHints = map[error]string{
+	errAPIUnauthorized:  "run `hubfly login` to sign in again",
+	errAPIForbidden:     "your account cannot do this here; check `hubfly whoami` and the project's members",
+	errAPIQuotaExceeded: "your plan's limit is reached; free something up or upgrade the plan",
+	errAPIRateLimited:   "too many requests; wait a moment and try again",
+}
+
+// apiErrorHint suggests what to do about err, or returns "" when there is
+// nothing better to say than the error itself.
+func apiErrorHint(err error) string {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	hint, ok := apiCodeHints[apiErr.Code]
+	if !ok {
+		hint = apiKindHints[apiErr.kind()]
+	}
+	if limit, ok := apiErr.Details["limit"]; ok && errors.Is(apiErr, errAPIQuotaExceeded) {
+		hint = fmt.Sprintf("the limit is %v; %s", limit, hint)
+	}
+	if fields := apiErr.fieldErrors(); len(fields) > 0 {
+		hint = strings.TrimPrefix(hint+"; ", "; ") + "check " + strings.Join(fields, ", ")
+	}
+	return hint
+}
+
+// fieldErrors reads validation details, sent as {"fields": {"name": "why"}},
+// as "name (why)", sorted by name.
+func (e *apiError) fieldErrors() []string {
+	fields, ok := e.Details["fields"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(fields))
+	for name, why := range fields {
+		if text, ok := why.(string); ok && text != "" {
+			out = append(out, fmt.Sprintf("%s (%s)", name, text))
+		} else {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
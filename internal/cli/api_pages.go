@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// apiPage is one page of a listing. A listing with more than fits in one
+// response says where the next page starts, with a cursor or with page
+// numbers. Endpoints that do not paginate leave both out, or return a bare
+// array.
+type apiPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor"`
+	Page       int    `json:"page"`
+	TotalPages int    `json:"totalPages"`
+}
+
+// next is the query that fetches the page after p, or "" when p is the last.
+func (p apiPage[T]) next() string {
+	if p.NextCursor != "" {
+		return "cursor=" + url.QueryEscape(p.NextCursor)
+	}
+	if p.Page > 0 && p.Page < p.TotalPages {
+		return "page=" + strconv.Itoa(p.Page+1)
+	}
+	return ""
+}
+
+func fetchPage[T any](token, requestURL, query string) (apiPage[T], error) {
+	var page apiPage[T]
+	if query != "" {
+		if strings.Contains(requestURL, "?") {
+			requestURL += "&" + query
+		} else {
+			requestURL += "?" + query
+		}
+	}
+	var raw json.RawMessage
+	if err := doJSONRequest(http.MethodGet, requestURL, token, nil, &raw); err != nil || len(raw) == 0 {
+		return page, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		return page, decodeAPIResponse(raw, &page.Items)
+	}
+	return page, decodeAPIResponse(raw, &page)
+}
+
+// fetchPages fetches up to limit pages of a listing, all of them when limit
+// is 0, starting with the page query selects. It returns the query for the
+// page after the last one fetched, or "" when there are no more.
+func fetchPages[T any](token, requestURL, query string, limit int) ([]T, string, error) {
+	var items []T
+	seen := map[string]bool{}
+	for pages := 0; limit == 0 || pages < limit; pages++ {
+		page, err := fetchPage[T](token, requestURL, query)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, page.Items...)
+		seen[query] = true
+		query = page.next()
+		if query == "" {
+			return items, "", nil
+		}
+		if seen[query] {
+			debugf("%s: page %q came back as its own next page; stopping", requestURL, query)
+			return items, "", nil
+		}
+	}
+	return items, query, nil
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hubfly-cli/internal/testsupport"
+)
+
+func TestAPIErrorCarriesRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_abc123")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"message":"Database unavailable","errorId":"err_9"}}`))
+	}))
+	defer srv.Close()
+
+	err := doJSONRequest(http.MethodGet, srv.URL, "", nil, nil)
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "req_abc123" {
+		t.Fatalf("err = %v, want an apiError with the header's request id", err)
+	}
+	if got := err.Error(); !strings.Contains(got, "request id: req_abc123") || !strings.Contains(got, "error id: err_9") {
+		t.Fatalf("Error() = %q", got)
+	}
+}
+
+func TestFindContainerUsesSearchAndFallsBackToScan(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HUBFLY_API_URL", "")
+	t.Chdir(t.TempDir())
+	original := apiHost
+	t.Cleanup(func() { apiHost, containerSearchUnavailable = original, false })
+
+	api := testsupport.NewMockAPI(t)
+	apiHost = api.URL
+	api.Handle(http.MethodGet, "/api/v1/projects", apiPage[project]{Items: []project{{ID: "p1", Name: "shop"}, {ID: "p2", Name: "billing"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p1", map[string]any{"containers": []map[string]any{{"id": "c1", "name": "web"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p2", map[string]any{"containers": []map[string]any{{"id": "c2", "name": "ledger"}}})
+	api.HandleFunc(http.MethodGet, "/api/v1/containers", func(w http.ResponseWriter, r *http.Request) {
+		items := []map[string]any{}
+		if strings.HasPrefix("ledger", r.URL.Query().Get("query")) {
+			items = append(items,
+				map[string]any{"id": "c3", "name": "ledger-old", "projectId": "p2", "projectName": "billing"},
+				map[string]any{"id": "c2", "name": "ledger", "projectId": "p2", "projectName": "billing"})
+		}
+		testsupport.WriteData(w, map[string]any{"items": items})
+	})
+	scans := func() int {
+		n := 0
+		for _, r := range api.Requests() {
+			if strings.HasPrefix(r.Path, "/api/v1/projects") {
+				n++
+			}
+		}
+		return n
+	}
+
+	p, c, err := findContainerInProjects("token", "", "ledger")
+	if err != nil || p.ID != "p2" || c.ID != "c2" {
+		t.Fatalf("search lookup = %+v %+v %v", p, c, err)
+	}
+	if _, _, err := findContainerInProjects("token", "", "missing"); err == nil || !strings.Contains(err.Error(), "not found in any project") {
+		t.Fatalf("search miss: %v", err)
+	}
+	if n := scans(); n != 0 {
+		t.Fatalf("searched lookups scanned projects %d time(s)", n)
+	}
+
+	// An API without the search endpoint falls back to the scan for the
+	// rest of the run.
+	api.HandleFunc(http.MethodGet, "/api/v1/containers", func(w http.ResponseWriter, _ *http.Request) {
+		testsupport.WriteError(w, http.StatusNotFound, "NOT_FOUND", "not found")
+	})
+	if p, _, err := findContainerInProjects("token", "", "web"); err != nil || p.ID != "p1" {
+		t.Fatalf("fallback lookup = %+v, %v", p, err)
+	}
+	if !containerSearchUnavailable || scans() == 0 {
+		t.Fatal("lookup did not fall back to scanning projects")
+	}
+}
+
+func TestSearchProjectsFetchesConcurrentlyAndKeepsOrder(t *testing.T) {
+	original := apiHost
+	t.Cleanup(func() { apiHost = original })
+	api := testsupport.NewMockAPI(t)
+	apiHost = api.URL
+
+	var inFlight, peak atomic.Int64
+	projects := make([]project, 30)
+	for i := range projects {
+		id := fmt.Sprintf("p%d", i)
+		projects[i] = project{ID: id, Name: id}
+		containers := []map[string]any{{"id": "c-" + id, "name": "worker"}}
+		if i == 7 || i == 20 {
+			containers = append(containers, map[string]any{"id": "web-" + id, "name": "web"})
+		}
+		api.HandleFunc(http.MethodGet, "/api/v1/projects/"+id, func(w http.ResponseWriter, _ *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			testsupport.WriteData(w, map[string]any{"containers": containers})
+		})
+	}
+
+	p, c, found, err := searchProjectsForContainer("token", projects, "web")
+	if err != nil || !found || p.ID != "p7" || c.ID != "web-p7" {
+		t.Fatalf("search = %+v %+v %v %v, want the match in p7", p, c, found, err)
+	}
+	if n := peak.Load(); n < 2 || n > projectFetchWorkers {
+		t.Fatalf("%d project fetches ran at once, want between 2 and %d", n, projectFetchWorkers)
+	}
+}
+
+func TestAPIRetriesIdempotentRequests(t *testing.T) {
+	originalBackoff := apiRetryBackoff
+	apiRetryBackoff = time.Millisecond
+	t.Cleanup(func() { apiRetryBackoff = originalBackoff })
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		testsupport.WriteData(w, user{ID: "u1"})
+	}))
+	defer srv.Close()
+
+	var u user
+	if err := doJSONRequest(http.MethodGet, srv.URL, "", nil, &u); err != nil || u.ID != "u1" || calls.Load() != 3 {
+		t.Fatalf("GET after two 503s: user=%+v err=%v calls=%d", u, err, calls.Load())
+	}
+
+	calls.Store(0)
+	err := doJSONRequest(http.MethodPost, srv.URL, "", map[string]string{}, nil)
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("POST: err=%v calls=%d, want one attempt", err, calls.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := doJSONRequestContext(ctx, http.MethodGet, srv.URL, "", nil, nil, time.Second); !errors.Is(err, errAPIInterrupted) {
+		t.Fatalf("cancelled request: err = %v", err)
+	}
+}
+
+func TestAPIErrorKindsAndHints(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/quota":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":"TUNNEL_QUOTA_EXCEEDED","message":"Tunnel quota exceeded","details":{"limit":5}}}`))
+		case "/invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ok":false,"error":{"code":"VALIDATION_FAILED","message":"Invalid tunnel","details":{"fields":{"ttl":"too long","port":""}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`not here`))
+		}
+	}))
+	defer srv.Close()
+
+	err := doJSONRequest(http.MethodPost, srv.URL+"/quota", "", map[string]string{}, nil)
+	if !errors.Is(err, errAPIQuotaExceeded) || errors.Is(err, errAPIForbidden) {
+		t.Fatalf("quota error %v does not match errAPIQuotaExceeded by its code", err)
+	}
+	if hint := apiErrorHint(fmt.Errorf("failed to create tunnel: %w", err)); !strings.HasPrefix(hint, "the limit is 5; delete unused tunnels with `hubfly tunnel delete") {
+		t.Errorf("quota hint = %q", hint)
+	}
+
+	err = doJSONRequest(http.MethodPost, srv.URL+"/invalid", "", map[string]string{}, nil)
+	if !errors.Is(err, errAPIInvalid) {
+		t.Fatalf("validation error %v does not match errAPIInvalid", err)
+	}
+	if hint := apiErrorHint(err); hint != "check port, ttl (too long)" {
+		t.Errorf("validation hint = %q", hint)
+	}
+
+	err = doJSONRequest(http.MethodGet, srv.URL+"/missing", "", nil, nil)
+	if !errors.Is(err, errAPINotFound) || apiErrorHint(err) != "" {
+		t.Errorf("plain 404: err = %v, hint = %q", err, apiErrorHint(err))
+	}
+}
+
+func TestListingsFollowEveryPage(t *testing.T) {
+	original := apiHost
+	t.Cleanup(func() { apiHost = original })
+	api := testsupport.NewMockAPI(t)
+	apiHost = api.URL
+	api.HandleFunc(http.MethodGet, "/api/v1/projects", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			testsupport.WriteData(w, map[string]any{"items": []project{{ID: "p1"}, {ID: "p2"}}, "nextCursor": "c/2"})
+		case "c/2":
+			testsupport.WriteData(w, map[string]any{"items": []project{{ID: "p3"}}})
+		}
+	})
+	api.HandleFunc(http.MethodGet, "/api/v1/projects/p1/tunnels", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		testsupport.WriteData(w, map[string]any{"items": []tunnel{{TunnelID: "t" + page}}, "page": len(page) + 1, "totalPages": 2})
+	})
+
+	projects, err := fetchProjects("token")
+	if err != nil || len(projects) != 3 || projects[2].ID != "p3" {
+		t.Fatalf("projects = %+v, err = %v", projects, err)
+	}
+	first, next, err := fetchProjectPages("token", "", "", 1)
+	if err != nil || len(first) != 2 || next != "cursor=c%2F2" {
+		t.Fatalf("first page = %+v, next = %q, err = %v", first, next, err)
+	}
+	tunnels, err := fetchTunnels("token", "p1")
+	if err != nil || len(tunnels) != 2 || tunnels[1].TunnelID != "t2" {
+		t.Fatalf("tunnels = %+v, err = %v", tunnels, err)
+	}
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// auditEntry is one line of the audit log. Hash covers Prev and every other
+// field, so editing, removing or reordering a line breaks the chain from
+// there on.
+type auditEntry struct {
+	Time    string `json:"time"`
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Detail  string `json:"detail,omitempty"`
+	APIHost string `json:"apiHost,omitempty"`
+	Prev    string `json:"prev"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// auditMu keeps the TUI's background commands from forking the chain.
+var auditMu sync.Mutex
+
+func auditLogPath() string {
+	return filepath.Join(hubflyDir(), "audit.log")
+}
+
+func (e auditEntry) digest() string {
+	e.Hash = ""
+	payload, _ := json.Marshal(e)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordAudit appends a mutating action to the audit log. The action has
+// already happened, so a failure to log it is only a warning.
+func recordAudit(action, target, detail string) {
+	if demoMode {
+		return
+	}
+	if err := appendAudit(auditEntry{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Action:  action,
+		Target:  target,
+		Detail:  detail,
+		APIHost: apiHost,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not write audit log: %v\n", err)
+	}
+}
+
+// auditFields lists the keys of a config change, not their values, which
+// can hold secrets.
+func auditFields(change map[string]any) string {
+	keys := make([]string, 0, len(change))
+	for key := range change {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func appendAudit(entry auditEntry) error {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	if err := ensurePrivateDir(hubflyDir()); err != nil {
+		return err
+	}
+	entries, err := loadAuditLog()
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		entry.Prev = entries[len(entries)-1].Hash
+	}
+	entry.Hash = entry.digest()
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(auditLogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(payload, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadAuditLog() ([]auditEntry, error) {
+	f, err := os.Open(auditLogPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s line %d is not an audit entry: %w", auditLogPath(), line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// verifyAuditChain returns the 1-based line of the first entry whose hash or
+// link to the previous entry does not match, or 0 when the chain is intact.
+func verifyAuditChain(entries []auditEntry) int {
+	prev := ""
+	for i, entry := range entries {
+		if entry.Prev != prev || entry.digest() != entry.Hash {
+			return i + 1
+		}
+		prev = entry.Hash
+	}
+	return 0
+}
+
+func auditCommand(args []string) error {
+	fs := newFlagSet("audit")
+	verify := fs.Bool("verify", false, "check that no entry was changed or removed")
+	limit := fs.Int("limit", 50, "show the last n entries (0 for all)")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *limit < 0 {
+		return errors.New("usage: hubfly audit [--verify] [--limit <n>]")
+	}
+
+	entries, err := loadAuditLog()
+	if err != nil {
+		return err
+	}
+	if *verify {
+		broken := verifyAuditChain(entries)
+		if jsonOutput {
+			if err := printJSON(map[string]any{"entries": len(entries), "intact": broken == 0, "brokenAtLine": broken}); err != nil {
+				return err
+			}
+		} else if broken == 0 {
+			fmt.Printf("Audit log intact: %d entries in %s.\n", len(entries), auditLogPath())
+		}
+		if broken != 0 {
+			return fmt.Errorf("audit log was modified: line %d of %s does not match the chain", broken, auditLogPath())
+		}
+		return nil
+	}
+
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+	if jsonOutput {
+		if entries == nil {
+			entries = []auditEntry{}
+		}
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No actions recorded in %s yet.\n", auditLogPath())
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Time\tAction\tTarget\tDetail")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Time, e.Action, e.Target, e.Detail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogDetectsTampering(t *testing.T) {
+	t.Cleanup(func() { storageRoot = "" })
+	storageRoot = t.TempDir()
+
+	recordAudit("tunnel.create", "tun_1", "project=p1 container=c1 port=5432")
+	recordAudit("container.restart", "c1", "project=p1")
+	recordAudit("tunnel.delete", "tun_1", "")
+
+	entries, err := loadAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[1].Prev != entries[0].Hash {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if broken := verifyAuditChain(entries); broken != 0 {
+		t.Fatalf("fresh log broken at line %d", broken)
+	}
+
+	content, err := os.ReadFile(auditLogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(content), "container.restart", "container.start", 1)
+	if err := os.WriteFile(auditLogPath(), []byte(edited), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = loadAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broken := verifyAuditChain(entries); broken != 2 {
+		t.Fatalf("edited log broken at line %d, want 2", broken)
+	}
+	// Dropping an entry breaks the link of the one after it.
+	if broken := verifyAuditChain(append(entries[:0:0], entries[0], entries[2])); broken != 2 {
+		t.Fatalf("log without line 2 broken at line %d, want 2", broken)
+	}
+}
@@ -1,15 +1,91 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 )
 
-const cliAuthURL = "https://dashboard.hubfly.space/cli/auth"
+const (
+	cliAuthURL        = "https://dashboard.hubfly.space/cli/auth"
+	cliBrowserAuthURL = "https://hubfly.space/cli/auth"
+	browserLoginWait  = 5 * time.Minute
+)
+
+func loginCommand(args []string) error {
+	fs := newFlagSet("login")
+	token := fs.String("token", "", "API token to store")
+	browser := fs.Bool("browser", false, "sign in through the browser instead of pasting a token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return errors.New("usage: hubfly login [--token <token> | --browser]")
+	}
+	if *browser {
+		if strings.TrimSpace(*token) != "" {
+			return errors.New("--browser and --token cannot be combined")
+		}
+		return browserLogin()
+	}
+	return login(*token)
+}
+
+// browserLogin opens the Hubfly CLI auth page with a one-shot localhost
+// callback. The page redirects back with ?token=...&state=..., and the token
+// is verified with whoami before it is stored.
+func browserLogin() error {
+	callback, err := startLocalCallbackServer("/callback")
+	if err != nil {
+		return err
+	}
+	defer callback.Close()
+
+	base := cliBrowserAuthURL
+	if override := strings.TrimSpace(os.Getenv("HUBFLY_AUTH_URL")); override != "" {
+		base = override
+	}
+	authURL, err := url.Parse(base)
+	if err != nil {
+		return fmt.Errorf("invalid auth URL %q: %w", base, err)
+	}
+	query := authURL.Query()
+	query.Set("callback", callback.URL())
+	query.Set("state", callback.State())
+	authURL.RawQuery = query.Encode()
+
+	fmt.Println("Opening your browser to sign in to Hubfly...")
+	if err := openBrowser(authURL.String()); err != nil {
+		debugf("failed to open browser: %v", err)
+	}
+	fmt.Printf("If it did not open, visit:\n  %s\n", authURL.String())
+	fmt.Println("Waiting for the browser to finish (Ctrl+C to cancel)...")
+
+	values, err := callback.Wait(context.Background(), browserLoginWait)
+	if err != nil {
+		return err
+	}
+	token := strings.TrimSpace(values.Get("token"))
+	if token == "" {
+		return errors.New("browser callback did not include a token")
+	}
+	u, err := fetchWhoAmI(token)
+	if err != nil {
+		return fmt.Errorf("token from browser could not be verified: %w", err)
+	}
+	if err := setToken(token); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully logged in as %s (%s)\n", u.Name, u.Email)
+	return nil
+}
 
 func authRequiredError() error {
-	return fmt.Errorf("authentication required; open %s to create a token, then run hubfly login --token <token>", cliAuthURL)
+	return fmt.Errorf("authentication required; run hubfly login --browser, or open %s to create a token and run hubfly login --token <token>", cliAuthURL)
 }
 
 func login(providedToken string) error {
@@ -104,3 +180,19 @@ func ensureAuth(silent bool) (string, error) {
 	}
 	return "", err
 }
+
+func whoamiFlow() error {
+	if !jsonOutput {
+		_, err := ensureAuth(false)
+		return err
+	}
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	u, err := fetchWhoAmI(token)
+	if err != nil {
+		return err
+	}
+	return printJSON(u)
+}
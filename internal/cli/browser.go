@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// openBrowser asks the desktop to open url. Callers always print the URL
+// too, since headless and SSH sessions have no browser to launch.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -2,9 +2,7 @@ package cli
 
 import (
 	"encoding/json"
-	"flag"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -56,8 +54,7 @@ func runBuildCommand(args []string) error {
 
 func parseBuildCommandOptions(command string, args []string) (buildCommandOptions, error) {
 	var opts buildCommandOptions
-	fs := flag.NewFlagSet("build "+command, flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("build " + command)
 	fs.StringVar(&opts.ConfigPath, "config", "", "path to hubfly.build.json or a project directory")
 	fs.StringVar(&opts.DockerfilePath, "dockerfile", "", "set build.mode=dockerfile and the Dockerfile path")
 	fs.StringVar(&opts.BuilderVersion, "builder-version", "", "pin a specific hubfly-builder release tag")
@@ -67,6 +64,7 @@ func parseBuildCommandOptions(command string, args []string) (buildCommandOption
 	if err := fs.Parse(args); err != nil {
 		return buildCommandOptions{}, err
 	}
+	opts.JSON = opts.JSON || jsonOutput
 	if len(fs.Args()) > 0 {
 		return buildCommandOptions{}, fmt.Errorf("unexpected build arguments: %s", strings.Join(fs.Args(), " "))
 	}
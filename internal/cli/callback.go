@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localCallbackServer receives a single browser redirect on 127.0.0.1 for
+// flows like browser login. It binds a free port, generates a CSRF state
+// value, and only accepts callbacks that echo that state back.
+type localCallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	path     string
+	state    string
+	results  chan callbackResult
+	once     sync.Once
+}
+
+type callbackResult struct {
+	values url.Values
+	err    error
+}
+
+const callbackSuccessPage = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Hubfly CLI</title></head>
+<body style="font-family: system-ui, sans-serif; text-align: center; padding-top: 4rem;">
+<h1>%s</h1>
+<p>%s</p>
+</body>
+</html>
+`
+
+func startLocalCallbackServer(path string) (*localCallbackServer, error) {
+	path = "/" + strings.Trim(strings.TrimSpace(path), "/")
+	state, err := randomCallbackState()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local callback port: %w", err)
+	}
+
+	s := &localCallbackServer{
+		listener: listener,
+		path:     path,
+		state:    state,
+		results:  make(chan callbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, s.handleCallback)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			debugf("callback server stopped: %v", err)
+		}
+	}()
+	debugf("callback server listening on %s", s.URL())
+	return s, nil
+}
+
+// URL is the redirect target to hand to the remote page.
+func (s *localCallbackServer) URL() string {
+	return fmt.Sprintf("http://%s%s", s.listener.Addr().String(), s.path)
+}
+
+// State is the CSRF value the remote page must send back unchanged.
+func (s *localCallbackServer) State() string {
+	return s.state
+}
+
+// Wait blocks until a valid callback arrives, the timeout elapses, or ctx is
+// cancelled. The returned values are the callback query parameters.
+func (s *localCallbackServer) Wait(ctx context.Context, timeout time.Duration) (url.Values, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-s.results:
+		return result.values, result.err
+	case <-timer.C:
+		return nil, fmt.Errorf("timed out after %s waiting for browser callback", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *localCallbackServer) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.server.Shutdown(ctx)
+}
+
+func (s *localCallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	received := query.Get("state")
+	if subtle.ConstantTimeCompare([]byte(received), []byte(s.state)) != 1 {
+		debugf("callback rejected: state mismatch")
+		writeCallbackPage(w, http.StatusBadRequest, "Request rejected", "The login state did not match. Start the flow again from the CLI.")
+		return
+	}
+
+	result := callbackResult{values: query}
+	if message := strings.TrimSpace(query.Get("error")); message != "" {
+		result.err = fmt.Errorf("browser flow failed: %s", message)
+		writeCallbackPage(w, http.StatusOK, "Something went wrong", message)
+	} else {
+		writeCallbackPage(w, http.StatusOK, "You're all set", "You can close this tab and return to the terminal.")
+	}
+
+	s.once.Do(func() {
+		s.results <- result
+	})
+}
+
+func writeCallbackPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, callbackSuccessPage, html.EscapeString(title), html.EscapeString(message))
+}
+
+func randomCallbackState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
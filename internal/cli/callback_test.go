@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLocalCallbackServerRejectsWrongState(t *testing.T) {
+	s, err := startLocalCallbackServer("/callback")
+	if err != nil {
+		t.Fatalf("failed to start callback server: %v", err)
+	}
+	defer s.Close()
+
+	resp, err := http.Get(s.URL() + "?state=wrong&token=abc")
+	if err != nil {
+		t.Fatalf("callback request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for mismatched state, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(s.URL() + "?state=" + url.QueryEscape(s.State()) + "&token=abc")
+	if err != nil {
+		t.Fatalf("callback request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for valid state, got %d", resp.StatusCode)
+	}
+
+	values, err := s.Wait(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("expected callback values, got error: %v", err)
+	}
+	if values.Get("token") != "abc" {
+		t.Fatalf("expected token abc, got %q", values.Get("token"))
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// clockSkewThreshold is how far this machine's clock may be off from the
+// API's before expiry checks switch to the API's time. Below it the Date
+// header's one-second resolution and request latency would only add noise.
+const clockSkewThreshold = time.Minute
+
+// clockSkewMaxAge is how long a skew measured by an earlier command is
+// trusted by commands that make no API call, such as tunnels check-expiry.
+const clockSkewMaxAge = 24 * time.Hour
+
+type clockSkewState struct {
+	// SkewSeconds is the API's clock minus this machine's.
+	SkewSeconds float64 `json:"skewSeconds"`
+	MeasuredAt  string  `json:"measuredAt"`
+}
+
+var clockSkew struct {
+	sync.Mutex
+	loaded bool
+	skew   time.Duration
+	warned bool
+}
+
+func clockSkewPath() string {
+	return filepath.Join(stateDir(), "clock.json")
+}
+
+// serverNow is the current time by the API's clock when this machine's clock
+// is known to be off by clockSkewThreshold or more, and time.Now otherwise.
+// Use it wherever a local time is compared with a time the API issued.
+func serverNow() time.Time {
+	clockSkew.Lock()
+	defer clockSkew.Unlock()
+	if !clockSkew.loaded {
+		clockSkew.loaded = true
+		clockSkew.skew = loadClockSkew()
+	}
+	return time.Now().Add(clockSkew.skew)
+}
+
+func loadClockSkew() time.Duration {
+	content, err := os.ReadFile(clockSkewPath())
+	if err != nil {
+		return 0
+	}
+	var state clockSkewState
+	if err := json.Unmarshal(content, &state); err != nil {
+		debugf("ignoring unreadable %s: %v", clockSkewPath(), err)
+		return 0
+	}
+	measured, err := time.Parse(time.RFC3339, state.MeasuredAt)
+	if err != nil || time.Since(measured) > clockSkewMaxAge {
+		return 0
+	}
+	return time.Duration(state.SkewSeconds * float64(time.Second))
+}
+
+// observeServerDate compares the Date header of an API response with the
+// local clock at the middle of the request, and updates the skew serverNow
+// applies. The first significant skew in a run is reported on stderr.
+func observeServerDate(header http.Header, sent, received time.Time) {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil || demoMode {
+		return
+	}
+	skew := date.Sub(sent.Add(received.Sub(sent) / 2))
+	if skew.Abs() < clockSkewThreshold {
+		skew = 0
+	}
+	skew = skew.Round(time.Second)
+
+	clockSkew.Lock()
+	changed := !clockSkew.loaded || skew != clockSkew.skew
+	clockSkew.loaded = true
+	clockSkew.skew = skew
+	warn := skew != 0 && !clockSkew.warned
+	if warn {
+		clockSkew.warned = true
+	}
+	clockSkew.Unlock()
+
+	if warn {
+		direction := "behind"
+		if skew < 0 {
+			direction = "ahead of"
+		}
+		fmt.Fprintf(os.Stderr, "warning: this machine's clock is %s %s the Hubfly API's; tunnel expiry uses the API's time. Sync the clock (for example with NTP) to fix it.\n", skew.Abs(), direction)
+	}
+	if changed {
+		saveClockSkew(skew)
+	}
+}
+
+func saveClockSkew(skew time.Duration) {
+	if skew == 0 {
+		_ = os.Remove(clockSkewPath())
+		return
+	}
+	payload, err := json.MarshalIndent(clockSkewState{
+		SkewSeconds: skew.Seconds(),
+		MeasuredAt:  time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := ensurePrivateDir(stateDir()); err != nil {
+		return
+	}
+	if err := writePrivateFile(clockSkewPath(), append(payload, '\n')); err != nil {
+		debugf("failed to record clock skew: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExpiryUsesServerTimeWhenClockIsSkewed(t *testing.T) {
+	t.Cleanup(func() {
+		storageRoot = ""
+		clockSkew.loaded, clockSkew.skew, clockSkew.warned = false, 0, false
+	})
+	storageRoot = t.TempDir()
+	observe := func(offset time.Duration) {
+		now := time.Now()
+		header := http.Header{"Date": {now.Add(offset).UTC().Format(http.TimeFormat)}}
+		observeServerDate(header, now, now)
+	}
+	inOneHour := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	// The API is two hours ahead: a ticket with an hour left locally has
+	// already expired.
+	observe(2 * time.Hour)
+	if got := tunnelState(inOneHour); got != "expired" {
+		t.Errorf("state with API two hours ahead = %s, want expired", got)
+	}
+
+	// A later command without an API call reads the recorded skew.
+	clockSkew.loaded, clockSkew.skew = false, 0
+	if skew := serverNow().Sub(time.Now()); skew < 119*time.Minute {
+		t.Errorf("recorded skew = %s, want about 2h", skew)
+	}
+
+	// A few seconds are within the Date header's noise and clear the record.
+	observe(10 * time.Second)
+	if got := tunnelState(inOneHour); got != "active" {
+		t.Errorf("state without skew = %s, want active", got)
+	}
+	if _, err := os.Stat(clockSkewPath()); !os.IsNotExist(err) {
+		t.Errorf("%s kept after the clock was back in sync", clockSkewPath())
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+func configCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(configUsage())
+	}
+	switch args[0] {
+	case "validate":
+		return configValidateFlow(args[1:])
+	case "get":
+		return configGetFlow(args[1:])
+	case "set":
+		return configSetFlow(args[1:])
+	case "unset":
+		return configUnsetFlow(args[1:])
+	case "use-profile":
+		return configUseProfileFlow(args[1:])
+	case "profiles":
+		return configProfilesFlow()
+	case "workspace":
+		return configWorkspaceFlow(args[1:])
+	default:
+		return fmt.Errorf("unknown config command: %s", args[0])
+	}
+}
+
+func configUsage() string {
+	return strings.TrimSpace(`
+usage: hubfly config get <key>
+       hubfly config set <key> <value>
+       hubfly config unset <key>
+       hubfly config use-profile <name>
+       hubfly config profiles
+       hubfly config validate [--file <path>]
+       hubfly config workspace
+
+keys: ` + strings.Join(profileKeyNames(), ", ") + `
+`)
+}
+
+func configValidateFlow(args []string) error {
+	fs := newFlagSet("config validate")
+	path := fs.String("file", configPath(), "config file to validate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+	issues := validateConfigJSON(content, storeConfigSchema)
+	if jsonOutput {
+		if err := printJSON(map[string]any{"path": *path, "valid": len(issues) == 0, "issues": issues}); err != nil {
+			return err
+		}
+	} else if len(issues) == 0 {
+		fmt.Printf("%s is valid.\n", *path)
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s:%s\n", *path, issue)
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d problem(s) found in %s", len(issues), *path)
+	}
+	return nil
+}
+
+// loadStoreConfig reads config.json, turning decode failures into
+// line-accurate errors and warning about keys this version does not know.
+func loadStoreConfig() (storeConfig, error) {
+	var cfg storeConfig
+	content, err := os.ReadFile(configPath())
+	if err != nil {
+		return cfg, err
+	}
+	issues := validateConfigJSON(content, storeConfigSchema)
+	if blocking := configBlockingIssues(issues); len(blocking) > 0 {
+		return cfg, &configValidationError{Path: configPath(), Issues: blocking}
+	}
+	for _, issue := range issues {
+		warnOnce("config-"+issue.Field, fmt.Sprintf("warning: %s:%s (run `hubfly config validate`)", configPath(), issue))
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return storeConfig{}, err
+	}
+	return cfg, nil
+}
+
+var warned struct {
+	sync.Mutex
+	keys map[string]bool
+}
+
+// warnOnce prints message to stderr the first time key is seen in a run. It
+// is safe to call from the goroutines the TUI fetches data on.
+func warnOnce(key, message string) {
+	warned.Lock()
+	seen := warned.keys[key]
+	if warned.keys == nil {
+		warned.keys = make(map[string]bool)
+	}
+	warned.keys[key] = true
+	warned.Unlock()
+	if seen {
+		return
+	}
+	fmt.Fprintln(os.Stderr, strings.TrimSpace(message))
+}
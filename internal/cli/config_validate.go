@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type schemaKind int
+
+const (
+	kindString schemaKind = iota
+	kindBool
+	kindNumber
+	kindObject
+	kindMap
+)
+
+func (k schemaKind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindBool:
+		return "boolean"
+	case kindNumber:
+		return "number"
+	case kindObject, kindMap:
+		return "object"
+	}
+	return "value"
+}
+
+// schemaNode describes the expected shape of a config value. Objects list
+// their known fields; maps accept any key with values of one shape.
+type schemaNode struct {
+	Kind   schemaKind
+	Fields map[string]*schemaNode
+	Values *schemaNode
+}
+
+var profileSchema = &schemaNode{
+	Kind: kindObject,
+	Fields: map[string]*schemaNode{
+		"token":              {Kind: kindString},
+		"apiHost":            {Kind: kindString},
+		"defaultProject":     {Kind: kindString},
+		"defaultProjectName": {Kind: kindString},
+		"locale":             {Kind: kindString},
+		"ssh": {
+			Kind: kindObject,
+			Fields: map[string]*schemaNode{
+				"execTimeout": {Kind: kindString},
+			},
+		},
+		"tunnels": {
+			Kind: kindObject,
+			Fields: map[string]*schemaNode{
+				"localPortRange": {Kind: kindString},
+			},
+		},
+		"tui": {
+			Kind: kindObject,
+			Fields: map[string]*schemaNode{
+				"refreshInterval": {Kind: kindString},
+			},
+		},
+		"tips": {
+			Kind: kindObject,
+			Fields: map[string]*schemaNode{
+				"track": {Kind: kindBool},
+			},
+		},
+		"update": {
+			Kind: kindObject,
+			Fields: map[string]*schemaNode{
+				"releaseURL": {Kind: kindString},
+				"channel":    {Kind: kindString},
+				"notify":     {Kind: kindBool},
+			},
+		},
+	},
+}
+
+var storeConfigSchema = &schemaNode{
+	Kind: kindObject,
+	Fields: map[string]*schemaNode{
+		"currentProfile": {Kind: kindString},
+		"profiles":       {Kind: kindMap, Values: profileSchema},
+		"token":          {Kind: kindString},
+		"keymap":         {Kind: kindMap, Values: &schemaNode{Kind: kindString}},
+	},
+}
+
+// configIssue is one problem found in a config file, located by line and
+// column so it can be fixed without guessing.
+type configIssue struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+	Unknown bool   `json:"unknown,omitempty"`
+}
+
+func (i configIssue) String() string {
+	if i.Field == "" {
+		return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Field, i.Message)
+}
+
+type configValidationError struct {
+	Path   string
+	Issues []configIssue
+}
+
+func (e *configValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues)+1)
+	lines = append(lines, fmt.Sprintf("invalid config %s:", e.Path))
+	for _, issue := range e.Issues {
+		lines = append(lines, fmt.Sprintf("  %s:%s", e.Path, issue))
+	}
+	lines = append(lines, "fix the file or run `hubfly logout` to reset it")
+	return strings.Join(lines, "\n")
+}
+
+// validateConfigJSON checks content against schema. Syntax errors stop the
+// walk; type mismatches and unknown keys are all collected.
+func validateConfigJSON(content []byte, schema *schemaNode) []configIssue {
+	v := &configValidator{content: content, dec: json.NewDecoder(bytes.NewReader(content))}
+	v.dec.UseNumber()
+	// json.Unmarshal reports syntax errors more precisely than the token
+	// stream, so check well-formedness first.
+	var probe any
+	if err := json.Unmarshal(content, &probe); err != nil {
+		v.addSyntaxError(err)
+		return v.issues
+	}
+	if err := v.value(schema, ""); err != nil {
+		v.addSyntaxError(err)
+		return v.issues
+	}
+	if _, err := v.dec.Token(); err != io.EOF {
+		line, col := offsetPosition(content, int(v.dec.InputOffset()))
+		v.issues = append(v.issues, configIssue{Line: line, Column: col, Message: "unexpected data after the top-level object"})
+	}
+	return v.issues
+}
+
+type configValidator struct {
+	content []byte
+	dec     *json.Decoder
+	issues  []configIssue
+}
+
+func (v *configValidator) add(offset int64, field, message string, unknown bool) {
+	line, col := offsetPosition(v.content, int(offset))
+	v.issues = append(v.issues, configIssue{Line: line, Column: col, Field: field, Message: message, Unknown: unknown})
+}
+
+func (v *configValidator) addSyntaxError(err error) {
+	var syntaxErr *json.SyntaxError
+	offset := v.dec.InputOffset()
+	message := err.Error()
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+		if offset > 0 {
+			offset--
+		}
+	} else if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		offset = int64(len(v.content))
+		message = "unexpected end of file"
+	}
+	line, col := positionAt(v.content, int(offset))
+	v.issues = append(v.issues, configIssue{Line: line, Column: col, Message: "syntax error: " + message})
+}
+
+func (v *configValidator) value(node *schemaNode, path string) error {
+	start := v.dec.InputOffset()
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			if node != nil {
+				v.add(start, path, fmt.Sprintf("expected %s, got array", node.Kind), false)
+			}
+			for v.dec.More() {
+				if err := v.value(nil, path+"[]"); err != nil {
+					return err
+				}
+			}
+			_, err := v.dec.Token()
+			return err
+		}
+		if node != nil && node.Kind != kindObject && node.Kind != kindMap {
+			v.add(start, path, fmt.Sprintf("expected %s, got object", node.Kind), false)
+			node = nil
+		}
+		for v.dec.More() {
+			keyOffset := v.dec.InputOffset()
+			keyTok, err := v.dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			var child *schemaNode
+			if node != nil {
+				switch node.Kind {
+				case kindMap:
+					child = node.Values
+				case kindObject:
+					child = node.Fields[key]
+					if child == nil {
+						v.add(keyOffset, childPath, "unknown field (expected one of: "+strings.Join(schemaFieldNames(node), ", ")+")", true)
+					}
+				}
+			}
+			if err := v.value(child, childPath); err != nil {
+				return err
+			}
+		}
+		_, err := v.dec.Token()
+		return err
+	case nil:
+		return nil
+	default:
+		if node == nil {
+			return nil
+		}
+		got := jsonKindName(t)
+		if got != node.Kind.String() {
+			v.add(start, path, fmt.Sprintf("expected %s, got %s", node.Kind, got), false)
+		}
+		return nil
+	}
+}
+
+func schemaFieldNames(node *schemaNode) []string {
+	names := make([]string, 0, len(node.Fields))
+	for name := range node.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func jsonKindName(tok json.Token) string {
+	switch tok.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	}
+	return "value"
+}
+
+// offsetPosition returns the 1-based line and column of the first token at
+// or after offset, skipping the whitespace and separators the decoder has
+// not consumed yet.
+func offsetPosition(content []byte, offset int) (int, int) {
+	for offset < len(content) && strings.IndexByte(" \t\r\n,:", content[offset]) >= 0 {
+		offset++
+	}
+	return positionAt(content, offset)
+}
+
+func positionAt(content []byte, offset int) (int, int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	line, col := 1, 1
+	for _, b := range content[:offset] {
+		if b == '\n' {
+			line++
+			col = 1
+			continue
+		}
+		col++
+	}
+	return line, col
+}
+
+// configBlockingIssues drops unknown-key findings, which are reported as
+// warnings so configs written by newer releases still load.
+func configBlockingIssues(issues []configIssue) []configIssue {
+	blocking := make([]configIssue, 0, len(issues))
+	for _, issue := range issues {
+		if !issue.Unknown {
+			blocking = append(blocking, issue)
+		}
+	}
+	return blocking
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigJSONReportsLineAndField(t *testing.T) {
+	content := []byte("{\n  \"token\": 42,\n  \"tokn\": \"abc\"\n}\n")
+	issues := validateConfigJSON(content, storeConfigSchema)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", issues)
+	}
+	if issues[0].Line != 2 || issues[0].Column != 12 || issues[0].Field != "token" || !strings.Contains(issues[0].Message, "expected string, got number") {
+		t.Fatalf("unexpected type issue: %+v", issues[0])
+	}
+	if issues[1].Line != 3 || issues[1].Column != 3 || issues[1].Field != "tokn" || !issues[1].Unknown {
+		t.Fatalf("unexpected unknown-key issue: %+v", issues[1])
+	}
+	if blocking := configBlockingIssues(issues); len(blocking) != 1 {
+		t.Fatalf("expected only the type mismatch to block loading, got %+v", blocking)
+	}
+}
+
+func TestValidateConfigJSONReportsSyntaxErrorPosition(t *testing.T) {
+	content := []byte("{\n  \"token\": \"abc\",\n}\n")
+	issues := validateConfigJSON(content, storeConfigSchema)
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %+v", issues)
+	}
+	if issues[0].Line != 3 || !strings.HasPrefix(issues[0].Message, "syntax error") {
+		t.Fatalf("unexpected syntax issue: %+v", issues[0])
+	}
+}
+
+func TestValidateConfigJSONAcceptsValidConfig(t *testing.T) {
+	if issues := validateConfigJSON([]byte(`{"token":"abc"}`), storeConfigSchema); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	vtOnce      sync.Once
+	vtSupported bool
+)
+
+// terminalSupportsVT reports whether stdout understands ANSI/VT escape
+// sequences. On Windows this also switches the console into VT mode, which
+// legacy conhost leaves off by default. HUBFLY_PLAIN=1 forces the plain
+// numbered-prompt flow everywhere.
+func terminalSupportsVT() bool {
+	vtOnce.Do(func() {
+		if plain := strings.TrimSpace(os.Getenv("HUBFLY_PLAIN")); plain != "" && plain != "0" {
+			return
+		}
+		vtSupported = enableVirtualTerminal()
+	})
+	return vtSupported
+}
+
+func teaProgramOptions() []tea.ProgramOption {
+	if !terminalSupportsVT() {
+		return nil
+	}
+	return []tea.ProgramOption{tea.WithAltScreen()}
+}
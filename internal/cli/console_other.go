@@ -0,0 +1,16 @@
+//go:build !windows
+
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+func enableVirtualTerminal() bool {
+	return os.Getenv("TERM") != "dumb"
+}
+
+func clearScreenFallback() {
+	fmt.Print("\n\n")
+}
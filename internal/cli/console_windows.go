@@ -0,0 +1,37 @@
+//go:build windows
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+func enableVirtualTerminal() bool {
+	handle := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console (redirected output or a mintty pipe); those hosts
+		// either ignore escapes or already interpret them.
+		return os.Getenv("TERM") != "" && os.Getenv("TERM") != "dumb"
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		debugf("console VT processing unavailable: %v", err)
+		return false
+	}
+	return true
+}
+
+func clearScreenFallback() {
+	cmd := exec.Command("cmd", "/c", "cls")
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		fmt.Print("\n\n")
+	}
+}
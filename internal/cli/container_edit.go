@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const maxContainerTagLength = 63
+
+// updateContainerConfig sends change to the container config endpoint. APIs
+// without support for the field answer 404, 405 or 501, which is reported
+// as such instead of as a failed request.
+func updateContainerConfig(token, projectID, containerID, what string, change map[string]any) error {
+	_, err := patchProjectContainerConfig(token, projectID, containerID, change)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return fmt.Errorf("this Hubfly API does not support %s: %w", what, err)
+		}
+	}
+	return err
+}
+
+func renameContainer(token, projectID, containerID, name string) error {
+	return updateContainerConfig(token, projectID, containerID, "renaming containers", map[string]any{"name": name})
+}
+
+func setContainerTags(token, projectID, containerID string, tags []string) error {
+	if tags == nil {
+		tags = []string{}
+	}
+	return updateContainerConfig(token, projectID, containerID, "container tags", map[string]any{"tags": tags})
+}
+
+// validateContainerName accepts names deploy would generate itself:
+// lowercase letters, digits and single dashes.
+func validateContainerName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("container name cannot be empty")
+	}
+	if clean := sanitizeContainerName(name); clean != name {
+		return fmt.Errorf("invalid container name %q: use lowercase letters, digits and dashes, e.g. %q", name, clean)
+	}
+	return nil
+}
+
+// parseContainerTags splits a comma or space separated tag list, dropping
+// duplicates and keeping the order given.
+func parseContainerTags(raw string) ([]string, error) {
+	fields := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	tags := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, tag := range fields {
+		if len(tag) > maxContainerTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxContainerTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// editContainerTags applies additions and removals to current.
+func editContainerTags(current, add, remove []string) []string {
+	drop := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		drop[tag] = true
+	}
+	out := make([]string, 0, len(current)+len(add))
+	seen := make(map[string]bool, len(current)+len(add))
+	for _, tag := range append(append([]string{}, current...), add...) {
+		if drop[tag] || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+func containersRenameFlow(args []string) error {
+	const usage = "usage: hubfly containers rename <containerIdOrName> <newName> [--project <id|name>]"
+	fs := newFlagSet("containers rename")
+	projectQuery := fs.String("project", "", "project id or name to search")
+	// Accept the names before or after the flags.
+	var positional []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return errors.New(usage)
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 2 {
+		return errors.New(usage)
+	}
+	newName := strings.TrimSpace(positional[1])
+	if err := validateContainerName(newName); err != nil {
+		return err
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	p, c, err := findContainerInProjects(token, *projectQuery, strings.TrimSpace(positional[0]))
+	if err != nil {
+		return err
+	}
+	if c.Name == newName {
+		fmt.Printf("Container %s is already named %s.\n", c.ID, newName)
+		return nil
+	}
+	if err := renameContainer(token, p.ID, c.ID, newName); err != nil {
+		return err
+	}
+	if jsonOutput {
+		c.Name = newName
+		return printJSON(newContainerListEntry(p, c))
+	}
+	fmt.Printf("Renamed %s to %s (%s).\n", c.Name, newName, c.ID)
+	return nil
+}
+
+func containersTagsFlow(args []string) error {
+	const usage = "usage: hubfly containers tags <containerIdOrName> [--add <tags>] [--remove <tags>] [--set <tags>] [--project <id|name>]"
+	fs := newFlagSet("containers tags")
+	projectQuery := fs.String("project", "", "project id or name to search")
+	addRaw := fs.String("add", "", "comma separated tags to add")
+	removeRaw := fs.String("remove", "", "comma separated tags to remove")
+	setRaw := fs.String("set", "", "replace all tags; an empty value clears them")
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return errors.New(usage)
+	}
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		name = ""
+	}
+	if strings.TrimSpace(name) == "" {
+		return errors.New(usage)
+	}
+	replace := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "set" {
+			replace = true
+		}
+	})
+	if replace && (*addRaw != "" || *removeRaw != "") {
+		return errors.New("--set cannot be combined with --add or --remove")
+	}
+	add, err := parseContainerTags(*addRaw)
+	if err != nil {
+		return err
+	}
+	remove, err := parseContainerTags(*removeRaw)
+	if err != nil {
+		return err
+	}
+	set, err := parseContainerTags(*setRaw)
+	if err != nil {
+		return err
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	p, c, err := findContainerInProjects(token, *projectQuery, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+
+	tags := c.Tags
+	if replace || len(add) > 0 || len(remove) > 0 {
+		if replace {
+			tags = set
+		} else {
+			tags = editContainerTags(c.Tags, add, remove)
+		}
+		if err := setContainerTags(token, p.ID, c.ID, tags); err != nil {
+			return err
+		}
+	}
+	if jsonOutput {
+		return printJSON(tags)
+	}
+	if len(tags) == 0 {
+		fmt.Printf("%s has no tags.\n", c.Name)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", c.Name, strings.Join(tags, ", "))
+	return nil
+}
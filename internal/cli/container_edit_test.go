@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hubfly-cli/internal/testsupport"
+)
+
+func TestEditContainerTags(t *testing.T) {
+	got := editContainerTags([]string{"prod", "eu"}, []string{"billing", "prod"}, []string{"eu"})
+	if want := []string{"prod", "billing"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("editContainerTags = %v, want %v", got, want)
+	}
+	tags, err := parseContainerTags("a, b,,a c")
+	if err != nil || !reflect.DeepEqual(tags, []string{"a", "b", "c"}) {
+		t.Fatalf("parseContainerTags = %v, %v", tags, err)
+	}
+	if err := validateContainerName("My App"); err == nil {
+		t.Error("name with spaces and capitals accepted")
+	}
+}
+
+func TestContainersTagsAndRenameFlows(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HUBFLY_API_URL", "")
+	original := apiHost
+	t.Cleanup(func() { apiHost = original })
+
+	api := testsupport.NewMockAPI(t)
+	apiHost = api.URL
+	if err := setToken("user-token"); err != nil {
+		t.Fatal(err)
+	}
+	const configPath = "/api/v1/projects/p1/containers/c1/config"
+	api.Handle(http.MethodGet, "/api/v1/auth/me", user{ID: "u1", Name: "Test", Email: "t@example.com"})
+	api.Handle(http.MethodGet, "/api/v1/projects", apiPage[project]{Items: []project{{ID: "p1", Name: "shop"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p1", map[string]any{
+		"containers": []map[string]any{{"id": "c1", "name": "web", "tags": []string{"prod"}}},
+		"volumes":    []any{},
+	})
+	api.Handle(http.MethodPost, configPath, map[string]any{})
+
+	if err := containersTagsFlow([]string{"web", "--add", "eu,billing", "--remove", "prod"}); err != nil {
+		t.Fatalf("containersTagsFlow: %v", err)
+	}
+	req, ok := api.LastRequest(http.MethodPost, configPath)
+	if !ok {
+		t.Fatal("config request was not sent")
+	}
+	var body struct {
+		Name *string  `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Name != nil || !reflect.DeepEqual(body.Tags, []string{"eu", "billing"}) {
+		t.Fatalf("tags body = %s", req.Body)
+	}
+
+	api.HandleError(http.MethodPost, configPath, http.StatusNotImplemented, "NOT_IMPLEMENTED", "not implemented")
+	err := containersRenameFlow([]string{"web", "storefront"})
+	if err == nil || !strings.Contains(err.Error(), "does not support renaming") {
+		t.Fatalf("rename against an API without support: %v", err)
+	}
+}
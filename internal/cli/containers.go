@@ -0,0 +1,424 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+func containersCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(containersUsage())
+	}
+	switch args[0] {
+	case "list", "ls":
+		return containersListFlow(args[1:])
+	case "get", "show":
+		return containersGetFlow(args[1:])
+	case "rename":
+		return containersRenameFlow(args[1:])
+	case "tags":
+		return containersTagsFlow(args[1:])
+	}
+	return errors.New(containersUsage())
+}
+
+func containersUsage() string {
+	return strings.TrimSpace(`
+usage: hubfly containers list [--project <id|name>] [--sort name|service|status] [--group-by service]
+       hubfly containers get <containerIdOrName> [--project <id|name>]
+       hubfly containers rename <containerIdOrName> <newName> [--project <id|name>]
+       hubfly containers tags <containerIdOrName> [--add <tags>] [--remove <tags>] [--set <tags>] [--project <id|name>]
+`)
+}
+
+type containerListEntry struct {
+	ProjectID   string   `json:"projectId"`
+	ProjectName string   `json:"projectName"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Source      string   `json:"source"`
+	ServiceType string   `json:"serviceType"`
+	Tier        string   `json:"tier"`
+	Tags        []string `json:"tags,omitempty"`
+	CPU         float64  `json:"cpu"`
+	RAM         float64  `json:"ramMb"`
+	Storage     float64  `json:"storageGb"`
+	Ports       []int    `json:"ports"`
+	Alias       string   `json:"networkAlias,omitempty"`
+}
+
+func newContainerListEntry(p project, c container) containerListEntry {
+	ports := make([]int, 0, len(c.Networking.Ports))
+	for _, port := range c.Networking.Ports {
+		ports = append(ports, port.Container)
+	}
+	return containerListEntry{
+		ProjectID:   p.ID,
+		ProjectName: p.Name,
+		ID:          c.ID,
+		Name:        c.Name,
+		Status:      c.Status,
+		Source:      c.Source.Type,
+		ServiceType: inferServiceType(c),
+		Tier:        c.Tier,
+		Tags:        c.Tags,
+		CPU:         c.Resources.CPU,
+		RAM:         c.Resources.RAM,
+		Storage:     c.Resources.Storage,
+		Ports:       ports,
+		Alias:       c.PrimaryNetworkAlias,
+	}
+}
+
+// scopedProjects returns the project matching query. Without a query it
+// returns just the default project when one is set (see
+// resolveDefaultProject), and every project otherwise.
+func scopedProjects(token, query string) ([]project, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		p, ok, err := resolveDefaultProject(token)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return []project{p}, nil
+		}
+		return fetchProjects(token)
+	}
+	projects, err := fetchProjects(token)
+	if err != nil {
+		return nil, err
+	}
+	selected, ok := resolveRequestedProject(projects, query)
+	if !ok {
+		return nil, fmt.Errorf("project '%s' not found", query)
+	}
+	return []project{selected}, nil
+}
+
+func containersListFlow(args []string) error {
+	fs := newFlagSet("containers list")
+	projectQuery := fs.String("project", "", "limit the listing to one project id or name")
+	allProjects := fs.Bool("all-projects", false, "list every project, not just the default one")
+	sortBy := fs.String("sort", "", "order by name, service or status")
+	groupBy := fs.String("group-by", "", "group the table by service type")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || (*allProjects && *projectQuery != "") {
+		return errors.New("usage: hubfly containers list [--project <id|name> | --all-projects] [--sort name|service|status] [--group-by service]")
+	}
+	switch *sortBy {
+	case "", "name", "service", "status":
+	default:
+		return fmt.Errorf("unknown sort %q: use name, service or status", *sortBy)
+	}
+	if *groupBy != "" && *groupBy != "service" {
+		return fmt.Errorf("unknown grouping %q: only service is supported", *groupBy)
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	var projects []project
+	if *allProjects {
+		projects, err = fetchProjects(token)
+	} else {
+		projects, err = scopedProjects(token, *projectQuery)
+	}
+	if err != nil {
+		return err
+	}
+	entries := make([]containerListEntry, 0)
+	for i, r := range fetchProjectDetails(token, projects, nil) {
+		p := projects[i]
+		if r.err != nil {
+			return fmt.Errorf("failed to fetch containers for %s: %w", p.Name, r.err)
+		}
+		for _, c := range r.details.Containers {
+			entries = append(entries, newContainerListEntry(p, c))
+		}
+	}
+
+	sortContainerEntries(entries, *sortBy, *groupBy == "service")
+
+	if jsonOutput {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No containers found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	if *groupBy == "service" {
+		// The service type leads and is only printed on a group's first row.
+		_, _ = fmt.Fprintln(tw, "Service\tProject\tName\tStatus\tType\tCPU\tRAM(MB)\tPorts\tID")
+		for i, e := range entries {
+			group := e.ServiceType
+			if i > 0 && entries[i-1].ServiceType == group {
+				group = ""
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.2f\t%.0f\t%s\t%s\n",
+				group, e.ProjectName, e.Name, e.Status, valueOrDash(e.Source), e.CPU, e.RAM, formatPortList(e.Ports), e.ID)
+		}
+		return tw.Flush()
+	}
+	_, _ = fmt.Fprintln(tw, "Project\tName\tStatus\tService\tType\tCPU\tRAM(MB)\tPorts\tID")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.2f\t%.0f\t%s\t%s\n",
+			e.ProjectName, e.Name, e.Status, e.ServiceType, valueOrDash(e.Source), e.CPU, e.RAM, formatPortList(e.Ports), e.ID)
+	}
+	return tw.Flush()
+}
+
+// sortContainerEntries orders entries by the --sort key, keeping the API's
+// order for ties. Grouping by service puts the service type first.
+func sortContainerEntries(entries []containerListEntry, sortBy string, groupByService bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if groupByService || sortBy == "service" {
+			if ra, rb := serviceTypeRank(a.ServiceType), serviceTypeRank(b.ServiceType); ra != rb {
+				return ra < rb
+			}
+		}
+		switch sortBy {
+		case "name":
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case "status":
+			return a.Status < b.Status
+		}
+		return false
+	})
+}
+
+func containersGetFlow(args []string) error {
+	fs := newFlagSet("containers get")
+	projectQuery := fs.String("project", "", "project id or name to search")
+	// Accept the container before or after the flags.
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		name = ""
+	}
+	if strings.TrimSpace(name) == "" {
+		return errors.New("usage: hubfly containers get <containerIdOrName> [--project <id|name>]")
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	p, c, err := findContainerInProjects(token, *projectQuery, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	entry := newContainerListEntry(p, c)
+
+	if jsonOutput {
+		return printJSON(entry)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Name:\t%s\n", entry.Name)
+	_, _ = fmt.Fprintf(tw, "ID:\t%s\n", entry.ID)
+	_, _ = fmt.Fprintf(tw, "Project:\t%s (%s)\n", entry.ProjectName, entry.ProjectID)
+	_, _ = fmt.Fprintf(tw, "Status:\t%s\n", entry.Status)
+	_, _ = fmt.Fprintf(tw, "Type:\t%s\n", valueOrDash(entry.Source))
+	_, _ = fmt.Fprintf(tw, "Service:\t%s\n", entry.ServiceType)
+	_, _ = fmt.Fprintf(tw, "Tier:\t%s\n", valueOrDash(entry.Tier))
+	_, _ = fmt.Fprintf(tw, "Tags:\t%s\n", valueOrDash(strings.Join(entry.Tags, ", ")))
+	_, _ = fmt.Fprintf(tw, "Resources:\t%.2f CPU, %.0f MB RAM, %.0f GB storage\n", entry.CPU, entry.RAM, entry.Storage)
+	_, _ = fmt.Fprintf(tw, "Ports:\t%s\n", formatPortList(entry.Ports))
+	_, _ = fmt.Fprintf(tw, "Network alias:\t%s\n", valueOrDash(entry.Alias))
+	return tw.Flush()
+}
+
+// findContainerInProjects resolves a container by id or name, optionally
+// restricted to one project. Without a project it tries the default project,
+// then the API's container search, and scans every project only when the
+// search is not available.
+func findContainerInProjects(token, projectQuery, containerIDOrName string) (project, container, error) {
+	containerIDOrName = resolveContainerAlias(containerIDOrName)
+	if strings.TrimSpace(projectQuery) != "" {
+		projects, err := scopedProjects(token, projectQuery)
+		if err != nil {
+			return project{}, container{}, err
+		}
+		p, c, found, err := searchProjectsForContainer(token, projects, containerIDOrName)
+		if found || err != nil {
+			return p, c, err
+		}
+		return project{}, container{}, fmt.Errorf("container '%s' not found in project '%s'", containerIDOrName, projectQuery)
+	}
+
+	defaultProject, hasDefault, err := resolveDefaultProject(token)
+	if err != nil {
+		return project{}, container{}, err
+	}
+	if hasDefault {
+		p, c, found, err := searchProjectsForContainer(token, []project{defaultProject}, containerIDOrName)
+		if found || err != nil {
+			return p, c, err
+		}
+	}
+	if p, c, found, searched := lookupContainer(token, containerIDOrName); searched {
+		if found {
+			return p, c, nil
+		}
+		return project{}, container{}, fmt.Errorf("container '%s' not found in any project", containerIDOrName)
+	}
+
+	all, err := fetchProjects(token)
+	if err != nil {
+		return project{}, container{}, err
+	}
+	others := make([]project, 0, len(all))
+	for _, candidate := range all {
+		if !hasDefault || candidate.ID != defaultProject.ID {
+			others = append(others, candidate)
+		}
+	}
+	p, c, found, err := searchProjectsForContainer(token, others, containerIDOrName)
+	if found || err != nil {
+		return p, c, err
+	}
+	return project{}, container{}, fmt.Errorf("container '%s' not found in any project", containerIDOrName)
+}
+
+// containerSearchUnavailable is set once the API has answered that it has
+// no container search, so the rest of the run goes straight to the scan.
+var containerSearchUnavailable bool
+
+// lookupContainer finds a container with the API's container search. It
+// reports searched=false when the search could not be used, and the caller
+// should scan the projects instead.
+func lookupContainer(token, containerIDOrName string) (project, container, bool, bool) {
+	if containerSearchUnavailable {
+		return project{}, container{}, false, false
+	}
+	matches, err := searchContainers(token, containerIDOrName)
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && (apiErr.Status == http.StatusNotFound || apiErr.Status == http.StatusMethodNotAllowed) {
+			containerSearchUnavailable = true
+		}
+		debugf("container search unavailable, scanning projects: %v", err)
+		return project{}, container{}, false, false
+	}
+	// The search may match on parts of a name; only an exact id or name
+	// counts, as it does in the scan.
+	for _, m := range matches {
+		if m.ID == containerIDOrName || m.Name == containerIDOrName {
+			return project{ID: m.ProjectID, Name: m.ProjectName}, m.container, true, true
+		}
+	}
+	return project{}, container{}, false, true
+}
+
+// searchProjectsForContainer looks for the container in each project's
+// details. A project that fails to load is skipped unless it is the only one.
+func searchProjectsForContainer(token string, projects []project, containerIDOrName string) (project, container, bool, error) {
+	find := func(details projectDetails) (container, bool) {
+		for _, c := range details.Containers {
+			if c.ID == containerIDOrName || c.Name == containerIDOrName {
+				return c, true
+			}
+		}
+		return container{}, false
+	}
+	fetched := fetchProjectDetails(token, projects, func(details projectDetails) bool {
+		_, found := find(details)
+		return found
+	})
+	for i, r := range fetched {
+		if r.err != nil {
+			if len(projects) == 1 {
+				return project{}, container{}, false, r.err
+			}
+			continue
+		}
+		if c, found := find(r.details); found {
+			return projects[i], c, true, nil
+		}
+	}
+	return project{}, container{}, false, nil
+}
+
+// projectFetchWorkers bounds the project detail requests running at once
+// when several projects are listed or searched.
+const projectFetchWorkers = 8
+
+type projectFetch struct {
+	details projectDetails
+	err     error
+}
+
+// fetchProjectDetails fetches the details of projects a few at a time and
+// returns them in the same order. When stop returns true for a project,
+// projects after it are no longer fetched and are left empty; every project
+// before it still is, so the first match in list order can be picked.
+func fetchProjectDetails(token string, projects []project, stop func(projectDetails) bool) []projectFetch {
+	fetched := make([]projectFetch, len(projects))
+	var first atomic.Int64
+	first.Store(int64(len(projects)))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(projectFetchWorkers, len(projects)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if int64(i) > first.Load() {
+					continue
+				}
+				details, err := fetchProject(token, projects[i].ID)
+				fetched[i] = projectFetch{details: details, err: err}
+				if err != nil || stop == nil || !stop(details) {
+					continue
+				}
+				for {
+					current := first.Load()
+					if int64(i) >= current || first.CompareAndSwap(current, int64(i)) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	for i := range projects {
+		if int64(i) > first.Load() {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return fetched
+}
+
+func formatPortList(ports []int) string {
+	if len(ports) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(ports))
+	for _, port := range ports {
+		parts = append(parts, strconv.Itoa(port))
+	}
+	return strings.Join(parts, ",")
+}
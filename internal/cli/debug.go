@@ -1,24 +1,49 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// debugLogMaxBytes is the size at which debug.log is rotated to
+	// debug.log.1; debugLogBackups older generations are kept.
+	debugLogMaxBytes = 4 << 20
+	debugLogBackups  = 3
+	// debugQueueSize bounds the lines waiting for the log writer. Lines
+	// arriving while it is full are counted and dropped rather than stalling
+	// the TUI.
+	debugQueueSize     = 1024
+	debugFlushInterval = 500 * time.Millisecond
 )
 
 var (
-	debugEnabled bool
+	// debugEnabled is read on every debugf without the lock; the TUI can
+	// flip it while fetches and tunnels are logging from other goroutines.
+	debugEnabled atomic.Bool
 	debugQuiet   bool
 	debugMu      sync.Mutex
+	debugLog     *debugFileWriter
+
+	// The last message logged and how many times it has repeated since,
+	// so a retry loop produces one line and a count instead of thousands.
+	debugLast    string
+	debugRepeats int
+	debugDropped int
 )
 
 func configureDebug(args []string) []string {
 	for _, envName := range []string{"HUBFLY_DEBUG", "DEBUG"} {
 		value := strings.TrimSpace(strings.ToLower(os.Getenv(envName)))
 		if value == "1" || value == "true" || value == "yes" || value == "on" {
-			debugEnabled = true
+			debugEnabled.Store(true)
 			break
 		}
 	}
@@ -26,7 +51,7 @@ func configureDebug(args []string) []string {
 	filtered := make([]string, 0, len(args))
 	for _, arg := range args {
 		if arg == "--debug" {
-			debugEnabled = true
+			debugEnabled.Store(true)
 			continue
 		}
 		filtered = append(filtered, arg)
@@ -34,38 +59,218 @@ func configureDebug(args []string) []string {
 	return filtered
 }
 
+// setTUIDebugMode sends debug lines to ~/.hubfly/logs/debug.log while a TUI
+// owns the terminal. Leaving TUI mode flushes and closes the log.
 func setTUIDebugMode(active bool) {
 	debugMu.Lock()
 	defer debugMu.Unlock()
+	if active == debugQuiet {
+		return
+	}
+	flushDebugRepeatsLocked(time.Now())
 	debugQuiet = active
+	if active {
+		if debugEnabled.Load() {
+			debugLog = newDebugFileWriter(debugLogPath())
+		}
+		return
+	}
+	if debugLog != nil {
+		debugLog.close()
+		debugLog = nil
+	}
+	debugDropped = 0
+}
+
+func debugLogPath() string {
+	return filepath.Join(hubflyDir(), "logs", "debug.log")
+}
+
+// setDebugEnabled turns debug logging on or off at runtime. In TUI mode the
+// log file is opened or flushed and closed to match.
+func setDebugEnabled(on bool) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugEnabled.Load() == on {
+		return
+	}
+	if !on {
+		flushDebugRepeatsLocked(time.Now())
+	}
+	debugEnabled.Store(on)
+	if !debugQuiet {
+		return
+	}
+	if on && debugLog == nil {
+		debugLog = newDebugFileWriter(debugLogPath())
+	} else if !on && debugLog != nil {
+		debugLog.close()
+		debugLog = nil
+		debugDropped = 0
+	}
 }
 
+// debugf logs a debug line tagged with the file it was called from, so
+// `api`, `tunnel_sessions` and friends can be told apart in a busy log.
 func debugf(format string, a ...any) {
-	if !debugEnabled {
+	if !debugEnabled.Load() {
 		return
 	}
-	line := fmt.Sprintf("[debug] "+format+"\n", a...)
+	component := "cli"
+	if _, file, _, ok := runtime.Caller(1); ok {
+		component = strings.TrimSuffix(filepath.Base(file), ".go")
+	}
+	message := component + ": " + fmt.Sprintf(format, a...)
+	now := time.Now()
 
 	debugMu.Lock()
-	quiet := debugQuiet
-	debugMu.Unlock()
+	defer debugMu.Unlock()
+	if message == debugLast {
+		debugRepeats++
+		return
+	}
+	flushDebugRepeatsLocked(now)
+	debugLast = message
+	emitDebugLocked(now, message)
+}
 
-	if !quiet {
-		_, _ = fmt.Fprint(os.Stderr, line)
+// flushDebug reports a pending repeat count before the process exits.
+func flushDebug() {
+	if !debugEnabled.Load() {
 		return
 	}
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	flushDebugRepeatsLocked(time.Now())
+}
 
-	logDir := filepath.Join(hubflyDir(), "logs")
-	if err := os.MkdirAll(logDir, 0o700); err != nil {
+func flushDebugRepeatsLocked(now time.Time) {
+	if debugRepeats > 0 {
+		emitDebugLocked(now, fmt.Sprintf("last message repeated %d more time(s)", debugRepeats))
+	}
+	debugRepeats = 0
+	debugLast = ""
+}
+
+func emitDebugLocked(now time.Time, message string) {
+	line := formatDebugLine(now, message)
+	if !debugQuiet {
+		_, _ = fmt.Fprint(os.Stderr, line)
 		return
 	}
-	logPath := filepath.Join(logDir, "debug.log")
-	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
-	if err != nil {
+	if debugLog == nil {
 		return
 	}
-	defer func() { _ = f.Close() }()
-	_, _ = f.WriteString(line)
+	if debugDropped > 0 {
+		dropped := formatDebugLine(now, fmt.Sprintf("dropped %d debug line(s) while the log writer was busy", debugDropped))
+		if !debugLog.enqueue(dropped) {
+			debugDropped++
+			return
+		}
+		debugDropped = 0
+	}
+	if !debugLog.enqueue(line) {
+		debugDropped++
+	}
+}
+
+func formatDebugLine(now time.Time, message string) string {
+	return "[debug] " + now.Format("2006-01-02T15:04:05.000Z07:00") + " " + message + "\n"
+}
+
+// debugFileWriter appends queued lines to a log file from its own goroutine,
+// keeping the file open and buffered and flushing every debugFlushInterval.
+type debugFileWriter struct {
+	path  string
+	lines chan string
+	done  chan struct{}
+}
+
+func newDebugFileWriter(path string) *debugFileWriter {
+	w := &debugFileWriter{
+		path:  path,
+		lines: make(chan string, debugQueueSize),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *debugFileWriter) enqueue(line string) bool {
+	select {
+	case w.lines <- line:
+		return true
+	default:
+		return false
+	}
+}
+
+// close writes out everything queued and waits for the file to be closed.
+func (w *debugFileWriter) close() {
+	close(w.lines)
+	<-w.done
+}
+
+func (w *debugFileWriter) run() {
+	defer close(w.done)
+	var (
+		f    *os.File
+		buf  *bufio.Writer
+		size int64
+	)
+	closeFile := func() {
+		if f != nil {
+			_ = buf.Flush()
+			_ = f.Close()
+			f, buf = nil, nil
+		}
+	}
+	defer closeFile()
+
+	ticker := time.NewTicker(debugFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-w.lines:
+			if !ok {
+				return
+			}
+			if f != nil && size+int64(len(line)) > debugLogMaxBytes {
+				closeFile()
+				rotateLogFile(w.path, debugLogBackups)
+			}
+			if f == nil {
+				if err := os.MkdirAll(filepath.Dir(w.path), 0o700); err != nil {
+					continue
+				}
+				opened, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+				if err != nil {
+					continue
+				}
+				size = 0
+				if info, err := opened.Stat(); err == nil {
+					size = info.Size()
+				}
+				f, buf = opened, bufio.NewWriter(opened)
+			}
+			n, _ := buf.WriteString(line)
+			size += int64(n)
+		case <-ticker.C:
+			if buf != nil {
+				_ = buf.Flush()
+			}
+		}
+	}
+}
+
+// rotateLogFile shifts path to path.1, path.1 to path.2 and so on, dropping
+// the generation past backups.
+func rotateLogFile(path string, backups int) {
+	_ = os.Remove(fmt.Sprintf("%s.%d", path, backups))
+	for i := backups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	_ = os.Rename(path, path+".1")
 }
 
 func maskToken(token string) string {
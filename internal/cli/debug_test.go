@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTUIDebugLogSuppressesRepeats(t *testing.T) {
+	t.Cleanup(func() { storageRoot = ""; debugEnabled.Store(false) })
+	storageRoot = t.TempDir()
+	debugEnabled.Store(true)
+
+	setTUIDebugMode(true)
+	debugf("retrying %s", "db")
+	debugf("retrying %s", "db")
+	debugf("retrying %s", "db")
+	debugf("connected")
+	setTUIDebugMode(false)
+
+	content, err := os.ReadFile(filepath.Join(storageRoot, "logs", "debug.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), content)
+	}
+	for i, want := range []string{"debug_test: retrying db", "last message repeated 2 more time(s)", "debug_test: connected"} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Fatalf("line %d = %q, want suffix %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestRotateLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if err := os.WriteFile(name, []byte(filepath.Base(name)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rotateLogFile(path, 2)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("%s still exists after rotation", path)
+	}
+	for name, want := range map[string]string{path + ".1": "debug.log", path + ".2": "debug.log.1"} {
+		if got, _ := os.ReadFile(name); string(got) != want {
+			t.Fatalf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hubfly-cli/internal/demo"
+)
+
+// demoMode is set by --demo or HUBFLY_DEMO=1. The CLI then talks to an
+// in-process fixture API and keeps its storage in a scratch directory, so
+// nothing touches ~/.hubfly or the network.
+var (
+	demoMode    bool
+	demoAPIHost string
+)
+
+func configureDemo(args []string) ([]string, error) {
+	filtered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--demo" {
+			demoMode = true
+			continue
+		}
+		filtered = append(filtered, arg)
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("HUBFLY_DEMO"))) {
+	case "1", "true", "yes":
+		demoMode = true
+	}
+	if !demoMode {
+		return filtered, nil
+	}
+
+	dir := strings.TrimSpace(os.Getenv("HUBFLY_DEMO_DIR"))
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "hubfly-demo")
+	}
+	storageRoot = dir
+	// Detached tunnels and other child processes must land in the same
+	// sandbox, so export the resolved settings.
+	_ = os.Setenv("HUBFLY_DEMO", "1")
+	_ = os.Setenv("HUBFLY_DEMO_DIR", dir)
+
+	if err := ensurePrivateDir(hubflyDir()); err != nil {
+		return nil, fmt.Errorf("failed to prepare demo storage: %w", err)
+	}
+	server, err := demo.Start(filepath.Join(hubflyDir(), "demo"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start demo server: %w", err)
+	}
+	demoAPIHost = server.URL
+	if token, _ := getToken(); token == "" {
+		if err := setToken(demo.Token); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Demo mode: using fixture data (storage in %s)\n", dir)
+	return filtered, nil
+}
+
+// rebaseDemoTicket re-targets a stored ticket at this process's demo server;
+// the server that issued it may have exited with its process.
+func rebaseDemoTicket(t tunnel) tunnel {
+	if demoMode && demoAPIHost != "" {
+		t.ConnectURL = demo.RebaseURL(t.ConnectURL, demoAPIHost)
+	}
+	return t
+}
+
+// checkDemoSupported rejects commands that need real infrastructure (builds,
+// account changes, self-update, the background service) before they try to
+// reach it.
+func checkDemoSupported(command string) error {
+	if !demoMode {
+		return nil
+	}
+	switch command {
+	case "deploy", "stack", "build", "update", "login", "logout", "uninstall", "service":
+		return fmt.Errorf("%s is not available in demo mode", command)
+	}
+	return nil
+}
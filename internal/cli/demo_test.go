@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"hubfly-cli/internal/testsupport"
+)
+
+func TestDemoModeTunnelEchoesThroughGateway(t *testing.T) {
+	t.Setenv("HUBFLY_DEMO", "1")
+	t.Setenv("HUBFLY_DEMO_DIR", t.TempDir())
+	t.Cleanup(func() {
+		demoMode, demoAPIHost, storageRoot, apiHost = false, "", "", defaultAPIHost
+	})
+
+	if _, err := configureDemo(nil); err != nil {
+		t.Fatalf("configureDemo: %v", err)
+	}
+	apiHost = getAPIHost()
+	token, err := getToken()
+	if err != nil || token == "" {
+		t.Fatalf("demo token not seeded: %q, %v", token, err)
+	}
+
+	c, projectID, err := findContainer(token, "web")
+	if err != nil {
+		t.Fatalf("findContainer: %v", err)
+	}
+	created, err := createTunnel(token, projectID, createTunnelRequest{ContainerID: c.ID, TargetPort: 3000})
+	if err != nil {
+		t.Fatalf("createTunnel: %v", err)
+	}
+	if err := saveTunnelTicket(created); err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := loadTunnelTicket(created.TunnelID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := primaryTunnelTarget(ticket, 3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := testsupport.FreePort(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = serveTunnelGateway(ctx, ticket, target, port, newTunnelStats()) }()
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "ping")
+}
@@ -433,6 +433,9 @@ func ensureDeployProjectBinding(token, projectDir string, cfg *deployConfigFile,
 	}
 
 	requestedProject := strings.TrimSpace(opts.Project)
+	if requestedProject == "" && strings.TrimSpace(cfg.Project.ID) == "" {
+		requestedProject = defaultProjectQuery()
+	}
 	if requestedProject != "" {
 		if strings.EqualFold(requestedProject, "new") {
 			return createProjectBinding(token, projectDir, cfg, opts, "")
@@ -117,31 +117,33 @@ func fetchGitHubReleaseForRepo(tag, owner, repo string) (githubRelease, error) {
 
 func fetchGitHubReleaseAtURL(url string) (githubRelease, error) {
 	var rel githubRelease
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
+	if err := fetchGitHubJSON(url, &rel); err != nil {
 		return rel, err
 	}
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", "hubfly-cli/"+version.Version)
+	if strings.TrimSpace(rel.TagName) == "" {
+		return rel, fmt.Errorf("release metadata from github is missing tag_name")
+	}
+	return rel, nil
+}
+
+func fetchGitHubJSON(url string, out any) error {
+	req, err := newGitHubAPIRequest(url)
+	if err != nil {
+		return err
+	}
 
 	client := &http.Client{Timeout: 20 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return rel, err
+		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return rel, fmt.Errorf("github API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
-		return rel, err
+		return githubAPIError(resp, body)
 	}
-	if strings.TrimSpace(rel.TagName) == "" {
-		return rel, fmt.Errorf("release metadata from github is missing tag_name")
-	}
-	return rel, nil
+	return json.NewDecoder(resp.Body).Decode(out)
 }
 
 func expectedBuilderChecksumAssetName(assetName string) string {
@@ -168,7 +170,7 @@ func installBuilderFromRelease(targetPath string, release githubRelease, assetNa
 	if err != nil {
 		return "", err
 	}
-	if err := verifyFileSHA256(archivePath, expectedChecksum); err != nil {
+	if err := verifyFileSHA256(archivePath, expectedChecksum, "hubfly-builder download"); err != nil {
 		return "", err
 	}
 
@@ -221,7 +223,7 @@ func downloadAssetToTemp(assetURL, suffix string) (string, func(), error) {
 		return "", nil, fmt.Errorf("download failed with %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
-	tmpDir, err := os.MkdirTemp("", "hubfly-builder-download-*")
+	tmpDir, err := os.MkdirTemp("", "hubfly-download-*")
 	if err != nil {
 		return "", nil, err
 	}
@@ -283,7 +285,7 @@ func downloadReleaseChecksum(assetURL string) (string, error) {
 	return "", fmt.Errorf("checksum file was empty")
 }
 
-func verifyFileSHA256(path, expectedChecksum string) error {
+func verifyFileSHA256(path, expectedChecksum, what string) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return err
@@ -297,7 +299,7 @@ func verifyFileSHA256(path, expectedChecksum string) error {
 	actual := hex.EncodeToString(hash.Sum(nil))
 	expected := strings.ToLower(strings.TrimSpace(expectedChecksum))
 	if actual != expected {
-		return fmt.Errorf("checksum mismatch for hubfly-builder download: expected %s, got %s", expected, actual)
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", what, expected, actual)
 	}
 	return nil
 }
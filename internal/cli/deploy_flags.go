@@ -1,9 +1,7 @@
 package cli
 
 import (
-	"flag"
 	"fmt"
-	"io"
 	"os"
 	"strings"
 
@@ -30,8 +28,7 @@ func parseDeployOptions(args []string) (deployOptions, error) {
 		rest = rest[1:]
 	}
 
-	fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("deploy")
 	fs.BoolVar(&opts.Advanced, "advanced", opts.Advanced, "open config review mode before deploy")
 	fs.StringVar(&opts.Project, "project", "", "target project id, name, or 'new'")
 	fs.StringVar(&opts.Region, "region", "", "target region id or name")
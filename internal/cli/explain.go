@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// explainMode is set by --explain: the command is described instead of run.
+var explainMode bool
+
+func configureExplain(args []string) []string {
+	filtered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--explain" {
+			explainMode = true
+			continue
+		}
+		filtered = append(filtered, arg)
+	}
+	return filtered
+}
+
+// commandEffects lists what a command may do outside the process. Entries
+// are the worst case: a flag or a prompt answer can make a command skip some
+// of them, never add others.
+type commandEffects struct {
+	apiCalls    []string
+	network     []string
+	files       []string
+	removes     []string
+	processes   []string
+	interactive bool
+	note        string
+}
+
+// commandPlan is the JSON printed by --explain.
+type commandPlan struct {
+	Command      string   `json:"command"`
+	Args         []string `json:"args"`
+	APIHost      string   `json:"apiHost"`
+	APICalls     []string `json:"apiCalls"`
+	Network      []string `json:"network"`
+	FilesWritten []string `json:"filesWritten"`
+	FilesRemoved []string `json:"filesRemoved"`
+	Processes    []string `json:"processes"`
+	Interactive  bool     `json:"interactive"`
+	Note         string   `json:"note,omitempty"`
+}
+
+const (
+	effectConfig      = "~/.hubfly/config.json"
+	effectAudit       = "~/.hubfly/audit.log"
+	effectTicket      = "~/.hubfly/tunnels/<tunnelId>.json"
+	effectLocalPorts  = "~/.hubfly/state/local-ports.json"
+	effectLiveStatus  = "~/.hubfly/state/live/<tunnelId>-<localPort>.json"
+	effectSession     = "~/.hubfly/state/sessions/<name>.json"
+	effectSessionLog  = "~/.hubfly/state/sessions/<name>.log"
+	effectDeployState = "<projectDir>/.hubfly/compose-state.json"
+	effectConnect     = "hubfly __connect-tunnel <tunnelId> <localPort> <targetPort> (detached)"
+	effectGateway     = "tunnel gateway websocket (connectUrl from the tunnel ticket)"
+	effectLocalAPI    = "tunnel service control API on 127.0.0.1:<port>"
+	effectGitHub      = "https://api.github.com and release downloads"
+)
+
+const (
+	apiWhoAmI       = "GET /api/v1/auth/me"
+	apiProjects     = "GET /api/v1/projects"
+	apiProject      = "GET /api/v1/projects/<projectId>"
+	apiSearch       = "GET /api/v1/containers?query=<name>"
+	apiTunnels      = "GET /api/v1/projects/<projectId>/tunnels"
+	apiCreateTunnel = "POST /api/v1/projects/<projectId>/tunnels/create"
+	apiDeleteTunnel = "DELETE /api/v1/tunnels/<tunnelId>"
+	apiContainerCfg = "POST /api/v1/projects/<projectId>/containers/<containerId>/config"
+)
+
+// openTunnelEffects is shared by the commands that create a tunnel and hold
+// it open in the foreground.
+var openTunnelEffects = commandEffects{
+	apiCalls:  []string{apiProjects, apiSearch, apiProject, apiCreateTunnel},
+	network:   []string{effectGateway},
+	files:     []string{effectAudit, effectTicket, effectLocalPorts, effectLiveStatus},
+	removes:   []string{effectLiveStatus},
+	processes: []string{effectConnect + " when run in the background"},
+}
+
+// explainedCommands is keyed by "<command>" or "<command> <subcommand>".
+var explainedCommands = map[string]commandEffects{
+	"login": {
+		apiCalls:    []string{apiWhoAmI},
+		network:     []string{"https://hubfly.space/cli/auth (browser)", "callback listener on 127.0.0.1"},
+		files:       []string{effectConfig},
+		processes:   []string{"browser opener (open, xdg-open or rundll32)"},
+		interactive: true,
+	},
+	"logout":  {files: []string{effectConfig}},
+	"whoami":  {apiCalls: []string{apiWhoAmI}},
+	"orgs":    {apiCalls: []string{"GET /api/v1/organizations"}},
+	"version": {network: []string{effectGitHub + " with --verify"}},
+	"help":    {note: "help --browse opens an interactive browser of the same help."},
+	"projects": {
+		apiCalls: []string{
+			"GET /api/v1/organizations", apiProjects, apiProject, apiTunnels, apiCreateTunnel, apiDeleteTunnel,
+			"POST /api/v1/projects/<projectId>/containers/create", apiContainerCfg,
+			"POST /api/v1/projects/<projectId>/containers/<containerId>/{start,stop,restart,remove}",
+			"POST /api/v1/projects/<projectId>/volumes/create", "POST /api/v1/projects/<projectId>/volumes/<volumeId>/remove",
+			"GET /api/v1/projects/<projectId>/containers/<containerId>/logs",
+		},
+		network:     []string{effectGateway},
+		files:       []string{effectAudit, effectTicket, effectLocalPorts, effectLiveStatus, effectSession, effectSessionLog, "~/.hubfly/logs/debug.log"},
+		processes:   []string{effectConnect},
+		interactive: true,
+		note:        "The TUI only calls the API for the actions picked in it; with --json it only lists projects.",
+	},
+	"deploy": {
+		apiCalls: []string{
+			apiProjects, "GET /api/v1/regions", "POST /api/v1/projects/create",
+			"POST /api/v1/cli/deploy/sessions", "GET /api/v1/cli/deploy/sessions/<buildId>",
+			"POST /api/v1/cli/deploy/sessions/<buildId>/fail", "GET /api/v1/cli/deploy/containers/<containerId>",
+		},
+		network:     []string{"image upload URL from the deploy session", effectGitHub + " for hubfly-builder"},
+		files:       []string{effectAudit, "<projectDir>/hubfly.build.json", "<projectDir>/.hubfly/Dockerfile.generated", "~/.hubfly/tools/hubfly-builder", "~/.hubfly/tools/hubfly-builder.json", "~/.hubfly/tools/hubfly-builder-release-cache.json", "temporary build secret files"},
+		processes:   []string{"hubfly-builder offline inspect", "docker build", "docker image inspect", "docker image rm", "$EDITOR (advanced mode)"},
+		interactive: true,
+	},
+	"stack plan":   {},
+	"stack status": {apiCalls: []string{apiProject}},
+	"stack up": {
+		apiCalls: []string{
+			apiProjects, "GET /api/v1/regions", "POST /api/v1/projects/create", apiProject,
+			"POST /api/v1/projects/<projectId>/containers/create", apiContainerCfg,
+			"POST /api/v1/projects/<projectId>/containers/<containerId>/remove", "POST /api/v1/projects/<projectId>/volumes/create",
+			"POST /api/v1/cli/deploy/sessions", "GET /api/v1/cli/deploy/sessions/<buildId>",
+		},
+		network:     []string{"image upload URL from the deploy session"},
+		files:       []string{effectAudit, effectDeployState},
+		processes:   []string{"docker build"},
+		interactive: true,
+	},
+	"stack down": {
+		apiCalls:    []string{apiProject, "POST /api/v1/projects/<projectId>/containers/<containerId>/remove", "POST /api/v1/projects/<projectId>/volumes/<volumeId>/remove"},
+		files:       []string{effectAudit, effectDeployState},
+		interactive: true,
+	},
+	"stack logs": {apiCalls: []string{apiProject, "GET /api/v1/projects/<projectId>/containers/<containerId>/logs"}},
+	"stack exec": {apiCalls: []string{apiProject, "POST /api/v1/projects/<projectId>/containers/<containerId>/exec"}},
+	"stack ssh": {
+		apiCalls:    []string{apiProject, "POST /api/v1/projects/<projectId>/containers/<containerId>/terminal/session"},
+		network:     []string{"terminal websocket from the terminal session"},
+		interactive: true,
+	},
+	"build init":     {files: []string{"<projectDir>/hubfly.build.json"}, note: "--print writes to stdout instead."},
+	"build validate": {network: []string{effectGitHub + " for hubfly-builder"}, files: []string{"~/.hubfly/tools/hubfly-builder"}, processes: []string{"hubfly-builder offline inspect"}},
+	"build explain":  {network: []string{effectGitHub + " for hubfly-builder"}, files: []string{"~/.hubfly/tools/hubfly-builder"}, processes: []string{"hubfly-builder offline inspect"}},
+	"build edit": {
+		files:       []string{"<projectDir>/hubfly.build.json"},
+		processes:   []string{"$EDITOR"},
+		interactive: true,
+	},
+	"use":               {},
+	"use project":       {apiCalls: []string{apiProjects}, files: []string{effectConfig}, note: "--clear makes no API call."},
+	"containers list":   {apiCalls: []string{apiProjects, apiProject}},
+	"containers get":    {apiCalls: []string{apiProjects, apiSearch, apiProject}},
+	"containers rename": {apiCalls: []string{apiProjects, apiSearch, apiProject, apiContainerCfg}, files: []string{effectAudit}},
+	"containers tags":   {apiCalls: []string{apiProjects, apiSearch, apiProject, apiContainerCfg}, files: []string{effectAudit}},
+	"tunnel":            openTunnelEffects,
+	"tunnel list":       {apiCalls: []string{apiProjects, apiTunnels}},
+	"tunnel create":     {apiCalls: []string{apiProjects, apiSearch, apiProject, apiCreateTunnel}, files: []string{effectAudit, effectTicket}},
+	"tunnel delete":     {apiCalls: []string{apiDeleteTunnel}, files: []string{effectAudit}, removes: []string{effectTicket}},
+	"tunnel up": {
+		apiCalls:  []string{apiProjects, apiSearch, apiProject, apiCreateTunnel},
+		files:     []string{effectAudit, effectTicket, effectLocalPorts, effectSession, effectSessionLog, effectLiveStatus},
+		processes: []string{effectConnect},
+	},
+	"tunnel plan":    {apiCalls: []string{apiProjects, apiProject}},
+	"tunnel reverse": {apiCalls: []string{apiProjects, apiSearch, apiProject, apiCreateTunnel}, network: []string{effectGateway}, files: []string{effectAudit, effectTicket}},
+	"tunnel save":    {apiCalls: []string{apiProjects, apiProject}, files: []string{"~/.hubfly/tunnels.yaml"}},
+	"tunnel saved":   {files: []string{"~/.hubfly/tunnels.yaml"}, note: "Only `saved rm` writes the file."},
+	"tunnel ps":      {},
+	"tunnel down": {
+		removes:   []string{effectSession, effectLiveStatus},
+		processes: []string{"stops the __connect-tunnel process of the session"},
+	},
+	"tunnel check-expiry": {processes: []string{"--exec command through sh -c (cmd /C on Windows)"}},
+	"tunnel healthcheck":  {network: []string{"TCP connect to each tunnel gateway", effectGateway + " with --probe"}},
+	"logs":                {apiCalls: []string{apiProjects, apiSearch, apiProject, "GET /api/v1/projects/<projectId>/containers/<containerId>/logs"}},
+	"ssh": {
+		apiCalls:    []string{apiProjects, apiSearch, apiProject, "POST /api/v1/projects/<projectId>/containers/<containerId>/terminal/session"},
+		network:     []string{"terminal websocket from the terminal session"},
+		interactive: true,
+		note:        "With -- <cmd> it runs the command through the exec endpoint instead.",
+	},
+	"exec":           {apiCalls: []string{apiProjects, apiSearch, apiProject, "POST /api/v1/projects/<projectId>/containers/<containerId>/exec"}},
+	"report tunnels": {apiCalls: []string{apiProjects, apiTunnels}, files: []string{"--output file"}},
+	"keys prune":     {files: []string{effectAudit}, removes: []string{"~/.hubfly/keys/<tunnelId>", "~/.hubfly/keys/<tunnelId>.pub"}, note: "--dry-run removes nothing."},
+	"preset add":     {network: []string{"the preset URL"}, files: []string{"~/.hubfly/presets/presets.json", "~/.hubfly/presets/<name>"}},
+	"preset list":    {},
+	"preset update":  {network: []string{"each preset's URL"}, files: []string{"~/.hubfly/presets/presets.json", "~/.hubfly/presets/<name>"}},
+	"preset rm":      {files: []string{"~/.hubfly/presets/presets.json"}, removes: []string{"~/.hubfly/presets/<name>"}},
+	"config get":     {},
+	"config set":     {files: []string{effectConfig}},
+	"config unset":   {files: []string{effectConfig}},
+	"config use-profile": {
+		files: []string{effectConfig},
+	},
+	"config profiles":  {},
+	"config validate":  {},
+	"config workspace": {},
+	"migrate":          {},
+	"migrate status":   {},
+	"migrate up":       {files: []string{"~/.hubfly/layout.json", "~/.hubfly/backups/"}},
+	"migrate rollback": {files: []string{"~/.hubfly/layout.json"}},
+	"service": {
+		network: []string{"control API listener on :<port>", effectGateway},
+		files:   []string{"~/.hubfly/service.json", "~/.hubfly/service-state.json", "~/.hubfly/service-state.key"},
+	},
+	"service start": {
+		network:   []string{effectLocalAPI},
+		files:     []string{"~/.hubfly/logs/service.log", "~/.hubfly/service.json"},
+		processes: []string{"hubfly service --port <port> --idle-timeout <duration> --max-connection-rate <n> (detached)"},
+	},
+	"service install": {
+		network:   []string{effectLocalAPI},
+		files:     []string{"~/.config/systemd/user/hubfly-service.service", "~/Library/LaunchAgents/<label>.plist"},
+		processes: []string{"systemctl --user enable and restart", "loginctl enable-linger", "launchctl load -w", "Windows service HubflyService"},
+		note:      "Stops a service started with `hubfly service start` first. On Windows it needs an Administrator prompt.",
+	},
+	"service uninstall": {
+		removes:   []string{"~/.config/systemd/user/hubfly-service.*", "~/Library/LaunchAgents/<label>.plist", "Windows service HubflyService"},
+		processes: []string{"systemctl --user disable --now", "launchctl unload -w"},
+	},
+	"service status":        {network: []string{effectLocalAPI}},
+	"service logs":          {network: []string{effectLocalAPI}},
+	"service stop":          {network: []string{effectLocalAPI}},
+	"service set-log-level": {network: []string{effectLocalAPI}},
+	"replay":                {},
+	"audit":                 {},
+	"tips":                  {files: []string{"~/.hubfly/state/usage.json"}, note: "--clear removes the file instead."},
+	"update": {
+		network: []string{effectGitHub},
+		files:   []string{"the hubfly binary", "~/.hubfly/state/pending-update.json"},
+		note:    "--check only reads the latest release.",
+	},
+	"uninstall": {
+		apiCalls:    []string{apiDeleteTunnel + " with --revoke"},
+		removes:     []string{"~/.hubfly", "the hubfly binary", "~/.config/systemd/user/hubfly-service.*", "~/Library/LaunchAgents/<label>.plist"},
+		processes:   []string{"systemctl --user disable --now", "launchctl unload -w"},
+		interactive: true,
+		note:        "--keep-data and --keep-binary leave those paths alone.",
+	},
+}
+
+// explainAliases maps subcommand aliases to their explainedCommands key.
+var explainAliases = map[string]string{
+	"containers ls":   "containers list",
+	"containers show": "containers get",
+	"tunnel ls":       "tunnel list",
+	"tunnel rm":       "tunnel delete",
+	"preset ls":       "preset list",
+	"preset remove":   "preset rm",
+}
+
+// explainCommand prints what args would do without running it.
+func explainCommand(args []string) error {
+	if len(args) == 0 {
+		return printJSON(newCommandPlan("hubfly", nil, commandEffects{apiCalls: []string{apiWhoAmI}, files: []string{effectConfig}, interactive: true}))
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok || cmd.hidden {
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	key, ok := explainKey(cmd.name, args[1:])
+	if !ok {
+		return fmt.Errorf("no explanation for %q; run `hubfly help %s` for its subcommands", strings.Join(args, " "), cmd.name)
+	}
+	effects := explainedCommands[key]
+	return printJSON(newCommandPlan(key, args[1:], effects))
+}
+
+// explainKey finds the explainedCommands entry for a command and its
+// arguments, preferring the subcommand's own entry.
+func explainKey(name string, args []string) (string, bool) {
+	if len(args) > 0 {
+		sub := name + " " + args[0]
+		if alias, ok := explainAliases[sub]; ok {
+			sub = alias
+		}
+		if _, ok := explainedCommands[sub]; ok {
+			return sub, true
+		}
+	}
+	_, ok := explainedCommands[name]
+	return name, ok
+}
+
+func newCommandPlan(command string, args []string, effects commandEffects) commandPlan {
+	orEmpty := func(s []string) []string {
+		if s == nil {
+			return []string{}
+		}
+		return s
+	}
+	return commandPlan{
+		Command:      command,
+		Args:         orEmpty(args),
+		APIHost:      apiHost,
+		APICalls:     orEmpty(effects.apiCalls),
+		Network:      orEmpty(effects.network),
+		FilesWritten: orEmpty(effects.files),
+		FilesRemoved: orEmpty(effects.removes),
+		Processes:    orEmpty(effects.processes),
+		Interactive:  effects.interactive,
+		Note:         effects.note,
+	}
+}
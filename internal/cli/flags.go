@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"strings"
+)
+
+// cliFlags is the flag set every command parses its arguments with. On top
+// of the flag package it accepts --no-<name> to turn a boolean flag off, and
+// the aliases in flagAliases for commands that have the long flag.
+type cliFlags struct {
+	*flag.FlagSet
+}
+
+// flagAliases maps short or alternative flag names to the flag they stand
+// for. An alias only applies when the command has no flag of that name.
+var flagAliases = map[string]string{
+	"p":     "port",
+	"l":     "local-port",
+	"local": "local-port",
+}
+
+func newFlagSet(name string) cliFlags {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return cliFlags{fs}
+}
+
+func (f cliFlags) Parse(args []string) error {
+	return f.FlagSet.Parse(f.expandArgs(args))
+}
+
+// expandArgs rewrites aliases and negated flags into names the flag package
+// knows. Like the flag package, it stops at the first argument that is not a
+// flag, so positional arguments and anything after "--" are left alone.
+func (f cliFlags) expandArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			return append(out, args[i:]...)
+		}
+		dashes := "-"
+		if strings.HasPrefix(arg, "--") {
+			dashes = "--"
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f.Lookup(name) == nil {
+			if target, ok := flagAliases[name]; ok && f.Lookup(target) != nil {
+				name = target
+			} else if negated, ok := strings.CutPrefix(name, "no-"); ok && !hasValue && isBoolFlag(f.Lookup(negated)) {
+				out = append(out, dashes+negated+"=false")
+				continue
+			}
+		}
+		if hasValue {
+			out = append(out, dashes+name+"="+value)
+			continue
+		}
+		out = append(out, dashes+name)
+		if fl := f.Lookup(name); fl != nil && !isBoolFlag(fl) && i+1 < len(args) {
+			// The next argument is this flag's value, even if it starts
+			// with a dash.
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+func isBoolFlag(fl *flag.Flag) bool {
+	if fl == nil {
+		return false
+	}
+	b, ok := fl.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// `hubfly help --browse` lists the commands of cliCommands next to the help
+// for the one under the cursor. The list's filter searches the whole help
+// text, so typing a flag such as ttl finds the commands that take it.
+
+// helpTopic is one entry of the browser: a command, or the global flags.
+type helpTopic struct {
+	name    string
+	summary string
+	body    string
+}
+
+func (t helpTopic) Title() string       { return t.name }
+func (t helpTopic) Description() string { return t.summary }
+func (t helpTopic) FilterValue() string { return t.name + " " + t.summary + " " + t.body }
+
+func helpTopics() []helpTopic {
+	var topics []helpTopic
+	for _, cmd := range cliCommands() {
+		if cmd.hidden {
+			continue
+		}
+		topics = append(topics, helpTopic{name: cmd.name, summary: cmd.summary, body: commandHelpText(cmd)})
+	}
+	var b strings.Builder
+	b.WriteString("Accepted before or after any command.\n\n")
+	for _, f := range globalFlags {
+		name := "--" + f.name
+		if f.value != "" {
+			name += " " + f.value
+		}
+		b.WriteString("  " + name + "\n      " + f.help)
+		if f.env != "" {
+			b.WriteString(" (env " + f.env + ")")
+		}
+		b.WriteString("\n")
+	}
+	return append(topics, helpTopic{name: "global flags", summary: "Flags every command accepts", body: b.String()})
+}
+
+// commandHelpText is what printCommandHelp prints, less the global flags,
+// with the command's flags gathered from its synopses.
+func commandHelpText(cmd cliCommand) string {
+	var b strings.Builder
+	b.WriteString("Usage:\n")
+	for _, line := range cmd.usage {
+		if strings.HasPrefix(line, " ") {
+			b.WriteString("  " + strings.Repeat(" ", len("hubfly")) + line + "\n")
+			continue
+		}
+		b.WriteString("  hubfly " + line + "\n")
+	}
+	if flags := commandFlags(cmd); len(flags) > 0 {
+		b.WriteString("\nFlags:\n")
+		for _, f := range flags {
+			b.WriteString("  " + f + "\n")
+		}
+	}
+	if len(cmd.examples) > 0 {
+		b.WriteString("\nExamples:\n")
+		for _, example := range cmd.examples {
+			b.WriteString("  hubfly " + example + "\n")
+		}
+	}
+	if len(cmd.aliases) > 0 {
+		b.WriteString("\nAliases: " + strings.Join(cmd.aliases, ", ") + "\n")
+	}
+	if cmd.json {
+		b.WriteString("\nPrints JSON with --json.\n")
+	}
+	return b.String()
+}
+
+var synopsisFlag = regexp.MustCompile(`(?:^|[\s\[|(])(--?[a-z][a-z0-9-]*)(?:[ =](<[^>]+>|[a-z]+(?:\|[a-z]+)+))?`)
+
+// commandFlags lists the flags in cmd's synopses, each once, with the value
+// it takes.
+func commandFlags(cmd cliCommand) []string {
+	seen := map[string]bool{}
+	var flags []string
+	for _, line := range cmd.usage {
+		for _, m := range synopsisFlag.FindAllStringSubmatch(line, -1) {
+			if seen[m[1]] {
+				continue
+			}
+			seen[m[1]] = true
+			flags = append(flags, strings.TrimSpace(m[1]+" "+m[2]))
+		}
+	}
+	return flags
+}
+
+// helpFilter keeps the topics whose text contains every word of term. The
+// list's default fuzzy match finds almost any short word somewhere in a
+// command's help, so it would hardly narrow the list.
+func helpFilter(term string, targets []string) []list.Rank {
+	words := strings.Fields(strings.ToLower(term))
+	var ranks []list.Rank
+	for i, target := range targets {
+		target = strings.ToLower(target)
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(target, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ranks = append(ranks, list.Rank{Index: i})
+		}
+	}
+	return ranks
+}
+
+const helpListWidth = 30
+
+type helpBrowserModel struct {
+	list   list.Model
+	width  int
+	height int
+}
+
+func newHelpBrowserModel() helpBrowserModel {
+	topics := helpTopics()
+	items := make([]list.Item, 0, len(topics))
+	for _, topic := range topics {
+		items = append(items, topic)
+	}
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+	l := list.New(items, delegate, helpListWidth, 20)
+	l.Title = "hubfly help"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.Filter = helpFilter
+	l.SetShowHelp(true)
+	l.Styles.Title = l.Styles.Title.Bold(true).Foreground(lipgloss.Color("12"))
+	l.Styles.HelpStyle = l.Styles.HelpStyle.Foreground(lipgloss.Color("8"))
+	l.Styles.FilterPrompt = l.Styles.FilterPrompt.Foreground(lipgloss.Color("10")).Bold(true)
+	l.Styles.FilterCursor = l.Styles.FilterCursor.Foreground(lipgloss.Color("10")).Bold(true)
+	return helpBrowserModel{list: l, width: 100, height: 24}
+}
+
+func (m helpBrowserModel) Init() tea.Cmd { return nil }
+
+func (m helpBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.list.SetSize(helpListWidth, max(msg.Height-2, 10))
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.list.FilterState() != list.Filtering {
+			switch msg.String() {
+			case "q":
+				return m, tea.Quit
+			case "esc":
+				if m.list.FilterState() == list.Unfiltered {
+					return m, tea.Quit
+				}
+			}
+		}
+	}
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m helpBrowserModel) textFieldFocused() bool { return m.list.FilterState() == list.Filtering }
+
+func (m helpBrowserModel) recordState() tuiState {
+	state := tuiState{Screen: "help"}
+	if topic, ok := m.list.SelectedItem().(helpTopic); ok {
+		state.Status = "cursor: " + topic.name
+	}
+	return state
+}
+
+func (m helpBrowserModel) View() string {
+	detail := "No command matches the filter."
+	if topic, ok := m.list.SelectedItem().(helpTopic); ok {
+		title := lipgloss.NewStyle().Bold(true).Render(topic.name) + "  " + topic.summary
+		detail = title + "\n\n" + topic.body
+	}
+	pane := lipgloss.NewStyle().
+		Width(max(m.width-helpListWidth-4, 20)).
+		Height(max(m.height-2, 10)).
+		PaddingLeft(2).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).
+		BorderForeground(lipgloss.Color("8"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.list.View(), pane.Render(detail))
+}
+
+// browseHelp runs the help browser, or prints the usage where a TUI cannot
+// run.
+func browseHelp() error {
+	if !isInteractiveShell() || !terminalSupportsVT() {
+		printUsage()
+		return nil
+	}
+	p := tea.NewProgram(recordTUI(newHelpBrowserModel()), teaProgramOptions()...)
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("help browser: %w", err)
+	}
+	return nil
+}
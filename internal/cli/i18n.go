@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// User-facing strings are looked up by ID in a message catalog. English in
+// locales/en.json is the source every other catalog translates; a catalog
+// may leave messages out, and those stay in English.
+//
+// A translation ships as locales/<locale>.json. One can also be tried out
+// before it ships by placing it in ~/.hubfly/locales, where it is layered
+// over the bundled catalog of the same name. English always starts from the
+// bundled copy, so a broken or partial en.json there cannot lose messages.
+
+const defaultLocale = "en"
+
+//go:embed locales/*.json
+var bundledLocales embed.FS
+
+var catalog struct {
+	sync.Mutex
+	messages map[string]string
+}
+
+// tr returns the message id in the current locale, formatted with args the
+// way fmt.Sprintf would.
+func tr(id string, args ...any) string {
+	catalog.Lock()
+	if catalog.messages == nil {
+		catalog.messages = loadMessages(currentLocale())
+	}
+	msg, ok := catalog.messages[id]
+	catalog.Unlock()
+	if !ok {
+		debugf("no message %q in any catalog", id)
+		msg = id
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// resetMessages drops the loaded catalog so the next tr reads the locale
+// again, after it has been changed.
+func resetMessages() {
+	catalog.Lock()
+	catalog.messages = nil
+	catalog.Unlock()
+}
+
+// currentLocale is the profile's locale setting, then the usual POSIX
+// variables in the order setlocale reads them.
+func currentLocale() string {
+	if locale := activeProfile().Locale; locale != "" {
+		return locale
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return defaultLocale
+}
+
+// localeCandidates turns a locale such as pt_BR.UTF-8 into the catalogs to
+// layer over English, most general first: pt, then pt-br.
+func localeCandidates(locale string) []string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" || locale == "c" || locale == "posix" || locale == defaultLocale {
+		return nil
+	}
+	language, _, regional := strings.Cut(locale, "-")
+	if !regional || language == defaultLocale {
+		return []string{locale}
+	}
+	return []string{language, locale}
+}
+
+func loadMessages(locale string) map[string]string {
+	english, err := parseCatalog(bundledLocales.ReadFile("locales/" + defaultLocale + ".json"))
+	if err != nil {
+		// en.json is embedded, so this only happens in a broken build.
+		panic(fmt.Sprintf("locales/%s.json: %v", defaultLocale, err))
+	}
+	messages := make(map[string]string, len(english))
+	for id, msg := range english {
+		messages[id] = msg
+	}
+	for _, name := range append([]string{defaultLocale}, localeCandidates(locale)...) {
+		if name != defaultLocale {
+			layerCatalog(messages, english, "locales/"+name+".json", bundledLocales.ReadFile)
+		}
+		layerCatalog(messages, english, filepath.Join(localesDir(), name+".json"), os.ReadFile)
+	}
+	return messages
+}
+
+// layerCatalog copies the messages of the catalog at path over messages,
+// skipping any that do not fit the English one. A catalog that cannot be
+// read is skipped with a warning, and a missing one silently.
+func layerCatalog(messages, english map[string]string, path string, read func(string) ([]byte, error)) {
+	translated, err := parseCatalog(read(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		warnOnce("locale-"+path, fmt.Sprintf("warning: ignoring %s: %v", path, err))
+		return
+	}
+	for id, msg := range translated {
+		if problem := checkTranslation(english, id, msg); problem != "" {
+			debugf("%s: %s", path, problem)
+			continue
+		}
+		messages[id] = msg
+	}
+}
+
+// parseCatalog decodes the content of a catalog file, passing on the error
+// from reading it.
+func parseCatalog(content []byte, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	var messages map[string]string
+	if err := json.Unmarshal(content, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func localesDir() string {
+	return filepath.Join(hubflyDir(), "locales")
+}
+
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// checkTranslation describes why msg cannot stand in for the English
+// message id, or returns "". A translation must use the same format verbs
+// in the same order, or the arguments would land in the wrong place.
+func checkTranslation(english map[string]string, id, msg string) string {
+	source, ok := english[id]
+	if !ok {
+		return fmt.Sprintf("%q is not an English message", id)
+	}
+	if want, got := formatVerb.FindAllString(source, -1), formatVerb.FindAllString(msg, -1); strings.Join(want, " ") != strings.Join(got, " ") {
+		return fmt.Sprintf("%q uses %v where English uses %v", id, got, want)
+	}
+	return ""
+}
+
+// availableLocales lists the catalogs hubfly can use, bundled or installed.
+func availableLocales() []string {
+	seen := map[string]bool{}
+	bundled, _ := fs.Glob(bundledLocales, "locales/*.json")
+	installed, _ := filepath.Glob(filepath.Join(localesDir(), "*.json"))
+	for _, path := range append(bundled, installed...) {
+		seen[strings.TrimSuffix(filepath.Base(path), ".json")] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateLocale accepts a locale that at least one catalog covers.
+func validateLocale(locale string) error {
+	candidates := localeCandidates(locale)
+	if len(candidates) == 0 {
+		return nil
+	}
+	available := availableLocales()
+	for _, name := range candidates {
+		if i := sort.SearchStrings(available, name); i < len(available) && available[i] == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("no translation for %q (available: %s); add one to %s", locale, strings.Join(available, ", "), localesDir())
+}
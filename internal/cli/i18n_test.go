@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestEveryMessageHasEnglishText(t *testing.T) {
+	english, err := parseCatalog(bundledLocales.ReadFile("locales/" + defaultLocale + ".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := regexp.MustCompile(`\btr\("([^"]+)"[,)]`)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range call.FindAllStringSubmatch(string(content), -1) {
+			if _, ok := english[m[1]]; !ok {
+				t.Errorf("%s: message %q is not in locales/en.json", file, m[1])
+			}
+		}
+	}
+	for view := viewProjects; view <= viewTunnelDashboard; view++ {
+		for _, line := range tuiHelpLines(view, view == viewTunnelsSingle) {
+			if _, ok := english["tui.help."+line.desc]; !ok {
+				t.Errorf("help line %q is not in locales/en.json", line.desc)
+			}
+		}
+	}
+}
+
+func TestTranslationsLayerOverEnglish(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_AT.UTF-8")
+	t.Cleanup(resetMessages)
+	resetMessages()
+
+	if err := os.MkdirAll(localesDir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(localesDir(), name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("de.json", `{"tui.back": "Zurück", "tui.help.quit": "beenden", "tui.debug.on": "Debug-Log an: %d"}`)
+	write("de-at.json", `{"tui.help.quit": "beenden (AT)"}`)
+
+	if got := tr("tui.back"); got != "Zurück" {
+		t.Errorf("tui.back = %q, want the de translation", got)
+	}
+	if got := tr("tui.help.quit"); got != "beenden (AT)" {
+		t.Errorf("tui.help.quit = %q, want the regional de-AT translation", got)
+	}
+	if got := tr("tui.debug.on", "/tmp/debug.log"); got != "Debug logging on: writing to /tmp/debug.log." {
+		t.Errorf("translation with the wrong verb was used: %q", got)
+	}
+	if got := tr("tui.help.title"); got != "Keys on this screen" {
+		t.Errorf("untranslated message = %q, want English", got)
+	}
+
+	if err := validateLocale("fr_FR"); err == nil {
+		t.Error("locale without a catalog was accepted")
+	}
+	if err := validateLocale("de_AT"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInstalledEnglishCatalogIsALayer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("LC_ALL", "de_DE")
+	t.Cleanup(resetMessages)
+	resetMessages()
+
+	if err := os.MkdirAll(localesDir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(localesDir(), name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("en.json", `{"tui.back": "Go back"}`)
+	write("de.json", `{"tui.help.quit": "beenden"}`)
+
+	if got := tr("tui.help.title"); got != "Keys on this screen" {
+		t.Errorf("a partial en.json lost the bundled message: %q", got)
+	}
+	if got := tr("tui.help.quit"); got != "beenden" {
+		t.Errorf("a partial en.json rejected a translation: %q", got)
+	}
+
+	write("en.json", `{not json`)
+	resetMessages()
+	if got := tr("tui.help.title"); got != "Keys on this screen" {
+		t.Errorf("a malformed en.json = %q, want the bundled English", got)
+	}
+}
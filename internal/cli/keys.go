@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func keysCommand(args []string) error {
+	if len(args) == 0 || args[0] != "prune" {
+		return errors.New("usage: hubfly keys prune [--dry-run]")
+	}
+	return keysPruneFlow(args[1:])
+}
+
+// staleKey is a key pair in ~/.hubfly/keys whose tunnel is expired or gone.
+type staleKey struct {
+	TunnelID string   `json:"tunnelId"`
+	Reason   string   `json:"reason"`
+	Paths    []string `json:"paths"`
+}
+
+// keysPruneFlow deletes key pairs left by older CLI versions once the API no
+// longer lists their tunnel as active. Keys are only judged against a full
+// tunnel listing, so an API error removes nothing.
+func keysPruneFlow(args []string) error {
+	fs := newFlagSet("keys prune")
+	dryRun := fs.Bool("dry-run", false, "list the keys that would be removed without deleting them")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: hubfly keys prune [--dry-run]")
+	}
+
+	keyFiles, err := listKeyFiles()
+	if err != nil {
+		return err
+	}
+	if len(keyFiles) == 0 {
+		if jsonOutput {
+			return printJSON([]staleKey{})
+		}
+		fmt.Printf("No keys in %s.\n", keysDir())
+		return nil
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	live := make(map[string]tunnel)
+	if err := forEachProjectTunnel(token, "", func(_ project, t tunnel) {
+		live[sanitizeID(t.TunnelID)] = t
+	}); err != nil {
+		return err
+	}
+	stale := findStaleKeys(keyFiles, live)
+
+	if !*dryRun {
+		for _, k := range stale {
+			for _, path := range k.Paths {
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+			}
+			recordAudit("key.remove", k.TunnelID, "reason="+k.Reason)
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(stale)
+	}
+	if len(stale) == 0 {
+		fmt.Printf("All %d key(s) in %s belong to active tunnels.\n", len(keyFiles), keysDir())
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Tunnel ID\tReason\tFiles")
+	for _, k := range stale {
+		names := make([]string, 0, len(k.Paths))
+		for _, path := range k.Paths {
+			names = append(names, filepath.Base(path))
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", k.TunnelID, k.Reason, strings.Join(names, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Printf("Would remove %d key(s). Run without --dry-run to delete them.\n", len(stale))
+	} else {
+		fmt.Printf("Removed %d key(s).\n", len(stale))
+	}
+	return nil
+}
+
+// listKeyFiles groups the files in the keys directory by tunnel ID, so a
+// private key and its .pub are pruned together.
+func listKeyFiles() (map[string][]string, error) {
+	entries, err := os.ReadDir(keysDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	keyFiles := make(map[string][]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".pub")
+		keyFiles[id] = append(keyFiles[id], filepath.Join(keysDir(), entry.Name()))
+	}
+	return keyFiles, nil
+}
+
+// findStaleKeys returns the key groups whose tunnel is missing from live or
+// has expired, sorted by tunnel ID. live is keyed by sanitized tunnel ID.
+func findStaleKeys(keyFiles map[string][]string, live map[string]tunnel) []staleKey {
+	stale := make([]staleKey, 0)
+	for id, paths := range keyFiles {
+		reason := ""
+		if t, ok := live[id]; !ok {
+			reason = "gone"
+		} else if tunnelState(t.ExpiresAt) == "expired" {
+			reason = "expired"
+		}
+		if reason == "" {
+			continue
+		}
+		sorted := append([]string(nil), paths...)
+		sort.Strings(sorted)
+		stale = append(stale, staleKey{TunnelID: id, Reason: reason, Paths: sorted})
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].TunnelID < stale[j].TunnelID })
+	return stale
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindStaleKeys(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	keyFiles := map[string][]string{
+		"active":  {"keys/active", "keys/active.pub"},
+		"expired": {"keys/expired.pub", "keys/expired"},
+		"gone":    {"keys/gone"},
+	}
+	live := map[string]tunnel{
+		"active":  {TunnelID: "active", ExpiresAt: future},
+		"expired": {TunnelID: "expired", ExpiresAt: past},
+	}
+
+	stale := findStaleKeys(keyFiles, live)
+	if len(stale) != 2 {
+		t.Fatalf("got %d stale keys, want 2: %+v", len(stale), stale)
+	}
+	if stale[0].TunnelID != "expired" || stale[0].Reason != "expired" || stale[0].Paths[0] != "keys/expired" {
+		t.Fatalf("unexpected first entry: %+v", stale[0])
+	}
+	if stale[1].TunnelID != "gone" || stale[1].Reason != "gone" {
+		t.Fatalf("unexpected second entry: %+v", stale[1])
+	}
+}
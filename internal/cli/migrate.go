@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	layoutFileName = "layout.json"
+	backupsDirName = "backups"
+	keepBackups    = 5
+)
+
+// migration upgrades ~/.hubfly from Version-1 to Version. Up must be safe to
+// re-run on a tree it already upgraded: a crash between Up and the layout
+// write replays it on the next start.
+type migration struct {
+	Version int
+	Name    string
+	Up      func() error
+}
+
+var migrations = []migration{
+	{Version: 1, Name: "baseline-layout", Up: migrateBaselineLayout},
+	{Version: 2, Name: "config-profiles", Up: migrateProfiles},
+}
+
+type layoutState struct {
+	Version int                `json:"version"`
+	Applied []appliedMigration `json:"applied"`
+}
+
+type appliedMigration struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	AppliedAt string `json:"appliedAt"`
+	Backup    string `json:"backup,omitempty"`
+}
+
+func layoutPath() string {
+	return filepath.Join(hubflyDir(), layoutFileName)
+}
+
+func backupsDir() string {
+	return filepath.Join(hubflyDir(), backupsDirName)
+}
+
+func latestLayoutVersion() int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+func loadLayoutState() (layoutState, error) {
+	var state layoutState
+	content, err := os.ReadFile(layoutPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return state, err
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return layoutState{}, fmt.Errorf("invalid %s: %w", layoutPath(), err)
+	}
+	return state, nil
+}
+
+func saveLayoutState(state layoutState) error {
+	payload, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(layoutPath(), append(payload, '\n'))
+}
+
+func pendingMigrations(state layoutState) []migration {
+	pending := make([]migration, 0)
+	for _, m := range migrations {
+		if m.Version > state.Version {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+	return pending
+}
+
+// runPendingMigrations upgrades ~/.hubfly on startup. Each step is preceded
+// by a backup so `hubfly migrate rollback` can undo it.
+func runPendingMigrations() error {
+	if _, err := os.Stat(hubflyDir()); err != nil {
+		// Nothing stored yet; fresh installs start at the latest layout.
+		return nil
+	}
+	state, err := loadLayoutState()
+	if err != nil {
+		return err
+	}
+	if state.Version > latestLayoutVersion() {
+		debugf("storage layout v%d is newer than this CLI (v%d); skipping migrations", state.Version, latestLayoutVersion())
+		return nil
+	}
+	for _, m := range pendingMigrations(state) {
+		backup, err := backupStorage(state.Version)
+		if err != nil {
+			return fmt.Errorf("backup before migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		debugf("applying storage migration %d (%s)", m.Version, m.Name)
+		if err := m.Up(); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w; restore with `hubfly migrate rollback`", m.Version, m.Name, err)
+		}
+		state.Version = m.Version
+		state.Applied = append(state.Applied, appliedMigration{
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now().UTC().Format(time.RFC3339),
+			Backup:    backup,
+		})
+		if err := saveLayoutState(state); err != nil {
+			return err
+		}
+	}
+	pruneBackups()
+	return nil
+}
+
+func migrateCommand(args []string) error {
+	sub := "status"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "status":
+		return migrateStatusFlow()
+	case "up":
+		if err := runPendingMigrations(); err != nil {
+			return err
+		}
+		return migrateStatusFlow()
+	case "rollback":
+		return migrateRollbackFlow()
+	default:
+		return errors.New("usage: hubfly migrate [status|up|rollback]")
+	}
+}
+
+func migrateStatusFlow() error {
+	state, err := loadLayoutState()
+	if err != nil {
+		return err
+	}
+	pending := pendingMigrations(state)
+	backups, _ := listBackups()
+	if jsonOutput {
+		names := make([]string, 0, len(pending))
+		for _, m := range pending {
+			names = append(names, fmt.Sprintf("%d-%s", m.Version, m.Name))
+		}
+		return printJSON(map[string]any{
+			"version": state.Version,
+			"latest":  latestLayoutVersion(),
+			"applied": state.Applied,
+			"pending": names,
+			"backups": backups,
+		})
+	}
+	fmt.Printf("Storage layout: v%d (latest v%d)\n", state.Version, latestLayoutVersion())
+	for _, a := range state.Applied {
+		fmt.Printf("  applied  %d %s at %s\n", a.Version, a.Name, a.AppliedAt)
+	}
+	for _, m := range pending {
+		fmt.Printf("  pending  %d %s\n", m.Version, m.Name)
+	}
+	if len(backups) > 0 {
+		fmt.Printf("Backups in %s: %s\n", backupsDir(), strings.Join(backups, ", "))
+	}
+	return nil
+}
+
+// migrateRollbackFlow restores the most recent pre-migration backup. The
+// backup carries its own layout.json, so the version rolls back with it.
+func migrateRollbackFlow() error {
+	backups, err := listBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return errors.New("no storage backups to roll back to")
+	}
+	latest := backups[len(backups)-1]
+	if err := restoreStorage(filepath.Join(backupsDir(), latest)); err != nil {
+		return fmt.Errorf("rollback to %s failed: %w", latest, err)
+	}
+	if err := os.RemoveAll(filepath.Join(backupsDir(), latest)); err != nil {
+		return err
+	}
+	state, _ := loadLayoutState()
+	fmt.Printf("Restored %s; storage layout is now v%d.\n", latest, state.Version)
+	if len(pendingMigrations(state)) > 0 {
+		fmt.Println("Pending migrations run again on the next command; set HUBFLY_SKIP_MIGRATIONS=1 to hold them.")
+	}
+	return nil
+}
+
+func listBackups() ([]string, error) {
+	entries, err := os.ReadDir(backupsDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func pruneBackups() {
+	backups, err := listBackups()
+	if err != nil || len(backups) <= keepBackups {
+		return
+	}
+	for _, name := range backups[:len(backups)-keepBackups] {
+		_ = os.RemoveAll(filepath.Join(backupsDir(), name))
+	}
+}
+
+func backupStorage(fromVersion int) (string, error) {
+	name := fmt.Sprintf("%s-v%d", time.Now().UTC().Format("20060102T150405.000000000"), fromVersion)
+	dest := filepath.Join(backupsDir(), name)
+	if err := ensurePrivateDir(dest); err != nil {
+		return "", err
+	}
+	if err := copyStorageTree(hubflyDir(), dest); err != nil {
+		_ = os.RemoveAll(dest)
+		return "", err
+	}
+	return name, nil
+}
+
+func restoreStorage(src string) error {
+	entries, err := os.ReadDir(hubflyDir())
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == backupsDirName {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(hubflyDir(), entry.Name())); err != nil {
+			return err
+		}
+	}
+	return copyStorageTree(src, hubflyDir())
+}
+
+// copyStorageTree copies regular files and directories from src to dst,
+// skipping the backups directory itself.
+func copyStorageTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if src == hubflyDir() && (rel == backupsDirName || strings.HasPrefix(rel, backupsDirName+string(filepath.Separator))) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return ensurePrivateDir(target)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyPrivateFile(path, target)
+	})
+}
+
+func copyPrivateFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// migrateBaselineLayout records the layout shipped before versioning existed:
+// config.json, tunnels/ and state/sessions/ directly under ~/.hubfly.
+func migrateBaselineLayout() error {
+	for _, dir := range []string{tunnelsDir(), sessionsDir()} {
+		if err := ensurePrivateDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRunPendingMigrationsBacksUpAndRollsBack(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if err := ensurePrivateDir(hubflyDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePrivateFile(configPath(), []byte(`{"token":"old"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	original := migrations
+	t.Cleanup(func() { migrations = original })
+	migrations = []migration{
+		{Version: 1, Name: "baseline-layout", Up: migrateBaselineLayout},
+		{Version: 2, Name: "rewrite-config", Up: func() error {
+			return writePrivateFile(configPath(), []byte(`{"token":"new"}`))
+		}},
+	}
+
+	if err := runPendingMigrations(); err != nil {
+		t.Fatalf("runPendingMigrations: %v", err)
+	}
+	state, err := loadLayoutState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != 2 || len(state.Applied) != 2 {
+		t.Fatalf("unexpected layout state: %+v", state)
+	}
+	if _, err := os.Stat(sessionsDir()); err != nil {
+		t.Fatalf("baseline migration did not create sessions dir: %v", err)
+	}
+
+	// A second run must be a no-op.
+	if err := runPendingMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := listBackups(); len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v", backups)
+	}
+
+	if err := migrateRollbackFlow(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	content, err := os.ReadFile(configPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"token":"old"}` {
+		t.Fatalf("config not restored, got %s", content)
+	}
+	state, _ = loadLayoutState()
+	if state.Version != 1 {
+		t.Fatalf("expected layout v1 after rollback, got v%d", state.Version)
+	}
+	if _, err := os.Stat(backupsDir()); err != nil {
+		t.Fatalf("backups dir removed by rollback: %v", err)
+	}
+}
+
+func TestMigrateProfilesMovesLegacyToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if err := ensurePrivateDir(hubflyDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePrivateFile(configPath(), []byte(`{"token":"legacy"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateProfiles(); err != nil {
+		t.Fatalf("migrateProfiles: %v", err)
+	}
+	cfg, err := loadStoreConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Token != "" || cfg.CurrentProfile != defaultProfileName {
+		t.Fatalf("legacy token not moved: %+v", cfg)
+	}
+	if p := cfg.Profiles[defaultProfileName]; p == nil || p.Token != "legacy" {
+		t.Fatalf("default profile missing token: %+v", cfg.Profiles)
+	}
+}
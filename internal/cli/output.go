@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+var jsonOutput bool
+
+// configureOutput strips --json, and --output json or -o json, a longer
+// spelling of it that also accepts text. It runs after the other global
+// flags are stripped, so args[0] is the command: report has an --output
+// flag of its own, which is left to it.
+func configureOutput(args []string) []string {
+	ownOutput := false
+	if len(args) > 0 {
+		if cmd, ok := findCommand(args[0]); ok && cmd.name == "report" {
+			ownOutput = true
+		}
+	}
+	filtered := make([]string, 0, len(args))
+	flagsDone := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--json" {
+			jsonOutput = true
+			continue
+		}
+		if arg == "--" {
+			flagsDone = true
+		}
+		if !flagsDone && !ownOutput {
+			format, consumed := "", 0
+			switch {
+			case (arg == "--output" || arg == "-o") && i+1 < len(args):
+				format, consumed = args[i+1], 1
+			case strings.HasPrefix(arg, "--output="):
+				format = strings.TrimPrefix(arg, "--output=")
+			}
+			if format == "json" || format == "text" {
+				jsonOutput = format == "json"
+				i += consumed
+				continue
+			}
+		}
+		filtered = append(filtered, arg)
+	}
+	return filtered
+}
+
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
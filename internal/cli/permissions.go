@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+type permissionFinding struct {
+	Path string
+	Mode fs.FileMode
+	Want fs.FileMode
+}
+
+// checkStoragePermissions warns when ~/.hubfly or the credentials inside it are
+// readable by other users, the same way OpenSSH complains about loose key
+// modes. Interactive sessions are offered an automatic fix.
+func checkStoragePermissions() {
+	if runtime.GOOS == "windows" {
+		hardenStorageACLs()
+		return
+	}
+	findings := collectPermissionFindings()
+	if len(findings) == 0 {
+		return
+	}
+
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "warning: %s is accessible by other users (mode %04o, expected %04o)\n", f.Path, f.Mode.Perm(), f.Want)
+	}
+	if !isInteractiveShell() || jsonOutput {
+		fmt.Fprintf(os.Stderr, "warning: run `chmod -R go-rwx %s` to restrict access\n", hubflyDir())
+		return
+	}
+
+	fix, err := promptYesNo("Restrict these permissions now", true)
+	if err != nil || !fix {
+		return
+	}
+	for _, f := range findings {
+		if err := os.Chmod(f.Path, f.Want); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to fix %s: %v\n", f.Path, err)
+		}
+	}
+}
+
+func collectPermissionFindings() []permissionFinding {
+	findings := make([]permissionFinding, 0)
+	check := func(path string, want fs.FileMode) {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&fs.ModeSymlink != 0 {
+			return
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			findings = append(findings, permissionFinding{Path: path, Mode: info.Mode(), Want: want})
+		}
+	}
+
+	check(hubflyDir(), 0o700)
+	check(configPath(), 0o600)
+	for _, dir := range []string{keysDir(), tunnelsDir()} {
+		check(dir, 0o700)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				debugf("permission check skipped %s: %v", dir, err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			check(filepath.Join(dir, entry.Name()), 0o600)
+		}
+	}
+	return findings
+}
+
+// hardenStorageACLs applies owner-only DACLs to the storage tree once. Mode
+// bits are meaningless on Windows, so instead of warning we fix the ACLs for
+// paths created before the CLI started setting them explicitly.
+func hardenStorageACLs() {
+	marker := filepath.Join(stateDir(), "acl-hardened")
+	if _, err := os.Stat(marker); err == nil {
+		return
+	}
+	if _, err := os.Stat(hubflyDir()); err != nil {
+		return
+	}
+	paths := []string{hubflyDir(), configPath(), keysDir(), tunnelsDir()}
+	for _, dir := range []string{keysDir(), tunnelsDir()} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	for _, path := range paths {
+		if err := restrictToOwner(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			debugf("failed to restrict ACL on %s: %v", path, err)
+		}
+	}
+	if err := ensurePrivateDir(stateDir()); err == nil {
+		_ = writePrivateFile(marker, []byte("1\n"))
+	}
+}
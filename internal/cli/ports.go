@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"hubfly-cli/internal/service"
+)
+
+// privilegedPortOffset moves well-known target ports out of the privileged
+// range when picking a local port: 80 becomes 8080, 22 becomes 8022.
+const privilegedPortOffset = 8000
+
+type portRange struct {
+	Low  int
+	High int
+}
+
+func (r portRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+func (r portRange) contains(port int) bool {
+	return port >= r.Low && port <= r.High
+}
+
+func parsePortRange(raw string) (portRange, error) {
+	lowRaw, highRaw, ok := strings.Cut(strings.TrimSpace(raw), "-")
+	if !ok {
+		return portRange{}, fmt.Errorf("expected a range such as 20000-20999, got %q", raw)
+	}
+	low, errLow := strconv.Atoi(strings.TrimSpace(lowRaw))
+	high, errHigh := strconv.Atoi(strings.TrimSpace(highRaw))
+	if errLow != nil || errHigh != nil {
+		return portRange{}, fmt.Errorf("expected a range such as 20000-20999, got %q", raw)
+	}
+	if low < 1 || high > 65535 || low > high {
+		return portRange{}, fmt.Errorf("range %q must satisfy 1 <= low <= high <= 65535", raw)
+	}
+	return portRange{Low: low, High: high}, nil
+}
+
+// localPortRange returns the active profile's tunnels.localPortRange.
+func localPortRange() (portRange, bool) {
+	p := activeProfile()
+	if p.Tunnels == nil || strings.TrimSpace(p.Tunnels.LocalPortRange) == "" {
+		return portRange{}, false
+	}
+	r, err := parsePortRange(p.Tunnels.LocalPortRange)
+	if err != nil {
+		warnOnce("port-range", fmt.Sprintf("warning: ignoring tunnels.localPortRange: %v", err))
+		return portRange{}, false
+	}
+	return r, true
+}
+
+// unprivilegedPortStart is the lowest port a normal user may bind. Linux
+// exposes it as a sysctl; macOS (10.14+) and Windows have no restriction on
+// loopback.
+func unprivilegedPortStart() int {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return 0
+	case "linux":
+		if raw, err := os.ReadFile("/proc/sys/net/ipv4/ip_unprivileged_port_start"); err == nil {
+			if n, err := strconv.Atoi(strings.TrimSpace(string(raw))); err == nil {
+				return n
+			}
+		}
+	}
+	return 1024
+}
+
+// capNetBindService is the CAP_NET_BIND_SERVICE bit in /proc/self/status.
+const capNetBindService = 10
+
+// hasBindCapability reports whether the process may bind privileged ports
+// without root, which on Linux means CAP_NET_BIND_SERVICE is effective (for
+// example after `setcap cap_net_bind_service=+ep` on the binary).
+func hasBindCapability() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	raw, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		value, ok := strings.CutPrefix(line, "CapEff:")
+		if !ok {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		return err == nil && caps&(1<<capNetBindService) != 0
+	}
+	return false
+}
+
+func isPrivilegedPort(port int) bool {
+	return port < unprivilegedPortStart() && os.Geteuid() != 0 && !hasBindCapability()
+}
+
+// privilegedPortError is returned for a local port the process cannot bind.
+// Suggested is a free unprivileged port to offer instead, or 0.
+type privilegedPortError struct {
+	Port      int
+	Suggested int
+}
+
+func (e *privilegedPortError) Error() string {
+	if e.Suggested > 0 {
+		return fmt.Sprintf("local port %d is privileged and needs root; pick a port >= %d (for example %d)",
+			e.Port, unprivilegedPortStart(), e.Suggested)
+	}
+	return fmt.Sprintf("local port %d is privileged and needs root; pick a port >= %d", e.Port, unprivilegedPortStart())
+}
+
+// privilegedPortHelp explains how to use port anyway on this OS.
+func privilegedPortHelp(port int) string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "hubfly"
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return strings.Join([]string{
+			fmt.Sprintf("To bind localhost:%d without root, either:", port),
+			fmt.Sprintf("  - allow this binary to bind low ports: sudo setcap cap_net_bind_service=+ep %s", exe),
+			"    (the capability is lost when the binary is replaced by `hubfly update`)",
+			fmt.Sprintf("  - or lower the limit for all users: sudo sysctl net.ipv4.ip_unprivileged_port_start=%d", port),
+		}, "\n")
+	case "darwin":
+		return strings.Join([]string{
+			fmt.Sprintf("macOS 10.14 and later let any user bind localhost:%d, so another process may hold it.", port),
+			"On older releases, forward the port with pf and keep the tunnel on a high port:",
+			fmt.Sprintf("  echo \"rdr pass on lo0 inet proto tcp from any to 127.0.0.1 port %d -> 127.0.0.1 port %d\" | sudo pfctl -ef -", port, port+privilegedPortOffset),
+		}, "\n")
+	default:
+		return fmt.Sprintf("Run the command from an elevated prompt to bind localhost:%d, or use a high port.", port)
+	}
+}
+
+// checkLocalPort validates a local port the user typed. A privileged port the
+// process cannot bind is a *privilegedPortError; a port outside the
+// configured range is a warning.
+func checkLocalPort(port int) (warning string, err error) {
+	if port <= 0 || port > 65535 {
+		return "", fmt.Errorf("invalid local port %d", port)
+	}
+	if isPrivilegedPort(port) {
+		return "", &privilegedPortError{Port: port, Suggested: suggestLocalPort(port, nil)}
+	}
+	if r, ok := localPortRange(); ok && !r.contains(port) {
+		return fmt.Sprintf("local port %d is outside the configured range %s (tunnels.localPortRange)", port, r), nil
+	}
+	return "", nil
+}
+
+// maxPortProbes bounds how many busy ports suggestLocalPort will try.
+const maxPortProbes = 256
+
+// suggestLocalPort picks a free local port for targetPort. It prefers the
+// target port itself, shifted out of the privileged range, and stays inside
+// the configured range. Ports in taken are skipped. It returns 0 when nothing
+// is free.
+func suggestLocalPort(targetPort int, taken map[int]bool) int {
+	r, ok := localPortRange()
+	if !ok {
+		r = portRange{Low: 1, High: 65535}
+	}
+	preferred := targetPort
+	if preferred <= 0 || isPrivilegedPort(preferred) {
+		preferred += privilegedPortOffset
+	}
+	if !r.contains(preferred) {
+		preferred = r.Low
+	}
+
+	size := r.High - r.Low + 1
+	probes := 0
+	for i := 0; i < size && probes < maxPortProbes; i++ {
+		port := r.Low + (preferred-r.Low+i)%size
+		if taken[port] || isPrivilegedPort(port) {
+			continue
+		}
+		probes++
+		if localPortFree(port) {
+			return port
+		}
+	}
+	return 0
+}
+
+// resolveLocalPort turns a local port argument into a port: "auto" picks one
+// with suggestLocalPort, anything else is validated with checkLocalPort and
+// its warning printed. For a privileged port an interactive user is offered
+// the suggested port instead; otherwise the error explains the OS options.
+func resolveLocalPort(raw string, targetPort int) (int, error) {
+	if strings.EqualFold(strings.TrimSpace(raw), "auto") {
+		port := suggestLocalPort(targetPort, nil)
+		if port == 0 {
+			return 0, errors.New("no free local port available in the configured range")
+		}
+		return port, nil
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || port <= 0 {
+		return 0, errors.New("invalid local port")
+	}
+	warning, err := checkLocalPort(port)
+	var privileged *privilegedPortError
+	if errors.As(err, &privileged) {
+		return offerUnprivilegedPort(privileged)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return port, nil
+}
+
+func offerUnprivilegedPort(perr *privilegedPortError) (int, error) {
+	if perr.Suggested == 0 || !isInteractiveShell() || jsonOutput {
+		return 0, fmt.Errorf("%w\n%s", perr, privilegedPortHelp(perr.Port))
+	}
+	fmt.Fprintf(os.Stderr, "Local port %d needs root on this system.\n%s\n", perr.Port, privilegedPortHelp(perr.Port))
+	use, err := promptYesNo(fmt.Sprintf("Use localhost:%d instead", perr.Suggested), true)
+	if err != nil {
+		return 0, err
+	}
+	if !use {
+		return 0, perr
+	}
+	fmt.Fprintf(os.Stderr, "note: forwarding from localhost:%d instead of %d\n", perr.Suggested, perr.Port)
+	return perr.Suggested, nil
+}
+
+// ensureLocalPortFree checks that port can be bound right now. When another
+// program holds it, autoPort moves to the next free port; otherwise an
+// interactive user is asked and a script gets an error naming the options.
+func ensureLocalPortFree(port int, autoPort bool) (int, error) {
+	if localPortFree(port) {
+		return port, nil
+	}
+	next := nextFreeLocalPort(port, nil)
+	if next == 0 {
+		return 0, fmt.Errorf("localhost:%d is already in use and no other local port is free", port)
+	}
+	if autoPort {
+		fmt.Fprintf(os.Stderr, "note: localhost:%d is already in use; using %d instead\n", port, next)
+		return next, nil
+	}
+	if !isInteractiveShell() || jsonOutput {
+		return 0, fmt.Errorf("localhost:%d is already in use; pick another port, pass `auto`, or add --auto-port", port)
+	}
+	use, err := promptYesNo(fmt.Sprintf("localhost:%d is already in use. Use localhost:%d instead", port, next), true)
+	if err != nil {
+		return 0, err
+	}
+	if !use {
+		return 0, fmt.Errorf("localhost:%d is already in use", port)
+	}
+	return next, nil
+}
+
+// nextFreeLocalPort finds a free port to offer instead of busy, skipping the
+// ports in taken. It returns 0 when nothing is free.
+func nextFreeLocalPort(busy int, taken map[int]bool) int {
+	skip := make(map[int]bool, len(taken)+1)
+	for port := range taken {
+		skip[port] = true
+	}
+	skip[busy] = true
+	return suggestLocalPort(busy, skip)
+}
+
+// listenLocal binds the loopback listener for a forward tunnel. A permission
+// error carries the same guidance as a privileged port rejected up front.
+func listenLocal(port int) (net.Listener, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		if service.IsAccessDenied(err) {
+			return nil, fmt.Errorf("failed to listen on localhost:%d: permission denied\n%s", port, privilegedPortHelp(port))
+		}
+		if service.IsAddrInUse(err) {
+			return nil, fmt.Errorf("localhost:%d is already in use by another program; pick another local port or use `auto`", port)
+		}
+		return nil, fmt.Errorf("failed to listen on localhost:%d: %w", port, err)
+	}
+	return listener, nil
+}
+
+func localPortFree(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	_ = l.Close()
+	return true
+}
+
+// lastLocalPortsPath records the local port each tunnel was last connected
+// on, so the next connect offers it again.
+func lastLocalPortsPath() string {
+	return filepath.Join(stateDir(), "local-ports.json")
+}
+
+func loadLastLocalPorts() map[string]int {
+	ports := map[string]int{}
+	content, err := os.ReadFile(lastLocalPortsPath())
+	if err != nil {
+		return ports
+	}
+	if err := json.Unmarshal(content, &ports); err != nil {
+		debugf("ignoring unreadable %s: %v", lastLocalPortsPath(), err)
+		return map[string]int{}
+	}
+	return ports
+}
+
+func saveLastLocalPorts(ports map[string]int) {
+	payload, err := json.MarshalIndent(ports, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := ensurePrivateDir(stateDir()); err != nil {
+		return
+	}
+	tmp := lastLocalPortsPath() + ".tmp"
+	if err := writePrivateFile(tmp, append(payload, '\n')); err != nil {
+		debugf("failed to remember local ports: %v", err)
+		return
+	}
+	_ = os.Rename(tmp, lastLocalPortsPath())
+}
+
+func rememberLocalPort(tunnelID string, port int) {
+	if tunnelID == "" || port <= 0 {
+		return
+	}
+	ports := loadLastLocalPorts()
+	if ports[tunnelID] == port {
+		return
+	}
+	ports[tunnelID] = port
+	saveLastLocalPorts(ports)
+}
+
+func forgetLocalPort(tunnelID string) {
+	ports := loadLastLocalPorts()
+	if _, ok := ports[tunnelID]; !ok {
+		return
+	}
+	delete(ports, tunnelID)
+	saveLastLocalPorts(ports)
+}
+
+// preferredLocalPort is the port a tunnel was last connected on, or its
+// target port the first time.
+func preferredLocalPort(t tunnel) int {
+	if port := loadLastLocalPorts()[t.TunnelID]; port > 0 {
+		return port
+	}
+	return selectedPrimaryPort(t)
+}
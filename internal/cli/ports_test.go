@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParsePortRange(t *testing.T) {
+	r, err := parsePortRange(" 20000 - 20010 ")
+	if err != nil || r != (portRange{Low: 20000, High: 20010}) {
+		t.Fatalf("parsePortRange = %+v, %v", r, err)
+	}
+	for _, raw := range []string{"20000", "a-b", "0-10", "10-5", "1-70000"} {
+		if _, err := parsePortRange(raw); err == nil {
+			t.Errorf("parsePortRange(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestSuggestLocalPortStaysInConfiguredRange(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	low := busy.Addr().(*net.TCPAddr).Port
+	if low > 65530 {
+		t.Skip("ephemeral port too close to the top of the range")
+	}
+	if err := updateStoreConfig(func(cfg *storeConfig) error {
+		return profileKeys["tunnels.localPortRange"].set(profileFor(cfg, defaultProfileName), portRange{Low: low, High: low + 3}.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The target port is outside the range and the first port is busy, so the
+	// next free one in the range is picked; taken ports are skipped too.
+	got := suggestLocalPort(5432, map[int]bool{low + 1: true})
+	if got < low+2 || got > low+3 {
+		t.Fatalf("suggestLocalPort = %d, want within %d-%d", got, low+2, low+3)
+	}
+	if warning, err := checkLocalPort(low - 1); err != nil || warning == "" {
+		t.Fatalf("checkLocalPort outside range = %q, %v; want a warning", warning, err)
+	}
+}
+
+func TestEnsureLocalPortFreeMovesOffBusyPort(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	if _, err := ensureLocalPortFree(port, false); err == nil {
+		t.Fatal("busy port accepted without --auto-port")
+	}
+	got, err := ensureLocalPortFree(port, true)
+	if err != nil || got == port || !localPortFree(got) {
+		t.Fatalf("ensureLocalPortFree(auto) = %d, %v; want a free port other than %d", got, err, port)
+	}
+	if next := nextFreeLocalPort(got, map[int]bool{got + 1: true}); next == got || next == got+1 {
+		t.Fatalf("nextFreeLocalPort = %d, want neither %d nor the taken %d", next, got, got+1)
+	}
+}
+
+func TestLastLocalPortIsOfferedAgain(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tun := tunnel{TunnelID: "t1", Targets: []tunnelTarget{{TargetPort: 5432}}}
+	if got := preferredLocalPort(tun); got != 5432 {
+		t.Fatalf("preferredLocalPort before any connect = %d", got)
+	}
+	rememberLocalPort("t1", 25432)
+	if got := preferredLocalPort(tun); got != 25432 {
+		t.Errorf("preferredLocalPort = %d, want 25432", got)
+	}
+	if got := defaultLocalPort(tun, nil); got != 25432 {
+		t.Errorf("defaultLocalPort = %d, want 25432", got)
+	}
+	if got := defaultLocalPort(tun, []int{25432}); got == 25432 {
+		t.Error("defaultLocalPort reused a port already assigned")
+	}
+	forgetLocalPort("t1")
+	if got := preferredLocalPort(tun); got != 5432 {
+		t.Errorf("preferredLocalPort after forget = %d", got)
+	}
+}
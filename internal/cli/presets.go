@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A team preset is a YAML file of container aliases and port mappings,
+// shaped like the containers and ports of hubfly.yaml, published at a URL
+// with a minisign signature next to it (<url>.minisig). Installed presets
+// apply everywhere, beneath any hubfly.yaml.
+
+// presetEntry is an installed preset in presets.json. The public key is
+// kept so `preset update` checks new versions against the same signer.
+type presetEntry struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	PublicKey string `json:"publicKey"`
+	SHA256    string `json:"sha256"`
+	FetchedAt string `json:"fetchedAt"`
+}
+
+func presetsDir() string {
+	return filepath.Join(hubflyDir(), "presets")
+}
+
+func presetsIndexPath() string {
+	return filepath.Join(presetsDir(), "presets.json")
+}
+
+func presetPath(name string) string {
+	return filepath.Join(presetsDir(), name+".yaml")
+}
+
+// loadPresetEntries reads presets.json in the order presets were added. A
+// missing file means none are installed.
+func loadPresetEntries() ([]presetEntry, error) {
+	content, err := os.ReadFile(presetsIndexPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []presetEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", presetsIndexPath(), err)
+	}
+	return entries, nil
+}
+
+func savePresetEntries(entries []presetEntry) error {
+	if err := ensurePrivateDir(presetsDir()); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(presetsIndexPath(), append(payload, '\n'))
+}
+
+// parsePreset checks a downloaded preset. Only containers and ports are
+// allowed: the project stays a per-checkout choice.
+func parsePreset(content []byte) (workspaceEnvironment, error) {
+	var env workspaceEnvironment
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&env); err != nil && !errors.Is(err, io.EOF) {
+		return workspaceEnvironment{}, err
+	}
+	if strings.TrimSpace(env.Project) != "" {
+		return workspaceEnvironment{}, errors.New("presets cannot set project; set it in hubfly.yaml")
+	}
+	for name, mappings := range env.Ports {
+		for _, mapping := range mappings {
+			if _, _, ok := parseWorkspacePort(mapping); !ok {
+				return workspaceEnvironment{}, fmt.Errorf("ports.%s: %q is not <local>:<target>", name, mapping)
+			}
+		}
+	}
+	return env, nil
+}
+
+// loadPresetDefaults merges every installed preset, later ones overriding
+// earlier ones on the same alias or container.
+func loadPresetDefaults() (workspaceEnvironment, error) {
+	entries, err := loadPresetEntries()
+	if err != nil || len(entries) == 0 {
+		return workspaceEnvironment{}, err
+	}
+	var merged workspaceEnvironment
+	for _, entry := range entries {
+		content, err := os.ReadFile(presetPath(entry.Name))
+		if err != nil {
+			return workspaceEnvironment{}, fmt.Errorf("preset %s: %w (run `hubfly preset update %s`)", entry.Name, err, entry.Name)
+		}
+		env, err := parsePreset(content)
+		if err != nil {
+			return workspaceEnvironment{}, fmt.Errorf("preset %s: %w", entry.Name, err)
+		}
+		merged.Containers = mergeWorkspaceMap(merged.Containers, env.Containers)
+		merged.Ports = mergeWorkspaceMap(merged.Ports, env.Ports)
+	}
+	return merged, nil
+}
+
+// checkPresetURL only allows plain HTTP for local test servers.
+func checkPresetURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid preset URL %q", raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("preset URL %q must use https", raw)
+}
+
+// fetchPreset downloads a preset and its signature and verifies both.
+func fetchPreset(rawURL, publicKey string) ([]byte, error) {
+	if err := checkPresetURL(rawURL); err != nil {
+		return nil, err
+	}
+	content, err := downloadReleaseFile(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	sig, err := downloadReleaseFile(rawURL + ".minisig")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the signature %s.minisig: %w", rawURL, err)
+	}
+	if err := verifyMinisign(publicKey, content, sig); err != nil {
+		return nil, fmt.Errorf("preset %s: %w", rawURL, err)
+	}
+	if _, err := parsePreset(content); err != nil {
+		return nil, fmt.Errorf("preset %s: %w", rawURL, err)
+	}
+	return content, nil
+}
+
+func installPreset(entry presetEntry, content []byte) (presetEntry, error) {
+	if err := ensurePrivateDir(presetsDir()); err != nil {
+		return entry, err
+	}
+	if err := writePrivateFile(presetPath(entry.Name), content); err != nil {
+		return entry, err
+	}
+	sum := sha256.Sum256(content)
+	entry.SHA256 = hex.EncodeToString(sum[:])
+	entry.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+	return entry, nil
+}
+
+func presetCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(presetUsage())
+	}
+	switch args[0] {
+	case "add":
+		return presetAddFlow(args[1:])
+	case "list", "ls":
+		return presetListFlow(args[1:])
+	case "update":
+		return presetUpdateFlow(args[1:])
+	case "rm", "remove":
+		return presetRemoveFlow(args[1:])
+	default:
+		return fmt.Errorf("unknown preset command: %s", args[0])
+	}
+}
+
+func presetUsage() string {
+	return strings.TrimSpace(`
+usage: hubfly preset add <url> --pubkey <key|file> [--name <name>]
+       hubfly preset list
+       hubfly preset update [name]
+       hubfly preset rm <name>
+`)
+}
+
+func presetAddFlow(args []string) error {
+	const usage = "usage: hubfly preset add <url> --pubkey <key|file> [--name <name>]"
+	var rawURL string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		rawURL, args = args[0], args[1:]
+	}
+	fs := newFlagSet("preset add")
+	pubkey := fs.String("pubkey", "", "minisign public key, or a minisign.pub file, the preset is signed with")
+	name := fs.String("name", "", "local name (defaults to the file name in the URL)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if rawURL == "" && fs.NArg() == 1 {
+		rawURL = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		rawURL = ""
+	}
+	if rawURL == "" || strings.TrimSpace(*pubkey) == "" {
+		return errors.New(usage)
+	}
+	publicKey := strings.TrimSpace(*pubkey)
+	if content, err := os.ReadFile(publicKey); err == nil {
+		publicKey = strings.TrimSpace(string(content))
+	}
+	if _, _, err := parseMinisignPublicKey(publicKey); err != nil {
+		return err
+	}
+	presetName := strings.TrimSpace(*name)
+	if presetName == "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			presetName = strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+		}
+	}
+	if presetName == "" || sanitizeID(presetName) != presetName {
+		return fmt.Errorf("preset name %q may only contain letters, digits, '-' and '_'; pass --name", presetName)
+	}
+
+	entries, err := loadPresetEntries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name == presetName {
+			return fmt.Errorf("preset %q is already installed; run `hubfly preset update %s` or remove it first", presetName, presetName)
+		}
+	}
+	content, err := fetchPreset(rawURL, publicKey)
+	if err != nil {
+		return err
+	}
+	entry, err := installPreset(presetEntry{Name: presetName, URL: rawURL, PublicKey: publicKey}, content)
+	if err != nil {
+		return err
+	}
+	if err := savePresetEntries(append(entries, entry)); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(entry)
+	}
+	fmt.Printf("Installed preset %s from %s.\n", entry.Name, entry.URL)
+	return nil
+}
+
+func presetListFlow(args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: hubfly preset list")
+	}
+	entries, err := loadPresetEntries()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if entries == nil {
+			entries = []presetEntry{}
+		}
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No presets installed. Add one with `hubfly preset add <url> --pubkey <key>`.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tURL\tFETCHED")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Name, e.URL, e.FetchedAt)
+	}
+	return tw.Flush()
+}
+
+// presetUpdateFlow fetches new versions of one or all presets. A preset
+// that fails to download or verify keeps its installed version.
+func presetUpdateFlow(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: hubfly preset update [name]")
+	}
+	entries, err := loadPresetEntries()
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 && !presetInstalled(entries, args[0]) {
+		return fmt.Errorf("preset %q is not installed", args[0])
+	}
+	failed := 0
+	for i, e := range entries {
+		if len(args) == 1 && e.Name != args[0] {
+			continue
+		}
+		content, err := fetchPreset(e.URL, e.PublicKey)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", e.Name, err)
+			continue
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) == e.SHA256 {
+			fmt.Printf("%s: unchanged\n", e.Name)
+			continue
+		}
+		if entries[i], err = installPreset(e, content); err != nil {
+			return err
+		}
+		fmt.Printf("%s: updated\n", e.Name)
+	}
+	if err := savePresetEntries(entries); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d preset(s) could not be updated", failed)
+	}
+	return nil
+}
+
+func presetRemoveFlow(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: hubfly preset rm <name>")
+	}
+	entries, err := loadPresetEntries()
+	if err != nil {
+		return err
+	}
+	if !presetInstalled(entries, args[0]) {
+		return fmt.Errorf("preset %q is not installed", args[0])
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Name != args[0] {
+			kept = append(kept, e)
+		}
+	}
+	if err := savePresetEntries(kept); err != nil {
+		return err
+	}
+	if err := os.Remove(presetPath(args[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	fmt.Printf("Removed preset %s.\n", args[0])
+	return nil
+}
+
+func presetInstalled(entries []presetEntry, name string) bool {
+	for _, e := range entries {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPresetAddVerifiesAndApplies(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Chdir(t.TempDir())
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{8, 7, 6, 5, 4, 3, 2, 1}
+	publicKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	sign := func(message []byte) []byte {
+		sig := ed25519.Sign(priv, message)
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), "team"...))
+		return []byte("untrusted comment: signature\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), sig...)) + "\n" +
+			"trusted comment: team\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+
+	preset := []byte("containers:\n  db: shop-postgres\nports:\n  db: [\"15432:5432\"]\n")
+	signature := sign(preset)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/team-tunnels.yaml":
+			_, _ = w.Write(preset)
+		case "/team-tunnels.yaml.minisig":
+			_, _ = w.Write(signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	if err := presetCommand([]string{"add", server.URL + "/team-tunnels.yaml", "--pubkey", publicKey}); err != nil {
+		t.Fatalf("preset add: %v", err)
+	}
+	if got := resolveContainerAlias("db"); got != "shop-postgres" {
+		t.Errorf("resolveContainerAlias(db) = %q", got)
+	}
+	if got := workspaceLocalPort("auto", "db", 5432); got != "15432" {
+		t.Errorf("workspaceLocalPort = %q, want 15432", got)
+	}
+
+	// A new version that is not signed by the same key is not installed.
+	preset = []byte("containers:\n  db: attacker-db\n")
+	if err := presetCommand([]string{"update"}); err == nil {
+		t.Fatal("update with a stale signature succeeded")
+	}
+	if got := resolveContainerAlias("db"); got != "shop-postgres" {
+		t.Errorf("alias after failed update = %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hubflyProcessAlive reports whether pid is a running hubfly process. PIDs
+// saved in state files outlive their processes across reboots and can be
+// reused by something else, so the executable has to match as well: the
+// current binary, or one with the same name, since an update or a move
+// changes the path under a process that was started earlier.
+func hubflyProcessAlive(pid int) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	exe, err := processExecutable(pid)
+	if err != nil {
+		debugf("cannot tell what pid %d runs: %v", pid, err)
+		return false
+	}
+	return isHubflyExecutable(exe)
+}
+
+func isHubflyExecutable(path string) bool {
+	name := executableName(path)
+	if name == "hubfly" {
+		return true
+	}
+	self, err := os.Executable()
+	return err == nil && (sameFilePath(path, self) || name == executableName(self))
+}
+
+func executableName(path string) string {
+	return strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".exe"))
+}
+
+func sameFilePath(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
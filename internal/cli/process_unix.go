@@ -0,0 +1,69 @@
+//go:build !windows
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// detachCommand starts the child in its own session so it survives the
+// terminal that launched it.
+func detachCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// processExecutable returns the path of the executable pid runs, from /proc
+// where there is one and from ps elsewhere, as on macOS.
+func processExecutable(pid int) (string, error) {
+	path, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err == nil {
+		// Linux marks a binary replaced since the process started.
+		return strings.TrimSuffix(path, " (deleted)"), nil
+	}
+	if _, statErr := os.Stat("/proc/self/exe"); statErr == nil {
+		return "", err
+	}
+	out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	if path = strings.TrimSpace(string(out)); path == "" {
+		return "", fmt.Errorf("no process %d", pid)
+	}
+	return path, nil
+}
+
+// terminateProcess stops pid, asking first and killing it after three
+// seconds. The pid comes from a state file, so a process that no longer
+// runs hubfly is left alone.
+func terminateProcess(pid int) error {
+	if !hubflyProcessAlive(pid) {
+		return nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if !processAlive(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
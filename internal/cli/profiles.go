@@ -0,0 +1,512 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	defaultProfileName = "default"
+	defaultExecTimeout = 55 * time.Second
+)
+
+// profileOverride is set by the global --profile flag or HUBFLY_PROFILE and
+// takes precedence over currentProfile in config.json.
+var profileOverride string
+
+func configureProfile(args []string) ([]string, error) {
+	filtered := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--profile":
+			if i+1 >= len(args) || strings.TrimSpace(args[i+1]) == "" {
+				return nil, errors.New("--profile requires a profile name")
+			}
+			profileOverride = strings.TrimSpace(args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--profile="):
+			profileOverride = strings.TrimSpace(strings.TrimPrefix(arg, "--profile="))
+		default:
+			filtered = append(filtered, arg)
+		}
+	}
+	if profileOverride == "" {
+		profileOverride = strings.TrimSpace(os.Getenv("HUBFLY_PROFILE"))
+	} else {
+		// Background tunnels and other child processes inherit the profile.
+		_ = os.Setenv("HUBFLY_PROFILE", profileOverride)
+	}
+	return filtered, nil
+}
+
+func currentProfileName(cfg storeConfig) string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if name := strings.TrimSpace(cfg.CurrentProfile); name != "" {
+		return name
+	}
+	return defaultProfileName
+}
+
+func profileFor(cfg *storeConfig, name string) *profileConfig {
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*profileConfig{}
+	}
+	p := cfg.Profiles[name]
+	if p == nil {
+		p = &profileConfig{}
+		if name == defaultProfileName && cfg.Token != "" {
+			p.Token = cfg.Token
+			cfg.Token = ""
+		}
+		cfg.Profiles[name] = p
+	}
+	return p
+}
+
+// activeProfile returns a copy of the selected profile, or an empty profile
+// when config.json is missing or unreadable.
+func activeProfile() profileConfig {
+	cfg, err := loadStoreConfig()
+	if err != nil {
+		return profileConfig{}
+	}
+	name := currentProfileName(cfg)
+	if p := cfg.Profiles[name]; p != nil {
+		return *p
+	}
+	if name == defaultProfileName {
+		return profileConfig{Token: cfg.Token}
+	}
+	return profileConfig{}
+}
+
+func saveStoreConfig(cfg storeConfig) error {
+	if err := ensurePrivateDir(hubflyDir()); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(configPath(), append(payload, '\n'))
+}
+
+func updateStoreConfig(mutate func(cfg *storeConfig) error) error {
+	cfg, err := loadStoreConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := mutate(&cfg); err != nil {
+		return err
+	}
+	return saveStoreConfig(cfg)
+}
+
+func execTimeout() time.Duration {
+	p := activeProfile()
+	if p.SSH == nil {
+		return defaultExecTimeout
+	}
+	raw := strings.TrimSpace(p.SSH.ExecTimeout)
+	if raw == "" {
+		return defaultExecTimeout
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		debugf("ignoring invalid ssh.execTimeout %q", raw)
+		return defaultExecTimeout
+	}
+	return d
+}
+
+type profileKey struct {
+	get func(p *profileConfig) string
+	set func(p *profileConfig, value string) error
+}
+
+var profileKeys = map[string]profileKey{
+	"token": {
+		get: func(p *profileConfig) string { return p.Token },
+		set: func(p *profileConfig, v string) error { p.Token = v; return nil },
+	},
+	"apiHost": {
+		get: func(p *profileConfig) string { return p.APIHost },
+		set: func(p *profileConfig, v string) error {
+			if v != "" {
+				u, err := url.Parse(v)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("apiHost must be an http(s) URL, got %q", v)
+				}
+				v = strings.TrimRight(v, "/")
+			}
+			p.APIHost = v
+			return nil
+		},
+	},
+	"defaultProject": {
+		get: func(p *profileConfig) string { return p.DefaultProject },
+		set: func(p *profileConfig, v string) error {
+			p.DefaultProject, p.DefaultProjectName = v, ""
+			return nil
+		},
+	},
+	"locale": {
+		get: func(p *profileConfig) string { return p.Locale },
+		set: func(p *profileConfig, v string) error {
+			if err := validateLocale(v); err != nil {
+				return fmt.Errorf("locale: %w", err)
+			}
+			p.Locale = v
+			return nil
+		},
+	},
+	"ssh.execTimeout": {
+		get: func(p *profileConfig) string {
+			if p.SSH == nil {
+				return ""
+			}
+			return p.SSH.ExecTimeout
+		},
+		set: func(p *profileConfig, v string) error {
+			if v != "" {
+				if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+					return fmt.Errorf("ssh.execTimeout must be a positive duration such as 90s, got %q", v)
+				}
+			}
+			if p.SSH == nil {
+				p.SSH = &sshDefaults{}
+			}
+			p.SSH.ExecTimeout = v
+			if v == "" {
+				p.SSH = nil
+			}
+			return nil
+		},
+	},
+	"tui.refreshInterval": {
+		get: func(p *profileConfig) string {
+			if p.TUI == nil {
+				return ""
+			}
+			return p.TUI.RefreshInterval
+		},
+		set: func(p *profileConfig, v string) error {
+			if v != "" && v != "0" {
+				if d, err := time.ParseDuration(v); err != nil || d < time.Second {
+					return fmt.Errorf("tui.refreshInterval must be a duration of at least 1s, or 0 to turn it off, got %q", v)
+				}
+			}
+			if v == "" {
+				p.TUI = nil
+				return nil
+			}
+			p.TUI = &tuiDefaults{RefreshInterval: v}
+			return nil
+		},
+	},
+	"tips.track": {
+		get: func(p *profileConfig) string {
+			if p.Tips == nil || p.Tips.Track == nil {
+				return ""
+			}
+			return strconv.FormatBool(*p.Tips.Track)
+		},
+		set: func(p *profileConfig, v string) error {
+			if v == "" {
+				p.Tips = nil
+				return nil
+			}
+			track, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("tips.track must be true or false, got %q", v)
+			}
+			p.Tips = &tipsDefaults{Track: &track}
+			return nil
+		},
+	},
+	"tunnels.localPortRange": {
+		get: func(p *profileConfig) string {
+			if p.Tunnels == nil {
+				return ""
+			}
+			return p.Tunnels.LocalPortRange
+		},
+		set: func(p *profileConfig, v string) error {
+			if v != "" {
+				r, err := parsePortRange(v)
+				if err != nil {
+					return fmt.Errorf("tunnels.localPortRange: %w", err)
+				}
+				v = r.String()
+			}
+			if v == "" {
+				p.Tunnels = nil
+				return nil
+			}
+			p.Tunnels = &tunnelDefaults{LocalPortRange: v}
+			return nil
+		},
+	},
+	"update.channel": {
+		get: func(p *profileConfig) string {
+			if p.Update == nil {
+				return ""
+			}
+			return p.Update.Channel
+		},
+		set: func(p *profileConfig, v string) error {
+			v = strings.ToLower(v)
+			if v != "" && !validUpdateChannel(v) {
+				return fmt.Errorf("update.channel must be one of %s, got %q", strings.Join(updateChannels, ", "), v)
+			}
+			if p.Update == nil {
+				p.Update = &updateDefaults{}
+			}
+			p.Update.Channel = v
+			if *p.Update == (updateDefaults{}) {
+				p.Update = nil
+			}
+			return nil
+		},
+	},
+	"update.notify": {
+		get: func(p *profileConfig) string {
+			if p.Update == nil || p.Update.Notify == nil {
+				return ""
+			}
+			return strconv.FormatBool(*p.Update.Notify)
+		},
+		set: func(p *profileConfig, v string) error {
+			var notify *bool
+			if v != "" {
+				parsed, err := strconv.ParseBool(v)
+				if err != nil {
+					return fmt.Errorf("update.notify must be true or false, got %q", v)
+				}
+				notify = &parsed
+			}
+			if p.Update == nil {
+				p.Update = &updateDefaults{}
+			}
+			p.Update.Notify = notify
+			if *p.Update == (updateDefaults{}) {
+				p.Update = nil
+			}
+			return nil
+		},
+	},
+	"update.releaseURL": {
+		get: func(p *profileConfig) string {
+			if p.Update == nil {
+				return ""
+			}
+			return p.Update.ReleaseURL
+		},
+		set: func(p *profileConfig, v string) error {
+			if v != "" {
+				u, err := url.Parse(v)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("update.releaseURL must be an http(s) URL, got %q", v)
+				}
+			}
+			if p.Update == nil {
+				p.Update = &updateDefaults{}
+			}
+			p.Update.ReleaseURL = strings.TrimRight(v, "/")
+			if *p.Update == (updateDefaults{}) {
+				p.Update = nil
+			}
+			return nil
+		},
+	},
+}
+
+func profileKeyNames() []string {
+	names := make([]string, 0, len(profileKeys))
+	for name := range profileKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupProfileKey(name string) (profileKey, error) {
+	key, ok := profileKeys[name]
+	if !ok {
+		return profileKey{}, fmt.Errorf("unknown config key %q (expected one of: %s)", name, strings.Join(profileKeyNames(), ", "))
+	}
+	return key, nil
+}
+
+func configGetFlow(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: hubfly config get <key>")
+	}
+	if args[0] == "currentProfile" {
+		cfg, err := loadStoreConfig()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		fmt.Println(currentProfileName(cfg))
+		return nil
+	}
+	key, err := lookupProfileKey(args[0])
+	if err != nil {
+		return err
+	}
+	p := activeProfile()
+	fmt.Println(key.get(&p))
+	return nil
+}
+
+func configSetFlow(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: hubfly config set <key> <value>")
+	}
+	key, err := lookupProfileKey(args[0])
+	if err != nil {
+		return err
+	}
+	value := strings.TrimSpace(args[1])
+	var profileName string
+	err = updateStoreConfig(func(cfg *storeConfig) error {
+		profileName = currentProfileName(*cfg)
+		p := profileFor(cfg, profileName)
+		if err := key.set(p, value); err != nil {
+			return err
+		}
+		value = key.get(p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if args[0] == "token" {
+		fmt.Printf("Updated token for profile %s.\n", profileName)
+		return nil
+	}
+	fmt.Printf("Set %s = %q for profile %s.\n", args[0], value, profileName)
+	return nil
+}
+
+func configUnsetFlow(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: hubfly config unset <key>")
+	}
+	return configSetFlow([]string{args[0], ""})
+}
+
+func configUseProfileFlow(args []string) error {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return errors.New("usage: hubfly config use-profile <name>")
+	}
+	name := strings.TrimSpace(args[0])
+	created := false
+	err := updateStoreConfig(func(cfg *storeConfig) error {
+		if _, exists := cfg.Profiles[name]; !exists {
+			created = true
+		}
+		profileFor(cfg, name)
+		cfg.CurrentProfile = name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Printf("Created profile %s and made it current. Run `hubfly login` to sign in.\n", name)
+		return nil
+	}
+	fmt.Printf("Switched to profile %s.\n", name)
+	return nil
+}
+
+type profileListEntry struct {
+	Name           string `json:"name"`
+	Current        bool   `json:"current"`
+	APIHost        string `json:"apiHost"`
+	DefaultProject string `json:"defaultProject,omitempty"`
+	LoggedIn       bool   `json:"loggedIn"`
+}
+
+func configProfilesFlow() error {
+	cfg, err := loadStoreConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	current := currentProfileName(cfg)
+	profileFor(&cfg, current)
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]profileListEntry, 0, len(names))
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		host := p.APIHost
+		if host == "" {
+			host = defaultAPIHost
+		}
+		entries = append(entries, profileListEntry{
+			Name:           name,
+			Current:        name == current,
+			APIHost:        host,
+			DefaultProject: p.DefaultProject,
+			LoggedIn:       strings.TrimSpace(p.Token) != "",
+		})
+	}
+	if jsonOutput {
+		return printJSON(entries)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "\tProfile\tAPI host\tDefault project\tLogged in")
+	for _, e := range entries {
+		marker := ""
+		if e.Current {
+			marker = "*"
+		}
+		loggedIn := "no"
+		if e.LoggedIn {
+			loggedIn = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", marker, e.Name, e.APIHost, valueOrDash(e.DefaultProject), loggedIn)
+	}
+	return tw.Flush()
+}
+
+// migrateProfiles moves the single pre-profile token into profiles.default.
+func migrateProfiles() error {
+	content, err := os.ReadFile(configPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var cfg storeConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		// Leave broken files for `hubfly config validate` to explain.
+		return nil
+	}
+	if cfg.Token == "" {
+		return nil
+	}
+	profileFor(&cfg, defaultProfileName)
+	if cfg.CurrentProfile == "" {
+		cfg.CurrentProfile = defaultProfileName
+	}
+	return saveStoreConfig(cfg)
+}
@@ -34,9 +34,57 @@ func projectsFlow(orgFilter string) error {
 		}
 	}
 
+	if !terminalSupportsVT() {
+		return plainProjectsFlow(token, orgID)
+	}
 	return runProjectsTUI(token, orgID)
 }
 
+// plainProjectsFlow drives the numbered-prompt screens for consoles that
+// cannot host the full-screen projects TUI.
+func plainProjectsFlow(token, orgID string) error {
+	projects, err := fetchProjectsWithOrg(token, orgID)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		fmt.Println("No projects found.")
+		return nil
+	}
+	for {
+		selected, cancelled, err := selectProject(projects)
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return nil
+		}
+		if err := manageProject(token, selected); err != nil {
+			return err
+		}
+	}
+}
+
+func projectsListFlow(orgFilter string) error {
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	orgID, err := resolveOrgID(token, orgFilter)
+	if err != nil {
+		return err
+	}
+	projects, err := fetchProjectsWithOrg(token, orgID)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(projects)
+	}
+	printProjectsTable(projects)
+	return nil
+}
+
 func manageProject(token string, p project) error {
 	for {
 		details, err := fetchProject(token, p.ID)
@@ -101,7 +149,16 @@ func manageContainer(token, projectID string, c container) error {
 
 		tunnels, err := fetchTunnels(token, projectID)
 		if err != nil {
-			fmt.Printf("Could not fetch tunnels: %v\n", err)
+			choice, promptErr := promptRetry("fetch tunnels", err)
+			if promptErr != nil {
+				return promptErr
+			}
+			switch choice {
+			case retryChoiceRetry:
+				continue
+			case retryChoiceAbort:
+				return nil
+			}
 		}
 
 		myTunnels := make([]tunnel, 0)
@@ -140,11 +197,23 @@ func manageContainer(token, projectID string, c container) error {
 			if port <= 0 {
 				continue
 			}
-			if err := createAndStoreTunnel(token, projectID, c, port); err != nil {
-				waitForEnter(fmt.Sprintf("Failed to create tunnel: %v\nPress Enter to continue...", err))
-				continue
+			for {
+				err := createAndStoreTunnel(token, projectID, c, port)
+				if err == nil {
+					waitForEnter("Tunnel created successfully. Press Enter to continue...")
+					break
+				}
+				choice, promptErr := promptRetry("create tunnel", err)
+				if promptErr != nil {
+					return promptErr
+				}
+				if choice == retryChoiceAbort {
+					return nil
+				}
+				if choice == retryChoiceSkip {
+					break
+				}
 			}
-			waitForEnter("Tunnel created successfully. Press Enter to continue...")
 		case 1:
 			if len(myTunnels) == 0 {
 				waitForEnter("No tunnels available. Press Enter to continue...")
@@ -157,13 +226,14 @@ func manageContainer(token, projectID string, c container) error {
 			if cancelled {
 				continue
 			}
-			local, lErr := promptNumberWithDefault("Enter local port to forward to", selectedPrimaryPort(selected))
+			local, lErr := promptNumberWithDefault("Enter local port to forward to", preferredLocalPort(selected))
 			if lErr != nil {
 				return lErr
 			}
 			if local <= 0 {
 				continue
 			}
+			rememberLocalPort(selected.TunnelID, local)
 			renderScreen("Tunnel Session", fmt.Sprintf("Tunnel %s", selected.TunnelID))
 			if err := runTunnelConnection(selected, "", local, selectedPrimaryPort(selected)); err != nil {
 				waitForEnter(fmt.Sprintf("Tunnel connection failed: %v\nPress Enter to continue...", err))
@@ -193,7 +263,7 @@ func connectMultipleTunnels(tunnels []tunnel) error {
 		return nil
 	}
 
-	useDefaults, err := promptYesNo("Use each tunnel target port as local port", true)
+	useDefaults, err := promptYesNo("Use each tunnel's last local port (or its target port) as local port", true)
 	if err != nil {
 		return err
 	}
@@ -205,9 +275,9 @@ func connectMultipleTunnels(tunnels []tunnel) error {
 
 	plans := make([]plannedTunnel, 0, len(selected))
 	for _, t := range selected {
-		localPort := selectedPrimaryPort(t)
+		localPort := preferredLocalPort(t)
 		if !useDefaults {
-			localPort, err = promptNumberWithDefault(fmt.Sprintf("Local port for %s", t.TunnelID), selectedPrimaryPort(t))
+			localPort, err = promptNumberWithDefault(fmt.Sprintf("Local port for %s", t.TunnelID), localPort)
 			if err != nil {
 				return err
 			}
@@ -375,11 +445,11 @@ func printTunnelsTable(tunnels []tunnel) {
 }
 
 func tunnelState(expiresAt string) string {
-	when, err := time.Parse(time.RFC3339, expiresAt)
-	if err != nil {
+	when, ok := parseExpiry(expiresAt)
+	if !ok {
 		return "unknown"
 	}
-	if when.Before(time.Now()) {
+	if when.Before(serverNow()) {
 		return "expired"
 	}
 	return "active"
@@ -487,23 +557,11 @@ func selectedPrimaryPort(t tunnel) int {
 }
 
 func findContainer(token string, containerIDOrName string) (*container, string, error) {
-	projects, err := fetchProjects(token)
+	p, c, err := findContainerInProjects(token, "", containerIDOrName)
 	if err != nil {
 		return nil, "", err
 	}
-
-	for _, p := range projects {
-		details, fetchErr := fetchProject(token, p.ID)
-		if fetchErr != nil {
-			continue
-		}
-		for _, c := range details.Containers {
-			if c.ID == containerIDOrName || c.Name == containerIDOrName {
-				return &c, p.ID, nil
-			}
-		}
-	}
-	return nil, "", fmt.Errorf("container '%s' not found in any project", containerIDOrName)
+	return &c, p.ID, nil
 }
 
 func logsFlow(containerIDOrName string, follow bool) error {
@@ -564,6 +622,10 @@ func organizationsFlow() error {
 		return err
 	}
 
+	if jsonOutput {
+		return printJSON(orgs)
+	}
+
 	if len(orgs) == 0 {
 		fmt.Println("You do not belong to any organizations.")
 		return nil
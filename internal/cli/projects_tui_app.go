@@ -2,14 +2,18 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -26,6 +30,13 @@ const (
 	viewPortInput
 	viewRunningSingle
 	viewRunningMulti
+	viewSavedTunnels
+	viewTunnelOverview
+	viewTextInput
+	viewLogs
+	viewNewContainer
+	viewProvisioning
+	viewTunnelDashboard
 )
 
 type portInputMode int
@@ -37,6 +48,14 @@ const (
 	portInputMultiCustom
 )
 
+type textInputMode int
+
+const (
+	textInputNone textInputMode = iota
+	textInputRename
+	textInputTags
+)
+
 type appItem struct {
 	title string
 	desc  string
@@ -47,23 +66,85 @@ func (i appItem) Title() string       { return i.title }
 func (i appItem) Description() string { return i.desc }
 func (i appItem) FilterValue() string { return i.title + " " + i.desc }
 
+// projectsLoadedMsg and containersLoadedMsg carry background, set for an
+// idle refresh, which updates the list in place instead of switching views.
 type projectsLoadedMsg struct {
-	projects []project
-	err      error
+	projects   []project
+	background bool
+	err        error
+	// next is the query for the page after these projects; pages is how
+	// many pages they span. more marks a page to append to the list.
+	next  string
+	pages int
+	more  bool
 }
 
 type containersLoadedMsg struct {
 	containers []container
+	background bool
 	err        error
 }
 
 type tunnelsLoadedMsg struct {
 	tunnels []tunnel
-	err     error
+	// reconcile marks a refresh after a local change. It updates the list
+	// in place instead of switching to the container menu.
+	reconcile bool
+	err       error
 }
 
 type tunnelCreatedMsg struct {
-	err error
+	tunnel     tunnel
+	targetPort int
+	err        error
+}
+
+type containerUpdatedMsg struct {
+	container container
+	what      string
+	err       error
+}
+
+type overviewLoadedMsg struct {
+	entries  []projectTunnel
+	failures []error
+	err      error
+}
+
+type overviewDeletedMsg struct {
+	deleted int
+	// failed are put back into the overview.
+	failed []projectTunnel
+	err    error
+}
+
+type tunnelDeletedMsg struct {
+	tunnel tunnel
+	index  int
+	err    error
+}
+
+// retryPrompt holds an API call that failed mid-flow. The user answers with
+// r, s or a instead of being dropped back to the menu.
+type retryPrompt struct {
+	what  string
+	retry tea.Cmd
+	// skip carries on without the result. Without it, skipping is the same
+	// as aborting: the user stays on the screen the call was made from.
+	skip func(m projectsApp) (projectsApp, tea.Cmd)
+}
+
+// confirmPrompt asks before a destructive action. y applies it; any other
+// key cancels.
+type confirmPrompt struct {
+	question string
+	apply    func(m projectsApp) (projectsApp, tea.Cmd)
+}
+
+type containerActionMsg struct {
+	action    string
+	container container
+	err       error
 }
 
 type singleSSHDoneMsg struct {
@@ -78,20 +159,25 @@ type singleStartMsg struct {
 	stderrBuf *bytes.Buffer
 }
 
+// tunnelLiveTickMsg polls the running tunnels' status files.
+type tunnelLiveTickMsg time.Time
+
 type multiTunnelPlan struct {
 	tunnel    tunnel
 	localPort int
 }
 
 type multiStartMsg struct {
-	cmds   []*exec.Cmd
-	plans  []multiTunnelPlan
-	events chan multiEvent
-	err    error
+	cmds    []*exec.Cmd
+	outputs []*outputTail
+	plans   []multiTunnelPlan
+	events  chan multiEvent
+	err     error
 }
 
 type multiEvent struct {
 	index int
+	cmd   *exec.Cmd
 	err   error
 }
 
@@ -107,12 +193,19 @@ type projectsApp struct {
 	input textinput.Model
 	view  projectsView
 
-	projects          []project
-	selectedProject   project
+	projects        []project
+	selectedProject project
+	// projectsNext is the query for the next page of projects, fetched as
+	// the cursor nears the end of the list; projectPages counts the pages
+	// loaded so far, which an idle refresh reloads.
+	projectsNext      string
+	projectPages      int
 	containers        []container
 	selectedContainer container
-	tunnels           []tunnel
-	selectedTunnel    tunnel
+	// groupContainers lists containers by inferred service type.
+	groupContainers bool
+	tunnels         []tunnel
+	selectedTunnel  tunnel
 
 	multiSelectedIdxs  map[int]bool
 	singleRunningCmd   *exec.Cmd
@@ -125,10 +218,79 @@ type projectsApp struct {
 	multiRunningPlans  []multiTunnelPlan
 	multiRunningState  []string
 	multiEvents        chan multiEvent
+	// multiRunningErrs, multiOutputs and multiExpanded are per tunnel of a
+	// multi run: why it ended, its last output, and whether its row shows
+	// them. multiCursor is the row keys act on.
+	multiRunningErrs []string
+	multiOutputs     []*outputTail
+	multiExpanded    map[int]bool
+	multiCursor      int
+	// singleLive and multiLive are the last status the running tunnel
+	// processes reported, polled every second while liveTicking.
+	singleLive        tunnelLiveStatus
+	multiLive         []tunnelLiveStatus
+	liveAt            time.Time
+	liveTicking       bool
+	savedTunnels      []savedTunnel
+	savedSelectedIdxs map[int]bool
+	// fromSaved is set while multi tunnels started from the saved tunnels
+	// screen are running, so stopping them returns there.
+	fromSaved bool
+	// overview lists tunnels across every project; fromOverview is set
+	// while tunnels started from it are running.
+	overview         []projectTunnel
+	overviewSelected map[int]bool
+	fromOverview     bool
+	// dashboard holds the background sessions on the running tunnels
+	// dashboard.
+	dashboard []tunnelSession
+	// pendingDeletes are tunnels already removed from the list whose
+	// delete has not been confirmed, so a refresh does not bring them back.
+	pendingDeletes map[string]bool
+	// deletePicker is set when the tunnel list was opened from Delete
+	// Tunnel, so Enter deletes instead of connecting.
+	deletePicker bool
+
+	// keys is the keymap from config.json; help shows its overlay.
+	keys tuiKeymap
+	help bool
+
+	// loading holds the data sets being fetched, shown with spinner;
+	// stale holds the error of the last failed refresh of each. Idle
+	// refreshes run every refreshInterval once no key was pressed since
+	// lastKey.
+	spinner         spinner.Model
+	loading         map[string]bool
+	stale           map[string]string
+	refreshInterval time.Duration
+	lastKey         time.Time
 
 	portMode        portInputMode
 	portInputPrompt string
 	portInputDef    int
+	// portConfirmed is a local port whose range warning was already shown;
+	// pressing Enter on it again accepts it.
+	portConfirmed int
+
+	textMode        textInputMode
+	textInputPrompt string
+
+	// logs is the logs view; logsGen is bumped whenever its polling should
+	// stop or restart.
+	logs       viewport.Model
+	logsGen    int
+	logsFollow bool
+
+	// newContainer is the New Container wizard; createGen is bumped to
+	// drop results for a wizard that was left or superseded.
+	newContainer     newContainerForm
+	createGen        int
+	provisionID      string
+	provisionStatus  string
+	provisionStarted time.Time
+
+	retry   *retryPrompt
+	confirm *confirmPrompt
 
 	status string
 	errMsg string
@@ -140,7 +302,7 @@ func runProjectsTUI(token string, orgID string) error {
 	setTUIDebugMode(true)
 	defer setTUIDebugMode(false)
 	m := newProjectsApp(token, orgID)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(recordTUI(m), teaProgramOptions()...)
 	_, err := p.Run()
 	return err
 }
@@ -152,7 +314,12 @@ func newProjectsApp(token string, orgID string) projectsApp {
 	l.SetFilteringEnabled(true)
 	l.SetShowStatusBar(true)
 	l.SetShowHelp(true)
-	l.Title = "Hubfly Projects"
+	l.Title = tr("tui.projects.title")
+	// The help overlay replaces the list's own full help.
+	keys := loadTUIKeymap()
+	l.KeyMap.ShowFullHelp.SetEnabled(false)
+	l.KeyMap.CloseFullHelp.SetEnabled(false)
+	l.AdditionalShortHelpKeys = func() []key.Binding { return []key.Binding{keys.helpBinding()} }
 
 	ti := textinput.New()
 	ti.Prompt = "> "
@@ -165,35 +332,100 @@ func newProjectsApp(token string, orgID string) projectsApp {
 		orgID:             orgID,
 		list:              l,
 		input:             ti,
+		keys:              keys,
+		spinner:           spinner.New(spinner.WithSpinner(spinner.Dot)),
+		loading:           map[string]bool{tuiDataProjects: true},
+		refreshInterval:   tuiRefreshInterval(),
+		status:            "Loading projects...",
 		view:              viewProjects,
 		multiSelectedIdxs: map[int]bool{},
+		savedSelectedIdxs: map[int]bool{},
 	}
 }
 
 func (m projectsApp) Init() tea.Cmd {
-	return fetchProjectsCmd(m.token, m.orgID)
+	return tea.Batch(fetchProjectsCmd(m.token, m.orgID), m.spinner.Tick, refreshTickCmd(m.refreshInterval))
 }
 
 func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.lastKey = time.Now()
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() != "ctrl+c" && !m.typing() {
+		if m.help {
+			m.help = false
+			return m, nil
+		}
+		translated, ok := m.keys.translateKey(key)
+		if !ok {
+			return m, nil
+		}
+		switch translated.String() {
+		case "?":
+			m.help = true
+			return m, nil
+		case "D":
+			m.status = toggleTUIDebug()
+			return m, nil
+		}
+		msg = translated
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.list.SetSize(msg.Width, max(10, msg.Height-6))
-		if m.view == viewPortInput {
+		if m.view == viewPortInput || m.view == viewTextInput || m.view == viewNewContainer {
 			m.input.Width = max(10, msg.Width-20)
 		}
+		m.logs.Width = msg.Width
+		m.logs.Height = m.logsHeight()
 		return m, nil
+	case containerLogsMsg, logsTickMsg:
+		return m.updateLogs(msg)
+	case containerCreatedMsg, provisionStatusMsg, provisionTickMsg:
+		return m.updateProvisioning(msg)
+	case spinner.TickMsg, refreshTickMsg:
+		return m.updateRefresh(msg)
 	case projectsLoadedMsg:
+		m.loaded(tuiDataProjects, msg.err, msg.background)
+		if msg.more {
+			if msg.err != nil {
+				m.errMsg = msg.err.Error()
+				return m, nil
+			}
+			m.projects = append(m.projects, msg.projects...)
+			m.projectsNext = msg.next
+			m.projectPages += msg.pages
+			if m.view != viewProjects {
+				return m, nil
+			}
+			m.list.Title = m.projectsTitle()
+			return m, m.list.SetItems(m.projectItems())
+		}
+		if msg.background {
+			if msg.err == nil {
+				m.projects = msg.projects
+				m.projectsNext = msg.next
+				m.projectPages = msg.pages
+			}
+			if m.view == viewProjects {
+				m.setProjectItems()
+			}
+			return m, nil
+		}
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			return m, nil
 		}
 		m.projects = msg.projects
+		m.projectsNext = msg.next
+		m.projectPages = msg.pages
 		if len(msg.projects) == 0 {
-			m.status = "No projects found. Press q to quit."
+			m.status = tr("tui.projects.empty")
 			m.list.SetItems([]list.Item{})
-			m.list.Title = "Hubfly Projects"
+			m.list.Title = tr("tui.projects.title")
 			m.view = viewProjects
 			return m, nil
 		}
@@ -203,6 +435,16 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.setProjectItems()
 		return m, nil
 	case containersLoadedMsg:
+		m.loaded(tuiDataContainers, msg.err, msg.background)
+		if msg.background {
+			if msg.err == nil {
+				m.containers = msg.containers
+			}
+			if m.view == viewContainers {
+				m.setContainerItems()
+			}
+			return m, nil
+		}
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			m.status = "Failed to load containers"
@@ -215,9 +457,30 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.setContainerItems()
 		return m, nil
 	case tunnelsLoadedMsg:
+		m.loaded(tuiDataTunnels, msg.err, msg.reconcile)
+		if msg.reconcile {
+			if msg.err != nil {
+				debugf("tunnel refresh failed: %v", msg.err)
+				m.refreshTunnelItems()
+				return m, nil
+			}
+			m.replaceTunnels(filterContainerTunnels(msg.tunnels, m.selectedContainer.ID))
+			return m, nil
+		}
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			m.status = "Failed to load tunnels"
+			m.retry = &retryPrompt{
+				what:  "load tunnels",
+				retry: fetchTunnelsCmd(m.token, m.selectedProject.ID),
+				skip: func(m projectsApp) (projectsApp, tea.Cmd) {
+					m.tunnels = nil
+					m.view = viewContainerMenu
+					m.status = "Tunnels not loaded"
+					m.setContainerActionItems()
+					return m, nil
+				},
+			}
 			return m, nil
 		}
 		m.errMsg = ""
@@ -226,17 +489,107 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = fmt.Sprintf("%d tunnel(s) loaded", len(m.tunnels))
 		m.setContainerActionItems()
 		return m, nil
+	case containerUpdatedMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			m.status = "Could not update " + msg.what
+			return m, nil
+		}
+		m.errMsg = ""
+		m.selectedContainer = msg.container
+		for i := range m.containers {
+			if m.containers[i].ID == msg.container.ID {
+				m.containers[i] = msg.container
+			}
+		}
+		m.status = fmt.Sprintf("Updated %s of %s", msg.what, msg.container.Name)
+		if m.view == viewContainerMenu {
+			m.setContainerActionItems()
+		}
+		return m, nil
+	case containerActionMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			m.status = fmt.Sprintf("Could not %s %s", msg.action, msg.container.Name)
+			m.retry = &retryPrompt{
+				what:  msg.action + " " + msg.container.Name,
+				retry: containerActionCmd(m.token, m.selectedProject.ID, msg.container, msg.action),
+			}
+			return m, nil
+		}
+		m.errMsg = ""
+		if m.selectedContainer.ID == msg.container.ID {
+			m.selectedContainer = msg.container
+		}
+		for i := range m.containers {
+			if m.containers[i].ID == msg.container.ID {
+				m.containers[i] = msg.container
+			}
+		}
+		m.status = fmt.Sprintf("%s %s, now %s", containerActionDone[msg.action], msg.container.Name, valueOrDash(msg.container.Status))
+		if m.view == viewContainerMenu {
+			m.setContainerActionItems()
+		}
+		return m, nil
 	case tunnelCreatedMsg:
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			m.status = "Tunnel creation failed"
-		} else {
-			m.errMsg = ""
-			m.status = "Tunnel created"
+			m.retry = &retryPrompt{
+				what:  "create tunnel",
+				retry: createTunnelTicketCmd(m.token, m.selectedProject.ID, m.selectedContainer, msg.targetPort),
+			}
+			return m, nil
 		}
-		m.view = viewContainerMenu
-		m.setContainerActionItems()
-		return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+		// Show the tunnel right away and let the refresh catch up.
+		created := msg.tunnel
+		if created.TargetContainerID == "" {
+			created.TargetContainerID = m.selectedContainer.ID
+		}
+		m.tunnels = append(m.tunnels, created)
+		m.errMsg = ""
+		m.status = fmt.Sprintf("Tunnel %s created", created.TunnelID)
+		return m, m.load(tuiDataTunnels, reconcileTunnelsCmd(m.token, m.selectedProject.ID))
+	case overviewLoadedMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			m.status = "Failed to load tunnels"
+			return m, nil
+		}
+		m.errMsg = ""
+		if len(msg.failures) > 0 {
+			m.errMsg = fmt.Sprintf("%d project(s) could not be listed: %v", len(msg.failures), msg.failures[0])
+		}
+		m.overview = msg.entries
+		m.overviewSelected = map[int]bool{}
+		m.view = viewTunnelOverview
+		m.status = fmt.Sprintf("%d tunnel(s) across all projects", len(msg.entries))
+		m.setOverviewItems(nil)
+		return m, nil
+	case overviewDeletedMsg:
+		m.overview = append(m.overview, msg.failed...)
+		if m.view == viewTunnelOverview {
+			m.setOverviewItems(nil)
+		}
+		m.status = fmt.Sprintf("Deleted %d tunnel(s)", msg.deleted)
+		if msg.err != nil {
+			m.errMsg = fmt.Sprintf("%d delete(s) failed: %v", len(msg.failed), msg.err)
+		}
+		return m, nil
+	case tunnelDeletedMsg:
+		delete(m.pendingDeletes, msg.tunnel.TunnelID)
+		if msg.err != nil {
+			// Put the tunnel back where it was.
+			index := min(msg.index, len(m.tunnels))
+			m.tunnels = append(m.tunnels[:index], append([]tunnel{msg.tunnel}, m.tunnels[index:]...)...)
+			m.refreshTunnelItems()
+			m.errMsg = msg.err.Error()
+			m.status = fmt.Sprintf("Failed to delete tunnel %s", msg.tunnel.TunnelID)
+			return m, nil
+		}
+		m.errMsg = ""
+		m.status = fmt.Sprintf("Deleted tunnel %s", msg.tunnel.TunnelID)
+		return m, m.load(tuiDataTunnels, reconcileTunnelsCmd(m.token, m.selectedProject.ID))
 	case singleStartMsg:
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
@@ -249,9 +602,10 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.singleRunningCmd = msg.cmd
 		m.singleRunningPort = msg.localPort
 		m.singleRunningState = "running"
+		m.singleLive = tunnelLiveStatus{}
 		m.view = viewRunningSingle
 		m.status = fmt.Sprintf("Tunnel open: localhost:%d -> %s:%d", msg.localPort, resolveTunnelForwardHost(m.selectedTunnel), selectedPrimaryPort(m.selectedTunnel))
-		return m, waitSingleTunnelDoneCmd(msg.cmd, msg.stderrBuf)
+		return m, tea.Batch(waitSingleTunnelDoneCmd(msg.cmd, msg.stderrBuf), m.startLiveTicks())
 	case singleSSHDoneMsg:
 		if m.singleRunningCmd == nil && m.view != viewRunningSingle {
 			return m, nil
@@ -278,6 +632,18 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			m.status = "Failed to start multi tunnel"
+			if m.fromOverview {
+				m.fromOverview = false
+				m.view = viewTunnelOverview
+				m.setOverviewItems(nil)
+				return m, nil
+			}
+			if m.fromSaved {
+				m.fromSaved = false
+				m.view = viewSavedTunnels
+				m.setSavedTunnelItems(nil)
+				return m, nil
+			}
 			m.view = viewContainerMenu
 			m.setContainerActionItems()
 			return m, nil
@@ -290,35 +656,83 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		for i := range m.multiRunningState {
 			m.multiRunningState[i] = "running"
 		}
+		m.multiRunningErrs = make([]string, len(msg.cmds))
+		m.multiOutputs = msg.outputs
+		m.multiExpanded = map[int]bool{}
+		m.multiCursor = 0
+		m.multiLive = make([]tunnelLiveStatus, len(msg.cmds))
 		m.view = viewRunningMulti
 		m.status = fmt.Sprintf("%d tunnel process(es) running", len(msg.cmds))
-		return m, waitMultiEventCmd(msg.events)
+		return m, tea.Batch(waitMultiEventCmd(msg.events), m.startLiveTicks())
+	case tunnelLiveTickMsg:
+		if m.view != viewRunningSingle && m.view != viewRunningMulti && m.view != viewTunnelDashboard {
+			m.liveTicking = false
+			return m, nil
+		}
+		m.liveAt = time.Time(msg)
+		if m.view == viewTunnelDashboard {
+			if !m.typing() {
+				m.reloadDashboard()
+			}
+			return m, liveTickCmd()
+		}
+		m.refreshLive()
+		return m, liveTickCmd()
+	case dashboardActionMsg:
+		return m.finishDashboardAction(msg), nil
 	case multiEventMsg:
-		if msg.event.index >= 0 && msg.event.index < len(m.multiRunningState) {
-			if msg.event.err != nil {
-				m.multiRunningState[msg.event.index] = "error"
-				m.errMsg = msg.event.err.Error()
-			} else {
-				m.multiRunningState[msg.event.index] = "exited"
+		return m.multiEnded(msg.event)
+	case multiRestartMsg:
+		return m.multiRestarted(msg)
+	}
+
+	if m.retry != nil {
+		if key, ok := msg.(tea.KeyMsg); ok {
+			return m.answerRetry(key.String())
+		}
+	}
+
+	if m.confirm != nil {
+		if key, ok := msg.(tea.KeyMsg); ok {
+			return m.answerConfirm(key.String())
+		}
+	}
+
+	if m.view == viewNewContainer || m.view == viewProvisioning {
+		if key, ok := msg.(tea.KeyMsg); !ok || key.String() != "ctrl+c" {
+			if m.view == viewProvisioning {
+				return m.updateProvisioning(msg)
 			}
+			return m.updateNewContainer(msg)
 		}
-		allDone := true
-		for _, st := range m.multiRunningState {
-			if st == "running" {
-				allDone = false
-				break
+	}
+
+	if m.view == viewLogs {
+		switch key := msg.(type) {
+		case tea.KeyMsg:
+			if key.String() != "ctrl+c" {
+				return m.updateLogs(msg)
 			}
+		case tea.MouseMsg:
+			return m.updateLogs(msg)
 		}
-		if allDone {
-			m.status = "All multi tunnels exited"
-			m.view = viewContainerMenu
-			m.setContainerActionItems()
-			m.multiRunningCmds = nil
-			m.multiRunningPlans = nil
-			m.multiRunningState = nil
-			return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+	}
+
+	if m.view == viewTextInput {
+		if key, ok := msg.(tea.KeyMsg); ok {
+			switch key.String() {
+			case "esc":
+				m.errMsg = ""
+				m.view = viewContainerMenu
+				m.setContainerActionItems()
+				return m, nil
+			case "enter":
+				return m.submitTextInput()
+			}
 		}
-		return m, waitMultiEventCmd(m.multiEvents)
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
 	}
 
 	if m.view == viewPortInput {
@@ -344,6 +758,40 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.errMsg = "Invalid port"
 					return m, nil
 				}
+				if m.portMode == portInputSingle || m.portMode == portInputMultiCustom {
+					warning, err := checkLocalPort(port)
+					var privileged *privilegedPortError
+					if errors.As(err, &privileged) && privileged.Suggested > 0 {
+						m.input.SetValue(strconv.Itoa(privileged.Suggested))
+						m.errMsg = fmt.Sprintf("Port %d needs root. Press Enter to use %d instead.", port, privileged.Suggested)
+						return m, nil
+					}
+					if err != nil {
+						m.errMsg = err.Error()
+						return m, nil
+					}
+					taken := make(map[int]bool, len(m.multiCustomPorts))
+					if m.portMode == portInputMultiCustom {
+						for _, assigned := range m.multiCustomPorts {
+							taken[assigned] = true
+						}
+					}
+					if taken[port] || !localPortFree(port) {
+						if next := nextFreeLocalPort(port, taken); next > 0 {
+							m.input.SetValue(strconv.Itoa(next))
+							m.errMsg = fmt.Sprintf("localhost:%d is already in use. Press Enter to use %d instead.", port, next)
+						} else {
+							m.errMsg = fmt.Sprintf("localhost:%d is already in use", port)
+						}
+						return m, nil
+					}
+					if warning != "" && m.portConfirmed != port {
+						m.portConfirmed = port
+						m.errMsg = ""
+						m.status = warning + ". Press Enter again to use it anyway."
+						return m, nil
+					}
+				}
 				m.errMsg = ""
 				switch m.portMode {
 				case portInputCreate:
@@ -363,7 +811,7 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.multiCustomIndex++
 					if m.multiCustomIndex < len(m.multiCustomList) {
 						next := m.multiCustomList[m.multiCustomIndex]
-						m.setPortInput(portInputMultiCustom, fmt.Sprintf("Local port for %s", next.TunnelID), selectedPrimaryPort(next))
+						m.setPortInput(portInputMultiCustom, fmt.Sprintf("Local port for %s", next.TunnelID), defaultLocalPort(next, m.multiCustomPorts))
 						return m, nil
 					}
 					plans := make([]multiTunnelPlan, 0, len(m.multiCustomList))
@@ -410,6 +858,32 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch m.view {
 		case viewProjects:
+			if key.String() == "a" && m.list.FilterState() != list.Filtering {
+				m.errMsg = ""
+				m.status = "Loading tunnels across all projects..."
+				return m, fetchOverviewCmd(m.token)
+			}
+			if key.String() == "u" && m.list.FilterState() != list.Filtering {
+				return m.openDashboard()
+			}
+			if key.String() == "t" && m.list.FilterState() != list.Filtering {
+				saved, err := loadSavedTunnels()
+				if err != nil {
+					m.errMsg = err.Error()
+					return m, nil
+				}
+				if len(saved) == 0 {
+					m.status = "No saved tunnels. Add one with `hubfly tunnel save`."
+					return m, nil
+				}
+				m.errMsg = ""
+				m.savedTunnels = saved
+				m.savedSelectedIdxs = map[int]bool{}
+				m.view = viewSavedTunnels
+				m.setSavedTunnelItems(nil)
+				m.status = "Connect saved tunnels"
+				return m, nil
+			}
 			if key.String() == "enter" {
 				item, ok := m.list.SelectedItem().(appItem)
 				if !ok {
@@ -435,10 +909,13 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch item.idx {
 				case 0:
 					m.status = "Loading containers..."
-					return m, fetchContainersCmd(m.token, m.selectedProject.ID)
+					return m, m.load(tuiDataContainers, fetchContainersCmd(m.token, m.selectedProject.ID))
 				case 1:
 					m.status = "Refreshing project..."
-					return m, fetchContainersCmd(m.token, m.selectedProject.ID)
+					return m, m.load(tuiDataContainers, fetchContainersCmd(m.token, m.selectedProject.ID))
+				case 2:
+					m.startNewContainer()
+					return m, nil
 				default:
 					m.view = viewProjects
 					m.setProjectItems()
@@ -451,6 +928,11 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.setProjectActionItems()
 				return m, nil
 			}
+			if key.String() == "g" && m.list.FilterState() != list.Filtering {
+				m.groupContainers = !m.groupContainers
+				m.setContainerItems()
+				return m, nil
+			}
 			if key.String() == "enter" {
 				item, ok := m.list.SelectedItem().(appItem)
 				if !ok {
@@ -458,7 +940,7 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.selectedContainer = m.containers[item.idx]
 				m.status = "Loading tunnels..."
-				return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+				return m, m.load(tuiDataTunnels, fetchTunnelsCmd(m.token, m.selectedProject.ID))
 			}
 		case viewContainerMenu:
 			if key.String() == "esc" {
@@ -481,6 +963,7 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						return m, nil
 					}
 					m.view = viewTunnelsSingle
+					m.deletePicker = false
 					m.setTunnelSingleItems()
 					return m, nil
 				case 2:
@@ -493,7 +976,34 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				case 3:
 					m.status = "Refreshing tunnels..."
-					return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+					return m, m.load(tuiDataTunnels, fetchTunnelsCmd(m.token, m.selectedProject.ID))
+				case 4:
+					m.setTextInput(textInputRename, "New container name", m.selectedContainer.Name)
+					return m, nil
+				case 5:
+					m.setTextInput(textInputTags, "Tags, separated by commas (empty clears them)", strings.Join(m.selectedContainer.Tags, ", "))
+					return m, nil
+				case 6:
+					return m, m.openLogs()
+				case 7:
+					m.askContainerAction(containerActionStart)
+					return m, nil
+				case 8:
+					m.askContainerAction(containerActionStop)
+					return m, nil
+				case 9:
+					m.askContainerAction(containerActionRestart)
+					return m, nil
+				case 10:
+					if len(m.tunnels) == 0 {
+						m.status = "No tunnels available"
+						return m, nil
+					}
+					m.view = viewTunnelsSingle
+					m.deletePicker = true
+					m.setTunnelSingleItems()
+					m.list.Select(0)
+					return m, nil
 				default:
 					m.view = viewContainers
 					m.setContainerItems()
@@ -506,6 +1016,14 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.setContainerActionItems()
 				return m, nil
 			}
+			if (key.String() == "d" && m.list.FilterState() != list.Filtering) || (key.String() == "enter" && m.deletePicker) {
+				item, ok := m.list.SelectedItem().(appItem)
+				if !ok || item.idx >= len(m.tunnels) {
+					return m, nil
+				}
+				m.askDeleteTunnel(m.tunnels[item.idx])
+				return m, nil
+			}
 			if key.String() == "enter" {
 				item, ok := m.list.SelectedItem().(appItem)
 				if !ok {
@@ -524,7 +1042,7 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 				m.selectedTunnel = candidate
-				m.setPortInput(portInputSingle, "Local forward port", selectedPrimaryPort(m.selectedTunnel))
+				m.setPortInput(portInputSingle, "Local forward port", defaultLocalPort(m.selectedTunnel, nil))
 				return m, nil
 			}
 		case viewTunnelsMulti:
@@ -586,10 +1104,13 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch item.idx {
 				case 0:
 					plans := make([]multiTunnelPlan, 0, len(m.multiCustomList))
+					assigned := make([]int, 0, len(m.multiCustomList))
 					for _, t := range m.multiCustomList {
+						port := defaultLocalPort(t, assigned)
+						assigned = append(assigned, port)
 						plans = append(plans, multiTunnelPlan{
 							tunnel:    t,
-							localPort: selectedPrimaryPort(t),
+							localPort: port,
 						})
 					}
 					m.status = "Starting multiple tunnels..."
@@ -603,7 +1124,7 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						return m, nil
 					}
 					first := m.multiCustomList[0]
-					m.setPortInput(portInputMultiCustom, fmt.Sprintf("Local port for %s", first.TunnelID), selectedPrimaryPort(first))
+					m.setPortInput(portInputMultiCustom, fmt.Sprintf("Local port for %s", first.TunnelID), defaultLocalPort(first, nil))
 					return m, nil
 				default:
 					m.view = viewTunnelsMulti
@@ -615,26 +1136,122 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if key.String() == "s" || key.String() == "enter" || key.String() == "esc" {
 				if m.singleRunningCmd != nil {
 					_ = stopSSHProcess(m.singleRunningCmd)
+					removeTunnelLiveStatus(m.selectedTunnel.TunnelID, m.singleRunningPort)
 					m.singleRunningCmd = nil
 					m.singleRunningState = "closed"
 					m.singleRunningPort = 0
 					m.view = viewContainerMenu
 					m.setContainerActionItems()
 					m.status = "Stopped tunnel session"
-					return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+					return m, m.load(tuiDataTunnels, fetchTunnelsCmd(m.token, m.selectedProject.ID))
 				}
 				m.singleRunningPort = 0
 				m.view = viewContainerMenu
 				m.setContainerActionItems()
-				return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+				return m, m.load(tuiDataTunnels, fetchTunnelsCmd(m.token, m.selectedProject.ID))
 			}
 		case viewRunningMulti:
-			if key.String() == "s" || key.String() == "enter" || key.String() == "esc" {
-				m.stopAllMulti()
-				m.view = viewContainerMenu
-				m.setContainerActionItems()
-				m.status = "Stopped all running multi tunnels"
-				return m, fetchTunnelsCmd(m.token, m.selectedProject.ID)
+			if next, cmd, handled := m.multiRunKey(key.String()); handled {
+				return next, cmd
+			}
+		case viewTunnelOverview:
+			switch key.String() {
+			case "esc":
+				m.view = viewProjects
+				m.setProjectItems()
+				return m, nil
+			case "space":
+				it, ok := m.list.SelectedItem().(appItem)
+				if !ok {
+					return m, nil
+				}
+				m.overviewSelected[it.idx] = !m.overviewSelected[it.idx]
+				m.setOverviewItems(&it.idx)
+				return m, nil
+			case "a":
+				all := true
+				for idx := range m.overview {
+					if !m.overviewSelected[idx] {
+						all = false
+						break
+					}
+				}
+				for idx := range m.overview {
+					m.overviewSelected[idx] = !all
+				}
+				m.setOverviewItems(nil)
+				return m, nil
+			case "r":
+				m.status = "Refreshing tunnels..."
+				return m, fetchOverviewCmd(m.token)
+			case "d":
+				picked, rest := m.pickOverview()
+				if len(picked) == 0 {
+					return m, nil
+				}
+				// Drop them from the list now; failures are put back.
+				m.overview = rest
+				m.overviewSelected = map[int]bool{}
+				m.setOverviewItems(nil)
+				m.errMsg = ""
+				m.status = fmt.Sprintf("Deleting %d tunnel(s)...", len(picked))
+				return m, deleteOverviewTunnelsCmd(m.token, picked)
+			case "enter":
+				picked, _ := m.pickOverview()
+				if len(picked) == 0 {
+					return m, nil
+				}
+				plans := make([]multiTunnelPlan, 0, len(picked))
+				assigned := make([]int, 0, len(picked))
+				for _, e := range picked {
+					port := defaultLocalPort(e.Tunnel, assigned)
+					assigned = append(assigned, port)
+					plans = append(plans, multiTunnelPlan{tunnel: e.Tunnel, localPort: port})
+				}
+				m.errMsg = ""
+				m.fromOverview = true
+				m.status = fmt.Sprintf("Starting %d tunnel(s)...", len(plans))
+				return m, startMultiTunnelsCmd(plans)
+			}
+		case viewTunnelDashboard:
+			switch key.String() {
+			case "esc", "r", "s", "enter":
+				return m.updateDashboard(key.String())
+			}
+		case viewSavedTunnels:
+			if key.String() == "esc" {
+				m.view = viewProjects
+				m.setProjectItems()
+				return m, nil
+			}
+			if key.String() == "space" {
+				it, ok := m.list.SelectedItem().(appItem)
+				if !ok {
+					return m, nil
+				}
+				m.savedSelectedIdxs[it.idx] = !m.savedSelectedIdxs[it.idx]
+				m.setSavedTunnelItems(&it.idx)
+				return m, nil
+			}
+			if key.String() == "enter" {
+				picked := make([]savedTunnel, 0)
+				for idx := range m.savedTunnels {
+					if m.savedSelectedIdxs[idx] {
+						picked = append(picked, m.savedTunnels[idx])
+					}
+				}
+				if len(picked) == 0 {
+					if it, ok := m.list.SelectedItem().(appItem); ok {
+						picked = append(picked, m.savedTunnels[it.idx])
+					}
+				}
+				if len(picked) == 0 {
+					return m, nil
+				}
+				m.errMsg = ""
+				m.fromSaved = true
+				m.status = fmt.Sprintf("Creating %d saved tunnel(s)...", len(picked))
+				return m, connectSavedTunnelsCmd(m.token, picked)
 			}
 		}
 	}
@@ -645,9 +1262,137 @@ func (m projectsApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+	if more := m.loadMoreProjects(); more != nil {
+		return m, tea.Batch(cmd, more)
+	}
 	return m, cmd
 }
 
+var projectsViewNames = map[projectsView]string{
+	viewProjects:        "projects",
+	viewProjectMenu:     "project-menu",
+	viewContainers:      "containers",
+	viewContainerMenu:   "container-menu",
+	viewTunnelsSingle:   "tunnels-single",
+	viewTunnelsMulti:    "tunnels-multi",
+	viewMultiPortMode:   "multi-port-mode",
+	viewPortInput:       "port-input",
+	viewRunningSingle:   "running-single",
+	viewRunningMulti:    "running-multi",
+	viewSavedTunnels:    "saved-tunnels",
+	viewTunnelOverview:  "tunnel-overview",
+	viewTextInput:       "text-input",
+	viewLogs:            "logs",
+	viewNewContainer:    "new-container",
+	viewProvisioning:    "provisioning",
+	viewTunnelDashboard: "tunnel-dashboard",
+}
+
+func (m projectsApp) recordState() tuiState {
+	return tuiState{
+		Screen:    projectsViewNames[m.view],
+		Project:   m.selectedProject.Name,
+		Container: m.selectedContainer.Name,
+		Tunnel:    m.selectedTunnel.TunnelID,
+		Status:    m.status,
+		Error:     m.errMsg,
+	}
+}
+
+var containerActionDone = map[string]string{
+	containerActionStart:   "Started",
+	containerActionStop:    "Stopped",
+	containerActionRestart: "Restarted",
+}
+
+var containerActionRunning = map[string]string{
+	containerActionStart:   "Starting",
+	containerActionStop:    "Stopping",
+	containerActionRestart: "Restarting",
+}
+
+func (m *projectsApp) askContainerAction(action string) {
+	c := m.selectedContainer
+	m.errMsg = ""
+	m.confirm = &confirmPrompt{
+		question: fmt.Sprintf("%s container %s?", strings.ToUpper(action[:1])+action[1:], c.Name),
+		apply: func(m projectsApp) (projectsApp, tea.Cmd) {
+			m.status = fmt.Sprintf("%s %s...", containerActionRunning[action], c.Name)
+			return m, containerActionCmd(m.token, m.selectedProject.ID, c, action)
+		},
+	}
+}
+
+// askDeleteTunnel confirms deleting t. The delete removes the tunnel from
+// the list right away; it comes back if the API call fails.
+func (m *projectsApp) askDeleteTunnel(t tunnel) {
+	m.errMsg = ""
+	m.confirm = &confirmPrompt{
+		question: fmt.Sprintf("Delete tunnel %s and its local ticket and keys?", t.TunnelID),
+		apply: func(m projectsApp) (projectsApp, tea.Cmd) {
+			index := -1
+			for i := range m.tunnels {
+				if m.tunnels[i].TunnelID == t.TunnelID {
+					index = i
+				}
+			}
+			if index < 0 {
+				return m, nil
+			}
+			m.tunnels = append(m.tunnels[:index:index], m.tunnels[index+1:]...)
+			if m.pendingDeletes == nil {
+				m.pendingDeletes = map[string]bool{}
+			}
+			m.pendingDeletes[t.TunnelID] = true
+			m.setTunnelSingleItems()
+			m.status = fmt.Sprintf("Deleting tunnel %s...", t.TunnelID)
+			return m, deleteTunnelCmd(m.token, t, index)
+		},
+	}
+}
+
+// answerConfirm handles a key while a confirmation prompt is showing.
+func (m projectsApp) answerConfirm(key string) (tea.Model, tea.Cmd) {
+	prompt := m.confirm
+	m.confirm = nil
+	switch key {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "y", "Y":
+		return prompt.apply(m)
+	}
+	m.status = "Cancelled"
+	return m, nil
+}
+
+// answerRetry handles a key while a retry prompt is showing.
+func (m projectsApp) answerRetry(key string) (tea.Model, tea.Cmd) {
+	prompt := m.retry
+	switch key {
+	case "ctrl+c":
+		m.retry = nil
+		return m, tea.Quit
+	case "r", "enter":
+		m.retry = nil
+		m.errMsg = ""
+		m.status = "Retrying: " + prompt.what + "..."
+		return m, prompt.retry
+	case "s":
+		m.retry = nil
+		m.errMsg = ""
+		if prompt.skip != nil {
+			return prompt.skip(m)
+		}
+		m.status = "Skipped: " + prompt.what
+		return m, nil
+	case "a", "esc":
+		m.retry = nil
+		m.status = "Aborted: " + prompt.what
+		return m, nil
+	}
+	return m, nil
+}
+
 func (m projectsApp) View() string {
 	header := "Hubfly CLI - Projects TUI\n"
 	if m.selectedProject.ID != "" {
@@ -663,13 +1408,44 @@ func (m projectsApp) View() string {
 		)
 	}
 	if strings.TrimSpace(m.status) != "" {
-		header += "Status: " + m.status + "\n"
+		if m.busy() {
+			header += "Status: " + m.spinner.View() + m.status + "\n"
+		} else {
+			header += "Status: " + m.status + "\n"
+		}
 	}
+	header += m.staleLine()
 	if strings.TrimSpace(m.errMsg) != "" {
 		header += "Error: " + m.errMsg + "\n"
 	}
+	if m.retry != nil {
+		header += fmt.Sprintf("Could not %s: [r]etry / [s]kip / [a]bort\n", m.retry.what)
+	}
+	if m.confirm != nil {
+		header += m.confirm.question + " [y/N]\n"
+	}
 	header += strings.Repeat("-", 80) + "\n"
 
+	if m.help {
+		return m.helpView(header)
+	}
+
+	if m.view == viewLogs {
+		return m.logsView(header)
+	}
+
+	if m.view == viewNewContainer {
+		return m.newContainerView(header)
+	}
+
+	if m.view == viewProvisioning {
+		return m.provisioningView(header)
+	}
+
+	if m.view == viewTextInput {
+		return header + "\n" + m.textInputPrompt + "\n" + m.input.View() + "\n\nEnter to save, Esc to cancel"
+	}
+
 	if m.view == viewPortInput {
 		return header + "\n" + m.portInputPrompt + "\n" + m.input.View() + "\n\nEnter to confirm, Esc to cancel"
 	}
@@ -684,6 +1460,9 @@ func (m projectsApp) View() string {
 			resolveTunnelForwardHost(m.selectedTunnel),
 			selectedPrimaryPort(m.selectedTunnel),
 		))
+		if m.singleRunningState == "running" {
+			b.WriteString("  " + m.liveLine(m.singleLive) + "\n")
+		}
 		b.WriteString("\nUse this endpoint locally now.\n")
 		if m.singleRunningState == "error" {
 			b.WriteString("Tunnel ended unexpectedly. Check error details above.\n")
@@ -695,24 +1474,7 @@ func (m projectsApp) View() string {
 	}
 
 	if m.view == viewRunningMulti {
-		var b strings.Builder
-		b.WriteString(header)
-		b.WriteString("\nRunning multi-tunnel sessions:\n\n")
-		for i, plan := range m.multiRunningPlans {
-			state := "running"
-			if i < len(m.multiRunningState) {
-				state = m.multiRunningState[i]
-			}
-			b.WriteString(fmt.Sprintf("- %s | localhost:%d -> %s:%d | %s\n",
-				plan.tunnel.TunnelID,
-				plan.localPort,
-				resolveTunnelForwardHost(plan.tunnel),
-				selectedPrimaryPort(plan.tunnel),
-				state,
-			))
-		}
-		b.WriteString("\nPress 's' (or Enter/Esc) to stop all and return.\n")
-		return b.String()
+		return m.multiRunView(header)
 	}
 
 	return header + "\n" + m.list.View()
@@ -730,47 +1492,76 @@ func (m *projectsApp) setListItems(title string, items []list.Item, status strin
 
 func (m *projectsApp) setProjectItems() {
 	m.selectedContainer = container{}
+	m.setListItems(m.projectsTitle(), m.projectItems(), tr("tui.projects.status"), true)
+}
+
+func (m projectsApp) projectsTitle() string {
+	if m.projectsNext != "" {
+		return tr("tui.projects.list.more", len(m.projects))
+	}
+	return tr("tui.projects.list")
+}
+
+func (m projectsApp) projectItems() []list.Item {
 	items := make([]list.Item, 0, len(m.projects))
 	for i, p := range m.projects {
 		items = append(items, appItem{
 			title: p.Name,
-			desc:  fmt.Sprintf("%s | %s | role=%s | spent=%s | %s", p.Region.Name, p.Status, p.Role, valueOrDash(p.Spent), p.ID),
+			desc:  fmt.Sprintf("%s | %s | role=%s | spent=%s | %s", p.Region.Name, p.Status, p.Role, valueOrDash(p.Spent), p.ID) + m.staleMark(tuiDataProjects),
 			idx:   i,
 		})
 	}
-	m.setListItems("Projects", items, "Type to filter, Enter select, q quit", true)
+	return items
 }
 
 func (m *projectsApp) setProjectActionItems() {
 	items := []list.Item{
-		appItem{title: "Manage Containers", desc: "Open containers for selected project", idx: 0},
-		appItem{title: "Refresh Project", desc: "Reload containers and metadata", idx: 1},
-		appItem{title: "Back", desc: "Return to projects list", idx: 2},
+		appItem{title: tr("tui.project.containers"), desc: tr("tui.project.containers.desc"), idx: 0},
+		appItem{title: tr("tui.project.refresh"), desc: tr("tui.project.refresh.desc"), idx: 1},
+		appItem{title: tr("tui.project.new"), desc: tr("tui.project.new.desc"), idx: 2},
+		appItem{title: tr("tui.back"), desc: tr("tui.project.back.desc"), idx: 3},
 	}
-	m.setListItems("Project Actions", items, "Enter select, Esc back", false)
+	m.setListItems(tr("tui.project.title"), items, tr("tui.menu.status"), false)
 }
 
 func (m *projectsApp) setContainerItems() {
+	order := make([]int, len(m.containers))
+	for i := range order {
+		order[i] = i
+	}
+	title := tr("tui.containers.title")
+	if m.groupContainers {
+		order = sortByServiceType(m.containers)
+		title = tr("tui.containers.grouped")
+	}
 	items := make([]list.Item, 0, len(m.containers))
-	for i, c := range m.containers {
+	for _, i := range order {
+		c := m.containers[i]
 		items = append(items, appItem{
 			title: c.Name,
-			desc:  fmt.Sprintf("%s | CPU %.2f | RAM %.0fMB | ports %d | %s", c.Status, c.Resources.CPU, c.Resources.RAM, len(c.Networking.Ports), c.ID),
+			desc:  fmt.Sprintf("%s | %s | CPU %.2f | RAM %.0fMB | ports %d | %s", inferServiceType(c), c.Status, c.Resources.CPU, c.Resources.RAM, len(c.Networking.Ports), c.ID) + m.staleMark(tuiDataContainers),
 			idx:   i,
 		})
 	}
-	m.setListItems("Containers", items, "Type to filter, Enter select, Esc back", true)
+	m.setListItems(title, items, tr("tui.containers.status"), true)
 }
 
 func (m *projectsApp) setContainerActionItems() {
 	items := []list.Item{
-		appItem{title: "Create New Tunnel", desc: "Create a direct tunnel session ticket", idx: 0},
-		appItem{title: "Connect One Tunnel", desc: "Open one direct tunnel session", idx: 1},
-		appItem{title: "Connect Multiple Tunnels", desc: "Run many direct tunnels concurrently", idx: 2},
-		appItem{title: "Refresh Tunnels", desc: "Reload current tunnel list", idx: 3},
-		appItem{title: "Back", desc: "Return to container list", idx: 4},
+		appItem{title: tr("tui.container.create"), desc: tr("tui.container.create.desc"), idx: 0},
+		appItem{title: tr("tui.container.connect"), desc: tr("tui.container.connect.desc"), idx: 1},
+		appItem{title: tr("tui.container.multi"), desc: tr("tui.container.multi.desc"), idx: 2},
+		appItem{title: tr("tui.container.delete"), desc: tr("tui.container.delete.desc"), idx: 10},
+		appItem{title: tr("tui.container.refresh"), desc: tr("tui.container.refresh.desc"), idx: 3},
+		appItem{title: tr("tui.container.rename"), desc: tr("tui.container.current", m.selectedContainer.Name), idx: 4},
+		appItem{title: tr("tui.container.tags"), desc: tr("tui.container.current", valueOrDash(strings.Join(m.selectedContainer.Tags, ", "))), idx: 5},
+		appItem{title: tr("tui.container.logs"), desc: tr("tui.container.logs.desc"), idx: 6},
+		appItem{title: tr("tui.container.start"), desc: tr("tui.container.current", valueOrDash(m.selectedContainer.Status)), idx: 7},
+		appItem{title: tr("tui.container.stop"), desc: tr("tui.container.stop.desc"), idx: 8},
+		appItem{title: tr("tui.container.restart"), desc: tr("tui.container.restart.desc"), idx: 9},
+		appItem{title: tr("tui.back"), desc: tr("tui.container.back.desc"), idx: 11},
 	}
-	m.setListItems("Container Actions", items, "Enter select, Esc back", false)
+	m.setListItems(tr("tui.container.title"), items, tr("tui.menu.status"), false)
 }
 
 func (m *projectsApp) setTunnelSingleItems() {
@@ -782,11 +1573,15 @@ func (m *projectsApp) setTunnelSingleItems() {
 		}
 		items = append(items, appItem{
 			title: t.TunnelID,
-			desc:  fmt.Sprintf("gateway -> %s:%d | %s | %s", resolveTunnelForwardHost(t), selectedPrimaryPort(t), tunnelState(t.ExpiresAt), ticketState),
+			desc:  fmt.Sprintf("gateway -> %s:%d | %s | %s", resolveTunnelForwardHost(t), selectedPrimaryPort(t), tunnelState(t.ExpiresAt), ticketState) + m.staleMark(tuiDataTunnels),
 			idx:   i,
 		})
 	}
-	m.setListItems("Pick Tunnel", items, "Type to filter, Enter select, Esc back", true)
+	if m.deletePicker {
+		m.setListItems("Delete Tunnel", items, "Type to filter, Enter delete, Esc back", true)
+		return
+	}
+	m.setListItems("Pick Tunnel", items, "Type to filter, Enter select, d delete, Esc back", true)
 }
 
 func (m *projectsApp) setTunnelMultiItems(preserveIdx *int) {
@@ -802,7 +1597,7 @@ func (m *projectsApp) setTunnelMultiItems(preserveIdx *int) {
 		}
 		items = append(items, appItem{
 			title: fmt.Sprintf("%s %s", mark, t.TunnelID),
-			desc:  fmt.Sprintf("gateway -> %s:%d", resolveTunnelForwardHost(t), selectedPrimaryPort(t)),
+			desc:  fmt.Sprintf("gateway -> %s:%d", resolveTunnelForwardHost(t), selectedPrimaryPort(t)) + m.staleMark(tuiDataTunnels),
 			idx:   i,
 		})
 	}
@@ -812,13 +1607,121 @@ func (m *projectsApp) setTunnelMultiItems(preserveIdx *int) {
 	}
 }
 
+func (m *projectsApp) setSavedTunnelItems(preserveIdx *int) {
+	cursor := m.list.Index()
+	if preserveIdx != nil {
+		cursor = *preserveIdx
+	}
+	items := make([]list.Item, 0, len(m.savedTunnels))
+	for i, s := range m.savedTunnels {
+		mark := "[ ]"
+		if m.savedSelectedIdxs[i] {
+			mark = "[x]"
+		}
+		local := "auto"
+		if s.LocalPort > 0 {
+			local = strconv.Itoa(s.LocalPort)
+		}
+		desc := fmt.Sprintf("localhost:%s -> %s:%d", local, s.Container, s.RemotePort)
+		if s.Direction == tunnelDirectionReverse {
+			desc = fmt.Sprintf("%s:%d -> localhost:%s (reverse)", s.Container, s.RemotePort, local)
+		}
+		if s.Project != "" {
+			desc += " | " + s.Project
+		}
+		items = append(items, appItem{title: fmt.Sprintf("%s %s", mark, s.Name), desc: desc, idx: i})
+	}
+	m.setListItems("Connect Saved Tunnels", items, "space toggle, enter connect selected (or current), esc back", false)
+	if cursor >= 0 && cursor < len(items) {
+		m.list.Select(cursor)
+	}
+}
+
+// setOverviewItems lists the tunnels of every project with their project
+// and target.
+func (m *projectsApp) setOverviewItems(preserveIdx *int) {
+	cursor := m.list.Index()
+	if preserveIdx != nil {
+		cursor = *preserveIdx
+	}
+	items := make([]list.Item, 0, len(m.overview))
+	for i, e := range m.overview {
+		mark := "[ ]"
+		if m.overviewSelected[i] {
+			mark = "[x]"
+		}
+		ticket := "ticket:ok"
+		if _, err := loadTunnelTicket(e.Tunnel.TunnelID); err != nil {
+			ticket = "ticket:missing"
+		}
+		project := e.Project.Name
+		if e.Org != "" {
+			project = e.Org + "/" + project
+		}
+		items = append(items, appItem{
+			title: fmt.Sprintf("%s %s", mark, e.Tunnel.TunnelID),
+			desc:  fmt.Sprintf("%s | %s:%d | %s | %s", project, resolveTunnelForwardHost(e.Tunnel), selectedPrimaryPort(e.Tunnel), tunnelState(e.Tunnel.ExpiresAt), ticket),
+			idx:   i,
+		})
+	}
+	m.setListItems("All Tunnels", items, "space toggle, a all, enter connect, d delete, r refresh, esc back", false)
+	if cursor >= 0 && cursor < len(items) {
+		m.list.Select(cursor)
+	}
+}
+
+// pickOverview splits the overview into the selected tunnels, or the one
+// under the cursor when none are selected, and the rest.
+func (m *projectsApp) pickOverview() (picked, rest []projectTunnel) {
+	chosen := make(map[int]bool, len(m.overviewSelected))
+	for idx, on := range m.overviewSelected {
+		if on {
+			chosen[idx] = true
+		}
+	}
+	if len(chosen) == 0 {
+		if it, ok := m.list.SelectedItem().(appItem); ok {
+			chosen[it.idx] = true
+		}
+	}
+	for idx, e := range m.overview {
+		if chosen[idx] {
+			picked = append(picked, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return picked, rest
+}
+
+// leaveMultiRun returns from the running multi-tunnel screen to where the
+// tunnels were started: the overview, the saved tunnels list or the
+// container menu.
+func (m *projectsApp) leaveMultiRun() tea.Cmd {
+	if m.fromOverview {
+		m.fromOverview = false
+		m.view = viewTunnelOverview
+		m.setOverviewItems(nil)
+		return nil
+	}
+	if m.fromSaved {
+		m.fromSaved = false
+		m.view = viewSavedTunnels
+		m.setSavedTunnelItems(nil)
+		return nil
+	}
+	m.view = viewContainerMenu
+	m.setContainerActionItems()
+	return m.load(tuiDataTunnels, fetchTunnelsCmd(m.token, m.selectedProject.ID))
+}
+
 func (m *projectsApp) setMultiPortModeItems() {
 	items := []list.Item{
-		appItem{title: "Use Target Ports", desc: "Use each tunnel target port as local port", idx: 0},
-		appItem{title: "Custom Local Ports", desc: "Set a custom local port per tunnel", idx: 1},
-		appItem{title: "Back", desc: "Return to tunnel selection", idx: 2},
+		appItem{title: tr("tui.ports.auto"), desc: tr("tui.ports.auto.desc"), idx: 0},
+		appItem{title: tr("tui.ports.custom"), desc: tr("tui.ports.custom.desc"), idx: 1},
+		appItem{title: tr("tui.back"), desc: tr("tui.ports.back.desc"), idx: 2},
 	}
-	m.setListItems("Multi Tunnel Port Mode", items, "Enter select, Esc back", false)
+	m.setListItems(tr("tui.ports.title"), items, tr("tui.menu.status"), false)
 }
 
 func (m *projectsApp) setPortInput(mode portInputMode, prompt string, def int) {
@@ -826,25 +1729,142 @@ func (m *projectsApp) setPortInput(mode portInputMode, prompt string, def int) {
 	m.portMode = mode
 	m.portInputPrompt = prompt
 	m.portInputDef = def
+	m.portConfirmed = 0
+	m.input.CharLimit = 10
 	m.input.SetValue(strconv.Itoa(def))
 	m.input.CursorEnd()
 	m.input.Focus()
 }
 
+func (m *projectsApp) setTextInput(mode textInputMode, prompt, value string) {
+	m.view = viewTextInput
+	m.textMode = mode
+	m.textInputPrompt = prompt
+	m.errMsg = ""
+	m.input.CharLimit = 256
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	m.input.Focus()
+}
+
+// submitTextInput validates the rename or tag input and sends it to the
+// API, returning to the container menu while it runs.
+func (m projectsApp) submitTextInput() (tea.Model, tea.Cmd) {
+	value := strings.TrimSpace(m.input.Value())
+	c := m.selectedContainer
+	switch m.textMode {
+	case textInputRename:
+		if err := validateContainerName(value); err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		m.errMsg = ""
+		m.view = viewContainerMenu
+		m.setContainerActionItems()
+		if value == c.Name {
+			return m, nil
+		}
+		m.status = "Renaming container..."
+		return m, renameContainerCmd(m.token, m.selectedProject.ID, c, value)
+	case textInputTags:
+		tags, err := parseContainerTags(value)
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		m.errMsg = ""
+		m.view = viewContainerMenu
+		m.setContainerActionItems()
+		m.status = "Saving tags..."
+		return m, setContainerTagsCmd(m.token, m.selectedProject.ID, c, tags)
+	}
+	return m, nil
+}
+
+// defaultLocalPort suggests a local port for t that avoids ports already
+// assigned in this flow, falling back to the target port.
+func defaultLocalPort(t tunnel, assigned []int) int {
+	taken := make(map[int]bool, len(assigned))
+	for _, port := range assigned {
+		taken[port] = true
+	}
+	if last := loadLastLocalPorts()[t.TunnelID]; last > 0 && !taken[last] && localPortFree(last) {
+		return last
+	}
+	if port := suggestLocalPort(selectedPrimaryPort(t), taken); port > 0 {
+		return port
+	}
+	return selectedPrimaryPort(t)
+}
+
 func (m *projectsApp) stopAllMulti() {
 	for _, cmd := range m.multiRunningCmds {
 		_ = stopSSHProcess(cmd)
 	}
+	for _, plan := range m.multiRunningPlans {
+		removeTunnelLiveStatus(plan.tunnel.TunnelID, plan.localPort)
+	}
 	m.multiRunningCmds = nil
+	m.multiLive = nil
 	m.multiRunningPlans = nil
 	m.multiRunningState = nil
+	m.multiRunningErrs = nil
+	m.multiOutputs = nil
 	m.multiEvents = nil
 }
 
+// startLiveTicks starts polling tunnel status unless a poll is already
+// scheduled.
+func (m *projectsApp) startLiveTicks() tea.Cmd {
+	m.liveAt = time.Now()
+	if m.liveTicking {
+		return nil
+	}
+	m.liveTicking = true
+	return liveTickCmd()
+}
+
+func liveTickCmd() tea.Cmd {
+	return tea.Tick(tunnelStatusInterval, func(t time.Time) tea.Msg { return tunnelLiveTickMsg(t) })
+}
+
+// refreshLive reads the status files of the tunnels still running. A tunnel
+// whose file is missing keeps its last known status.
+func (m *projectsApp) refreshLive() {
+	if m.view == viewRunningSingle && m.singleRunningCmd != nil {
+		if s, ok := loadTunnelLiveStatus(tunnelStatusPath(m.selectedTunnel.TunnelID, m.singleRunningPort)); ok {
+			m.singleLive = s
+		}
+	}
+	for i, plan := range m.multiRunningPlans {
+		if i >= len(m.multiLive) || i >= len(m.multiRunningState) || m.multiRunningState[i] != "running" {
+			continue
+		}
+		if s, ok := loadTunnelLiveStatus(tunnelStatusPath(plan.tunnel.TunnelID, plan.localPort)); ok {
+			m.multiLive[i] = s
+		}
+	}
+}
+
+// liveLine describes a running tunnel for the running views.
+func (m projectsApp) liveLine(s tunnelLiveStatus) string {
+	if s.State == "" {
+		return "starting..."
+	}
+	return describeTunnelLive(s, m.liveAt)
+}
+
 func fetchProjectsCmd(token, orgID string) tea.Cmd {
 	return func() tea.Msg {
-		projects, err := fetchProjectsWithOrg(token, orgID)
-		return projectsLoadedMsg{projects: projects, err: err}
+		projects, next, err := fetchProjectPages(token, orgID, "", 1)
+		return projectsLoadedMsg{projects: projects, next: next, pages: 1, err: err}
+	}
+}
+
+func fetchMoreProjectsCmd(token, orgID, query string) tea.Cmd {
+	return func() tea.Msg {
+		projects, next, err := fetchProjectPages(token, orgID, query, 1)
+		return projectsLoadedMsg{projects: projects, next: next, pages: 1, more: true, err: err}
 	}
 }
 
@@ -865,23 +1885,118 @@ func fetchTunnelsCmd(token, projectID string) tea.Cmd {
 	}
 }
 
+func fetchOverviewCmd(token string) tea.Cmd {
+	return func() tea.Msg {
+		entries, failures, err := fetchAllProjectTunnels(token)
+		return overviewLoadedMsg{entries: entries, failures: failures, err: err}
+	}
+}
+
+// deleteOverviewTunnelsCmd deletes tunnels one by one and reports the ones
+// that failed, with the first error.
+func deleteOverviewTunnelsCmd(token string, entries []projectTunnel) tea.Cmd {
+	return func() tea.Msg {
+		var msg overviewDeletedMsg
+		for _, e := range entries {
+			err := deleteTunnel(token, e.Tunnel.TunnelID)
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && apiErr.Status == 404 {
+				err = nil
+			}
+			if err == nil {
+				_, err = removeLocalTunnelCredentials(e.Tunnel.TunnelID)
+			}
+			if err != nil {
+				msg.failed = append(msg.failed, e)
+				if msg.err == nil {
+					msg.err = fmt.Errorf("%s: %w", e.Tunnel.TunnelID, err)
+				}
+				continue
+			}
+			msg.deleted++
+		}
+		return msg
+	}
+}
+
+func reconcileTunnelsCmd(token, projectID string) tea.Cmd {
+	return func() tea.Msg {
+		tunnels, err := fetchTunnels(token, projectID)
+		return tunnelsLoadedMsg{tunnels: tunnels, reconcile: true, err: err}
+	}
+}
+
 func createTunnelTicketCmd(token, projectID string, c container, targetPort int) tea.Cmd {
 	return func() tea.Msg {
-		err := createTunnelTicket(token, projectID, c, targetPort)
-		return tunnelCreatedMsg{err: err}
+		t, err := createTunnelTicket(token, projectID, c, targetPort)
+		return tunnelCreatedMsg{tunnel: t, targetPort: targetPort, err: err}
 	}
 }
 
-func createTunnelTicket(token, projectID string, c container, targetPort int) error {
+func createTunnelTicket(token, projectID string, c container, targetPort int) (tunnel, error) {
 	t, err := createTunnel(token, projectID, createTunnelRequest{
 		ContainerID: c.ID,
 		TargetPort:  targetPort,
 		LocalPort:   targetPort,
 	})
 	if err != nil {
-		return err
+		return t, err
+	}
+	return t, saveTunnelTicket(t)
+}
+
+func renameContainerCmd(token, projectID string, c container, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := renameContainer(token, projectID, c.ID, name)
+		if err == nil {
+			c.Name = name
+		}
+		return containerUpdatedMsg{container: c, what: "name", err: err}
+	}
+}
+
+func setContainerTagsCmd(token, projectID string, c container, tags []string) tea.Cmd {
+	return func() tea.Msg {
+		err := setContainerTags(token, projectID, c.ID, tags)
+		if err == nil {
+			c.Tags = tags
+		}
+		return containerUpdatedMsg{container: c, what: "tags", err: err}
+	}
+}
+
+// containerActionCmd runs a lifecycle action, then re-reads the container so
+// the menu shows its new status.
+func containerActionCmd(token, projectID string, c container, action string) tea.Cmd {
+	return func() tea.Msg {
+		if err := runContainerAction(token, projectID, c.ID, action); err != nil {
+			return containerActionMsg{action: action, container: c, err: err}
+		}
+		if details, err := fetchProject(token, projectID); err == nil {
+			for _, fresh := range details.Containers {
+				if fresh.ID == c.ID {
+					c = fresh
+				}
+			}
+		}
+		return containerActionMsg{action: action, container: c}
+	}
+}
+
+// deleteTunnelCmd deletes a tunnel and its local ticket. A tunnel already
+// gone on the server counts as deleted.
+func deleteTunnelCmd(token string, t tunnel, index int) tea.Cmd {
+	return func() tea.Msg {
+		err := deleteTunnel(token, t.TunnelID)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Status == 404 {
+			err = nil
+		}
+		if err == nil {
+			_, err = removeLocalTunnelCredentials(t.TunnelID)
+		}
+		return tunnelDeletedMsg{tunnel: t, index: index, err: err}
 	}
-	return saveTunnelTicket(t)
 }
 
 func startSingleTunnelCmd(t tunnel, localPort, targetPort int) tea.Cmd {
@@ -894,12 +2009,10 @@ func startSingleTunnelCmd(t tunnel, localPort, targetPort int) tea.Cmd {
 		}
 
 		stderrBuf := &bytes.Buffer{}
-		cmd, err := startTunnelConnectionBackground(t, "", localPort, targetPort)
+		cmd, err := startTunnelProcess(t, localPort, targetPort, stderrBuf, stderrBuf)
 		if err != nil {
 			return singleStartMsg{err: err}
 		}
-		cmd.Stdout = stderrBuf
-		cmd.Stderr = stderrBuf
 
 		debugf("started tunnel %s localhost:%d -> %s:%d", t.TunnelID, localPort, resolveTunnelForwardHost(t), targetPort)
 		return singleStartMsg{cmd: cmd, localPort: localPort, stderrBuf: stderrBuf}
@@ -920,6 +2033,7 @@ func waitSingleTunnelDoneCmd(cmd *exec.Cmd, stderrBuf *bytes.Buffer) tea.Cmd {
 func startMultiTunnelsCmd(plans []multiTunnelPlan) tea.Cmd {
 	return func() tea.Msg {
 		cmds := make([]*exec.Cmd, 0, len(plans))
+		outputs := make([]*outputTail, 0, len(plans))
 		events := make(chan multiEvent, len(plans)*2)
 		for idx, plan := range plans {
 			if tunnelIsExpired(plan.tunnel.ExpiresAt) {
@@ -935,7 +2049,7 @@ func startMultiTunnelsCmd(plans []multiTunnelPlan) tea.Cmd {
 				return multiStartMsg{err: fmt.Errorf("missing local tunnel ticket for tunnel %s", plan.tunnel.TunnelID)}
 			}
 
-			cmd, err := startTunnelConnectionBackground(plan.tunnel, "", plan.localPort, selectedPrimaryPort(plan.tunnel))
+			cmd, output, err := startMultiTunnelProcess(idx, plan, events)
 			if err != nil {
 				for _, started := range cmds {
 					_ = stopSSHProcess(started)
@@ -943,11 +2057,39 @@ func startMultiTunnelsCmd(plans []multiTunnelPlan) tea.Cmd {
 				return multiStartMsg{err: err}
 			}
 			cmds = append(cmds, cmd)
-			go func(i int, c *exec.Cmd) {
-				events <- multiEvent{index: i, err: c.Wait()}
-			}(idx, cmd)
+			outputs = append(outputs, output)
+		}
+		return multiStartMsg{cmds: cmds, outputs: outputs, plans: plans, events: events}
+	}
+}
+
+// connectSavedTunnelsCmd creates a tunnel for each saved definition and starts
+// them together like "Connect Multiple Tunnels".
+func connectSavedTunnelsCmd(token string, saved []savedTunnel) tea.Cmd {
+	return func() tea.Msg {
+		plans := make([]multiTunnelPlan, 0, len(saved))
+		taken := make(map[int]bool, len(saved))
+		for _, s := range saved {
+			port, err := savedTunnelLocalPort(s, taken)
+			if err != nil {
+				return multiStartMsg{err: err}
+			}
+			if s.Direction != tunnelDirectionReverse && (taken[port] || !localPortFree(port)) {
+				next := nextFreeLocalPort(port, taken)
+				if next == 0 {
+					return multiStartMsg{err: fmt.Errorf("saved tunnel %s: localhost:%d is already in use", s.Name, port)}
+				}
+				debugf("saved tunnel %s: localhost:%d busy, using %d", s.Name, port, next)
+				port = next
+			}
+			taken[port] = true
+			t, _, _, err := createSavedTunnel(token, s, port)
+			if err != nil {
+				return multiStartMsg{err: err}
+			}
+			plans = append(plans, multiTunnelPlan{tunnel: t, localPort: port})
 		}
-		return multiStartMsg{cmds: cmds, plans: plans, events: events}
+		return startMultiTunnelsCmd(plans)()
 	}
 }
 
@@ -959,11 +2101,48 @@ func waitMultiEventCmd(events chan multiEvent) tea.Cmd {
 }
 
 func tunnelIsExpired(expiresAt string) bool {
-	when, err := time.Parse(time.RFC3339, expiresAt)
-	if err != nil {
+	when, ok := parseExpiry(expiresAt)
+	if !ok {
 		return false
 	}
-	return when.Before(time.Now())
+	return when.Before(serverNow())
+}
+
+// replaceTunnels swaps in a refreshed tunnel list, leaving out tunnels still
+// being deleted and keeping multi-select marks on the same tunnels.
+func (m *projectsApp) replaceTunnels(tunnels []tunnel) {
+	selected := map[string]bool{}
+	for idx, on := range m.multiSelectedIdxs {
+		if on && idx < len(m.tunnels) {
+			selected[m.tunnels[idx].TunnelID] = true
+		}
+	}
+	kept := make([]tunnel, 0, len(tunnels))
+	for _, t := range tunnels {
+		if !m.pendingDeletes[t.TunnelID] {
+			kept = append(kept, t)
+		}
+	}
+	m.tunnels = kept
+	if m.multiSelectedIdxs != nil {
+		m.multiSelectedIdxs = map[int]bool{}
+		for idx, t := range kept {
+			if selected[t.TunnelID] {
+				m.multiSelectedIdxs[idx] = true
+			}
+		}
+	}
+	m.refreshTunnelItems()
+}
+
+// refreshTunnelItems redraws the tunnel list when one is on screen.
+func (m *projectsApp) refreshTunnelItems() {
+	switch m.view {
+	case viewTunnelsSingle:
+		m.setTunnelSingleItems()
+	case viewTunnelsMulti:
+		m.setTunnelMultiItems(nil)
+	}
 }
 
 func filterContainerTunnels(tunnels []tunnel, containerID string) []tunnel {
@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Steps of the New Container wizard, in the order they are asked.
+const (
+	createStepName = iota
+	createStepImage
+	createStepTier
+	createStepPorts
+	createStepEnv
+	createStepReview
+)
+
+const (
+	provisionPollInterval = 2 * time.Second
+	// provisionTimeout is how long the wizard watches a new container before
+	// leaving it to provision in the background.
+	provisionTimeout = 5 * time.Minute
+)
+
+// newContainerForm collects the New Container wizard's answers.
+type newContainerForm struct {
+	step  int
+	name  string
+	image string
+	tier  string
+	ports []deployPort
+	env   []deployEnvVar
+}
+
+// newContainerPayload builds the create request from the form, with the
+// same defaults `hubfly deploy` uses for the tier.
+func newContainerPayload(projectID string, f newContainerForm) map[string]any {
+	var cfg deployConfigFile
+	cfg.Deploy.Tier = normalizeDeployTier(f.tier)
+	cfg.Deploy.Resources = deployResources{CPU: 1, RAM: 800, Storage: 1}
+	cfg.Deploy.Runtime = deployRuntime{Is24x7: true}
+	applyDeployTierConstraints(&cfg)
+	return map[string]any{
+		"projectId": projectID,
+		"name":      f.name,
+		"tier":      cfg.Deploy.Tier,
+		"source": map[string]any{
+			"type":        "docker",
+			"dockerImage": f.image,
+		},
+		"resources": map[string]any{
+			"cpu":     cfg.Deploy.Resources.CPU,
+			"ram":     cfg.Deploy.Resources.RAM,
+			"storage": cfg.Deploy.Resources.Storage,
+		},
+		"runtime": map[string]any{
+			"autoSleep": cfg.Deploy.Runtime.AutoSleep,
+			"autoScale": cfg.Deploy.Runtime.AutoScale,
+			"is24x7":    cfg.Deploy.Runtime.Is24x7,
+		},
+		"networkAliases":       []string{f.name},
+		"environmentVariables": stackEnvironmentPayload(f.env),
+		"ports":                stackCreatePortsPayload(f.ports),
+	}
+}
+
+// parseFormPorts reads a comma separated list such as "80, 8443:443,
+// 53/udp" using the compose port syntax.
+func parseFormPorts(raw string) ([]deployPort, error) {
+	var ports []deployPort
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		port, warning := parseComposePortString(entry)
+		if warning != "" || port.Container <= 0 || port.Container > 65535 || port.Host < 0 || port.Host > 65535 {
+			return nil, fmt.Errorf("invalid port %q: use 80, 8080:80 or 53/udp", entry)
+		}
+		ports = append(ports, port)
+	}
+	return uniqueDeployPorts(ports), nil
+}
+
+// parseFormEnv reads one KEY=VALUE pair.
+func parseFormEnv(raw string) (deployEnvVar, error) {
+	key, value, ok := strings.Cut(raw, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return deployEnvVar{}, errors.New("use KEY=VALUE, or leave it empty to finish")
+	}
+	return deployEnvVar{Name: key, Value: value}, nil
+}
+
+type containerCreatedMsg struct {
+	gen int
+	id  string
+	err error
+}
+
+type provisionStatusMsg struct {
+	gen       int
+	container container
+	found     bool
+	err       error
+}
+
+type provisionTickMsg struct {
+	gen int
+}
+
+func createContainerCmd(token, projectID string, f newContainerForm, gen int) tea.Cmd {
+	return func() tea.Msg {
+		created, err := createProjectContainer(token, projectID, newContainerPayload(projectID, f))
+		if err != nil {
+			return containerCreatedMsg{gen: gen, err: err}
+		}
+		id := stringValue(created["id"])
+		if id == "" {
+			return containerCreatedMsg{gen: gen, err: fmt.Errorf("container %s was created without an ID in the API response", f.name)}
+		}
+		return containerCreatedMsg{gen: gen, id: id}
+	}
+}
+
+func provisionStatusCmd(token, projectID, containerID string, gen int) tea.Cmd {
+	return func() tea.Msg {
+		details, err := fetchProject(token, projectID)
+		if err != nil {
+			return provisionStatusMsg{gen: gen, err: err}
+		}
+		for _, c := range details.Containers {
+			if c.ID == containerID {
+				return provisionStatusMsg{gen: gen, container: c, found: true}
+			}
+		}
+		return provisionStatusMsg{gen: gen}
+	}
+}
+
+func provisionTickCmd(gen int) tea.Cmd {
+	return tea.Tick(provisionPollInterval, func(time.Time) tea.Msg { return provisionTickMsg{gen: gen} })
+}
+
+// provisioningDone reports whether status is final for a new container.
+func provisioningDone(status string) (done, failed bool) {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "running", "healthy":
+		return true, false
+	case "failed", "error", "crashed", "exited", "stopped":
+		return true, true
+	}
+	return false, false
+}
+
+func (m *projectsApp) startNewContainer() {
+	m.createGen++
+	m.newContainer = newContainerForm{tier: "dedicated"}
+	m.view = viewNewContainer
+	m.errMsg = ""
+	m.status = "New container in " + m.selectedProject.Name
+	m.setCreateStep(createStepName)
+}
+
+func (m *projectsApp) setCreateStep(step int) {
+	f := &m.newContainer
+	f.step = step
+	m.input.CharLimit = 256
+	m.input.Focus()
+	switch step {
+	case createStepName:
+		m.input.SetValue(f.name)
+	case createStepImage:
+		m.input.SetValue(f.image)
+	case createStepTier:
+		m.input.SetValue(f.tier)
+	case createStepPorts:
+		parts := make([]string, 0, len(f.ports))
+		for _, p := range f.ports {
+			parts = append(parts, formatFormPort(p))
+		}
+		m.input.SetValue(strings.Join(parts, ", "))
+	default:
+		m.input.SetValue("")
+	}
+	m.input.CursorEnd()
+}
+
+func formatFormPort(p deployPort) string {
+	text := fmt.Sprint(p.Container)
+	if p.Host > 0 {
+		text = fmt.Sprintf("%d:%d", p.Host, p.Container)
+	}
+	if strings.EqualFold(p.Protocol, "udp") {
+		text += "/udp"
+	}
+	return text
+}
+
+// updateNewContainer handles keys in the wizard. Esc steps back, and leaves
+// the wizard from the first step.
+func (m projectsApp) updateNewContainer(msg tea.Msg) (projectsApp, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || (key.String() != "enter" && key.String() != "esc") {
+		if m.newContainer.step == createStepReview {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	f := &m.newContainer
+	if key.String() == "esc" {
+		m.errMsg = ""
+		if f.step == createStepName {
+			m.view = viewProjectMenu
+			m.status = ""
+			m.setProjectActionItems()
+			return m, nil
+		}
+		m.setCreateStep(f.step - 1)
+		return m, nil
+	}
+
+	value := strings.TrimSpace(m.input.Value())
+	switch f.step {
+	case createStepName:
+		if err := validateContainerName(value); err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		f.name = value
+	case createStepImage:
+		if value == "" || strings.ContainsAny(value, " \t") {
+			m.errMsg = "Enter a Docker image, for example nginx:1.27 or ghcr.io/acme/api:latest"
+			return m, nil
+		}
+		f.image = value
+	case createStepTier:
+		switch strings.ToLower(value) {
+		case "dedicated", "shared":
+			f.tier = strings.ToLower(value)
+		default:
+			m.errMsg = "Tier must be dedicated or shared"
+			return m, nil
+		}
+	case createStepPorts:
+		ports, err := parseFormPorts(value)
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		f.ports = ports
+	case createStepEnv:
+		// Each Enter adds one variable; an empty line moves on.
+		if value != "" {
+			env, err := parseFormEnv(m.input.Value())
+			if err != nil {
+				m.errMsg = err.Error()
+				return m, nil
+			}
+			f.env = append(f.env, env)
+			m.errMsg = ""
+			m.input.SetValue("")
+			return m, nil
+		}
+	case createStepReview:
+		m.errMsg = ""
+		m.view = viewProvisioning
+		m.createGen++
+		m.provisionStarted = time.Now()
+		m.provisionStatus = "creating"
+		m.provisionID = ""
+		m.status = "Creating container " + f.name + "..."
+		return m, createContainerCmd(m.token, m.selectedProject.ID, *f, m.createGen)
+	}
+	m.errMsg = ""
+	m.setCreateStep(f.step + 1)
+	return m, nil
+}
+
+// updateProvisioning follows a created container until it runs, fails or
+// provisionTimeout passes.
+func (m projectsApp) updateProvisioning(msg tea.Msg) (projectsApp, tea.Cmd) {
+	switch msg := msg.(type) {
+	case containerCreatedMsg:
+		if msg.gen != m.createGen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			m.status = "Could not create " + m.newContainer.name
+			m.provisionStatus = "failed"
+			m.retry = &retryPrompt{
+				what:  "create container " + m.newContainer.name,
+				retry: createContainerCmd(m.token, m.selectedProject.ID, m.newContainer, m.createGen),
+				skip: func(m projectsApp) (projectsApp, tea.Cmd) {
+					m.view = viewNewContainer
+					m.setCreateStep(createStepReview)
+					return m, nil
+				},
+			}
+			return m, nil
+		}
+		m.provisionID = msg.id
+		m.provisionStatus = "provisioning"
+		m.status = "Provisioning " + m.newContainer.name + "..."
+		return m, provisionStatusCmd(m.token, m.selectedProject.ID, msg.id, m.createGen)
+	case provisionStatusMsg:
+		if msg.gen != m.createGen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+		} else if msg.found {
+			m.errMsg = ""
+			m.provisionStatus = valueOrDash(msg.container.Status)
+			if done, failed := provisioningDone(msg.container.Status); done {
+				m.createGen++
+				if failed {
+					m.status = fmt.Sprintf("%s is %s", msg.container.Name, msg.container.Status)
+					return m, nil
+				}
+				m.status = fmt.Sprintf("%s is running", msg.container.Name)
+				return m, m.load(tuiDataContainers, fetchContainersCmd(m.token, m.selectedProject.ID))
+			}
+		}
+		if time.Since(m.provisionStarted) > provisionTimeout {
+			m.createGen++
+			m.status = fmt.Sprintf("%s is still %s; it keeps provisioning in the background", m.newContainer.name, m.provisionStatus)
+			return m, nil
+		}
+		return m, provisionTickCmd(m.createGen)
+	case provisionTickMsg:
+		if msg.gen != m.createGen {
+			return m, nil
+		}
+		return m, provisionStatusCmd(m.token, m.selectedProject.ID, m.provisionID, m.createGen)
+	case tea.KeyMsg:
+		if msg.String() == "esc" || msg.String() == "enter" {
+			// Stop watching; the container carries on provisioning.
+			m.createGen++
+			m.errMsg = ""
+			return m, m.load(tuiDataContainers, fetchContainersCmd(m.token, m.selectedProject.ID))
+		}
+	}
+	return m, nil
+}
+
+func (m projectsApp) newContainerView(header string) string {
+	f := m.newContainer
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("\nNew container in " + m.selectedProject.Name + "\n\n")
+	ports := make([]string, 0, len(f.ports))
+	for _, p := range f.ports {
+		ports = append(ports, formatFormPort(p))
+	}
+	env := make([]string, 0, len(f.env))
+	for _, e := range f.env {
+		env = append(env, e.Name)
+	}
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Name", f.name},
+		{"Image", f.image},
+		{"Tier", f.tier},
+		{"Ports", strings.Join(ports, ", ")},
+		{"Env", strings.Join(env, ", ")},
+	}
+	for i, row := range rows {
+		marker := "  "
+		if i == f.step {
+			marker = "> "
+		}
+		if i < f.step || f.step == createStepReview || (i == createStepEnv && len(f.env) > 0) {
+			b.WriteString(fmt.Sprintf("%s%-6s %s\n", marker, row.label, valueOrDash(row.value)))
+		} else {
+			b.WriteString(fmt.Sprintf("%s%-6s\n", marker, row.label))
+		}
+	}
+	b.WriteString("\n")
+	switch f.step {
+	case createStepName:
+		b.WriteString("Container name (lowercase letters, digits and dashes)\n" + m.input.View())
+	case createStepImage:
+		b.WriteString("Docker image\n" + m.input.View())
+	case createStepTier:
+		b.WriteString("Tier: dedicated or shared\n" + m.input.View())
+	case createStepPorts:
+		b.WriteString("Ports, separated by commas (e.g. 80, 8080:80, 53/udp), or empty for none\n" + m.input.View())
+	case createStepEnv:
+		b.WriteString("Environment variable KEY=VALUE; Enter adds it, an empty line continues\n" + m.input.View())
+	case createStepReview:
+		b.WriteString("Press Enter to create the container, Esc to go back")
+		return b.String()
+	}
+	b.WriteString("\n\nEnter to continue, Esc to go back")
+	return b.String()
+}
+
+func (m projectsApp) provisioningView(header string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString(fmt.Sprintf("\nContainer %s (%s)\n\n", m.newContainer.name, m.newContainer.image))
+	id := valueOrDash(m.provisionID)
+	b.WriteString(fmt.Sprintf("ID:      %s\nStatus:  %s\nElapsed: %s\n", id, m.provisionStatus, formatElapsed(time.Since(m.provisionStarted))))
+	b.WriteString("\nEnter/Esc to return to the container list; provisioning continues either way.\n")
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The running tunnels dashboard lists the background sessions started with
+// `hubfly tunnel up`, from every project, with the live status each
+// tunnel process reports. It reloads with the live ticks.
+
+type dashboardActionMsg struct {
+	name   string
+	action string
+	err    error
+}
+
+var dashboardActionDone = map[string]string{
+	"stop":      "Stopped",
+	"reconnect": "Reconnected",
+}
+
+// openDashboard shows the running tunnels dashboard.
+func (m projectsApp) openDashboard() (projectsApp, tea.Cmd) {
+	sessions, err := listTunnelSessions()
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.errMsg = ""
+	m.dashboard = sessions
+	m.view = viewTunnelDashboard
+	m.status = fmt.Sprintf("%d background tunnel(s)", len(sessions))
+	m.setDashboardItems()
+	m.list.Select(0)
+	return m, m.startLiveTicks()
+}
+
+// reloadDashboard re-reads the sessions and their status files in place.
+func (m *projectsApp) reloadDashboard() {
+	sessions, err := listTunnelSessions()
+	if err != nil {
+		debugf("dashboard reload failed: %v", err)
+		return
+	}
+	m.dashboard = sessions
+	m.setDashboardItems()
+}
+
+func (m *projectsApp) setDashboardItems() {
+	cursor := m.list.Index()
+	projectNames := make(map[string]string, len(m.projects))
+	for _, p := range m.projects {
+		projectNames[p.ID] = p.Name
+	}
+	items := make([]list.Item, 0, len(m.dashboard))
+	for i, s := range m.dashboard {
+		state := tunnelSessionState(s)
+		if state == "running" {
+			if live, ok := loadTunnelLiveStatus(tunnelStatusPath(s.TunnelID, s.LocalPort)); ok {
+				state = m.liveLine(live)
+			}
+		}
+		project := projectNames[s.ProjectID]
+		if project == "" {
+			project = valueOrDash(s.ProjectID)
+		}
+		items = append(items, appItem{
+			title: s.Name,
+			desc:  fmt.Sprintf("localhost:%d -> %s:%d | %s | %s", s.LocalPort, valueOrDash(s.Container), s.TargetPort, project, state),
+			idx:   i,
+		})
+	}
+	m.setListItems("Running Tunnels", items, "Enter reconnect, s stop, r refresh, Esc back", false)
+	if cursor >= 0 && cursor < len(items) {
+		m.list.Select(cursor)
+	}
+}
+
+func (m projectsApp) updateDashboard(key string) (projectsApp, tea.Cmd) {
+	switch key {
+	case "esc":
+		m.view = viewProjects
+		m.setProjectItems()
+		return m, nil
+	case "r":
+		m.reloadDashboard()
+		m.status = fmt.Sprintf("%d background tunnel(s)", len(m.dashboard))
+		return m, nil
+	case "s", "enter":
+		it, ok := m.list.SelectedItem().(appItem)
+		if !ok || it.idx >= len(m.dashboard) {
+			return m, nil
+		}
+		s := m.dashboard[it.idx]
+		if key == "enter" {
+			m.errMsg = ""
+			m.status = fmt.Sprintf("Reconnecting %s...", s.Name)
+			return m, restartSessionCmd(s)
+		}
+		m.confirm = &confirmPrompt{
+			question: fmt.Sprintf("Stop %s (localhost:%d)?", s.Name, s.LocalPort),
+			apply: func(m projectsApp) (projectsApp, tea.Cmd) {
+				m.errMsg = ""
+				m.status = fmt.Sprintf("Stopping %s...", s.Name)
+				return m, stopSessionCmd(s)
+			},
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m projectsApp) finishDashboardAction(msg dashboardActionMsg) projectsApp {
+	if msg.err != nil {
+		m.errMsg = msg.err.Error()
+		m.status = fmt.Sprintf("Could not %s %s", msg.action, msg.name)
+	} else {
+		m.errMsg = ""
+		m.status = dashboardActionDone[msg.action] + " " + msg.name
+	}
+	if m.view == viewTunnelDashboard {
+		m.reloadDashboard()
+	}
+	return m
+}
+
+func stopSessionCmd(s tunnelSession) tea.Cmd {
+	return func() tea.Msg {
+		return dashboardActionMsg{name: s.Name, action: "stop", err: stopTunnelSession(s)}
+	}
+}
+
+func restartSessionCmd(s tunnelSession) tea.Cmd {
+	return func() tea.Msg {
+		_, err := restartTunnelSession(s)
+		return dashboardActionMsg{name: s.Name, action: "reconnect", err: err}
+	}
+}
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiAction is something a key does in the projects TUI. The Update code
+// checks for the built-in key; keys bound with the keymap section of
+// config.json are translated to it before Update sees them.
+type tuiAction struct {
+	name     string
+	builtin  string
+	defaults []string
+}
+
+var tuiActions = []tuiAction{
+	{name: "up", builtin: "up", defaults: []string{"up", "k"}},
+	{name: "down", builtin: "down", defaults: []string{"down", "j"}},
+	{name: "select", builtin: "enter", defaults: []string{"enter"}},
+	{name: "back", builtin: "esc", defaults: []string{"esc"}},
+	{name: "toggle", builtin: "space", defaults: []string{"space"}},
+	{name: "all", builtin: "a", defaults: []string{"a"}},
+	{name: "delete", builtin: "d", defaults: []string{"d"}},
+	{name: "refresh", builtin: "r", defaults: []string{"r"}},
+	{name: "filter", builtin: "/", defaults: []string{"/"}},
+	{name: "group", builtin: "g", defaults: []string{"g"}},
+	{name: "saved", builtin: "t", defaults: []string{"t"}},
+	{name: "running", builtin: "u", defaults: []string{"u"}},
+	{name: "stop", builtin: "s", defaults: []string{"s"}},
+	{name: "follow", builtin: "f", defaults: []string{"f"}},
+	{name: "quit", builtin: "q", defaults: []string{"q"}},
+	{name: "debug", builtin: "D", defaults: []string{"D"}},
+	{name: "help", builtin: "?", defaults: []string{"?"}},
+}
+
+// tuiKeymap is the effective key binding of every action.
+type tuiKeymap struct {
+	keys map[string][]string
+	// translate maps a pressed key to the built-in key of its action. An
+	// empty value means the key was rebound away and does nothing.
+	translate map[string]string
+}
+
+// newTUIKeymap applies keymap overrides from config.json: each names an
+// action and lists its keys separated by commas, replacing the defaults.
+func newTUIKeymap(overrides map[string]string) (tuiKeymap, error) {
+	km := tuiKeymap{keys: map[string][]string{}, translate: map[string]string{}}
+	known := map[string]bool{}
+	for _, a := range tuiActions {
+		known[a.name] = true
+		km.keys[a.name] = a.defaults
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			return tuiKeymap{}, fmt.Errorf("keymap: unknown action %q (known: %s)", name, strings.Join(tuiActionNames(), ", "))
+		}
+		var keys []string
+		for _, k := range strings.Split(overrides[name], ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			return tuiKeymap{}, fmt.Errorf("keymap: %s has no keys", name)
+		}
+		km.keys[name] = keys
+	}
+
+	owner := map[string]string{}
+	for _, a := range tuiActions {
+		for _, k := range km.keys[a.name] {
+			if k == "ctrl+c" {
+				return tuiKeymap{}, fmt.Errorf("keymap: %s: ctrl+c always quits and cannot be bound", a.name)
+			}
+			if other, ok := owner[k]; ok {
+				return tuiKeymap{}, fmt.Errorf("keymap: %q is bound to both %s and %s", k, other, a.name)
+			}
+			owner[k] = a.name
+			km.translate[k] = a.builtin
+		}
+	}
+	for _, a := range tuiActions {
+		if _, ok := owner[a.builtin]; !ok {
+			km.translate[a.builtin] = ""
+		}
+	}
+	return km, nil
+}
+
+func tuiActionNames() []string {
+	names := make([]string, 0, len(tuiActions))
+	for _, a := range tuiActions {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// loadTUIKeymap reads the keymap from config.json. A broken keymap is
+// reported once and the defaults are used.
+func loadTUIKeymap() tuiKeymap {
+	cfg, _ := loadStoreConfig()
+	km, err := newTUIKeymap(cfg.Keymap)
+	if err != nil {
+		warnOnce("keymap", "warning: "+err.Error()+"; using the default keys")
+		km, _ = newTUIKeymap(nil)
+	}
+	return km
+}
+
+// label is how the help shows the keys of an action.
+func (km tuiKeymap) label(action string) string {
+	return strings.Join(km.keys[action], "/")
+}
+
+// translateKey rewrites msg into the built-in key of the action it is bound
+// to. ok is false for keys that were rebound away.
+func (km tuiKeymap) translateKey(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	builtin, bound := km.translate[msg.String()]
+	if !bound {
+		return msg, true
+	}
+	if builtin == "" {
+		return msg, false
+	}
+	if builtin == msg.String() {
+		return msg, true
+	}
+	switch builtin {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}, true
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}, true
+	case "space":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, true
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}, true
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}, true
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(builtin)}, true
+}
+
+// typing reports whether keys are going to a text field or a prompt, where
+// they must reach it unchanged.
+func (m projectsApp) typing() bool {
+	switch m.view {
+	case viewPortInput, viewTextInput, viewNewContainer:
+		return true
+	}
+	return m.retry != nil || m.confirm != nil || m.list.FilterState() == list.Filtering
+}
+
+// textFieldFocused reports whether keys are going to a text field, which
+// recordings leave out of their key events.
+func (m projectsApp) textFieldFocused() bool {
+	switch m.view {
+	case viewPortInput, viewTextInput:
+		return true
+	case viewNewContainer:
+		return m.newContainer.step != createStepReview
+	}
+	return m.list.FilterState() == list.Filtering
+}
+
+// toggleTUIDebug flips debug logging while the TUI runs, so a trace can be
+// captured right when a problem shows up, and returns the status to show.
+func toggleTUIDebug() string {
+	if debugEnabled.Load() {
+		setDebugEnabled(false)
+		return tr("tui.debug.off")
+	}
+	setDebugEnabled(true)
+	return tr("tui.debug.on", debugLogPath())
+}
+
+// helpBinding lets the list's own help line point at the overlay.
+func (km tuiKeymap) helpBinding() key.Binding {
+	return key.NewBinding(key.WithKeys(km.keys["help"]...), key.WithHelp(km.label("help"), tr("tui.help.binding")))
+}
+
+// helpLine pairs an action with the tui.help message that describes it.
+type helpLine struct {
+	action string
+	desc   string
+}
+
+// tuiHelpLines lists what each action does on a view, in the order the
+// help overlay shows them.
+func tuiHelpLines(view projectsView, deletePicker bool) []helpLine {
+	var lines []helpLine
+	switch view {
+	case viewProjects:
+		lines = []helpLine{{"select", "open-project"}, {"all", "all-tunnels"}, {"saved", "saved"}, {"running", "running"}, {"filter", "filter"}}
+	case viewProjectMenu, viewContainerMenu, viewMultiPortMode:
+		lines = []helpLine{{"select", "choose"}, {"back", "back"}}
+	case viewContainers:
+		lines = []helpLine{{"select", "open-container"}, {"group", "group"}, {"filter", "filter"}, {"back", "back"}}
+	case viewTunnelsSingle:
+		if deletePicker {
+			lines = []helpLine{{"select", "delete-tunnel"}}
+		} else {
+			lines = []helpLine{{"select", "connect"}, {"delete", "delete-tunnel"}}
+		}
+		lines = append(lines, helpLine{"filter", "filter"}, helpLine{"back", "back"})
+	case viewTunnelsMulti:
+		lines = []helpLine{{"toggle", "select-tunnel"}, {"all", "select-all"}, {"select", "connect-selected"}, {"back", "back"}}
+	case viewSavedTunnels:
+		lines = []helpLine{{"toggle", "select-tunnel"}, {"select", "connect-selected"}, {"back", "back"}}
+	case viewTunnelOverview:
+		lines = []helpLine{{"toggle", "select-tunnel"}, {"all", "select-all"}, {"select", "connect-selected"}, {"delete", "delete-selected"}, {"refresh", "refresh"}, {"back", "back"}}
+	case viewTunnelDashboard:
+		lines = []helpLine{{"select", "reconnect"}, {"stop", "stop"}, {"refresh", "refresh"}, {"back", "back"}}
+	case viewRunningSingle:
+		lines = []helpLine{{"stop", "stop-all"}}
+	case viewRunningMulti:
+		lines = []helpLine{{"select", "details"}, {"refresh", "restart"}, {"stop", "stop-all"}, {"back", "stop-all"}}
+	case viewLogs:
+		lines = []helpLine{{"follow", "follow"}, {"refresh", "refresh"}, {"back", "back"}}
+	case viewProvisioning:
+		lines = []helpLine{{"back", "close-when-done"}}
+	}
+	return append(lines, helpLine{"up", "up"}, helpLine{"down", "down"}, helpLine{"debug", "debug"}, helpLine{"quit", "quit"}, helpLine{"help", "close"})
+}
+
+func (m projectsApp) helpView(header string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString(tr("tui.help.title") + "\n\n")
+	tw := tabwriter.NewWriter(&b, 0, 2, 2, ' ', 0)
+	for _, line := range tuiHelpLines(m.view, m.deletePicker) {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\n", m.keys.label(line.action), tr("tui.help."+line.desc))
+	}
+	_ = tw.Flush()
+	b.WriteString("\n" + tr("tui.help.footer", configPath()))
+	return b.String()
+}
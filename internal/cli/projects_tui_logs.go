@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// tuiLogsPollInterval matches `hubfly logs --follow`.
+	tuiLogsPollInterval = 2 * time.Second
+	// tuiLogsMaxLines keeps the viewport to the recent end of long logs.
+	tuiLogsMaxLines = 2000
+)
+
+// containerLogsMsg carries a logs fetch for the logs view. gen ties it to
+// the view that asked: results for a closed view or a superseded request
+// are dropped.
+type containerLogsMsg struct {
+	gen  int
+	logs containerLogsOutput
+	err  error
+}
+
+type logsTickMsg struct {
+	gen int
+}
+
+func fetchContainerLogsCmd(token, projectID, containerID string, gen int) tea.Cmd {
+	return func() tea.Msg {
+		logs, err := fetchContainerLogs(token, projectID, containerID)
+		return containerLogsMsg{gen: gen, logs: logs, err: err}
+	}
+}
+
+func logsTickCmd(gen int) tea.Cmd {
+	return tea.Tick(tuiLogsPollInterval, func(time.Time) tea.Msg { return logsTickMsg{gen: gen} })
+}
+
+// openLogs switches to the logs view for the selected container with
+// follow mode on.
+func (m *projectsApp) openLogs() tea.Cmd {
+	m.view = viewLogs
+	m.errMsg = ""
+	m.logsGen++
+	m.logsFollow = true
+	m.logs = viewport.New(max(20, m.width), m.logsHeight())
+	m.logs.SetContent("Loading logs...")
+	m.status = "Loading logs for " + m.selectedContainer.Name + "..."
+	return fetchContainerLogsCmd(m.token, m.selectedProject.ID, m.selectedContainer.ID, m.logsGen)
+}
+
+// closeLogs leaves the logs view; bumping the generation stops polling.
+func (m *projectsApp) closeLogs() {
+	m.logsGen++
+	m.view = viewContainerMenu
+	m.errMsg = ""
+	m.status = ""
+	m.setContainerActionItems()
+}
+
+func (m *projectsApp) logsHeight() int {
+	if m.height == 0 {
+		return 20
+	}
+	return max(5, m.height-10)
+}
+
+func (m projectsApp) updateLogs(msg tea.Msg) (projectsApp, tea.Cmd) {
+	switch msg := msg.(type) {
+	case containerLogsMsg:
+		if msg.gen != m.logsGen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			m.status = "Could not load logs"
+		} else {
+			m.errMsg = ""
+			m.logs.SetContent(formatTUILogs(msg.logs, tuiLogsMaxLines))
+			m.status = m.logsStatus()
+			if m.logsFollow {
+				m.logs.GotoBottom()
+			}
+		}
+		if m.logsFollow {
+			return m, logsTickCmd(m.logsGen)
+		}
+		return m, nil
+	case logsTickMsg:
+		if msg.gen != m.logsGen || !m.logsFollow {
+			return m, nil
+		}
+		return m, fetchContainerLogsCmd(m.token, m.selectedProject.ID, m.selectedContainer.ID, m.logsGen)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.closeLogs()
+			return m, nil
+		case "f":
+			m.logsFollow = !m.logsFollow
+			m.logsGen++
+			m.status = m.logsStatus()
+			if !m.logsFollow {
+				return m, nil
+			}
+			m.logs.GotoBottom()
+			return m, fetchContainerLogsCmd(m.token, m.selectedProject.ID, m.selectedContainer.ID, m.logsGen)
+		case "r":
+			m.logsGen++
+			m.status = "Refreshing logs..."
+			return m, fetchContainerLogsCmd(m.token, m.selectedProject.ID, m.selectedContainer.ID, m.logsGen)
+		}
+	}
+	var cmd tea.Cmd
+	m.logs, cmd = m.logs.Update(msg)
+	// Scrolling away from the end pauses follow so new lines do not yank
+	// the view back down.
+	if _, ok := msg.(tea.KeyMsg); ok && m.logsFollow && !m.logs.AtBottom() {
+		m.logsFollow = false
+		m.logsGen++
+		m.status = m.logsStatus()
+	}
+	return m, cmd
+}
+
+func (m projectsApp) logsStatus() string {
+	if m.logsFollow {
+		return "Following logs for " + m.selectedContainer.Name
+	}
+	return "Logs for " + m.selectedContainer.Name + " (follow paused)"
+}
+
+func (m projectsApp) logsView(header string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString(m.logs.View())
+	b.WriteString("\n" + strings.Repeat("-", 80) + "\n")
+	b.WriteString("Up/Down/PgUp/PgDn scroll, f toggle follow, r refresh, Esc back")
+	return b.String()
+}
+
+// formatTUILogs lays out logs the way `hubfly logs` prints them, keeping
+// only the last maxLines lines.
+func formatTUILogs(logs containerLogsOutput, maxLines int) string {
+	var b strings.Builder
+	if logs.Stdout != "" {
+		b.WriteString(strings.TrimRight(logs.Stdout, "\n") + "\n")
+	}
+	if logs.Stderr != "" {
+		b.WriteString("--- STDERR ---\n")
+		b.WriteString(strings.TrimRight(logs.Stderr, "\n") + "\n")
+	}
+	if b.Len() == 0 {
+		return "No log output yet."
+	}
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	if len(lines) > maxLines {
+		lines = append([]string{"... earlier lines omitted ..."}, lines[len(lines)-maxLines:]...)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The running multi-tunnel view lists one row per tunnel process. Enter
+// expands the row under the cursor to show how the process was started, its
+// pid, its last lines of output and why it ended; r restarts it alone. A
+// tunnel that fails is expanded by itself, so its error stays next to it.
+
+// multiOutputLines is how many lines of a tunnel process's output are kept.
+const multiOutputLines = 8
+
+// outputTail keeps the last lines written to it. Tunnel processes write to
+// it from their own goroutines while the TUI reads it.
+type outputTail struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string
+}
+
+func (o *outputTail) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	text := o.partial + string(p)
+	parts := strings.Split(text, "\n")
+	o.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			o.lines = append(o.lines, line)
+		}
+	}
+	if len(o.lines) > multiOutputLines {
+		o.lines = append([]string(nil), o.lines[len(o.lines)-multiOutputLines:]...)
+	}
+	return len(p), nil
+}
+
+// Lines returns the kept lines, oldest first, with any unfinished last line.
+func (o *outputTail) Lines() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	lines := append([]string(nil), o.lines...)
+	if strings.TrimSpace(o.partial) != "" {
+		lines = append(lines, o.partial)
+	}
+	if len(lines) > multiOutputLines {
+		lines = lines[len(lines)-multiOutputLines:]
+	}
+	return lines
+}
+
+type multiRestartMsg struct {
+	index  int
+	cmd    *exec.Cmd
+	output *outputTail
+	err    error
+}
+
+// startMultiTunnelProcess starts one tunnel of a multi run and reports its
+// exit on events, tagged with its index and process.
+func startMultiTunnelProcess(index int, plan multiTunnelPlan, events chan multiEvent) (*exec.Cmd, *outputTail, error) {
+	output := &outputTail{}
+	cmd, err := startTunnelProcess(plan.tunnel, plan.localPort, selectedPrimaryPort(plan.tunnel), output, output)
+	if err != nil {
+		return nil, nil, err
+	}
+	go func() {
+		events <- multiEvent{index: index, cmd: cmd, err: cmd.Wait()}
+	}()
+	return cmd, output, nil
+}
+
+// restartMultiTunnelCmd stops the process of one tunnel, if it still runs,
+// and starts it again.
+func restartMultiTunnelCmd(index int, plan multiTunnelPlan, running *exec.Cmd, events chan multiEvent) tea.Cmd {
+	return func() tea.Msg {
+		if running != nil {
+			_ = stopSSHProcess(running)
+		}
+		if tunnelIsExpired(plan.tunnel.ExpiresAt) {
+			return multiRestartMsg{index: index, err: fmt.Errorf("tunnel %s is expired", plan.tunnel.TunnelID)}
+		}
+		cmd, output, err := startMultiTunnelProcess(index, plan, events)
+		return multiRestartMsg{index: index, cmd: cmd, output: output, err: err}
+	}
+}
+
+// multiEnded records that the process of one tunnel exited. Events from a
+// process that was since restarted are ignored.
+func (m projectsApp) multiEnded(ev multiEvent) (tea.Model, tea.Cmd) {
+	i := ev.index
+	if i < 0 || i >= len(m.multiRunningState) || m.multiRunningCmds[i] != ev.cmd || m.multiRunningState[i] == "restarting" {
+		return m, waitMultiEventCmd(m.multiEvents)
+	}
+	if ev.err != nil {
+		m.multiRunningState[i] = "error"
+		m.multiRunningErrs[i] = ev.err.Error()
+		m.multiExpanded[i] = true
+	} else {
+		m.multiRunningState[i] = "exited"
+	}
+	failed := false
+	for i, st := range m.multiRunningState {
+		if st == "running" {
+			return m, waitMultiEventCmd(m.multiEvents)
+		}
+		failed = failed || m.multiRunningErrs[i] != ""
+	}
+	if failed {
+		// Stay, so the errors can be read and the tunnels restarted.
+		m.status = "All multi tunnels exited; r restarts the one under the cursor"
+		return m, waitMultiEventCmd(m.multiEvents)
+	}
+	m.status = "All multi tunnels exited"
+	m.multiRunningCmds = nil
+	m.multiRunningPlans = nil
+	m.multiRunningState = nil
+	return m, m.leaveMultiRun()
+}
+
+func (m projectsApp) multiRestarted(msg multiRestartMsg) (tea.Model, tea.Cmd) {
+	i := msg.index
+	if i < 0 || i >= len(m.multiRunningPlans) {
+		if msg.cmd != nil {
+			_ = stopSSHProcess(msg.cmd)
+		}
+		return m, nil
+	}
+	id := m.multiRunningPlans[i].tunnel.TunnelID
+	if msg.err != nil {
+		m.multiRunningCmds[i] = nil
+		m.multiRunningState[i] = "error"
+		m.multiRunningErrs[i] = msg.err.Error()
+		m.multiExpanded[i] = true
+		m.status = fmt.Sprintf("Restarting %s failed", id)
+		return m, nil
+	}
+	m.multiRunningCmds[i] = msg.cmd
+	m.multiOutputs[i] = msg.output
+	m.multiRunningState[i] = "running"
+	m.multiRunningErrs[i] = ""
+	m.multiLive[i] = tunnelLiveStatus{}
+	m.status = fmt.Sprintf("Restarted %s", id)
+	return m, m.startLiveTicks()
+}
+
+// multiRunKey handles the keys of the running multi-tunnel view.
+func (m projectsApp) multiRunKey(key string) (tea.Model, tea.Cmd, bool) {
+	switch key {
+	case "up":
+		if m.multiCursor > 0 {
+			m.multiCursor--
+		}
+	case "down":
+		if m.multiCursor < len(m.multiRunningPlans)-1 {
+			m.multiCursor++
+		}
+	case "enter":
+		m.multiExpanded[m.multiCursor] = !m.multiExpanded[m.multiCursor]
+	case "r":
+		i := m.multiCursor
+		if i >= len(m.multiRunningPlans) || m.multiRunningState[i] == "restarting" {
+			return m, nil, true
+		}
+		m.multiRunningState[i] = "restarting"
+		m.status = fmt.Sprintf("Restarting %s...", m.multiRunningPlans[i].tunnel.TunnelID)
+		return m, restartMultiTunnelCmd(i, m.multiRunningPlans[i], m.multiRunningCmds[i], m.multiEvents), true
+	case "s", "esc":
+		m.stopAllMulti()
+		m.status = "Stopped all running multi tunnels"
+		return m, m.leaveMultiRun(), true
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+func (m projectsApp) multiRunView(header string) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("\nRunning multi-tunnel sessions:\n\n")
+	for i, plan := range m.multiRunningPlans {
+		state := "running"
+		if i < len(m.multiRunningState) {
+			state = m.multiRunningState[i]
+		}
+		if state == "running" && i < len(m.multiLive) {
+			state = m.liveLine(m.multiLive[i])
+		}
+		cursor, marker := "  ", "+"
+		if i == m.multiCursor {
+			cursor = "> "
+		}
+		if m.multiExpanded[i] {
+			marker = "-"
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s | localhost:%d -> %s:%d\n    %s\n",
+			cursor, marker,
+			plan.tunnel.TunnelID,
+			plan.localPort,
+			resolveTunnelForwardHost(plan.tunnel),
+			selectedPrimaryPort(plan.tunnel),
+			state,
+		))
+		if m.multiExpanded[i] {
+			b.WriteString(m.multiDetail(i))
+		}
+	}
+	b.WriteString("\nEnter shows or hides a tunnel's details, 'r' restarts it, 's' (or Esc) stops all and returns.\n")
+	return b.String()
+}
+
+// multiDetail is the expanded part of row i.
+func (m projectsApp) multiDetail(i int) string {
+	var b strings.Builder
+	pid := "-"
+	if cmd := m.multiRunningCmds[i]; cmd != nil {
+		b.WriteString("    command: " + quoteArgs(cmd.Args) + "\n")
+		if cmd.Process != nil {
+			pid = strconv.Itoa(cmd.Process.Pid)
+		}
+	}
+	b.WriteString("    pid: " + pid + "\n")
+	if err := m.multiRunningErrs[i]; err != "" {
+		b.WriteString("    error: " + err + "\n")
+	}
+	var lines []string
+	if m.multiOutputs[i] != nil {
+		lines = m.multiOutputs[i].Lines()
+	}
+	if len(lines) == 0 {
+		b.WriteString("    no output yet\n")
+		return b.String()
+	}
+	b.WriteString("    output:\n")
+	for _, line := range lines {
+		b.WriteString("      " + line + "\n")
+	}
+	return b.String()
+}
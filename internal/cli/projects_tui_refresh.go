@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const defaultTUIRefreshInterval = 30 * time.Second
+
+// Data sets the projects TUI loads, for the spinner and stale markers.
+const (
+	tuiDataProjects   = "projects"
+	tuiDataContainers = "containers"
+	tuiDataTunnels    = "tunnels"
+)
+
+// refreshTickMsg asks for a background refresh of the list on screen.
+type refreshTickMsg struct{}
+
+// tuiRefreshInterval is tui.refreshInterval from the active profile. Zero
+// turns background refreshes off.
+func tuiRefreshInterval() time.Duration {
+	p := activeProfile()
+	if p.TUI == nil || strings.TrimSpace(p.TUI.RefreshInterval) == "" {
+		return defaultTUIRefreshInterval
+	}
+	raw := strings.TrimSpace(p.TUI.RefreshInterval)
+	if raw == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < time.Second {
+		debugf("ignoring invalid tui.refreshInterval %q", raw)
+		return defaultTUIRefreshInterval
+	}
+	return d
+}
+
+func refreshTickCmd(interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return refreshTickMsg{} })
+}
+
+// load marks what as loading and runs cmd with the spinner going.
+func (m *projectsApp) load(what string, cmd tea.Cmd) tea.Cmd {
+	if m.loading == nil {
+		m.loading = map[string]bool{}
+	}
+	m.loading[what] = true
+	return tea.Batch(cmd, m.spinner.Tick)
+}
+
+// loaded records the result of a load of what. A failed background load
+// keeps the rows on screen but marks them stale until a later load
+// succeeds; other failures are reported where they happen.
+func (m *projectsApp) loaded(what string, err error, background bool) {
+	delete(m.loading, what)
+	if err != nil {
+		if !background {
+			return
+		}
+		if m.stale == nil {
+			m.stale = map[string]string{}
+		}
+		m.stale[what] = err.Error()
+		return
+	}
+	delete(m.stale, what)
+}
+
+// projectsLoadAhead is how close to the end of the projects list the
+// cursor gets before the next page is fetched.
+const projectsLoadAhead = 5
+
+// loadMoreProjects fetches the next page of projects once the cursor is
+// near the end of the list.
+func (m *projectsApp) loadMoreProjects() tea.Cmd {
+	if m.view != viewProjects || m.projectsNext == "" || m.loading[tuiDataProjects] {
+		return nil
+	}
+	if m.list.Index() < len(m.list.VisibleItems())-projectsLoadAhead {
+		return nil
+	}
+	return m.load(tuiDataProjects, fetchMoreProjectsCmd(m.token, m.orgID, m.projectsNext))
+}
+
+func (m projectsApp) busy() bool {
+	return len(m.loading) > 0
+}
+
+// staleMark is appended to the rows of a data set whose last refresh
+// failed.
+func (m projectsApp) staleMark(what string) string {
+	if _, ok := m.stale[what]; ok {
+		return " | stale"
+	}
+	return ""
+}
+
+// refreshIdle reports whether a background refresh may run: a list is on
+// screen, nothing is loading or being typed, and no key was pressed for a
+// whole interval.
+func (m projectsApp) refreshIdle(now time.Time) bool {
+	switch m.view {
+	case viewProjects, viewContainers, viewContainerMenu, viewTunnelsSingle, viewTunnelsMulti:
+	default:
+		return false
+	}
+	if m.busy() || m.typing() || m.help {
+		return false
+	}
+	return now.Sub(m.lastKey) >= m.refreshInterval
+}
+
+// backgroundRefresh reloads the data behind the current view in place.
+func (m *projectsApp) backgroundRefresh() tea.Cmd {
+	switch m.view {
+	case viewProjects:
+		token, orgID, pages := m.token, m.orgID, max(m.projectPages, 1)
+		return m.load(tuiDataProjects, func() tea.Msg {
+			projects, next, err := fetchProjectPages(token, orgID, "", pages)
+			return projectsLoadedMsg{projects: projects, next: next, pages: pages, background: true, err: err}
+		})
+	case viewContainers:
+		token, projectID := m.token, m.selectedProject.ID
+		return m.load(tuiDataContainers, func() tea.Msg {
+			details, err := fetchProject(token, projectID)
+			if err != nil {
+				return containersLoadedMsg{background: true, err: err}
+			}
+			return containersLoadedMsg{containers: details.Containers, background: true}
+		})
+	default:
+		return m.load(tuiDataTunnels, reconcileTunnelsCmd(m.token, m.selectedProject.ID))
+	}
+}
+
+func (m projectsApp) updateRefresh(msg tea.Msg) (projectsApp, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !m.busy() {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case refreshTickMsg:
+		next := refreshTickCmd(m.refreshInterval)
+		if !m.refreshIdle(time.Now()) {
+			return m, next
+		}
+		return m, tea.Batch(m.backgroundRefresh(), next)
+	}
+	return m, nil
+}
+
+// staleLine explains stale rows in the header.
+func (m projectsApp) staleLine() string {
+	for _, what := range []string{tuiDataProjects, tuiDataContainers, tuiDataTunnels} {
+		if err, ok := m.stale[what]; ok {
+			return fmt.Sprintf("Showing cached %s: the last refresh failed: %s\n", what, err)
+		}
+	}
+	return ""
+}
@@ -110,3 +110,32 @@ func promptYesNo(label string, defaultYes bool) (bool, error) {
 		fmt.Println("Please answer yes or no.")
 	}
 }
+
+type retryChoice int
+
+const (
+	retryChoiceRetry retryChoice = iota
+	retryChoiceSkip
+	retryChoiceAbort
+)
+
+// promptRetry reports a failed step of an interactive flow and asks whether
+// to retry it, skip it and carry on, or abort back to the previous screen.
+func promptRetry(what string, failure error) (retryChoice, error) {
+	fmt.Printf("Could not %s: %v\n", what, failure)
+	for {
+		text, err := prompt("[r]etry / [s]kip / [a]bort (default r): ")
+		if err != nil {
+			return retryChoiceAbort, err
+		}
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "", "r", "retry":
+			return retryChoiceRetry, nil
+		case "s", "skip":
+			return retryChoiceSkip, nil
+		case "a", "abort":
+			return retryChoiceAbort, nil
+		}
+		fmt.Println("Please answer r, s or a.")
+	}
+}
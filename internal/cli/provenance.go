@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+
+	"hubfly-cli/internal/version"
+)
+
+// buildInfo is what `hubfly version --json` reports: the release stamped in
+// by the build plus what the Go toolchain embedded, including the checksum of
+// every module compiled in.
+type buildInfo struct {
+	Version   string        `json:"version"`
+	Commit    string        `json:"commit"`
+	Date      string        `json:"date"`
+	GoVersion string        `json:"goVersion"`
+	OS        string        `json:"os"`
+	Arch      string        `json:"arch"`
+	Settings  []buildValue  `json:"settings,omitempty"`
+	Modules   []buildModule `json:"modules,omitempty"`
+}
+
+type buildValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type buildModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version.Version,
+		Commit:    version.Commit,
+		Date:      version.Date,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	embedded, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range embedded.Settings {
+		info.Settings = append(info.Settings, buildValue{Key: s.Key, Value: s.Value})
+	}
+	for _, dep := range embedded.Deps {
+		m := buildModule{Path: dep.Path, Version: dep.Version, Sum: dep.Sum}
+		if dep.Replace != nil {
+			m.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+			m.Sum = dep.Replace.Sum
+		}
+		info.Modules = append(info.Modules, m)
+	}
+	return info
+}
+
+// verifyRunningBinary downloads the release this binary claims to be,
+// checks the archive against the release checksum the way `hubfly update`
+// does (checksums.txt, whose signature must verify when a key is configured,
+// or the per-asset .sha256 when there is neither list nor key), and compares
+// the binary inside with the one running. Any difference is an error.
+func verifyRunningBinary() error {
+	tag := normalizeVersion(version.Version)
+	if tag == "" {
+		return fmt.Errorf("version %q is not a release build; there is nothing to verify against", version.Version)
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	actual, err := fileSHA256(exePath)
+	if err != nil {
+		return err
+	}
+
+	rel, err := fetchReleaseByTag(tag)
+	if err != nil {
+		return fmt.Errorf("failed to fetch release %s: %w", tag, err)
+	}
+	assetName := expectedAssetName(runtime.GOOS, runtime.GOARCH)
+	assetURL, err := findAssetURL(rel, assetName)
+	if err != nil {
+		return err
+	}
+	releaseBinary, cleanup, err := downloadVerifiedBinary(rel, assetName, assetURL)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	expected, err := fileSHA256(releaseBinary)
+	if err != nil {
+		return err
+	}
+
+	result := map[string]any{
+		"version":  tag,
+		"binary":   exePath,
+		"sha256":   actual,
+		"expected": expected,
+		"verified": actual == expected,
+	}
+	if jsonOutput {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("binary:   %s\n", exePath)
+		fmt.Printf("sha256:   %s\n", actual)
+		fmt.Printf("release:  %s (%s)\n", expected, assetName)
+	}
+	if actual != expected {
+		return fmt.Errorf("%s does not match the %s release binary", exePath, tag)
+	}
+	if !jsonOutput {
+		fmt.Printf("Verified: this binary is the %s release for %s/%s.\n", tag, runtime.GOOS, runtime.GOARCH)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
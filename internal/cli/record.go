@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"hubfly-cli/internal/version"
+)
+
+// recordBodyLimit caps how much of each request/response body is kept.
+const recordBodyLimit = 64 << 10
+
+const redactedValue = "[redacted]"
+
+// recordEntry is one line of a --record file. Kind is session, api, tui or
+// exit; the remaining fields are filled per kind.
+type recordEntry struct {
+	Time      string          `json:"time"`
+	ElapsedMs int64           `json:"elapsedMs"`
+	Kind      string          `json:"kind"`
+	Args      []string        `json:"args,omitempty"`
+	Version   string          `json:"version,omitempty"`
+	Platform  string          `json:"platform,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	URL       string          `json:"url,omitempty"`
+	Status    int             `json:"status,omitempty"`
+	TookMs    int64           `json:"tookMs,omitempty"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	State     *tuiState       `json:"state,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// tuiState is the part of a TUI model worth recording: enough to tell where
+// the user was, without the rendered screen.
+type tuiState struct {
+	Screen    string `json:"screen"`
+	Project   string `json:"project,omitempty"`
+	Container string `json:"container,omitempty"`
+	Tunnel    string `json:"tunnel,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// tuiStateReporter is implemented by TUI models that can describe their
+// current screen for recordings.
+type tuiStateReporter interface {
+	recordState() tuiState
+}
+
+// tuiTextFieldReporter is implemented by TUI models with text fields. While one
+// has focus, the characters typed are left out of recordings: they can be
+// passwords or environment values.
+type tuiTextFieldReporter interface {
+	textFieldFocused() bool
+}
+
+type sessionRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	started time.Time
+}
+
+var recorder *sessionRecorder
+
+// configureRecording handles --record <file> (or HUBFLY_RECORD). Recording
+// is opt-in; nothing is written unless one of them is set.
+func configureRecording(args []string) ([]string, error) {
+	path := strings.TrimSpace(os.Getenv("HUBFLY_RECORD"))
+	filtered := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--record":
+			if i+1 >= len(args) || strings.TrimSpace(args[i+1]) == "" {
+				return nil, errors.New("--record requires a file path")
+			}
+			path = strings.TrimSpace(args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--record="):
+			path = strings.TrimSpace(strings.TrimPrefix(arg, "--record="))
+		default:
+			filtered = append(filtered, arg)
+		}
+	}
+	if path == "" {
+		return filtered, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	recorder = &sessionRecorder{file: file, started: time.Now()}
+	recorder.write(recordEntry{
+		Kind:     "session",
+		Args:     redactArgs(filtered),
+		Version:  version.Version,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+	})
+	return filtered, nil
+}
+
+// finishRecording writes the exit entry and closes the file.
+func finishRecording(runErr error) {
+	if recorder == nil {
+		return
+	}
+	entry := recordEntry{Kind: "exit"}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	recorder.write(entry)
+	recorder.mu.Lock()
+	_ = recorder.file.Close()
+	recorder.mu.Unlock()
+	recorder = nil
+}
+
+func (r *sessionRecorder) write(entry recordEntry) {
+	now := time.Now()
+	entry.Time = now.UTC().Format(time.RFC3339Nano)
+	entry.ElapsedMs = now.Sub(r.started).Milliseconds()
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.file.Write(append(payload, '\n'))
+}
+
+func recordAPI(method, rawURL string, status int, took time.Duration, request, response []byte, err error) {
+	if recorder == nil {
+		return
+	}
+	entry := recordEntry{
+		Kind:     "api",
+		Method:   method,
+		URL:      redactURL(rawURL),
+		Status:   status,
+		TookMs:   took.Milliseconds(),
+		Request:  redactBody(request),
+		Response: redactBody(response),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	recorder.write(entry)
+}
+
+// recordingModel wraps a Bubble Tea model and records key presses and screen
+// changes. Everything else passes straight through.
+type recordingModel struct {
+	inner tea.Model
+	last  *tuiState
+}
+
+func recordTUI(m tea.Model) tea.Model {
+	if recorder == nil {
+		return m
+	}
+	return recordingModel{inner: m}
+}
+
+// unwrapRecordedModel returns the wrapped model so callers can type-assert
+// the final result of Program.Run.
+func unwrapRecordedModel(m tea.Model) tea.Model {
+	if rm, ok := m.(recordingModel); ok {
+		return rm.inner
+	}
+	return m
+}
+
+func (m recordingModel) Init() tea.Cmd {
+	return m.inner.Init()
+}
+
+func (m recordingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	focused := false
+	if reporter, ok := m.inner.(tuiTextFieldReporter); ok {
+		focused = reporter.textFieldFocused()
+	}
+	next, cmd := m.inner.Update(msg)
+	m.inner = next
+
+	event := ""
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if !isTypedKey(msg, focused) {
+			event = "key " + msg.String()
+		}
+	case tea.WindowSizeMsg:
+		event = fmt.Sprintf("resize %dx%d", msg.Width, msg.Height)
+	}
+
+	var state *tuiState
+	if reporter, ok := next.(tuiStateReporter); ok {
+		s := reporter.recordState()
+		if m.last == nil || *m.last != s {
+			state = &s
+			m.last = &s
+			if event == "" {
+				event = messageName(msg)
+			}
+		}
+	}
+	if event != "" && recorder != nil {
+		recorder.write(recordEntry{Kind: "tui", Event: event, State: state})
+	}
+	return m, cmd
+}
+
+func (m recordingModel) View() string {
+	return m.inner.View()
+}
+
+// isTypedKey reports whether key is text rather than navigation: anything
+// pasted, and characters and spaces typed while a text field has focus.
+func isTypedKey(key tea.KeyMsg, focused bool) bool {
+	if key.Paste {
+		return true
+	}
+	return focused && (key.Type == tea.KeyRunes || key.Type == tea.KeySpace)
+}
+
+func messageName(msg tea.Msg) string {
+	if msg == nil {
+		return "nil"
+	}
+	t := reflect.TypeOf(msg)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// isSecretKey reports whether a JSON key or query parameter carries a
+// credential.
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, marker := range []string{"token", "secret", "password", "authorization", "apikey", "api_key", "privatekey", "credential", "cookie"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	for i, arg := range out {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !isSecretKey(name) {
+			continue
+		}
+		if hasValue {
+			out[i] = arg[:strings.Index(arg, "=")+1] + redactedValue
+		} else if i+1 < len(out) {
+			out[i+1] = redactedValue
+		}
+	}
+	return out
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.User = nil
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if isSecretKey(key) {
+			query.Set(key, redactedValue)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// redactBody returns body as JSON with credential fields replaced. Bodies
+// that are not JSON are kept as a (truncated) string.
+func redactBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		text := string(body)
+		if len(text) > recordBodyLimit {
+			text = text[:recordBodyLimit] + "...(truncated)"
+		}
+		encoded, _ := json.Marshal(text)
+		return encoded
+	}
+	encoded, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return nil
+	}
+	if len(encoded) > recordBodyLimit {
+		encoded, _ = json.Marshal(fmt.Sprintf("(%d bytes, truncated)", len(encoded)))
+	}
+	return encoded
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if isEnvEntry(v) {
+			if _, ok := v["value"].(string); ok {
+				v["value"] = redactedValue
+			}
+		}
+		for key, inner := range v {
+			if isSecretKey(key) {
+				if _, isString := inner.(string); isString {
+					v[key] = redactedValue
+					continue
+				}
+			}
+			v[key] = redactValue(inner)
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+		return v
+	}
+	return value
+}
+
+// isEnvEntry reports whether m is an environment variable, such as
+// {"key":…,"value":…,"isSecret":…} or {"name":…,"value":…,"secret":…}.
+// Their values are redacted whether or not they are marked secret, since
+// a variable that holds a credential is not always marked as one.
+func isEnvEntry(m map[string]any) bool {
+	if _, ok := m["value"]; !ok {
+		return false
+	}
+	for _, key := range []string{"key", "name"} {
+		if _, ok := m[key].(string); ok {
+			return true
+		}
+	}
+	for _, key := range []string{"isSecret", "secret"} {
+		if secret, _ := m[key].(bool); secret {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRedactBodyHidesCredentials(t *testing.T) {
+	body := []byte(`{"data":{"connectToken":"abc","tunnelId":"tun_1","targets":[{"privateKey":"pem"}]},"token":"t"}`)
+	got := string(redactBody(body))
+	for _, secret := range []string{`"abc"`, `"pem"`, `"t"`} {
+		if strings.Contains(got, secret) {
+			t.Fatalf("secret %s survived redaction: %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"tun_1"`) {
+		t.Fatalf("non-secret field was dropped: %s", got)
+	}
+
+	if got := redactURL("https://api.example/x?token=abc&q=1"); strings.Contains(got, "abc") || !strings.Contains(got, "q=1") {
+		t.Fatalf("redactURL = %s", got)
+	}
+	args := redactArgs([]string{"login", "--token", "abc", "--token=def"})
+	if strings.Contains(strings.Join(args, " "), "abc") || strings.Contains(strings.Join(args, " "), "def") {
+		t.Fatalf("redactArgs = %v", args)
+	}
+}
+
+func TestRecordingModelLogsScreenChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	t.Cleanup(func() { recorder = nil })
+	if _, err := configureRecording([]string{"--record", path, "projects"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var m tea.Model = recordTUI(newProjectsApp("token", ""))
+	m, _ = m.Update(projectsLoadedMsg{projects: []project{{ID: "p1", Name: "shop"}}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := unwrapRecordedModel(m).(projectsApp); !ok {
+		t.Fatalf("unwrapRecordedModel returned %T", unwrapRecordedModel(m))
+	}
+	finishRecording(nil)
+
+	entries, err := readRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var screens []string
+	for _, e := range entries {
+		if e.Kind == "tui" && e.State != nil {
+			screens = append(screens, e.State.Screen)
+		}
+	}
+	if len(screens) < 2 || screens[len(screens)-1] != "project-menu" {
+		t.Fatalf("recorded screens = %v, entries = %+v", screens, entries)
+	}
+}
+
+func TestRedactBodyHidesEnvironmentValues(t *testing.T) {
+	body := []byte(`{"environment":[{"key":"DATABASE_URL","value":"postgres://u:pw@db","isSecret":true},{"key":"MODE","value":"prod","isSecret":false}],"env":[{"name":"X","value":"y"}]}`)
+	got := string(redactBody(body))
+	for _, value := range []string{"pw@db", `"prod"`, `"y"`} {
+		if strings.Contains(got, value) {
+			t.Fatalf("env value %s survived redaction: %s", value, got)
+		}
+	}
+	if !strings.Contains(got, `"DATABASE_URL"`) || !strings.Contains(got, `"MODE"`) {
+		t.Fatalf("env keys were dropped: %s", got)
+	}
+}
+
+func TestRecordingModelLeavesOutTypedText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	t.Cleanup(func() { recorder = nil })
+	if _, err := configureRecording([]string{"--record", path, "projects"}); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newProjectsApp("token", "")
+	app.setTextInput(textInputRename, "New name", "")
+	var m tea.Model = recordTUI(app)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s3cr3t")})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	_, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	finishRecording(nil)
+
+	entries, err := readRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Event, "key ") {
+			keys = append(keys, e.Event)
+		}
+	}
+	if len(keys) != 1 || keys[0] != "key esc" {
+		t.Fatalf("recorded keys = %v, want only the esc", keys)
+	}
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const replayUsage = "usage: hubfly replay <file> [--step] [--bodies] [--only api|tui]"
+
+// replayCommand prints a --record file as a timeline. --step pauses after
+// each entry so a support engineer can walk through the session with the
+// user.
+func replayCommand(args []string) error {
+	fs := newFlagSet("replay")
+	step := fs.Bool("step", false, "pause after each entry")
+	bodies := fs.Bool("bodies", false, "print redacted request and response bodies")
+	only := fs.String("only", "", "show only api or tui entries")
+	path := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		path = ""
+	}
+	if path == "" {
+		return errors.New(replayUsage)
+	}
+	switch *only {
+	case "", "api", "tui":
+	default:
+		return fmt.Errorf("--only must be api or tui")
+	}
+
+	entries, err := readRecording(path)
+	if err != nil {
+		return err
+	}
+	shown := make([]recordEntry, 0, len(entries))
+	for _, e := range entries {
+		if *only == "" || e.Kind == *only || e.Kind == "session" || e.Kind == "exit" {
+			shown = append(shown, e)
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(shown)
+	}
+	for i, e := range shown {
+		fmt.Println(formatReplayEntry(e))
+		if *bodies {
+			printReplayBody("request", e.Request)
+			printReplayBody("response", e.Response)
+		}
+		if *step && i < len(shown)-1 {
+			fmt.Printf("  [%d/%d] Enter for next, q to stop: ", i+1, len(shown))
+			line, err := stdin.ReadString('\n')
+			if err != nil || strings.TrimSpace(strings.ToLower(line)) == "q" {
+				fmt.Println()
+				break
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println(summarizeRecording(entries))
+	return nil
+}
+
+func readRecording(path string) ([]recordEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]recordEntry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4*recordBodyLimit)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e recordEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: not a recording entry: %v", path, lineNo, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	return entries, nil
+}
+
+func formatReplayEntry(e recordEntry) string {
+	prefix := fmt.Sprintf("+%8.3fs  %-7s ", float64(e.ElapsedMs)/1000, e.Kind)
+	switch e.Kind {
+	case "session":
+		return prefix + fmt.Sprintf("hubfly %s (version %s, %s)", strings.Join(e.Args, " "), valueOrDash(e.Version), valueOrDash(e.Platform))
+	case "api":
+		target := e.URL
+		if u, err := url.Parse(e.URL); err == nil {
+			target = u.RequestURI()
+		}
+		line := fmt.Sprintf("%s %s", e.Method, target)
+		if e.Status > 0 {
+			line += fmt.Sprintf(" -> %d", e.Status)
+		}
+		line += fmt.Sprintf(" (%dms)", e.TookMs)
+		if e.Error != "" {
+			line += " error: " + e.Error
+		}
+		return prefix + line
+	case "tui":
+		line := e.Event
+		if e.State != nil {
+			line += "  => " + formatTUIState(*e.State)
+		}
+		return prefix + line
+	case "exit":
+		if e.Error != "" {
+			return prefix + "failed: " + e.Error
+		}
+		return prefix + "ok"
+	}
+	return prefix + e.Event
+}
+
+func formatTUIState(s tuiState) string {
+	parts := []string{"screen " + valueOrDash(s.Screen)}
+	if s.Project != "" {
+		parts = append(parts, "project "+s.Project)
+	}
+	if s.Container != "" {
+		parts = append(parts, "container "+s.Container)
+	}
+	if s.Tunnel != "" {
+		parts = append(parts, "tunnel "+s.Tunnel)
+	}
+	if s.Status != "" {
+		parts = append(parts, "status "+fmt.Sprintf("%q", s.Status))
+	}
+	if s.Error != "" {
+		parts = append(parts, "error "+fmt.Sprintf("%q", s.Error))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func printReplayBody(label string, body json.RawMessage) {
+	if len(body) == 0 {
+		return
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "             ", "  "); err != nil {
+		pretty.Reset()
+		pretty.Write(body)
+	}
+	fmt.Printf("             %s: %s\n", label, pretty.String())
+}
+
+// summarizeRecording gives the one-line answer to "where did it stop".
+func summarizeRecording(entries []recordEntry) string {
+	apiCalls, apiFailures, tuiEvents := 0, 0, 0
+	lastScreen := ""
+	outcome := "no exit entry (the process was killed or is still running)"
+	for _, e := range entries {
+		switch e.Kind {
+		case "api":
+			apiCalls++
+			if e.Error != "" || e.Status >= 400 || e.Status == 0 {
+				apiFailures++
+			}
+		case "tui":
+			tuiEvents++
+			if e.State != nil {
+				lastScreen = e.State.Screen
+			}
+		case "exit":
+			outcome = "exited ok"
+			if e.Error != "" {
+				outcome = "exited with error"
+			}
+		}
+	}
+	summary := fmt.Sprintf("%d API calls (%d failed), %d TUI events", apiCalls, apiFailures, tuiEvents)
+	if lastScreen != "" {
+		summary += ", last screen " + lastScreen
+	}
+	return summary + "; " + outcome + "."
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func reportCommand(args []string) error {
+	if len(args) == 0 || args[0] != "tunnels" {
+		return errors.New("usage: hubfly report tunnels [--project <id|name>] [--format table|csv|json] [--output <file>]")
+	}
+	return reportTunnelsFlow(args[1:])
+}
+
+// tunnelReportRow is one line of the bill-of-tunnels report. Field order is
+// the CSV column order.
+type tunnelReportRow struct {
+	ProjectID     string `json:"projectId"`
+	ProjectName   string `json:"projectName"`
+	TunnelID      string `json:"tunnelId"`
+	ContainerName string `json:"containerName"`
+	TargetPort    int    `json:"targetPort"`
+	Mode          string `json:"mode"`
+	Status        string `json:"status"`
+	CreatedBy     string `json:"createdBy"`
+	CreatedAt     string `json:"createdAt"`
+	ExpiresAt     string `json:"expiresAt"`
+	State         string `json:"state"`
+	LocalTicket   bool   `json:"localTicket"`
+	LocalKey      bool   `json:"localKey"`
+}
+
+var tunnelReportHeader = []string{
+	"project_id", "project_name", "tunnel_id", "container", "target_port", "mode", "status",
+	"created_by", "created_at", "expires_at", "state", "local_ticket", "local_key",
+}
+
+func (r tunnelReportRow) csvRecord() []string {
+	return []string{
+		r.ProjectID, r.ProjectName, r.TunnelID, r.ContainerName, strconv.Itoa(r.TargetPort), r.Mode, r.Status,
+		r.CreatedBy, r.CreatedAt, r.ExpiresAt, r.State, strconv.FormatBool(r.LocalTicket), strconv.FormatBool(r.LocalKey),
+	}
+}
+
+func reportTunnelsFlow(args []string) error {
+	fs := newFlagSet("report tunnels")
+	projectQuery := fs.String("project", "", "limit the report to one project id or name")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	output := fs.String("output", "", "write the report to a file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if jsonOutput {
+		*format = "json"
+	}
+	switch *format {
+	case "table", "csv", "json":
+	default:
+		return fmt.Errorf("unsupported report format %q (expected table, csv or json)", *format)
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	rows := make([]tunnelReportRow, 0)
+	err = forEachProjectTunnel(token, *projectQuery, func(p project, t tunnel) {
+		rows = append(rows, buildTunnelReportRow(p, t))
+	})
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if strings.TrimSpace(*output) != "" {
+		f, err := os.OpenFile(*output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	switch *format {
+	case "csv":
+		err = writeTunnelReportCSV(out, rows)
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(map[string]any{
+			"generatedAt": time.Now().UTC().Format(time.RFC3339),
+			"tunnels":     rows,
+		})
+	default:
+		err = writeTunnelReportTable(out, rows)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(*output) != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %d tunnel(s) to %s\n", len(rows), *output)
+	}
+	return nil
+}
+
+func buildTunnelReportRow(p project, t tunnel) tunnelReportRow {
+	_, ticketErr := loadTunnelTicket(t.TunnelID)
+	_, keyErr := os.Stat(filepath.Join(keysDir(), sanitizeID(t.TunnelID)))
+	return tunnelReportRow{
+		ProjectID:     p.ID,
+		ProjectName:   p.Name,
+		TunnelID:      t.TunnelID,
+		ContainerName: resolveTunnelForwardHost(t),
+		TargetPort:    selectedPrimaryPort(t),
+		Mode:          t.Mode,
+		Status:        t.Status,
+		CreatedBy:     tunnelCreatorName(t.CreatedBy),
+		CreatedAt:     t.CreatedAt,
+		ExpiresAt:     t.ExpiresAt,
+		State:         tunnelState(t.ExpiresAt),
+		LocalTicket:   ticketErr == nil,
+		LocalKey:      keyErr == nil,
+	}
+}
+
+// tunnelCreatorName accepts the createdBy shapes the API has used: a plain
+// string, or an object with email/name/id.
+func tunnelCreatorName(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+	var user struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return ""
+	}
+	for _, candidate := range []string{user.Email, user.Name, user.ID} {
+		if strings.TrimSpace(candidate) != "" {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func writeTunnelReportCSV(out io.Writer, rows []tunnelReportRow) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(tunnelReportHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := w.Write(r.csvRecord()); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeTunnelReportTable(out io.Writer, rows []tunnelReportRow) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(out, "No tunnels found.")
+		return err
+	}
+	active, expired, withCredentials := 0, 0, 0
+	tw := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Project\tTunnel ID\tTarget\tCreated by\tExpires\tState\tLocal creds")
+	for _, r := range rows {
+		switch r.State {
+		case "active":
+			active++
+		case "expired":
+			expired++
+		}
+		creds := "none"
+		if r.LocalTicket || r.LocalKey {
+			withCredentials++
+			creds = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s:%d\t%s\t%s\t%s\t%s\n",
+			r.ProjectName, r.TunnelID, r.ContainerName, r.TargetPort, valueOrDash(r.CreatedBy), valueOrDash(r.ExpiresAt), r.State, creds)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%d tunnel(s): %d active, %d expired, %d with local credentials on this machine\n",
+		len(rows), active, expired, withCredentials)
+	return err
+}
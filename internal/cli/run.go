@@ -5,169 +5,657 @@ import (
 	"fmt"
 	"os"
 	"strconv"
-	"time"
+	"strings"
+	"text/tabwriter"
 )
 
 func Run(args []string) int {
 	args = configureDebug(args)
+	args = configureExplain(args)
+	args, err := configureProfile(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	args, err = configureAPIHost(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	args, err = configureRecording(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	args, err = configureDemo(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	args = configureOutput(args)
+	if explainMode {
+		// Describe only: no migrations, pending updates or update checks.
+		apiHost = getAPIHost()
+		err = explainCommand(args)
+		finishRecording(err)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
 	debugf("debug mode enabled")
-	if err := run(args); err != nil {
+	if os.Getenv("HUBFLY_SKIP_MIGRATIONS") == "" {
+		if err := runPendingMigrations(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	checkStoragePermissions()
+	applyPendingUpdate()
+	apiHost = getAPIHost()
+	debugf("using API host %s", apiHost)
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+		if cmd, ok := findCommand(command); ok {
+			command = cmd.name
+		}
+	}
+	notifyUpdate := startUpdateNotifier(command)
+	err = run(args)
+	flushDebug()
+	finishRecording(err)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		if hint := apiErrorHint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, "Hint: "+hint)
+		}
 		return 1
 	}
+	notifyUpdate()
+	if tip := recordUsage(args); tip != "" {
+		fmt.Fprintln(os.Stderr, "Tip: "+tip)
+	}
 	return 0
 }
 
+// cliCommand is a top-level command. usage lists its synopses without the
+// leading "hubfly"; run gets the arguments after the command name, with the
+// global flags already removed.
+type cliCommand struct {
+	name     string
+	aliases  []string
+	summary  string
+	usage    []string
+	examples []string
+	// json marks commands that honour --json.
+	json   bool
+	hidden bool
+	run    func(args []string) error
+}
+
+// globalFlag is a flag accepted anywhere on the command line. Each is
+// stripped by its configure function in Run before the command sees args.
+type globalFlag struct {
+	name  string
+	value string
+	env   string
+	help  string
+}
+
+var globalFlags = []globalFlag{
+	{name: "json", help: "print machine-readable JSON"},
+	{name: "output", value: "json|text", help: "same as --json for json; -o for short"},
+	{name: "profile", value: "<name>", env: "HUBFLY_PROFILE", help: "use this config profile"},
+	{name: "api-host", value: "<url>", env: "HUBFLY_API_URL", help: "talk to this API host instead of the profile's"},
+	{name: "explain", help: "print what the command would do as JSON, without running it"},
+	{name: "debug", env: "HUBFLY_DEBUG=1", help: "log API calls and internals to stderr"},
+	{name: "record", value: "<file>", env: "HUBFLY_RECORD", help: "record the session for support"},
+	{name: "demo", env: "HUBFLY_DEMO=1", help: "use fixture data instead of an account"},
+}
+
+// cliCommands is the command tree, in the order `hubfly help` lists it.
+func cliCommands() []cliCommand {
+	return []cliCommand{
+		{
+			name:    "login",
+			summary: "Sign in with an API token or through the browser",
+			usage:   []string{"login [--token <token> | --browser]"},
+			run:     loginCommand,
+		},
+		{
+			name:    "logout",
+			summary: "Remove the stored token from the current profile",
+			usage:   []string{"logout"},
+			run:     logoutCommand,
+		},
+		{
+			name:    "whoami",
+			summary: "Show the signed-in user",
+			usage:   []string{"whoami"},
+			json:    true,
+			run:     noArgs("whoami", whoamiFlow),
+		},
+		{
+			name:    "projects",
+			summary: "Browse projects, containers and tunnels",
+			usage:   []string{"projects [--org <id>]"},
+			json:    true,
+			run:     projectsCommand,
+		},
+		{
+			name:    "use",
+			summary: "Pick the project later commands run against",
+			usage:   []string{"use", "use project <id|name>", "use project --clear"},
+			json:    true,
+			run:     useCommand,
+		},
+		{
+			name:    "orgs",
+			aliases: []string{"org", "organizations"},
+			summary: "List your organizations",
+			usage:   []string{"orgs"},
+			json:    true,
+			run:     noArgs("orgs", organizationsFlow),
+		},
+		{
+			name:    "deploy",
+			summary: "Build and deploy the current directory",
+			usage: []string{
+				"deploy [advanced|--advanced] [--project <id|name|new>] [--region <region>] [--yes]",
+				"       [--config <path>] [--detach] [--dockerfile <path>] [--builder-version <tag>]",
+			},
+			examples: []string{
+				"deploy",
+				"deploy --project new --region rw-kigali-1 --yes",
+				"deploy --project my-api --dockerfile ./deploy/Dockerfile --builder-version v1.7.1",
+			},
+			run: func(args []string) error {
+				opts, err := parseDeployOptions(args)
+				if err != nil {
+					return err
+				}
+				return deployFlowWithOptions(opts)
+			},
+		},
+		{
+			name:    "stack",
+			summary: "Deploy and manage a compose stack",
+			usage: []string{
+				"stack plan [--file <compose-file>]",
+				"stack up [--file <compose-file>] [--project <id|name|new>] [--region <region>] [--yes] [--remove-orphans] [--no-build]",
+				"stack status [--file <compose-file>]",
+				"stack logs [service...] [--follow|-f]",
+				"stack exec <service> -- <cmd> [args...]",
+				"stack ssh <service>",
+				"stack down [--file <compose-file>] [--volumes] [--yes]",
+			},
+			examples: []string{
+				"stack plan --file docker-compose.yml",
+				"stack up --project new --region eu-1 --yes",
+				"stack exec api -- printenv",
+			},
+			json: true,
+			run:  stackFlow,
+		},
+		{
+			name:    "build",
+			summary: "Create and check hubfly.build.json",
+			usage: []string{
+				"build init [--config <path>] [--dockerfile <path>] [--force] [--print]",
+				"build validate [--config <path>] [--dockerfile <path>] [--builder-version <tag>]",
+				"build edit [--config <path>]",
+				"build explain [--config <path>] [--dockerfile <path>] [--builder-version <tag>]",
+			},
+			examples: []string{
+				"build init",
+				"build validate --config ./hubfly.build.json",
+				"build explain --json",
+			},
+			json: true,
+			run:  runBuildCommand,
+		},
+		{
+			name:    "containers",
+			aliases: []string{"container"},
+			summary: "List, inspect, rename and tag containers",
+			usage: []string{
+				"containers list [--project <id|name> | --all-projects] [--sort name|service|status]",
+				"       [--group-by service]",
+				"containers get <containerIdOrName> [--project <id|name>]",
+				"containers rename <containerIdOrName> <newName> [--project <id|name>]",
+				"containers tags <containerIdOrName> [--add <tags>] [--remove <tags>] [--set <tags>]",
+				"       [--project <id|name>]",
+			},
+			json: true,
+			run:  containersCommand,
+		},
+		{
+			name:    "tunnel",
+			aliases: []string{"tunnels"},
+			summary: "Open, save and manage tunnels to containers",
+			usage: []string{
+				"tunnel [--auto-port] <containerIdOrName> <localPort|auto> <targetPort>",
+				"tunnel list [--project <id|name> | --all-projects]",
+				"tunnel create --container <idOrName> --port <targetPort> [--project <id|name>]",
+				"       [--local-port <port>] [--ttl <duration>] [--from-file <path|->]",
+				"tunnel delete <tunnelId>",
+				"tunnel up [--name <name>] [--auto-port] <containerIdOrName> <localPort|auto> <targetPort>",
+				"tunnel up [--name <name>] [--auto-port] <savedName>",
+				"tunnel up --compose <file> [--project <id|name>] [--auto-port] [--auto-approve]",
+				"tunnel plan --compose <file> [--project <id|name>]",
+				"tunnel reverse <containerIdOrName> <remotePort> <localPort>",
+				"tunnel save <name> --container <idOrName> --port <remotePort> [--project <id|name>]",
+				"       [--local-port <port>] [--reverse]",
+				"tunnel saved [rm <name>]",
+				"tunnel ps",
+				"tunnel down <name> | --all",
+				"tunnel check-expiry [--within <duration>] [--exec <command>] [--skip-expired]",
+				"tunnel healthcheck [--probe] [--timeout <duration>]",
+			},
+			examples: []string{
+				"tunnel create --container db --port 5432 --ttl 2h",
+				"tunnel create --from-file tunnel.json --ttl 30m",
+			},
+			json: true,
+			run:  tunnelCommand,
+		},
+		{
+			name:    "logs",
+			summary: "Show or follow a container's logs",
+			usage:   []string{"logs <containerIdOrName> [--follow|-f]"},
+			run:     logsCommand,
+		},
+		{
+			name:    "ssh",
+			summary: "Open a shell in a container, or run one command",
+			usage:   []string{"ssh <containerIdOrName> [-- <cmd> [args...]]"},
+			run:     sshCommand,
+		},
+		{
+			name:    "exec",
+			summary: "Run a command in a container",
+			usage:   []string{"exec <containerIdOrName> -- <cmd> [args...]"},
+			run:     execCommand,
+		},
+		{
+			name:    "report",
+			summary: "Export a report of tunnels",
+			usage:   []string{"report tunnels [--project <id|name>] [--format table|csv|json] [--output <file>]"},
+			json:    true,
+			run:     reportCommand,
+		},
+		{
+			name:    "tips",
+			summary: "Suggest shortcuts for the flows you repeat",
+			usage:   []string{"tips [--clear]"},
+			json:    true,
+			run:     tipsCommand,
+		},
+		{
+			name:    "keys",
+			summary: "Delete key pairs left by expired tunnels",
+			usage:   []string{"keys prune [--dry-run]"},
+			json:    true,
+			run:     keysCommand,
+		},
+		{
+			name:    "audit",
+			summary: "Show or verify the log of changes made from this machine",
+			usage:   []string{"audit [--verify] [--limit <n>]"},
+			json:    true,
+			run:     auditCommand,
+		},
+		{
+			name:    "preset",
+			summary: "Install shared container aliases and port mappings",
+			usage: []string{
+				"preset add <url> --pubkey <key|file> [--name <name>]",
+				"preset list | update [name] | rm <name>",
+			},
+			json: true,
+			run:  presetCommand,
+		},
+		{
+			name:    "config",
+			summary: "Read and change profile settings",
+			usage: []string{
+				"config <get|set|unset> <key> [value]",
+				"config use-profile <name> | profiles",
+				"config validate [--file <path>]",
+				"config workspace",
+			},
+			run: configCommand,
+		},
+		{
+			name:    "migrate",
+			summary: "Show or run storage layout migrations",
+			usage:   []string{"migrate [status|up|rollback]"},
+			run:     migrateCommand,
+		},
+		{
+			name:    "service",
+			summary: "Run or control the local tunnel service",
+			usage: []string{
+				"service [--port <port>] [--idle-timeout <duration>] [--max-connection-rate <n>]",
+				"service start [--port <port>] [--idle-timeout <duration>] [--max-connection-rate <n>]",
+				"service install [--port <port>] [--max-connection-rate <n>] [--force]",
+				"service uninstall",
+				"service status [--verbose]",
+				"service logs [tunnelId]",
+				"service stop <tunnelId>",
+				"service set-log-level [info|debug]",
+			},
+			json: true,
+			run:  serviceCommand,
+		},
+		{
+			name:    "replay",
+			summary: "Play back a recorded session",
+			usage:   []string{"replay <file> [--step] [--bodies] [--only api|tui]"},
+			run:     replayCommand,
+		},
+		{
+			name:    "version",
+			aliases: []string{"--version", "-v"},
+			summary: "Show build information, or verify this binary",
+			usage:   []string{"version [--verify]"},
+			json:    true,
+			run:     showVersion,
+		},
+		{
+			name:    "update",
+			summary: "Install a newer or pinned release",
+			usage:   []string{"update [--check] [--force] [--channel stable|beta | --version <tag>]"},
+			run:     updateCommand,
+		},
+		{
+			name:    "uninstall",
+			summary: "Remove hubfly and its data",
+			usage:   []string{"uninstall [--revoke] [--keep-data] [--keep-binary] [--yes]"},
+			run:     uninstallFlow,
+		},
+		{
+			name:    "help",
+			aliases: []string{"--help", "-h"},
+			summary: "Show help for hubfly or one command",
+			usage:   []string{"help [command]", "help --browse"},
+			run:     helpCommand,
+		},
+		{
+			name:   "__update-check",
+			hidden: true,
+			usage:  []string{"__update-check <channel>"},
+			run: func(args []string) error {
+				if len(args) != 1 || !validUpdateChannel(args[0]) {
+					return errors.New("usage: hubfly __update-check <channel>")
+				}
+				return updateCheckCommand(args[0])
+			},
+		},
+		{
+			name:   "__connect-tunnel",
+			hidden: true,
+			usage:  []string{"__connect-tunnel <tunnelId> <localPort> <targetPort>"},
+			run:    connectTunnelCommand,
+		},
+	}
+}
+
+func findCommand(name string) (cliCommand, bool) {
+	for _, cmd := range cliCommands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return cliCommand{}, false
+}
+
 func run(args []string) error {
 	if len(args) == 0 {
 		_, err := ensureAuth(false)
 		return err
 	}
 
-	switch args[0] {
-	case "login":
-		var provided string
-		if len(args) >= 3 && args[1] == "--token" {
-			provided = args[2]
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		err := withUsageSuggestion(args, fmt.Errorf("unknown command: %s", args[0]))
+		var suggested *usageSuggestionError
+		if !errors.As(err, &suggested) {
+			printUsage()
 		}
-		return login(provided)
-	case "logout":
-		if err := deleteToken(); err != nil {
-			return err
-		}
-		fmt.Println("Logged out successfully.")
+		return err
+	}
+	if !cmd.hidden && cmd.name != "help" && wantsHelp(args[1:]) {
+		printCommandHelp(cmd)
 		return nil
-	case "whoami":
-		_, err := ensureAuth(false)
+	}
+	if err := checkDemoSupported(cmd.name); err != nil {
 		return err
-	case "projects":
-		orgFilter := ""
-		for i, arg := range args {
-			if arg == "--org" && i+1 < len(args) {
-				orgFilter = args[i+1]
-				break
-			}
-		}
-		return projectsFlow(orgFilter)
-	case "deploy":
-		opts, err := parseDeployOptions(args[1:])
-		if err != nil {
-			return err
-		}
-		return deployFlowWithOptions(opts)
-	case "stack":
-		return stackFlow(args[1:])
-	case "build":
-		return runBuildCommand(args[1:])
-	case "tunnel":
-		if len(args) != 4 {
-			return errors.New("usage: hubfly tunnel <containerIdOrName> <localPort> <targetPort>")
-		}
-		localPort, err := strconv.Atoi(args[2])
-		if err != nil || localPort <= 0 {
-			return errors.New("invalid local port")
-		}
-		targetPort, err := strconv.Atoi(args[3])
-		if err != nil || targetPort <= 0 {
-			return errors.New("invalid target port")
-		}
-		return tunnelFlow(args[1], localPort, targetPort)
-	case "__connect-tunnel":
-		if len(args) != 4 {
-			return errors.New("usage: hubfly __connect-tunnel <tunnelId> <localPort> <targetPort>")
-		}
-		localPort, err := strconv.Atoi(args[2])
-		if err != nil || localPort <= 0 {
-			return errors.New("invalid local port")
-		}
-		targetPort, err := strconv.Atoi(args[3])
-		if err != nil || targetPort <= 0 {
-			return errors.New("invalid target port")
-		}
-		return connectStoredTunnelFlow(args[1], localPort, targetPort)
-	case "ssh":
-		if len(args) < 2 {
-			return errors.New("usage: hubfly ssh <containerIdOrName> [-- <cmd> [args...]]")
-		}
-		if len(args) == 2 {
-			return sshFlow(args[1])
-		}
-		if len(args) >= 4 && args[2] == "--" {
-			return execFlow(args[1], args[3:], 55*time.Second)
-		}
-		return errors.New("usage: hubfly ssh <containerIdOrName> [-- <cmd> [args...]]")
-	case "exec":
-		if len(args) < 2 {
-			return errors.New("usage: hubfly exec <containerIdOrName> -- <cmd> [args...]")
-		}
-		dashIdx := -1
-		for i, a := range args {
-			if a == "--" {
-				dashIdx = i
-				break
-			}
-		}
-		if dashIdx == -1 || dashIdx == len(args)-1 {
-			return errors.New("usage: hubfly exec <containerIdOrName> -- <cmd> [args...] (missing -- or command)")
-		}
-		return execFlow(args[1], args[dashIdx+1:], 55*time.Second)
-	case "orgs", "org", "organizations":
-		return organizationsFlow()
-	case "logs":
-		if len(args) < 2 {
-			return errors.New("usage: hubfly logs <containerIdOrName> [--follow|-f]")
-		}
-		follow := false
-		if len(args) >= 3 && (args[2] == "--follow" || args[2] == "-f") {
-			follow = true
+	}
+	return withUsageSuggestion(args, cmd.run(args[1:]))
+}
+
+// wantsHelp reports whether args ask for help. Anything after "--" belongs
+// to a remote command and is left alone.
+func wantsHelp(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "-h", "-help", "--help":
+			return true
 		}
-		return logsFlow(args[1], follow)
-	case "version", "--version", "-v":
-		showVersion()
-		return nil
-	case "update":
-		checkOnly := len(args) > 1 && args[1] == "--check"
-		return updateFlow(checkOnly)
-	case "help", "--help", "-h":
+	}
+	return false
+}
+
+func helpCommand(args []string) error {
+	if len(args) == 1 && (args[0] == "--browse" || args[0] == "-b") {
+		return browseHelp()
+	}
+	if len(args) == 0 {
 		printUsage()
 		return nil
-	default:
-		printUsage()
-		return fmt.Errorf("unknown command: %s", args[0])
 	}
+	cmd, ok := findCommand(args[0])
+	if len(args) > 1 || !ok || cmd.hidden {
+		return fmt.Errorf("unknown command: %s", strings.Join(args, " "))
+	}
+	printCommandHelp(cmd)
+	return nil
 }
 
 func printUsage() {
 	fmt.Println("Hubfly CLI")
+	fmt.Println("")
 	fmt.Println("Usage:")
-	fmt.Println("  hubfly [--debug] login [--token <token>]")
-	fmt.Println("  hubfly [--debug] logout")
-	fmt.Println("  hubfly [--debug] whoami")
-	fmt.Println("  hubfly [--debug] projects")
-	fmt.Println("  hubfly [--debug] orgs")
-	fmt.Println("  hubfly [--debug] logs <containerIdOrName> [--follow|-f]")
-	fmt.Println("  hubfly [--debug] deploy [advanced|--advanced] [--project <id|name|new>] [--region <region>] [--yes]")
-	fmt.Println("       [--config <path>] [--detach] [--dockerfile <path>] [--builder-version <tag>]")
-	fmt.Println("  hubfly [--debug] stack <plan|up|status|logs|exec|ssh|down> [options]")
-	fmt.Println("  hubfly [--debug] build <init|validate|edit|explain>")
-	fmt.Println("  hubfly [--debug] tunnel <containerIdOrName> <localPort> <targetPort>")
-	fmt.Println("  hubfly [--debug] ssh <containerIdOrName> [-- <cmd> [args...]]")
-	fmt.Println("  hubfly [--debug] exec <containerIdOrName> -- <cmd> [args...]")
-	fmt.Println("  hubfly [--debug] version")
-	fmt.Println("  hubfly [--debug] update [--check]")
-	fmt.Println("  hubfly service [--port <port>]")
+	fmt.Println("  hubfly [global flags] <command> [args]")
 	fmt.Println("")
-	fmt.Println("Deploy examples:")
-	fmt.Println("  hubfly deploy")
-	fmt.Println("  hubfly deploy --project new --region rw-kigali-1 --yes")
-	fmt.Println("  hubfly deploy --project my-api --dockerfile ./deploy/Dockerfile --builder-version v1.7.1")
+	fmt.Println("Commands:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	for _, cmd := range cliCommands() {
+		if !cmd.hidden {
+			fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.summary)
+		}
+	}
+	_ = w.Flush()
 	fmt.Println("")
-	fmt.Println("Build config helpers:")
-	fmt.Println("  hubfly build init")
-	fmt.Println("  hubfly build validate --config ./hubfly.build.json")
-	fmt.Println("  hubfly build explain --json")
+	printGlobalFlags(true)
+	fmt.Println("")
+	fmt.Println("Run `hubfly help <command>` or `hubfly <command> --help` for a command's usage,")
+	fmt.Println("or `hubfly help --browse` to browse and search them all.")
+}
+
+func printCommandHelp(cmd cliCommand) {
+	fmt.Println(cmd.summary)
 	fmt.Println("")
-	fmt.Println("Debug mode:")
-	fmt.Println("  --debug")
-	fmt.Println("  HUBFLY_DEBUG=1")
+	fmt.Println("Usage:")
+	for _, line := range cmd.usage {
+		if strings.HasPrefix(line, " ") {
+			// Continuation of the previous synopsis.
+			fmt.Println("  " + strings.Repeat(" ", len("hubfly")) + line)
+			continue
+		}
+		fmt.Println("  hubfly " + line)
+	}
+	if len(cmd.aliases) > 0 {
+		fmt.Println("")
+		fmt.Println("Aliases: " + strings.Join(cmd.aliases, ", "))
+	}
+	if len(cmd.examples) > 0 {
+		fmt.Println("")
+		fmt.Println("Examples:")
+		for _, example := range cmd.examples {
+			fmt.Println("  hubfly " + example)
+		}
+	}
+	fmt.Println("")
+	fmt.Println("Boolean flags also take --no-<flag>. -p and -l stand for --port and --local-port where a command has them.")
+	fmt.Println("")
+	printGlobalFlags(cmd.json)
+}
+
+// printGlobalFlags lists the global flags, leaving out --json and --output
+// for commands without machine-readable output.
+func printGlobalFlags(withJSON bool) {
+	fmt.Println("Global flags:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	for _, f := range globalFlags {
+		if (f.name == "json" || f.name == "output") && !withJSON {
+			continue
+		}
+		name := "--" + f.name
+		if f.value != "" {
+			name += " " + f.value
+		}
+		help := f.help
+		if f.env != "" {
+			help += " (" + f.env + ")"
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", name, help)
+	}
+	_ = w.Flush()
+}
+
+// noArgs adapts a command that takes no arguments.
+func noArgs(name string, fn func() error) func([]string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("usage: hubfly %s", name)
+		}
+		return fn()
+	}
+}
+
+func logoutCommand(args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: hubfly logout")
+	}
+	if err := deleteToken(); err != nil {
+		return err
+	}
+	fmt.Println("Logged out successfully.")
+	return nil
+}
+
+func projectsCommand(args []string) error {
+	fs := newFlagSet("projects")
+	orgFilter := fs.String("org", "", "only show projects of this organization")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: hubfly projects [--org <id>]")
+	}
+	if jsonOutput {
+		return projectsListFlow(*orgFilter)
+	}
+	return projectsFlow(*orgFilter)
+}
+
+func logsCommand(args []string) error {
+	fs := newFlagSet("logs")
+	follow := fs.Bool("follow", false, "keep streaming new output")
+	fs.BoolVar(follow, "f", false, "keep streaming new output")
+	// Accept the container before or after the flags.
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return errors.New("usage: hubfly logs <containerIdOrName> [--follow|-f]")
+	}
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		name = ""
+	}
+	if strings.TrimSpace(name) == "" {
+		return errors.New("usage: hubfly logs <containerIdOrName> [--follow|-f]")
+	}
+	return logsFlow(name, *follow)
+}
+
+func sshCommand(args []string) error {
+	switch {
+	case len(args) == 1:
+		return sshFlow(args[0])
+	case len(args) >= 3 && args[1] == "--":
+		return execFlow(args[0], args[2:], execTimeout())
+	}
+	return errors.New("usage: hubfly ssh <containerIdOrName> [-- <cmd> [args...]]")
+}
+
+func execCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: hubfly exec <containerIdOrName> -- <cmd> [args...]")
+	}
+	dashIdx := -1
+	for i, a := range args {
+		if a == "--" {
+			dashIdx = i
+			break
+		}
+	}
+	if dashIdx == -1 || dashIdx == len(args)-1 {
+		return errors.New("usage: hubfly exec <containerIdOrName> -- <cmd> [args...] (missing -- or command)")
+	}
+	return execFlow(args[0], args[dashIdx+1:], execTimeout())
+}
+
+func updateCommand(args []string) error {
+	fs := newFlagSet("update")
+	checkOnly := fs.Bool("check", false, "only report whether an update is available")
+	force := fs.Bool("force", false, "replace the binary even while background tunnels use it")
+	channelFlag := fs.String("channel", "", "release channel: stable or beta")
+	pinned := fs.String("version", "", "install this release tag instead of the newest")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: hubfly update [--check] [--force] [--channel stable|beta | --version <tag>]")
+	}
+	if *channelFlag != "" && *pinned != "" {
+		return errors.New("--channel and --version cannot be combined")
+	}
+	channel, err := updateChannel(*channelFlag)
+	if err != nil {
+		return err
+	}
+	return updateFlow(*checkOnly, *force, channel, *pinned)
+}
+
+func connectTunnelCommand(args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: hubfly __connect-tunnel <tunnelId> <localPort> <targetPort>")
+	}
+	localPort, err := strconv.Atoi(args[1])
+	if err != nil || localPort <= 0 {
+		return errors.New("invalid local port")
+	}
+	targetPort, err := strconv.Atoi(args[2])
+	if err != nil || targetPort <= 0 {
+		return errors.New("invalid target port")
+	}
+	return connectStoredTunnelFlow(args[0], localPort, targetPort)
 }
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFindCommandResolvesAliases(t *testing.T) {
+	for alias, want := range map[string]string{
+		"tunnels":       "tunnel",
+		"organizations": "orgs",
+		"-v":            "version",
+		"--help":        "help",
+		"logs":          "logs",
+	} {
+		cmd, ok := findCommand(alias)
+		if !ok || cmd.name != want {
+			t.Errorf("findCommand(%q) = %q, %v; want %q", alias, cmd.name, ok, want)
+		}
+	}
+	if _, ok := findCommand("nope"); ok {
+		t.Error("findCommand(nope) succeeded")
+	}
+}
+
+func TestCommandNamesAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, cmd := range cliCommands() {
+		for _, name := range append([]string{cmd.name}, cmd.aliases...) {
+			if seen[name] {
+				t.Errorf("%q is used by more than one command", name)
+			}
+			seen[name] = true
+		}
+		if cmd.run == nil || len(cmd.usage) == 0 {
+			t.Errorf("%s has no run func or usage", cmd.name)
+		}
+	}
+}
+
+func TestWantsHelpStopsAtDoubleDash(t *testing.T) {
+	if !wantsHelp([]string{"list", "--help"}) {
+		t.Error("--help not seen")
+	}
+	if wantsHelp([]string{"api", "--", "grep", "-h"}) {
+		t.Error("-h after -- taken as a help request")
+	}
+}
+
+func TestConfigureAPIHost(t *testing.T) {
+	t.Setenv("HUBFLY_API_URL", "")
+	args, err := configureAPIHost([]string{"projects", "--api-host=https://api.example.com/"})
+	if err != nil || len(args) != 1 || args[0] != "projects" {
+		t.Fatalf("configureAPIHost = %v, %v", args, err)
+	}
+	if got := getAPIHost(); got != "https://api.example.com" {
+		t.Errorf("getAPIHost = %q", got)
+	}
+	if _, err := configureAPIHost([]string{"--api-host", "api.example.com"}); err == nil {
+		t.Error("host without a scheme accepted")
+	}
+}
+
+func TestEveryCommandCanBeExplained(t *testing.T) {
+	for _, cmd := range cliCommands() {
+		if cmd.hidden {
+			continue
+		}
+		for _, line := range cmd.usage {
+			fields := strings.Fields(line)
+			if strings.HasPrefix(line, " ") || len(fields) == 0 {
+				continue
+			}
+			// "config <get|set|unset> ..." and "preset list | update ..." name
+			// several subcommands on one line.
+			var subs []string
+			for i, field := range fields[1:] {
+				if i == 0 || fields[i] == "|" {
+					for _, sub := range strings.Split(strings.Trim(field, "<>"), "|") {
+						if sub != "" && !strings.HasPrefix(sub, "-") && !strings.HasPrefix(sub, "[") {
+							subs = append(subs, sub)
+						}
+					}
+				}
+			}
+			if len(subs) == 0 {
+				subs = []string{""}
+			}
+			for _, sub := range subs {
+				args := []string{}
+				if sub != "" {
+					args = append(args, sub)
+				}
+				if _, ok := explainKey(cmd.name, args); !ok {
+					t.Errorf("no --explain entry for %q (usage %q)", strings.TrimSpace(cmd.name+" "+sub), line)
+				}
+			}
+		}
+	}
+}
+
+func TestFlagAliasesAndNegation(t *testing.T) {
+	fs := newFlagSet("test")
+	port := fs.Int("port", 0, "")
+	local := fs.Int("local-port", 0, "")
+	follow := fs.Bool("follow", true, "")
+	name := fs.String("name", "", "")
+	if err := fs.Parse([]string{"-p", "5432", "--local=15432", "--no-follow", "--name", "-l", "db", "-p"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 5432 || *local != 15432 || *follow || *name != "-l" {
+		t.Fatalf("port=%d local=%d follow=%v name=%q", *port, *local, *follow, *name)
+	}
+	if got := fs.Args(); len(got) != 2 || got[0] != "db" || got[1] != "-p" {
+		t.Fatalf("positional args = %q, want them untouched", got)
+	}
+
+	jsonOutput = false
+	t.Cleanup(func() { jsonOutput = false })
+	if args := configureOutput([]string{"containers", "list", "-o", "json"}); !jsonOutput || len(args) != 2 {
+		t.Fatalf("-o json: args=%q json=%v", args, jsonOutput)
+	}
+	jsonOutput = false
+	if args := configureOutput([]string{"report", "tunnels", "--output", "json"}); jsonOutput || len(args) != 4 {
+		t.Fatalf("report keeps its own --output: args=%q json=%v", args, jsonOutput)
+	}
+}
+
+func TestUsageErrorsSuggestACommandLine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if err := saveUsageLog(usageLog{Flows: map[string]*usageStat{"tunnel:api:80": {Count: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"tunel", "api", "8080", "80"}, "tunnel api 8080 80"},
+		{[]string{"tunnel", "8080", "apy", "80"}, "tunnel api 8080 80"},
+		{[]string{"tunnel", "api:80"}, "tunnel api auto 80"},
+		{[]string{"tunnel", "lsit", "--all-projetcs"}, "tunnel list --all-projects"},
+		{[]string{"tunnel", "create", "--container", "db", "-port", "5432", "--tll", "2h"}, "tunnel create --container db -port 5432 --ttl 2h"},
+		{[]string{"logs", "web", "--follow"}, ""},
+		{[]string{"frobnicate"}, ""},
+	} {
+		got := quoteArgs(suggestInvocation(tc.args))
+		if got != tc.want {
+			t.Errorf("suggestInvocation(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+
+	err := withUsageSuggestion([]string{"tunnel", "api", "80"}, errors.New(tunnelUsage()))
+	if got := err.Error(); got != "wrong arguments for hubfly tunnel\ndid you mean: hubfly tunnel api auto 80\nRun `hubfly help tunnel` for all of its usage." {
+		t.Fatalf("error = %q", got)
+	}
+	if err := withUsageSuggestion([]string{"whoami"}, errors.New("not logged in")); err.Error() != "not logged in" {
+		t.Fatalf("non-usage error changed: %q", err)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cli
+
+import "os"
+
+// restrictToOwner re-applies owner-only permission bits. File modes already
+// carry the intent on unix, so this only matters for paths created by older
+// versions or by other tools.
+func restrictToOwner(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return os.Chmod(path, 0o700)
+	}
+	return os.Chmod(path, 0o600)
+}
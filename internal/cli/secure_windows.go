@@ -0,0 +1,49 @@
+//go:build windows
+
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// restrictToOwner replaces the DACL on path with a protected one granting full
+// control to the current user and SYSTEM only. Unix mode bits passed to
+// os.WriteFile/os.MkdirAll are ignored on Windows, and OpenSSH for Windows
+// refuses key files that inherit broader access from the profile directory.
+func restrictToOwner(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+	sid := user.User.Sid
+
+	inherit := ""
+	if info.IsDir() {
+		inherit = "OICI"
+	}
+	sddl := fmt.Sprintf("D:P(A;%s;FA;;;%s)(A;%s;FA;;;SY)", inherit, sid.String(), inherit)
+	sd, err := windows.SecurityDescriptorFromString(sddl)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(
+		path,
+		windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION|windows.OWNER_SECURITY_INFORMATION,
+		sid,
+		nil,
+		dacl,
+		nil,
+	)
+}
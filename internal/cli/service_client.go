@@ -0,0 +1,458 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hubfly-cli/internal/service"
+)
+
+func serviceCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: hubfly service [--port <port>] [--idle-timeout <duration>] | hubfly service start | hubfly service install | hubfly service uninstall | hubfly service status [--verbose] | hubfly service logs [tunnelId] | hubfly service stop <tunnelId> | hubfly service set-log-level [info|debug]")
+	}
+	switch args[0] {
+	case "start":
+		return serviceStartFlow(args[1:])
+	case "install":
+		return serviceInstallFlow(args[1:])
+	case "uninstall":
+		if len(args) != 1 {
+			return errors.New("usage: hubfly service uninstall")
+		}
+		return serviceUninstallFlow()
+	case "status":
+		return serviceStatusFlow(args[1:])
+	case "logs":
+		if len(args) > 2 {
+			return errors.New("usage: hubfly service logs [tunnelId]")
+		}
+		path := "/logs"
+		if len(args) == 2 {
+			path = "/tunnels/" + url.PathEscape(args[1]) + "/logs"
+		}
+		return serviceLogsFlow(path)
+	case "stop":
+		if len(args) != 2 {
+			return errors.New("usage: hubfly service stop <tunnelId>")
+		}
+		if err := serviceRequest(http.MethodPost, "/stop", map[string]string{"id": args[1]}, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Stopped %s.\n", args[1])
+		return nil
+	case "set-log-level":
+		return serviceSetLogLevelFlow(args[1:])
+	default:
+		return fmt.Errorf("unknown service command: %s", args[0])
+	}
+}
+
+// defaultServiceIdleTimeout applies to services launched by `service start`:
+// they are started on demand, so they exit again once nothing uses them.
+const defaultServiceIdleTimeout = 10 * time.Minute
+
+// serviceStartFlow launches the tunnel service in the background unless one
+// is already answering. It is safe to run before every use of the API.
+func serviceStartFlow(args []string) error {
+	fs := newFlagSet("service start")
+	port := fs.Int("port", 5600, "control API port")
+	idleTimeout := fs.Duration("idle-timeout", defaultServiceIdleTimeout, "exit after this long without tunnels or API calls (0 disables)")
+	connectionRate := fs.Int("max-connection-rate", service.DefaultConnectionRate, "new connections per second across all tunnels (0 disables)")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *port <= 0 || *idleTimeout < 0 || *connectionRate < 0 {
+		return errors.New("usage: hubfly service start [--port <port>] [--idle-timeout <duration>] [--max-connection-rate <n>]")
+	}
+
+	if info, ok := runningService(); ok {
+		if jsonOutput {
+			return printJSON(info)
+		}
+		fmt.Printf("Tunnel service is already running on port %d (pid %d).\n", info.Port, info.PID)
+		return nil
+	}
+
+	logDir := filepath.Join(hubflyDir(), "logs")
+	if err := ensurePrivateDir(logDir); err != nil {
+		return err
+	}
+	logPath := filepath.Join(logDir, "service.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logFile.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "service", "--port", strconv.Itoa(*port), "--idle-timeout", idleTimeout.String(), "--max-connection-rate", strconv.Itoa(*connectionRate))
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detachCommand(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := service.ReadInfo(); err == nil && info.PID == pid {
+			if jsonOutput {
+				return printJSON(info)
+			}
+			fmt.Printf("Tunnel service started on port %d (pid %d).\n", info.Port, info.PID)
+			if *idleTimeout > 0 {
+				fmt.Printf("It exits after %s without tunnels or API calls; run `hubfly service start` again when needed.\n", idleTimeout)
+			}
+			return nil
+		}
+		if !processAlive(pid) {
+			return fmt.Errorf("tunnel service exited during startup:\n%s", tailFile(logPath, 10))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("tunnel service did not start within 5s; see %s", logPath)
+}
+
+// serviceInstallFlow hands the tunnel service to the platform's service
+// manager: a systemd user unit, a launchd agent or a Windows service. Unlike
+// `service start`, the installed service has no idle timeout; it starts on
+// boot or login and is restarted if it fails.
+func serviceInstallFlow(args []string) error {
+	fs := newFlagSet("service install")
+	port := fs.Int("port", 5600, "control API port")
+	connectionRate := fs.Int("max-connection-rate", service.DefaultConnectionRate, "new connections per second across all tunnels (0 disables)")
+	force := fs.Bool("force", false, "replace a unit that was not written by hubfly")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *port <= 0 || *connectionRate < 0 {
+		return errors.New("usage: hubfly service install [--port <port>] [--max-connection-rate <n>] [--force]")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	// A service started on demand would hold the port the installed one
+	// needs. It shuts down like on SIGTERM, so persistent tunnels carry over.
+	if info, ok := runningService(); ok {
+		if err := terminateProcess(info.PID); err != nil {
+			return fmt.Errorf("failed to stop the running tunnel service (pid %d): %w", info.PID, err)
+		}
+		if !jsonOutput {
+			fmt.Printf("Stopped the tunnel service that was running on port %d (pid %d).\n", info.Port, info.PID)
+		}
+	}
+
+	unit, err := installServiceUnit(exe, []string{"service", "--port", strconv.Itoa(*port), "--max-connection-rate", strconv.Itoa(*connectionRate)}, *force)
+	if err != nil {
+		return err
+	}
+	var lingerErr error
+	if unit.Manager == "systemd" {
+		lingerErr = enableLinger()
+	}
+
+	var info service.Info
+	started := false
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, started = runningService(); started {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if jsonOutput {
+		unit.Running = started
+		return printJSON(unit)
+	}
+	fmt.Printf("Installed the tunnel service as %s %s.\n", unit.Manager, unit.Name)
+	if unit.Path != "" {
+		fmt.Printf("Unit file: %s\n", unit.Path)
+	}
+	if started {
+		fmt.Printf("Tunnel service is running on port %d (pid %d).\n", info.Port, info.PID)
+	} else {
+		fmt.Printf("The tunnel service did not answer within 5s; see %s.\n", serviceUnitLogs())
+	}
+	switch {
+	case lingerErr != nil:
+		fmt.Fprintf(os.Stderr, "warning: could not enable lingering (%v); the service will only run while you are logged in.\n", lingerErr)
+	case unit.Manager == "launchd":
+		fmt.Println("It starts when you log in and stops when you log out.")
+	}
+	return nil
+}
+
+// serviceUninstallFlow stops the installed service and removes its unit.
+// Tunnels marked persistent stay in ~/.hubfly/service-state.json.
+func serviceUninstallFlow() error {
+	removed, err := removeServiceUnits()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(map[string]any{"removed": removed})
+	}
+	if len(removed) == 0 {
+		fmt.Println("The tunnel service is not installed.")
+		return nil
+	}
+	for _, path := range removed {
+		fmt.Printf("Removed %s\n", path)
+	}
+	return nil
+}
+
+// runningService reports the service described by service.json when its
+// process is alive and answering /health.
+func runningService() (service.Info, bool) {
+	info, err := service.ReadInfo()
+	if err != nil || !processAlive(info.PID) {
+		return service.Info{}, false
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/health", info.Port))
+	if err != nil {
+		return service.Info{}, false
+	}
+	_ = resp.Body.Close()
+	return info, resp.StatusCode == http.StatusOK
+}
+
+func readServiceInfo() (service.Info, error) {
+	info, err := service.ReadInfo()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return info, errors.New("tunnel service is not running (start it with `hubfly service start`)")
+		}
+		return info, fmt.Errorf("failed to read %s: %w", service.InfoPath(), err)
+	}
+	return info, nil
+}
+
+// serviceRequest calls the local tunnel service using the port and shared
+// secret the service wrote to ~/.hubfly/service.json on startup.
+func serviceRequest(method, path string, body any, out any) error {
+	info, err := readServiceInfo()
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://127.0.0.1:%d%s", info.Port, path), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+info.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tunnel service unreachable on port %d: %w", info.Port, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("tunnel service rejected the token in %s; restart the service", service.InfoPath())
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("tunnel service error (%d): %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// serviceSetLogLevelFlow changes the running service's log level without
+// restarting it, so tunnels keep their connections. With no level it prints
+// the current one.
+func serviceSetLogLevelFlow(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: hubfly service set-log-level [info|debug]")
+	}
+	var current struct {
+		Level string `json:"level"`
+	}
+	var err error
+	if len(args) == 0 {
+		err = serviceRequest(http.MethodGet, "/log-level", nil, &current)
+	} else {
+		level := strings.ToLower(strings.TrimSpace(args[0]))
+		if level != service.LogLevelInfo && level != service.LogLevelDebug {
+			return errors.New("usage: hubfly service set-log-level [info|debug]")
+		}
+		err = serviceRequest(http.MethodPost, "/log-level", map[string]string{"level": level}, &current)
+	}
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(current)
+	}
+	fmt.Printf("Tunnel service log level: %s\n", current.Level)
+	return nil
+}
+
+func serviceStatusFlow(args []string) error {
+	fs := newFlagSet("service status")
+	verbose := fs.Bool("verbose", false, "show goroutines, buffers and process resources")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: hubfly service status [--verbose]")
+	}
+	path := "/status"
+	if *verbose {
+		path += "?verbose=1"
+	}
+	if unit, ok := installedServiceUnit(); ok && !jsonOutput {
+		state := "disabled"
+		if unit.Enabled {
+			state = "enabled"
+		}
+		if unit.Running {
+			state += ", running"
+		} else {
+			state += ", not running"
+		}
+		fmt.Printf("Installed as %s %s (%s)\n", unit.Manager, unit.Name, state)
+	}
+	var statuses []service.TunnelStatus
+	if err := serviceRequest(http.MethodGet, path, nil, &statuses); err != nil {
+		return err
+	}
+	var metrics service.Metrics
+	if *verbose {
+		if err := serviceRequest(http.MethodGet, "/metrics", nil, &metrics); err != nil {
+			return err
+		}
+	}
+	if jsonOutput {
+		if *verbose {
+			return printJSON(map[string]any{"tunnels": statuses, "process": metrics.Process})
+		}
+		return printJSON(statuses)
+	}
+	if *verbose {
+		p := metrics.Process
+		fds := "unknown"
+		if p.OpenFDs >= 0 {
+			fds = strconv.Itoa(p.OpenFDs)
+		}
+		fmt.Printf("Process: %d goroutines (%d in tunnels), %s open fds, %s heap\n",
+			p.Goroutines, p.TrackedGoroutines, fds, formatBytes(int64(p.HeapBytes)))
+		if p.Warning != "" {
+			fmt.Printf("Warning: %s\n", p.Warning)
+		}
+	}
+	if len(statuses) == 0 {
+		fmt.Println("Tunnel service is running with no active tunnels.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	if !*verbose {
+		_, _ = fmt.Fprintln(tw, "ID\tLocal\tTarget\tStatus\tStreams\tSent\tReceived")
+		for _, s := range statuses {
+			_, _ = fmt.Fprintf(tw, "%s\tlocalhost:%d\t%s\t%s\t%d\t%d\t%d\n",
+				s.ID, s.LocalPort, s.Target, s.Status, s.ActiveStreams, s.BytesSent, s.BytesReceived)
+		}
+		return tw.Flush()
+	}
+	_, _ = fmt.Fprintln(tw, "ID\tLocal\tStatus\tStreams\tStalled\tGoroutines\tBuffers\tWarning")
+	for _, s := range statuses {
+		var r service.TunnelResources
+		if s.Resources != nil {
+			r = *s.Resources
+		}
+		_, _ = fmt.Fprintf(tw, "%s\tlocalhost:%d\t%s\t%d\t%d\t%d\t%s\t%s\n",
+			s.ID, s.LocalPort, s.Status, s.ActiveStreams, s.StreamsStalled, r.Goroutines, formatBytes(r.BufferBytes), r.Warning)
+	}
+	return tw.Flush()
+}
+
+// serviceLogsFlow follows a service log stream until interrupted. Events are
+// printed one per line, or as newline-delimited JSON with --json.
+func serviceLogsFlow(path string) error {
+	info, err := readServiceInfo()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d%s", info.Port, path), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+info.Token)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("tunnel service unreachable on port %d: %w", info.Port, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("tunnel service error (%d): %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev service.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			debugf("skipping malformed service event: %v", err)
+			continue
+		}
+		if jsonOutput {
+			payload, _ := json.Marshal(ev)
+			fmt.Println(string(payload))
+			continue
+		}
+		fmt.Println(formatServiceEvent(ev))
+	}
+	return scanner.Err()
+}
+
+func formatServiceEvent(ev service.Event) string {
+	stamp := ev.Time
+	if parsed, err := time.Parse(time.RFC3339Nano, ev.Time); err == nil {
+		stamp = parsed.Local().Format("15:04:05")
+	}
+	if ev.Type == "stats" {
+		return fmt.Sprintf("%s %s stats active=%d opened=%d sent=%dB recv=%dB",
+			stamp, ev.TunnelID, ev.ActiveStreams, ev.StreamsOpened, ev.BytesSent, ev.BytesReceived)
+	}
+	return fmt.Sprintf("%s %s %s %s", stamp, ev.TunnelID, ev.Type, ev.Message)
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+)
+
+// Service types inferred for containers, in the order grouped lists show
+// them.
+const (
+	serviceTypeWeb   = "web"
+	serviceTypeDB    = "db"
+	serviceTypeCache = "cache"
+	serviceTypeOther = "other"
+)
+
+var serviceTypeOrder = []string{serviceTypeWeb, serviceTypeDB, serviceTypeCache, serviceTypeOther}
+
+// serviceTypeHints maps image and container name prefixes to a service
+// type. Caches come before databases so "redis-stack" is not caught by a
+// broader database entry.
+var serviceTypeHints = []struct {
+	kind     string
+	prefixes []string
+}{
+	{serviceTypeCache, []string{"redis", "valkey", "keydb", "memcached", "dragonfly"}},
+	{serviceTypeDB, []string{"postgres", "postgis", "timescale", "mysql", "mariadb", "mongo", "cockroach", "clickhouse", "mssql", "couchdb", "cassandra", "scylla", "influxdb", "neo4j", "surrealdb"}},
+	{serviceTypeWeb, []string{"nginx", "httpd", "caddy", "traefik", "haproxy", "ghost", "wordpress", "web", "frontend", "api"}},
+}
+
+var serviceTypePorts = map[int]string{
+	5432:  serviceTypeDB,
+	3306:  serviceTypeDB,
+	27017: serviceTypeDB,
+	1433:  serviceTypeDB,
+	9042:  serviceTypeDB,
+	26257: serviceTypeDB,
+	5984:  serviceTypeDB,
+	7687:  serviceTypeDB,
+	6379:  serviceTypeCache,
+	11211: serviceTypeCache,
+	80:    serviceTypeWeb,
+	443:   serviceTypeWeb,
+	3000:  serviceTypeWeb,
+	4000:  serviceTypeWeb,
+	5000:  serviceTypeWeb,
+	8000:  serviceTypeWeb,
+	8080:  serviceTypeWeb,
+	8443:  serviceTypeWeb,
+}
+
+// inferServiceType guesses what a container is from its image, tier and
+// exposed ports, falling back to its name. Containers with nothing to go on
+// are "other".
+func inferServiceType(c container) string {
+	if kind := serviceTypeFromName(imageBaseName(c.Source.Image)); kind != "" {
+		return kind
+	}
+	if strings.EqualFold(c.Tier, "database") {
+		return serviceTypeDB
+	}
+	// A database or cache port says more than a web port next to it, such as
+	// an admin UI.
+	web := false
+	for _, port := range c.Networking.Ports {
+		switch serviceTypePorts[port.Container] {
+		case serviceTypeDB:
+			return serviceTypeDB
+		case serviceTypeCache:
+			return serviceTypeCache
+		case serviceTypeWeb:
+			web = true
+		}
+		if p := strings.ToLower(port.Protocol); p == "http" || p == "https" {
+			web = true
+		}
+	}
+	if web {
+		return serviceTypeWeb
+	}
+	if kind := serviceTypeFromName(strings.ToLower(c.Name)); kind != "" {
+		return kind
+	}
+	return serviceTypeOther
+}
+
+func serviceTypeFromName(name string) string {
+	if name == "" {
+		return ""
+	}
+	for _, hint := range serviceTypeHints {
+		for _, prefix := range hint.prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return hint.kind
+			}
+		}
+	}
+	return ""
+}
+
+// imageBaseName reduces "ghcr.io/acme/postgres:16@sha256:..." to "postgres".
+func imageBaseName(image string) string {
+	image = strings.ToLower(strings.TrimSpace(image))
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	if i := strings.Index(image, ":"); i >= 0 {
+		image = image[:i]
+	}
+	return image
+}
+
+func serviceTypeRank(kind string) int {
+	for i, k := range serviceTypeOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(serviceTypeOrder)
+}
+
+// sortByServiceType orders indexes into containers by service type, keeping
+// the existing order within a type.
+func sortByServiceType(containers []container) []int {
+	order := make([]int, len(containers))
+	kinds := make([]string, len(containers))
+	for i, c := range containers {
+		order[i] = i
+		kinds[i] = inferServiceType(c)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return serviceTypeRank(kinds[order[a]]) < serviceTypeRank(kinds[order[b]])
+	})
+	return order
+}
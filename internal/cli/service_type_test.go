@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testContainer(name, tier, image string, ports ...int) container {
+	c := container{Name: name, Tier: tier}
+	c.Source.Image = image
+	for _, port := range ports {
+		c.Networking.Ports = append(c.Networking.Ports, struct {
+			Protocol  string `json:"protocol"`
+			Container int    `json:"container"`
+			TunnelURL string `json:"tunnelUrl"`
+		}{Protocol: "tcp", Container: port})
+	}
+	return c
+}
+
+func TestInferServiceType(t *testing.T) {
+	cases := []struct {
+		c    container
+		want string
+	}{
+		{testContainer("main", "", "ghcr.io/acme/postgres:16@sha256:abc"), serviceTypeDB},
+		{testContainer("sessions", "", "bitnami/redis:7", 6379), serviceTypeCache},
+		{testContainer("store", "database", ""), serviceTypeDB},
+		{testContainer("admin", "", "", 8080, 5432), serviceTypeDB},
+		{testContainer("app", "", "node:20", 3000), serviceTypeWeb},
+		{testContainer("memcached", "", ""), serviceTypeCache},
+		{testContainer("worker", "", ""), serviceTypeOther},
+	}
+	for _, tc := range cases {
+		if got := inferServiceType(tc.c); got != tc.want {
+			t.Errorf("inferServiceType(%s) = %s, want %s", tc.c.Name, got, tc.want)
+		}
+	}
+}
+
+func TestSortByServiceTypeIsStable(t *testing.T) {
+	containers := []container{
+		testContainer("worker", "", ""),
+		testContainer("db", "", "postgres"),
+		testContainer("web", "", "", 80),
+		testContainer("api", "", "", 8080),
+	}
+	if got := sortByServiceType(containers); !reflect.DeepEqual(got, []int{2, 3, 1, 0}) {
+		t.Fatalf("sortByServiceType = %v", got)
+	}
+}
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	systemdUnitName = "hubfly-service.service"
+	// The optional socket units that start the service on the first
+	// connection (see "Socket activation" in the README).
+	systemdSocketName       = "hubfly-service.socket"
+	systemdTunnelSocketName = "hubfly-service-tunnels.socket"
+	launchdLabel            = "space.hubfly.service"
+	// serviceUnitMarker is in every file `hubfly service install` writes,
+	// so it never replaces a unit written by hand, such as the one for
+	// socket activation.
+	serviceUnitMarker = "Written by `hubfly service install`"
+)
+
+// serviceUnit is the tunnel service as the platform's service manager sees
+// it.
+type serviceUnit struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Enabled bool   `json:"enabled"`
+	Running bool   `json:"running"`
+}
+
+func systemdUnitPath() string {
+	return systemdUserUnitPath(systemdUnitName)
+}
+
+func systemdUserUnitPath(name string) string {
+	configHome := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME"))
+	if configHome == "" {
+		configHome = filepath.Join(userHomeDir(), ".config")
+	}
+	return filepath.Join(configHome, "systemd", "user", name)
+}
+
+func launchdPlistPath() string {
+	return filepath.Join(userHomeDir(), "Library", "LaunchAgents", launchdLabel+".plist")
+}
+
+// removeServiceUnits disables and deletes the per-user systemd unit or
+// launchd agent that runs `hubfly service`, returning the files removed.
+func removeServiceUnits() ([]string, error) {
+	removed := make([]string, 0, 2)
+	switch runtime.GOOS {
+	case "linux":
+		// Sockets go first so they cannot start the service again.
+		for _, unit := range []string{systemdSocketName, systemdTunnelSocketName, systemdUnitName} {
+			path := systemdUserUnitPath(unit)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if out, err := exec.Command("systemctl", "--user", "disable", "--now", unit).CombinedOutput(); err != nil {
+				debugf("systemctl disable %s: %v: %s", unit, err, strings.TrimSpace(string(out)))
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return removed, err
+			}
+			removed = append(removed, path)
+		}
+		if len(removed) > 0 {
+			_ = exec.Command("systemctl", "--user", "daemon-reload").Run()
+		}
+	case "darwin":
+		path := launchdPlistPath()
+		if _, err := os.Stat(path); err != nil {
+			return removed, nil
+		}
+		if out, err := exec.Command("launchctl", "unload", "-w", path).CombinedOutput(); err != nil {
+			debugf("launchctl unload %s: %v: %s", path, err, strings.TrimSpace(string(out)))
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, path)
+	case "windows":
+		name, err := removeWindowsService()
+		if err != nil {
+			return removed, err
+		}
+		if name != "" {
+			removed = append(removed, name)
+		}
+	}
+	return removed, nil
+}
+
+// installServiceUnit registers `hubfly <args>` with the platform's service
+// manager so it starts on boot or login and is restarted if it fails, then
+// starts it. A unit that was not written by hubfly is only replaced with
+// force.
+func installServiceUnit(exe string, args []string, force bool) (serviceUnit, error) {
+	command := append([]string{exe}, args...)
+	switch runtime.GOOS {
+	case "linux":
+		path := systemdUnitPath()
+		if err := writeServiceUnitFile(path, systemdServiceUnit(command), force); err != nil {
+			return serviceUnit{}, err
+		}
+		for _, step := range [][]string{{"daemon-reload"}, {"enable", systemdUnitName}, {"restart", systemdUnitName}} {
+			if err := runServiceManager("systemctl", append([]string{"--user"}, step...)...); err != nil {
+				return serviceUnit{}, err
+			}
+		}
+	case "darwin":
+		path := launchdPlistPath()
+		logPath := filepath.Join(hubflyDir(), "logs", "service.log")
+		if err := ensurePrivateDir(filepath.Dir(logPath)); err != nil {
+			return serviceUnit{}, err
+		}
+		if err := writeServiceUnitFile(path, launchdPlist(command, logPath), force); err != nil {
+			return serviceUnit{}, err
+		}
+		_ = exec.Command("launchctl", "unload", path).Run()
+		if err := runServiceManager("launchctl", "load", "-w", path); err != nil {
+			return serviceUnit{}, err
+		}
+	case "windows":
+		if err := installWindowsService(exe, args); err != nil {
+			return serviceUnit{}, err
+		}
+	default:
+		return serviceUnit{}, fmt.Errorf("hubfly service install does not support %s; run `hubfly service` from your own init system", runtime.GOOS)
+	}
+	unit, _ := installedServiceUnit()
+	return unit, nil
+}
+
+// installedServiceUnit reports the unit `hubfly service install` manages,
+// if there is one.
+func installedServiceUnit() (serviceUnit, bool) {
+	switch runtime.GOOS {
+	case "linux":
+		path := systemdUnitPath()
+		if !isHubflyServiceUnit(path) {
+			return serviceUnit{}, false
+		}
+		enabled, _ := exec.Command("systemctl", "--user", "is-enabled", systemdUnitName).Output()
+		active, _ := exec.Command("systemctl", "--user", "is-active", systemdUnitName).Output()
+		return serviceUnit{
+			Manager: "systemd",
+			Name:    systemdUnitName,
+			Path:    path,
+			Enabled: strings.TrimSpace(string(enabled)) == "enabled",
+			Running: strings.TrimSpace(string(active)) == "active",
+		}, true
+	case "darwin":
+		path := launchdPlistPath()
+		if !isHubflyServiceUnit(path) {
+			return serviceUnit{}, false
+		}
+		out, err := exec.Command("launchctl", "list", launchdLabel).Output()
+		return serviceUnit{
+			Manager: "launchd",
+			Name:    launchdLabel,
+			Path:    path,
+			Enabled: err == nil,
+			Running: err == nil && bytes.Contains(out, []byte(`"PID" =`)),
+		}, true
+	case "windows":
+		return windowsServiceStatus()
+	}
+	return serviceUnit{}, false
+}
+
+// serviceUnitLogs says where an installed service's output goes.
+func serviceUnitLogs() string {
+	if runtime.GOOS == "linux" {
+		return "journalctl --user -u " + systemdUnitName
+	}
+	return filepath.Join(hubflyDir(), "logs", "service.log")
+}
+
+func isHubflyServiceUnit(path string) bool {
+	content, err := os.ReadFile(path)
+	return err == nil && bytes.Contains(content, []byte(serviceUnitMarker))
+}
+
+func writeServiceUnitFile(path, content string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force && !isHubflyServiceUnit(path) {
+		return fmt.Errorf("%s was not written by hubfly; pass --force to replace it", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+func runServiceManager(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// enableLinger lets the user's systemd instance, and so the service, run
+// from boot and keep running after the user logs out.
+func enableLinger() error {
+	return runServiceManager("loginctl", "enable-linger")
+}
+
+func systemdServiceUnit(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	return fmt.Sprintf(`# %s; run it again to change this file.
+[Unit]
+Description=Hubfly tunnel service
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`, serviceUnitMarker, strings.Join(quoted, " "))
+}
+
+// systemdQuote escapes arg for an ExecStart line, where % starts a
+// specifier and $ a variable.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func launchdPlist(command []string, logPath string) string {
+	escape := func(s string) string {
+		var b bytes.Buffer
+		_ = xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	var program strings.Builder
+	for _, arg := range command {
+		program.WriteString("    <string>" + escape(arg) + "</string>\n")
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<!-- %s; run it again to change this file. -->
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>%s</string>
+  <key>ProgramArguments</key>
+  <array>
+%s  </array>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <dict>
+    <key>SuccessfulExit</key>
+    <false/>
+  </dict>
+  <key>StandardOutPath</key>
+  <string>%s</string>
+  <key>StandardErrorPath</key>
+  <string>%s</string>
+</dict>
+</plist>
+`, serviceUnitMarker, launchdLabel, program.String(), escape(logPath), escape(logPath))
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cli
+
+import "errors"
+
+func installWindowsService(string, []string) error {
+	return errors.New("Windows services can only be installed on Windows")
+}
+
+func removeWindowsService() (string, error) {
+	return "", nil
+}
+
+func windowsServiceStatus() (serviceUnit, bool) {
+	return serviceUnit{}, false
+}
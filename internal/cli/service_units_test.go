@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceUnitsQuoteTheCommand(t *testing.T) {
+	command := []string{"/opt/my tools/hubfly", "service", "--port", "5600"}
+	unit := systemdServiceUnit(command)
+	if want := `ExecStart="/opt/my tools/hubfly" service --port 5600`; !strings.Contains(unit, want+"\n") {
+		t.Errorf("systemd unit missing %q:\n%s", want, unit)
+	}
+	if got := systemdQuote("50%$HOME"); got != "50%%$$HOME" {
+		t.Errorf("systemdQuote = %q", got)
+	}
+
+	plist := launchdPlist([]string{"/Users/a&b/hubfly", "service"}, "/tmp/log")
+	if !strings.Contains(plist, "<string>/Users/a&amp;b/hubfly</string>") {
+		t.Errorf("plist does not escape the binary path:\n%s", plist)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hubfly-service.service")
+	if err := os.WriteFile(path, []byte("[Service]\nExecStart=/usr/bin/true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeServiceUnitFile(path, unit, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("replaced a hand-written unit: %v", err)
+	}
+	if err := writeServiceUnitFile(path, unit, true); err != nil {
+		t.Fatal(err)
+	}
+	if !isHubflyServiceUnit(path) {
+		t.Error("written unit is not recognised as hubfly's")
+	}
+	if err := writeServiceUnitFile(path, unit, false); err != nil {
+		t.Errorf("could not rewrite hubfly's own unit: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+//go:build windows
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"hubfly-cli/internal/service"
+)
+
+// installWindowsService registers an automatic-start service that runs
+// exe with args, or updates the one already registered, and starts it.
+// Creating a service needs an elevated (Administrator) prompt.
+func installWindowsService(exe string, args []string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to open the service manager (run from an Administrator prompt): %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	config := mgr.Config{
+		DisplayName: "Hubfly tunnel service",
+		Description: "Runs hubfly tunnels in the background.",
+		StartType:   mgr.StartAutomatic,
+	}
+	s, err := m.OpenService(service.WindowsServiceName)
+	if err == nil {
+		if err := stopWindowsService(s); err != nil {
+			_ = s.Close()
+			return err
+		}
+		current, err := s.Config()
+		if err != nil {
+			_ = s.Close()
+			return err
+		}
+		binaryPath := syscall.EscapeArg(exe)
+		for _, arg := range args {
+			binaryPath += " " + syscall.EscapeArg(arg)
+		}
+		current.BinaryPathName = binaryPath
+		current.DisplayName, current.Description, current.StartType = config.DisplayName, config.Description, config.StartType
+		if err := s.UpdateConfig(current); err != nil {
+			_ = s.Close()
+			return err
+		}
+	} else {
+		s, err = m.CreateService(service.WindowsServiceName, exe, config, args...)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() { _ = s.Close() }()
+
+	// The service runs as LocalSystem; pointing its profile at the
+	// installing user's keeps it on the same ~/.hubfly as the CLI.
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+service.WindowsServiceName, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	err = key.SetStringsValue("Environment", []string{"USERPROFILE=" + userHomeDir()})
+	_ = key.Close()
+	if err != nil {
+		return err
+	}
+
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: 5 * time.Second}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return err
+	}
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return err
+	}
+	return s.Start()
+}
+
+// removeWindowsService stops and deletes the service, returning its name,
+// or "" when it is not installed.
+func removeWindowsService() (string, error) {
+	if _, ok := windowsServiceStatus(); !ok {
+		return "", nil
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to open the service manager (run from an Administrator prompt): %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+	s, err := m.OpenService(service.WindowsServiceName)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = s.Close() }()
+	if err := stopWindowsService(s); err != nil {
+		return "", err
+	}
+	if err := s.Delete(); err != nil {
+		return "", err
+	}
+	return "Windows service " + service.WindowsServiceName, nil
+}
+
+// stopWindowsService asks s to stop and waits for it to drain its tunnels.
+func stopWindowsService(s *mgr.Service) error {
+	status, err := s.Query()
+	if err != nil || status.State == svc.Stopped {
+		return err
+	}
+	if status.State != svc.StopPending {
+		if status, err = s.Control(svc.Stop); err != nil {
+			return err
+		}
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return errors.New("the tunnel service did not stop within 30s")
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// windowsServiceStatus opens the service read-only, which unlike
+// installing or removing it does not need an elevated prompt.
+func windowsServiceStatus() (serviceUnit, bool) {
+	scm, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		return serviceUnit{}, false
+	}
+	defer func() { _ = windows.CloseServiceHandle(scm) }()
+	name, err := windows.UTF16PtrFromString(service.WindowsServiceName)
+	if err != nil {
+		return serviceUnit{}, false
+	}
+	h, err := windows.OpenService(scm, name, windows.SERVICE_QUERY_STATUS|windows.SERVICE_QUERY_CONFIG)
+	if err != nil {
+		return serviceUnit{}, false
+	}
+	s := &mgr.Service{Name: service.WindowsServiceName, Handle: h}
+	defer func() { _ = s.Close() }()
+	unit := serviceUnit{Manager: "windows", Name: service.WindowsServiceName}
+	if config, err := s.Config(); err == nil {
+		unit.Enabled = config.StartType == mgr.StartAutomatic
+	}
+	if status, err := s.Query(); err == nil {
+		unit.Running = status.State == svc.Running
+	}
+	return unit, true
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// readJSONSpec decodes a create spec given with --from-file into out. A
+// path of "-" reads standard input. Unknown fields are rejected so a typo
+// does not silently fall back to a default.
+func readJSONSpec(path string, out any) error {
+	var (
+		content []byte
+		err     error
+		source  = path
+	)
+	if path == "-" {
+		source = "standard input"
+		content, err = io.ReadAll(stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return fmt.Errorf("%s is empty", source)
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("invalid spec in %s: %w", source, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid spec in %s: expected a single JSON object", source)
+	}
+	return nil
+}
+
+// tunnelCreateSpec is the --from-file input of `hubfly tunnel create`: the
+// API's create payload, plus the project, which the API takes in the URL.
+// containerId may also be a container name.
+type tunnelCreateSpec struct {
+	ProjectID string `json:"projectId,omitempty"`
+	createTunnelRequest
+}
+
+func (s tunnelCreateSpec) validate() error {
+	switch s.Direction {
+	case "", tunnelDirectionReverse:
+	default:
+		return fmt.Errorf("unknown direction %q: leave it empty or use %q", s.Direction, tunnelDirectionReverse)
+	}
+	if s.Direction == tunnelDirectionReverse && s.LocalPort <= 0 {
+		return errors.New("reverse tunnels need localPort")
+	}
+	if s.TTLSeconds < 0 {
+		return errors.New("ttlSeconds must be positive")
+	}
+	return nil
+}
@@ -58,14 +58,14 @@ func saveTunnelTicket(t tunnel) error {
 	if strings.TrimSpace(t.TunnelID) == "" || strings.TrimSpace(t.ConnectToken) == "" {
 		return nil
 	}
-	if err := os.MkdirAll(tunnelsDir(), 0o700); err != nil {
+	if err := ensurePrivateDir(tunnelsDir()); err != nil {
 		return err
 	}
 	payload, err := json.Marshal(t)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(tunnelTicketPath(t.TunnelID), payload, 0o600)
+	return writePrivateFile(tunnelTicketPath(t.TunnelID), payload)
 }
 
 func loadTunnelTicket(tunnelID string) (tunnel, error) {
@@ -77,7 +77,45 @@ func loadTunnelTicket(tunnelID string) (tunnel, error) {
 	if err := json.Unmarshal(content, &t); err != nil {
 		return tunnel{}, err
 	}
-	return t, nil
+	for _, problem := range normalizeTunnel(&t) {
+		debugf("tunnel ticket %s: %s", tunnelID, problem)
+	}
+	return rebaseDemoTicket(t), nil
+}
+
+// listTunnelTickets returns every readable ticket in the tunnels directory.
+func listTunnelTickets() ([]tunnel, error) {
+	entries, err := os.ReadDir(tunnelsDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	tickets := make([]tunnel, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(tunnelsDir(), entry.Name()))
+		if err != nil {
+			continue
+		}
+		var t tunnel
+		if err := json.Unmarshal(content, &t); err != nil {
+			debugf("skipping unreadable tunnel ticket %s", entry.Name())
+			continue
+		}
+		for _, problem := range normalizeTunnel(&t) {
+			debugf("tunnel ticket %s: %s", entry.Name(), problem)
+		}
+		if t.TunnelID == "" {
+			debugf("skipping tunnel ticket %s without a tunnel id", entry.Name())
+			continue
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, nil
 }
 
 func removeTunnelTicket(tunnelID string) error {
@@ -119,6 +157,9 @@ func mergeTunnelMetadata(base, overlay tunnel) tunnel {
 	if strings.TrimSpace(base.Mode) == "" {
 		base.Mode = overlay.Mode
 	}
+	if strings.TrimSpace(base.Direction) == "" {
+		base.Direction = overlay.Direction
+	}
 	if len(base.Targets) == 0 {
 		base.Targets = overlay.Targets
 	}
@@ -168,6 +209,9 @@ func runTunnelConnection(t tunnel, _ string, localPort, targetPort int) error {
 	if err != nil {
 		return err
 	}
+	if err := checkTunnelConnectable(loaded, target); err != nil {
+		return err
+	}
 	if localPort <= 0 {
 		localPort = target.LocalPort
 	}
@@ -175,11 +219,35 @@ func runTunnelConnection(t tunnel, _ string, localPort, targetPort int) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	stats := newTunnelStats()
+	reportCtx, stopReport := context.WithCancel(context.Background())
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		stats.report(reportCtx)
+	}()
+	defer func() {
+		stopReport()
+		<-reported
+	}()
+	stats.write()
+
+	if isReverseTunnel(loaded) {
+		fmt.Println("Establishing reverse tunnel...")
+		fmt.Printf("Remote: %s:%d -> Local: localhost:%d\n", resolveTunnelForwardHost(loaded), target.TargetPort, localPort)
+		fmt.Printf("Gateway: %s\n", loaded.ConnectURL)
+		if err := serveReverseTunnel(ctx, loaded, target, localPort, stats); err != nil {
+			return err
+		}
+		_ = removeTunnelTicket(loaded.TunnelID)
+		return nil
+	}
+
 	fmt.Println("Establishing tunnel...")
 	fmt.Printf("Local: localhost:%d -> Remote: %s:%d\n", localPort, resolveTunnelForwardHost(loaded), target.TargetPort)
 	fmt.Printf("Gateway: %s\n", loaded.ConnectURL)
 
-	if err := serveTunnelGateway(ctx, loaded, target, localPort); err != nil {
+	if err := serveTunnelGateway(ctx, loaded, target, localPort, stats); err != nil {
 		return err
 	}
 	_ = removeTunnelTicket(loaded.TunnelID)
@@ -187,10 +255,23 @@ func runTunnelConnection(t tunnel, _ string, localPort, targetPort int) error {
 }
 
 func startTunnelConnectionBackground(t tunnel, _ string, localPort, targetPort int) (*exec.Cmd, error) {
+	return startTunnelProcess(t, localPort, targetPort, os.Stdout, os.Stderr)
+}
+
+// startTunnelProcess starts the process that serves a tunnel, writing its
+// output to stdout and stderr.
+func startTunnelProcess(t tunnel, localPort, targetPort int, stdout, stderr io.Writer) (*exec.Cmd, error) {
 	loaded, err := hydrateTunnelTicket(t)
 	if err != nil {
 		return nil, err
 	}
+	target, err := primaryTunnelTarget(loaded, targetPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTunnelConnectable(loaded, target); err != nil {
+		return nil, err
+	}
 	if err := saveTunnelTicket(loaded); err != nil {
 		return nil, err
 	}
@@ -206,11 +287,14 @@ func startTunnelConnectionBackground(t tunnel, _ string, localPort, targetPort i
 		strconv.Itoa(localPort),
 		strconv.Itoa(targetPort),
 	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// The process reports its state and traffic for the TUI to show.
+	cmd.Env = append(os.Environ(), "HUBFLY_TUNNEL_STATUS="+tunnelStatusPath(loaded.TunnelID, localPort))
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
+	rememberLocalPort(loaded.TunnelID, localPort)
 	return cmd, nil
 }
 
@@ -222,73 +306,80 @@ func connectStoredTunnelFlow(tunnelID string, localPort, targetPort int) error {
 	return runTunnelConnection(t, "", localPort, targetPort)
 }
 
+// serveTunnelGateway forwards connections on localPort through the gateway.
+// When the gateway session drops it is redialled with backoff while the
+// local listener stays open; connections arriving meanwhile are refused.
 func serveTunnelGateway(
 	ctx context.Context,
 	t tunnel,
 	target tunnelTarget,
 	localPort int,
+	stats *tunnelStats,
 ) error {
-	wsConfig, err := websocket.NewConfig(t.ConnectURL, apiHost)
+	session, err := dialTunnelSession(ctx, t)
 	if err != nil {
-		return fmt.Errorf("invalid tunnel connect url: %w", err)
+		return err
 	}
 
-	dialCtx, cancelDial := context.WithTimeout(ctx, tunnelDialTimeout)
-	defer cancelDial()
-	conn, err := wsConfig.DialContext(dialCtx)
+	listener, err := listenLocal(localPort)
 	if err != nil {
-		return fmt.Errorf("failed to connect to tunnel gateway: %w", err)
+		session.Close()
+		return err
 	}
-	defer conn.Close()
+	defer listener.Close()
 
-	if err := sendTunnelMessage(conn, tunnelClientMessage{
-		Type:            "authenticate",
-		ProtocolVersion: max(1, t.ProtocolVersion),
-		TunnelID:        t.TunnelID,
-		ConnectToken:    t.ConnectToken,
-	}); err != nil {
-		return fmt.Errorf("failed to authenticate tunnel session: %w", err)
+	fmt.Println("Tunnel connected.")
+	fmt.Println("Press Ctrl+C to stop.")
+	stats.setState(tunnelStateConnected, "")
+	fallback := newTargetFallback(t, target, stats)
+	if len(fallback.targets) > 1 {
+		debugf("tunnel %s: %d fallback target(s) after %s", t.TunnelID, len(fallback.targets)-1, describeTunnelTarget(target))
 	}
 
-	for {
-		var raw []byte
-		if err := websocket.Message.Receive(conn, &raw); err != nil {
-			return fmt.Errorf("tunnel handshake failed: %w", err)
-		}
-		var msg tunnelServerMessage
-		if err := json.Unmarshal(raw, &msg); err != nil {
-			continue
+	var (
+		sessionMu sync.Mutex
+		current   = session
+	)
+	currentSession := func() *yamux.Session {
+		sessionMu.Lock()
+		defer sessionMu.Unlock()
+		return current
+	}
+	superviseErrCh := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				superviseErrCh <- nil
+				return
+			case <-session.CloseChan():
+			}
+			sessionMu.Lock()
+			current = nil
+			sessionMu.Unlock()
+			if ctx.Err() != nil {
+				superviseErrCh <- nil
+				return
+			}
+			next, err := redialTunnelSession(ctx, t, stats)
+			if err != nil || next == nil {
+				superviseErrCh <- err
+				return
+			}
+			sessionMu.Lock()
+			current, session = next, next
+			sessionMu.Unlock()
 		}
-		switch msg.Type {
-		case "hello":
-			debugf("tunnel hello: protocol version %d", msg.ProtocolVersion)
-		case "authenticated":
-			goto authenticated
-		case "error":
-			return fmt.Errorf("tunnel session failed: %s", msg.Message)
+	}()
+	defer func() {
+		if s := currentSession(); s != nil {
+			_ = s.Close()
 		}
-	}
-
-authenticated:
-	session, err := yamux.Client(conn, nil)
-	if err != nil {
-		return fmt.Errorf("failed to initialize tunnel session: %w", err)
-	}
-	defer session.Close()
-
-	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
-	if err != nil {
-		return fmt.Errorf("failed to listen on localhost:%d: %w", localPort, err)
-	}
-	defer listener.Close()
-
-	fmt.Println("Tunnel connected.")
-	fmt.Println("Press Ctrl+C to stop.")
+	}()
 
 	go func() {
 		<-ctx.Done()
 		_ = listener.Close()
-		_ = session.Close()
 	}()
 
 	var wg sync.WaitGroup
@@ -304,26 +395,90 @@ authenticated:
 				acceptErrCh <- err
 				return
 			}
+			active := currentSession()
+			if active == nil {
+				_ = clientConn.Close()
+				continue
+			}
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				if err := proxyTunnelConnection(ctx, session, target, clientConn); err != nil {
+				picked := fallback.pick()
+				err := proxyTunnelConnection(ctx, active, picked, clientConn, stats)
+				if err != nil {
 					debugf("tunnel proxy error: %v", err)
 				}
+				fallback.report(picked, err)
 			}()
 		}
 	}()
 
+	var result error
 	select {
 	case <-ctx.Done():
-	case err := <-acceptErrCh:
-		if err != nil {
-			return err
+	case result = <-acceptErrCh:
+	case result = <-superviseErrCh:
+	}
+	_ = listener.Close()
+	if s := currentSession(); s != nil {
+		_ = s.Close()
+	}
+	wg.Wait()
+	return result
+}
+
+// dialTunnelSession opens the gateway websocket, authenticates the tunnel and
+// starts a yamux client on it. Closing the session closes the websocket.
+func dialTunnelSession(ctx context.Context, t tunnel) (*yamux.Session, error) {
+	wsConfig, err := websocket.NewConfig(t.ConnectURL, apiHost)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tunnel connect url: %w", err)
+	}
+
+	dialCtx, cancelDial := context.WithTimeout(ctx, tunnelDialTimeout)
+	defer cancelDial()
+	conn, err := wsConfig.DialContext(dialCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to tunnel gateway: %w", err)
+	}
+
+	if err := sendTunnelMessage(conn, tunnelClientMessage{
+		Type:            "authenticate",
+		ProtocolVersion: max(1, t.ProtocolVersion),
+		TunnelID:        t.TunnelID,
+		ConnectToken:    t.ConnectToken,
+	}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to authenticate tunnel session: %w", err)
+	}
+
+	for authenticated := false; !authenticated; {
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("tunnel handshake failed: %w", err)
+		}
+		var msg tunnelServerMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "hello":
+			debugf("tunnel hello: protocol version %d", msg.ProtocolVersion)
+		case "authenticated":
+			authenticated = true
+		case "error":
+			conn.Close()
+			return nil, &tunnelRejectedError{message: msg.Message}
 		}
 	}
 
-	wg.Wait()
-	return nil
+	session, err := yamux.Client(conn, nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialize tunnel session: %w", err)
+	}
+	return session, nil
 }
 
 func proxyTunnelConnection(
@@ -331,63 +486,81 @@ func proxyTunnelConnection(
 	session *yamux.Session,
 	target tunnelTarget,
 	clientConn net.Conn,
+	stats *tunnelStats,
 ) error {
 	defer clientConn.Close()
 
-	stream, err := session.OpenStream()
+	stream, reader, err := openTargetStream(session, target)
 	if err != nil {
 		return err
 	}
 	defer stream.Close()
 
+	stats.activeStreams.Add(1)
+	defer stats.activeStreams.Add(-1)
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		copyCounted(stream, clientConn, &stats.sent)
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		copyCounted(clientConn, reader, &stats.received)
+		cancel()
+	}()
+	<-copyCtx.Done()
+	_ = clientConn.Close()
+	_ = stream.Close()
+	wg.Wait()
+	return nil
+}
+
+// openTargetStream opens a gateway stream to target and waits for the
+// gateway to confirm it. The reader holds any bytes read past the reply.
+func openTargetStream(session *yamux.Session, target tunnelTarget) (*yamux.Stream, *bufio.Reader, error) {
+	stream, err := session.OpenStream()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	header, err := json.Marshal(tunnelStreamConnectRequest{
 		Type:     "connect",
 		TargetID: target.TargetID,
 	})
 	if err != nil {
-		return err
+		stream.Close()
+		return nil, nil, err
 	}
 	if _, err := stream.Write(append(header, '\n')); err != nil {
-		return err
+		stream.Close()
+		return nil, nil, err
 	}
 
 	reader := bufio.NewReader(stream)
 	line, err := reader.ReadBytes('\n')
 	if err != nil {
-		return fmt.Errorf("failed to read tunnel stream response: %w", err)
+		stream.Close()
+		return nil, nil, fmt.Errorf("failed to read tunnel stream response: %w", err)
 	}
 	var response tunnelStreamConnectResponse
 	if err := json.Unmarshal(bytesTrimSpace(line), &response); err != nil {
-		return fmt.Errorf("invalid tunnel stream response: %w", err)
+		stream.Close()
+		return nil, nil, fmt.Errorf("invalid tunnel stream response: %w", err)
 	}
 	if response.Type != "connected" {
+		stream.Close()
 		message := response.Message
 		if message == "" {
 			message = response.Code
 		}
-		return fmt.Errorf("tunnel stream rejected: %s", message)
+		return nil, nil, &streamRejectedError{message: message}
 	}
-
-	copyCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
-		_, _ = io.Copy(stream, clientConn)
-		cancel()
-	}()
-	go func() {
-		defer wg.Done()
-		_, _ = io.Copy(clientConn, reader)
-		cancel()
-	}()
-	<-copyCtx.Done()
-	_ = clientConn.Close()
-	_ = stream.Close()
-	wg.Wait()
-	return nil
+	return stream, reader, nil
 }
 
 func sendTunnelMessage(conn *websocket.Conn, msg tunnelClientMessage) error {
@@ -2,9 +2,7 @@ package cli
 
 import (
 	"errors"
-	"flag"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -51,10 +49,9 @@ examples:
 }
 
 func stackPlanFlow(args []string) error {
-	fs := flag.NewFlagSet("stack plan", flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("stack plan")
 	filePath := fs.String("file", "", "compose file path")
-	useJSON := fs.Bool("json", false, "print JSON-ish summary")
+	useJSON := fs.Bool("json", false, "print the plan as JSON")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -64,16 +61,8 @@ func stackPlanFlow(args []string) error {
 		return err
 	}
 
-	if *useJSON {
-		fmt.Printf("stack=%s file=%s services=%d volumes=%d\n", spec.Name, spec.FilePath, len(spec.Services), len(spec.Volumes))
-		for _, service := range spec.Services {
-			buildMode := "image"
-			if service.Build != nil {
-				buildMode = "build"
-			}
-			fmt.Printf("service=%s container=%s mode=%s depends=%s\n", service.Name, service.ContainerName, buildMode, strings.Join(service.DependsOn, ","))
-		}
-		return nil
+	if *useJSON || jsonOutput {
+		return printJSON(stackPlanJSON(spec))
 	}
 
 	fmt.Println("Hubfly Stack Plan")
@@ -101,9 +90,79 @@ func stackPlanFlow(args []string) error {
 	return nil
 }
 
+type stackPlanService struct {
+	Name      string          `json:"name"`
+	Container string          `json:"container"`
+	Mode      string          `json:"mode"`
+	Image     string          `json:"image,omitempty"`
+	DependsOn []string        `json:"dependsOn"`
+	Ports     []deployPort    `json:"ports"`
+	Resources deployResources `json:"resources"`
+	Mounts    int             `json:"mounts"`
+}
+
+type stackPlanVolume struct {
+	Name        string `json:"name"`
+	ManagedName string `json:"managedName"`
+	SizeGb      int    `json:"sizeGb"`
+	ReadOnly    bool   `json:"readOnly"`
+}
+
+type stackPlan struct {
+	Name       string             `json:"name"`
+	File       string             `json:"file"`
+	ProjectDir string             `json:"projectDir"`
+	Services   []stackPlanService `json:"services"`
+	Volumes    []stackPlanVolume  `json:"volumes"`
+	Warnings   []string           `json:"warnings"`
+}
+
+// stackPlanJSON is what `stack plan --json` prints. Lists are never null,
+// so scripts can iterate them without checking.
+func stackPlanJSON(spec stackSpec) stackPlan {
+	plan := stackPlan{
+		Name:       spec.Name,
+		File:       spec.FilePath,
+		ProjectDir: spec.ProjectDir,
+		Services:   []stackPlanService{},
+		Volumes:    []stackPlanVolume{},
+		Warnings:   append([]string{}, spec.Warnings...),
+	}
+	for _, service := range spec.Services {
+		mode := "image"
+		if service.Build != nil {
+			mode = "build"
+		}
+		plan.Services = append(plan.Services, stackPlanService{
+			Name:      service.Name,
+			Container: service.ContainerName,
+			Mode:      mode,
+			Image:     service.Image,
+			DependsOn: append([]string{}, service.DependsOn...),
+			Ports:     append([]deployPort{}, service.Ports...),
+			Resources: service.Resources,
+			Mounts:    len(service.Mounts),
+		})
+	}
+	names := make([]string, 0, len(spec.Volumes))
+	for name := range spec.Volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		volume := spec.Volumes[name]
+		plan.Volumes = append(plan.Volumes, stackPlanVolume{
+			Name:        name,
+			ManagedName: volume.ManagedName,
+			SizeGb:      volume.SizeGb,
+			ReadOnly:    volume.ReadOnly,
+		})
+	}
+	return plan
+}
+
 func stackUpFlow(args []string) error {
-	fs := flag.NewFlagSet("stack up", flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("stack up")
 	filePath := fs.String("file", "", "compose file path")
 	project := fs.String("project", "", "target project id, name, or 'new'")
 	region := fs.String("region", "", "target region id or name")
@@ -230,8 +289,7 @@ func stackUpFlow(args []string) error {
 }
 
 func stackStatusFlow(args []string) error {
-	fs := flag.NewFlagSet("stack status", flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("stack status")
 	filePath := fs.String("file", "", "compose file path")
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -276,8 +334,7 @@ func stackStatusFlow(args []string) error {
 }
 
 func stackLogsFlow(args []string) error {
-	fs := flag.NewFlagSet("stack logs", flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("stack logs")
 	filePath := fs.String("file", "", "compose file path")
 	follow := fs.Bool("follow", false, "follow logs")
 	fs.BoolVar(follow, "f", false, "follow logs")
@@ -384,8 +441,7 @@ func stackSSHFlow(args []string) error {
 }
 
 func stackDownFlow(args []string) error {
-	fs := flag.NewFlagSet("stack down", flag.ContinueOnError)
-	fs.SetOutput(io.Discard)
+	fs := newFlagSet("stack down")
 	filePath := fs.String("file", "", "compose file path")
 	removeVolumes := fs.Bool("volumes", false, "remove managed volumes")
 	autoApprove := fs.Bool("yes", false, "skip confirmation")
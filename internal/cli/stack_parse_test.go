@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -92,3 +93,58 @@ func TestStackDeployOrderResolvesDependencies(t *testing.T) {
 		t.Fatalf("unexpected order: %s, %s, %s", ordered[0].Name, ordered[1].Name, ordered[2].Name)
 	}
 }
+
+func TestStackPlanJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	compose := `name: sample
+services:
+  web:
+    image: nginx:1.25-alpine
+    depends_on:
+      - db
+  db:
+    build: .
+    volumes:
+      - db-data:/var/lib/postgresql/data
+volumes:
+  db-data:
+    x-hubfly:
+      sizeGb: 25
+`
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(compose), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := loadStackSpec(composePath)
+	if err != nil {
+		t.Fatalf("loadStackSpec returned error: %v", err)
+	}
+
+	payload, err := json.Marshal(stackPlanJSON(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan stackPlan
+	if err := json.Unmarshal(payload, &plan); err != nil {
+		t.Fatalf("stack plan --json is not JSON: %v\n%s", err, payload)
+	}
+	if plan.Name != "sample" || plan.File != composePath || plan.Warnings == nil {
+		t.Fatalf("stack plan --json = %s", payload)
+	}
+	if len(plan.Services) != 2 {
+		t.Fatalf("expected 2 services, got %d: %s", len(plan.Services), payload)
+	}
+	modes := map[string]stackPlanService{}
+	for _, service := range plan.Services {
+		modes[service.Name] = service
+	}
+	if web := modes["web"]; web.Container == "" || web.Mode != "image" || len(web.DependsOn) != 1 || web.DependsOn[0] != "db" {
+		t.Fatalf("web service = %+v", web)
+	}
+	if db := modes["db"]; db.Mode != "build" || db.Mounts != 1 || db.DependsOn == nil {
+		t.Fatalf("db service = %+v", db)
+	}
+	if len(plan.Volumes) != 1 || plan.Volumes[0].Name != "db-data" || plan.Volumes[0].SizeGb != 25 {
+		t.Fatalf("volumes = %+v", plan.Volumes)
+	}
+}
@@ -1,13 +1,19 @@
 package cli
 
 import (
-	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
 )
 
+// storageRoot replaces ~/.hubfly when set; demo mode points it at a scratch
+// directory.
+var storageRoot string
+
 func hubflyDir() string {
+	if storageRoot != "" {
+		return storageRoot
+	}
 	return filepath.Join(userHomeDir(), ".hubfly")
 }
 
@@ -19,6 +25,14 @@ func tunnelsDir() string {
 	return filepath.Join(hubflyDir(), "tunnels")
 }
 
+func stateDir() string {
+	return filepath.Join(hubflyDir(), "state")
+}
+
+func sessionsDir() string {
+	return filepath.Join(stateDir(), "sessions")
+}
+
 func configPath() string {
 	return filepath.Join(hubflyDir(), "config.json")
 }
@@ -32,34 +46,64 @@ func userHomeDir() string {
 }
 
 func getToken() (string, error) {
-	content, err := os.ReadFile(configPath())
+	cfg, err := loadStoreConfig()
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return "", nil
 		}
 		return "", err
 	}
-	var cfg storeConfig
-	if err := json.Unmarshal(content, &cfg); err != nil {
-		return "", err
+	name := currentProfileName(cfg)
+	if p := cfg.Profiles[name]; p != nil {
+		return p.Token, nil
+	}
+	if name == defaultProfileName {
+		return cfg.Token, nil
 	}
-	return cfg.Token, nil
+	return "", nil
 }
 
 func setToken(token string) error {
-	if err := os.MkdirAll(hubflyDir(), 0o700); err != nil {
+	return updateStoreConfig(func(cfg *storeConfig) error {
+		profileFor(cfg, currentProfileName(*cfg)).Token = token
+		return nil
+	})
+}
+
+// ensurePrivateDir creates dir (and parents) readable only by the current user.
+func ensurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return err
 	}
-	cfg := storeConfig{Token: token}
-	payload, err := json.Marshal(cfg)
-	if err != nil {
+	return restrictToOwner(dir)
+}
+
+// writePrivateFile writes credentials or session state readable only by the
+// current user.
+func writePrivateFile(path string, payload []byte) error {
+	if err := os.WriteFile(path, payload, 0o600); err != nil {
 		return err
 	}
-	return os.WriteFile(configPath(), payload, 0o600)
+	return restrictToOwner(path)
 }
 
+// deleteToken logs the active profile out. A config that no longer parses
+// is removed entirely so `hubfly logout` always gets the user unstuck.
 func deleteToken() error {
-	err := os.Remove(configPath())
+	err := updateStoreConfig(func(cfg *storeConfig) error {
+		name := currentProfileName(*cfg)
+		if p := cfg.Profiles[name]; p != nil {
+			p.Token = ""
+		}
+		if name == defaultProfileName {
+			cfg.Token = ""
+		}
+		return nil
+	})
+	var validationErr *configValidationError
+	if errors.As(err, &validationErr) {
+		err = os.Remove(configPath())
+	}
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
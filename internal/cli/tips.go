@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Usage is counted in ~/.hubfly/state/usage.json, which never leaves this
+// machine. Only the shape of a flow is kept: the command, and for tunnels
+// the container and port, never tokens or other arguments.
+
+const (
+	// usageWindow is how far back a flow's days are kept.
+	usageWindow = 14 * 24 * time.Hour
+	// tipMinDays is how many days in the window a flow must run on before
+	// a tip suggests a shortcut for it.
+	tipMinDays = 3
+	// tipQuietPeriod is how long a tip stays quiet after it was shown at
+	// the end of a command.
+	tipQuietPeriod = 7 * 24 * time.Hour
+)
+
+type usageLog struct {
+	Flows map[string]*usageStat `json:"flows"`
+	// TipsShown is when each tip was last printed after a command.
+	TipsShown map[string]string `json:"tipsShown,omitempty"`
+}
+
+type usageStat struct {
+	Count    int    `json:"count"`
+	LastUsed string `json:"lastUsed"`
+	// Days are the dates (YYYY-MM-DD) the flow ran on within usageWindow,
+	// oldest first.
+	Days []string `json:"days"`
+}
+
+// usageTip suggests a faster path for a flow the user repeats.
+type usageTip struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Command string `json:"command"`
+}
+
+func usageLogPath() string {
+	return filepath.Join(stateDir(), "usage.json")
+}
+
+func loadUsageLog() usageLog {
+	log := usageLog{Flows: map[string]*usageStat{}, TipsShown: map[string]string{}}
+	content, err := os.ReadFile(usageLogPath())
+	if err != nil {
+		return log
+	}
+	if err := json.Unmarshal(content, &log); err != nil {
+		debugf("ignoring unreadable %s: %v", usageLogPath(), err)
+	}
+	if log.Flows == nil {
+		log.Flows = map[string]*usageStat{}
+	}
+	if log.TipsShown == nil {
+		log.TipsShown = map[string]string{}
+	}
+	return log
+}
+
+func saveUsageLog(log usageLog) error {
+	if err := ensurePrivateDir(stateDir()); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(usageLogPath(), append(payload, '\n'))
+}
+
+// usageTrackingEnabled reports whether flows are counted. tips.track=false
+// and HUBFLY_NO_USAGE turn it off; demo runs are never counted.
+func usageTrackingEnabled() bool {
+	if demoMode || os.Getenv("HUBFLY_NO_USAGE") != "" {
+		return false
+	}
+	p := activeProfile()
+	return p.Tips == nil || p.Tips.Track == nil || *p.Tips.Track
+}
+
+// usageFlows names the flows a command line performs: the command itself,
+// a tunnel to a container and port, a saved tunnel, a --project it was
+// scoped to.
+func usageFlows(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok || cmd.hidden {
+		return nil
+	}
+	switch cmd.name {
+	case "help", "tips", "version":
+		return nil
+	}
+	rest := args[1:]
+	sub := ""
+	if len(rest) > 0 {
+		if _, ok := explainedCommands[cmd.name+" "+rest[0]]; ok {
+			sub, rest = rest[0], rest[1:]
+		}
+	}
+	flows := []string{"command:" + strings.TrimSpace(cmd.name+" "+sub)}
+
+	positional, flags := splitUsageArgs(rest)
+	if cmd.name == "tunnel" {
+		switch {
+		case (sub == "" || sub == "up") && len(positional) == 3 && flags["compose"] == "":
+			flows = append(flows, "tunnel:"+positional[0]+":"+positional[2])
+		case sub == "up" && len(positional) == 1 && flags["compose"] == "":
+			flows = append(flows, "saved:"+positional[0])
+		case sub == "create" && flags["container"] != "" && flags["port"] != "":
+			flows = append(flows, "tunnel:"+flags["container"]+":"+flags["port"])
+		}
+	}
+	if project := flags["project"]; project != "" {
+		flows = append(flows, "project:"+project)
+	}
+	return flows
+}
+
+// usageValueFlags are the flags that take a value as the next argument.
+var usageValueFlags = map[string]bool{
+	"project": true, "container": true, "port": true, "local-port": true,
+	"name": true, "ttl": true, "from-file": true, "compose": true,
+	"within": true, "exec": true, "timeout": true, "limit": true,
+}
+
+// splitUsageArgs separates positional arguments from flags. Everything
+// after "--" belongs to a remote command and is ignored.
+func splitUsageArgs(args []string) ([]string, map[string]string) {
+	var positional []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if target, ok := flagAliases[name]; ok {
+			name = target
+		}
+		if !hasValue && usageValueFlags[name] && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+	return positional, flags
+}
+
+// recordUsage counts the flows of a command that succeeded and returns a
+// tip for one of them, or "" when none is due.
+func recordUsage(args []string) string {
+	flows := usageFlows(args)
+	if len(flows) == 0 || !usageTrackingEnabled() {
+		return ""
+	}
+	now := time.Now()
+	log := loadUsageLog()
+	for _, flow := range flows {
+		stat := log.Flows[flow]
+		if stat == nil {
+			stat = &usageStat{}
+			log.Flows[flow] = stat
+		}
+		stat.Count++
+		stat.LastUsed = now.UTC().Format(time.RFC3339)
+		stat.Days = recentDays(append(stat.Days, now.Format(time.DateOnly)), now)
+	}
+
+	tip := ""
+	if tipsNoticeEnabled() {
+		relevant := make(map[string]bool, len(flows))
+		for _, flow := range flows {
+			relevant[flow] = true
+		}
+		for _, t := range usageTips(log, now) {
+			if !relevant[t.ID] {
+				continue
+			}
+			shown, err := time.Parse(time.RFC3339, log.TipsShown[t.ID])
+			if err == nil && now.Sub(shown) < tipQuietPeriod {
+				continue
+			}
+			log.TipsShown[t.ID] = now.UTC().Format(time.RFC3339)
+			tip = t.Message + " " + t.Command
+			break
+		}
+	}
+	if err := saveUsageLog(log); err != nil {
+		debugf("failed to record usage: %v", err)
+	}
+	return tip
+}
+
+// tipsNoticeEnabled reports whether a tip may be printed after a command.
+// Like the update notice, it is only shown to a person at a terminal.
+func tipsNoticeEnabled() bool {
+	if jsonOutput || os.Getenv("CI") != "" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// recentDays drops duplicate days and days older than usageWindow.
+func recentDays(days []string, now time.Time) []string {
+	cutoff := now.Add(-usageWindow).Format(time.DateOnly)
+	seen := map[string]bool{}
+	kept := days[:0]
+	for _, day := range days {
+		if day >= cutoff && !seen[day] {
+			seen[day] = true
+			kept = append(kept, day)
+		}
+	}
+	sort.Strings(kept)
+	return kept
+}
+
+// usageTips suggests shortcuts for the flows run on at least tipMinDays
+// days of the window, most frequent first.
+func usageTips(log usageLog, now time.Time) []usageTip {
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		debugf("tips: %v", err)
+	}
+	defaultProject := defaultProjectQuery()
+
+	type candidate struct {
+		tip  usageTip
+		days int
+	}
+	var candidates []candidate
+	for flow, stat := range log.Flows {
+		days := len(recentDays(append([]string(nil), stat.Days...), now))
+		if days < tipMinDays {
+			continue
+		}
+		kind, subject, _ := strings.Cut(flow, ":")
+		var tip usageTip
+		switch kind {
+		case "tunnel":
+			container, port, _ := strings.Cut(subject, ":")
+			remotePort, err := strconv.Atoi(port)
+			if err != nil {
+				continue
+			}
+			tip = tunnelTip(container, remotePort, days, saved)
+		case "project":
+			if subject == defaultProject {
+				continue
+			}
+			tip = usageTip{
+				Message: fmt.Sprintf("You passed --project %s on %d of the last 14 days. Make it the default instead:", subject, days),
+				Command: "hubfly use project " + subject,
+			}
+		default:
+			continue
+		}
+		tip.ID = flow
+		candidates = append(candidates, candidate{tip: tip, days: days})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].days != candidates[j].days {
+			return candidates[i].days > candidates[j].days
+		}
+		return candidates[i].tip.ID < candidates[j].tip.ID
+	})
+	tips := make([]usageTip, 0, len(candidates))
+	for _, c := range candidates {
+		tips = append(tips, c.tip)
+	}
+	return tips
+}
+
+func tunnelTip(container string, remotePort, days int, saved []savedTunnel) usageTip {
+	for _, s := range saved {
+		if s.Container == container && s.RemotePort == remotePort {
+			return usageTip{
+				Message: fmt.Sprintf("You opened a tunnel to %s:%d on %d of the last 14 days, and it is saved as %s. Start it by name:", container, remotePort, days, s.Name),
+				Command: "hubfly tunnel up " + s.Name,
+			}
+		}
+	}
+	return usageTip{
+		Message: fmt.Sprintf("You opened a tunnel to %s:%d on %d of the last 14 days. Save it once, then start it with `hubfly tunnel up %s`:", container, remotePort, days, container),
+		Command: fmt.Sprintf("hubfly tunnel save %s --container %s --port %d", container, container, remotePort),
+	}
+}
+
+func tipsCommand(args []string) error {
+	fs := newFlagSet("tips")
+	forget := fs.Bool("clear", false, "forget the recorded usage")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: hubfly tips [--clear]")
+	}
+
+	if *forget {
+		if err := os.Remove(usageLogPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]any{"cleared": true, "path": usageLogPath()})
+		}
+		fmt.Printf("Forgot the usage recorded in %s.\n", usageLogPath())
+		return nil
+	}
+
+	tips := usageTips(loadUsageLog(), time.Now())
+	if jsonOutput {
+		return printJSON(map[string]any{"tips": tips, "tracking": usageTrackingEnabled(), "path": usageLogPath()})
+	}
+	switch {
+	case !usageTrackingEnabled():
+		fmt.Println("Usage tracking is off (tips.track=false or HUBFLY_NO_USAGE), so there are no tips.")
+	case len(tips) == 0:
+		fmt.Println("No tips yet. They appear once you repeat a flow that has a shorter path.")
+	default:
+		for i, tip := range tips {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(tip.Message)
+			fmt.Println("  " + tip.Command)
+		}
+	}
+	fmt.Printf("\nUsage is counted in %s and never leaves this machine. `hubfly tips --clear` forgets it.\n", usageLogPath())
+	return nil
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsageFlowsAndTips(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Chdir(t.TempDir())
+
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"tunnels", "--auto-port", "db", "auto", "5432"}, []string{"command:tunnel", "tunnel:db:5432"}},
+		{[]string{"tunnel", "create", "--container=db", "--port", "5432", "--project", "shop"}, []string{"command:tunnel create", "tunnel:db:5432", "project:shop"}},
+		{[]string{"tunnel", "up", "--name", "dev", "db"}, []string{"command:tunnel up", "saved:db"}},
+		{[]string{"ssh", "web", "--", "ls", "--project", "x"}, []string{"command:ssh"}},
+		{[]string{"tips"}, nil},
+	} {
+		if got := usageFlows(tc.args); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("usageFlows(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	log := usageLog{Flows: map[string]*usageStat{
+		"tunnel:db:5432":  {Days: []string{"2026-03-17", "2026-03-18", "2026-03-19", "2026-03-20"}},
+		"tunnel:web:8080": {Days: []string{"2026-03-19", "2026-03-20"}},
+		"project:shop":    {Days: []string{"2026-03-01", "2026-03-02", "2026-03-18", "2026-03-19", "2026-03-20"}},
+	}}
+	tips := usageTips(log, now)
+	if len(tips) != 2 || tips[0].ID != "tunnel:db:5432" || tips[1].ID != "project:shop" {
+		t.Fatalf("tips = %+v, want db:5432 then project shop (only its last 3 days count)", tips)
+	}
+	if tips[0].Command != "hubfly tunnel save db --container db --port 5432" || tips[1].Command != "hubfly use project shop" {
+		t.Fatalf("tip commands = %q, %q", tips[0].Command, tips[1].Command)
+	}
+
+	if err := writeSavedTunnels([]savedTunnel{{Name: "pg", Container: "db", RemotePort: 5432}}); err != nil {
+		t.Fatal(err)
+	}
+	if tip := usageTips(log, now)[0]; tip.Command != "hubfly tunnel up pg" || !strings.Contains(tip.Message, "saved as pg") {
+		t.Fatalf("tip for a saved tunnel = %+v", tip)
+	}
+}
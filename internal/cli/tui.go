@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -30,6 +31,8 @@ type menuModel struct {
 
 func (m menuModel) Init() tea.Cmd { return nil }
 
+func (m menuModel) textFieldFocused() bool { return m.list.FilterState() == list.Filtering }
+
 func (m menuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -58,6 +61,14 @@ func (m menuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m menuModel) recordState() tuiState {
+	state := tuiState{Screen: m.list.Title}
+	if item, ok := m.list.SelectedItem().(listItem); ok {
+		state.Status = "cursor: " + item.title
+	}
+	return state
+}
+
 func (m menuModel) View() string {
 	if strings.TrimSpace(m.subtitle) == "" {
 		return m.list.View()
@@ -68,6 +79,9 @@ func (m menuModel) View() string {
 }
 
 func tuiPickOne(title, subtitle string, options []listOption) (int, bool, error) {
+	if !terminalSupportsVT() {
+		return plainPickOne(title, subtitle, options)
+	}
 	items := make([]list.Item, 0, len(options))
 	for _, opt := range options {
 		items = append(items, listItem{title: opt.Title, desc: opt.Desc})
@@ -85,12 +99,12 @@ func tuiPickOne(title, subtitle string, options []listOption) (int, bool, error)
 	l.Styles.FilterPrompt = l.Styles.FilterPrompt.Foreground(lipgloss.Color("10")).Bold(true)
 	l.Styles.FilterCursor = l.Styles.FilterCursor.Foreground(lipgloss.Color("10")).Bold(true)
 	m := menuModel{list: l, subtitle: subtitle}
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(recordTUI(m), teaProgramOptions()...)
 	result, err := p.Run()
 	if err != nil {
 		return 0, false, err
 	}
-	finalModel, ok := result.(menuModel)
+	finalModel, ok := unwrapRecordedModel(result).(menuModel)
 	if !ok {
 		return 0, true, fmt.Errorf("unexpected menu result")
 	}
@@ -175,6 +189,24 @@ func (m multiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m multiModel) recordState() tuiState {
+	state := tuiState{Screen: m.title}
+	if m.cursor < len(m.options) {
+		state.Status = fmt.Sprintf("cursor: %s, %d selected", m.options[m.cursor].Title, countSelected(m.selected))
+	}
+	return state
+}
+
+func countSelected(selected map[int]bool) int {
+	n := 0
+	for _, on := range selected {
+		if on {
+			n++
+		}
+	}
+	return n
+}
+
 func (m multiModel) View() string {
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
 	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
@@ -213,13 +245,17 @@ func tuiPickMany(title, subtitle string, options []listOption) ([]int, bool, err
 		return nil, true, nil
 	}
 
+	if !terminalSupportsVT() {
+		return plainPickMany(title, subtitle, options)
+	}
+
 	m := newMultiModel(title, subtitle, options)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(recordTUI(m), teaProgramOptions()...)
 	result, err := p.Run()
 	if err != nil {
 		return nil, false, err
 	}
-	finalModel, ok := result.(multiModel)
+	finalModel, ok := unwrapRecordedModel(result).(multiModel)
 	if !ok {
 		return nil, false, fmt.Errorf("unexpected multi-select result")
 	}
@@ -238,3 +274,79 @@ func tuiPickMany(title, subtitle string, options []listOption) ([]int, bool, err
 	}
 	return indices, false, nil
 }
+
+// plainPickOne is the numbered-prompt fallback used when the console cannot
+// render the bubbletea list (legacy conhost without VT processing).
+func plainPickOne(title, subtitle string, options []listOption) (int, bool, error) {
+	printPlainOptions(title, subtitle, options)
+	choice, err := promptNumber("Select an option (0 to cancel): ", len(options))
+	if err != nil {
+		return 0, false, err
+	}
+	if choice == 0 {
+		return 0, true, nil
+	}
+	return choice - 1, false, nil
+}
+
+func plainPickMany(title, subtitle string, options []listOption) ([]int, bool, error) {
+	printPlainOptions(title, subtitle, options)
+	for {
+		text, err := prompt("Select options (e.g. 1,3 or 'all'; empty to cancel): ")
+		if err != nil {
+			return nil, false, err
+		}
+		indices, ok := parsePlainSelection(text, len(options))
+		if !ok {
+			fmt.Println("Please enter numbers from the list separated by commas.")
+			continue
+		}
+		if len(indices) == 0 {
+			return nil, true, nil
+		}
+		return indices, false, nil
+	}
+}
+
+// printPlainOptions does not clear the screen: callers such as manageProject
+// print context (tables) right before asking for a choice.
+func printPlainOptions(title, subtitle string, options []listOption) {
+	fmt.Println(title)
+	if strings.TrimSpace(subtitle) != "" {
+		fmt.Println(subtitle)
+	}
+	for i, opt := range options {
+		fmt.Printf("  %d) %s\n", i+1, opt.Title)
+		if strings.TrimSpace(opt.Desc) != "" {
+			fmt.Printf("     %s\n", opt.Desc)
+		}
+	}
+	fmt.Println()
+}
+
+func parsePlainSelection(text string, count int) ([]int, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, true
+	}
+	if strings.EqualFold(text, "all") {
+		indices := make([]int, count)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices, true
+	}
+	seen := map[int]bool{}
+	indices := make([]int, 0)
+	for _, part := range strings.Split(text, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 1 || value > count {
+			return nil, false
+		}
+		if !seen[value-1] {
+			seen[value-1] = true
+			indices = append(indices, value-1)
+		}
+	}
+	return indices, true
+}
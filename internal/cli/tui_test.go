@@ -0,0 +1,604 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParsePlainSelection(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []int
+		ok   bool
+	}{
+		{in: "", want: nil, ok: true},
+		{in: "2", want: []int{1}, ok: true},
+		{in: "3, 1,3", want: []int{2, 0}, ok: true},
+		{in: "ALL", want: []int{0, 1, 2}, ok: true},
+		{in: "4", ok: false},
+		{in: "x", ok: false},
+	}
+	for _, tc := range cases {
+		got, ok := parsePlainSelection(tc.in, 3)
+		if ok != tc.ok {
+			t.Fatalf("parsePlainSelection(%q) ok = %v, want %v", tc.in, ok, tc.ok)
+		}
+		if tc.ok && !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("parsePlainSelection(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestProjectsTUIRetryPrompt(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.view = viewContainers
+	m.selectedContainer = container{ID: "c1", Name: "web"}
+
+	next, _ := m.Update(tunnelsLoadedMsg{err: errors.New("bad gateway")})
+	m = next.(projectsApp)
+	if m.retry == nil || m.view != viewContainers {
+		t.Fatalf("failed load: retry = %v, view = %v", m.retry, m.view)
+	}
+	if !strings.Contains(m.View(), "[r]etry / [s]kip / [a]bort") {
+		t.Fatalf("retry prompt not rendered:\n%s", m.View())
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = next.(projectsApp)
+	if m.retry != nil || cmd == nil {
+		t.Fatalf("retry: prompt = %v, cmd = %v", m.retry, cmd)
+	}
+
+	next, _ = m.Update(tunnelsLoadedMsg{err: errors.New("bad gateway")})
+	next, _ = next.(projectsApp).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = next.(projectsApp)
+	if m.retry != nil || m.view != viewContainerMenu {
+		t.Fatalf("skip: prompt = %v, view = %v", m.retry, m.view)
+	}
+}
+
+func TestProjectsTUIOptimisticTunnelChanges(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.view = viewTunnelsSingle
+	m.selectedContainer = container{ID: "c1", Name: "web"}
+	m.tunnels = []tunnel{{TunnelID: "t1", TargetContainerID: "c1"}, {TunnelID: "t2", TargetContainerID: "c1"}}
+	m.setTunnelSingleItems()
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = next.(projectsApp)
+	if m.confirm == nil || len(m.tunnels) != 2 || cmd != nil {
+		t.Fatalf("d deleted without asking: tunnels = %+v", m.tunnels)
+	}
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = next.(projectsApp)
+	if len(m.tunnels) != 1 || m.tunnels[0].TunnelID != "t2" || cmd == nil {
+		t.Fatalf("after d: tunnels = %+v, cmd = %v", m.tunnels, cmd)
+	}
+
+	// A refresh that lands before the delete finishes must not bring t1 back.
+	next, _ = m.Update(tunnelsLoadedMsg{tunnels: []tunnel{{TunnelID: "t1", TargetContainerID: "c1"}, {TunnelID: "t2", TargetContainerID: "c1"}}, reconcile: true})
+	m = next.(projectsApp)
+	if len(m.tunnels) != 1 || m.view != viewTunnelsSingle {
+		t.Fatalf("after refresh: tunnels = %+v, view = %v", m.tunnels, m.view)
+	}
+
+	next, _ = m.Update(tunnelDeletedMsg{tunnel: tunnel{TunnelID: "t1", TargetContainerID: "c1"}, index: 0, err: errors.New("forbidden")})
+	m = next.(projectsApp)
+	if len(m.tunnels) != 2 || m.tunnels[0].TunnelID != "t1" || m.errMsg == "" {
+		t.Fatalf("failed delete not rolled back: tunnels = %+v, err = %q", m.tunnels, m.errMsg)
+	}
+
+	next, cmd = m.Update(tunnelCreatedMsg{tunnel: tunnel{TunnelID: "t3"}, targetPort: 80})
+	m = next.(projectsApp)
+	if len(m.tunnels) != 3 || m.tunnels[2].TargetContainerID != "c1" || cmd == nil {
+		t.Fatalf("created tunnel not shown: tunnels = %+v", m.tunnels)
+	}
+}
+
+func TestProjectsTUIDeleteTunnelFromMenu(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.selectedContainer = container{ID: "c1", Name: "web"}
+	m.tunnels = []tunnel{{TunnelID: "t1", TargetContainerID: "c1"}}
+	m.view = viewContainerMenu
+	m.setContainerActionItems()
+	m.list.Select(3)
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewTunnelsSingle || !m.deletePicker {
+		t.Fatalf("view = %v, deletePicker = %v", m.view, m.deletePicker)
+	}
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if cmd != nil || !strings.Contains(m.View(), "Delete tunnel t1 and its local ticket and keys? [y/N]") {
+		t.Fatalf("enter did not ask first:\n%s", m.View())
+	}
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(projectsApp)
+	if m.confirm != nil || cmd != nil || len(m.tunnels) != 1 {
+		t.Fatalf("esc did not cancel: tunnels = %+v", m.tunnels)
+	}
+}
+
+func TestProjectsTUIKeymapAndHelp(t *testing.T) {
+	if _, err := newTUIKeymap(map[string]string{"back": "esc,d"}); err == nil {
+		t.Error("key bound to two actions accepted")
+	}
+	keys, err := newTUIKeymap(map[string]string{"delete": "x", "back": "esc,h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newProjectsApp("token", "")
+	m.keys = keys
+	m.view = viewTunnelsSingle
+	m.selectedContainer = container{ID: "c1", Name: "web"}
+	m.tunnels = []tunnel{{TunnelID: "t1", TargetContainerID: "c1"}}
+	m.setTunnelSingleItems()
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = next.(projectsApp)
+	if m.confirm != nil {
+		t.Fatal("d still deletes after delete was rebound")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = next.(projectsApp)
+	if m.confirm == nil {
+		t.Fatal("x did not delete")
+	}
+	m.confirm = nil
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	m = next.(projectsApp)
+	if view := m.View(); !m.help || !strings.Contains(view, "x") || !strings.Contains(view, "esc/h") {
+		t.Fatalf("help overlay:\n%s", view)
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	m = next.(projectsApp)
+	if m.help || m.view != viewTunnelsSingle {
+		t.Fatalf("closing help: help = %v, view = %v", m.help, m.view)
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	m = next.(projectsApp)
+	if m.view != viewContainerMenu {
+		t.Fatalf("h did not go back: view = %v", m.view)
+	}
+}
+
+func TestProjectsTUIBackgroundRefresh(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.refreshInterval = time.Minute
+	m.loading = nil
+	m.selectedProject = project{ID: "p1", Name: "shop"}
+	m.containers = []container{{ID: "c1", Name: "web", Status: "running"}}
+	m.view = viewContainers
+	m.setContainerItems()
+
+	m.lastKey = time.Now()
+	next, _ := m.Update(refreshTickMsg{})
+	if next.(projectsApp).busy() {
+		t.Fatal("refreshed while keys were being pressed")
+	}
+
+	m.lastKey = time.Time{}
+	next, cmd := m.Update(refreshTickMsg{})
+	m = next.(projectsApp)
+	if !m.loading[tuiDataContainers] || cmd == nil {
+		t.Fatalf("idle tick did not refresh: loading = %v", m.loading)
+	}
+
+	next, _ = m.Update(containersLoadedMsg{background: true, err: errors.New("gateway timeout")})
+	m = next.(projectsApp)
+	item, _ := m.list.SelectedItem().(appItem)
+	if m.view != viewContainers || m.busy() || !strings.Contains(item.desc, "stale") || !strings.Contains(m.View(), "Showing cached containers") {
+		t.Fatalf("failed refresh: view = %v, row = %q", m.view, item.desc)
+	}
+
+	next, _ = m.Update(containersLoadedMsg{background: true, containers: []container{{ID: "c1", Name: "web", Status: "stopped"}}})
+	m = next.(projectsApp)
+	item, _ = m.list.SelectedItem().(appItem)
+	if m.view != viewContainers || strings.Contains(item.desc, "stale") || m.containers[0].Status != "stopped" {
+		t.Fatalf("refresh not applied in place: view = %v, row = %q", m.view, item.desc)
+	}
+}
+
+func TestProjectsTUITunnelOverviewBulkDelete(t *testing.T) {
+	m := newProjectsApp("token", "")
+	entries := []projectTunnel{
+		{Project: project{Name: "staging"}, Tunnel: tunnel{TunnelID: "t1"}},
+		{Org: "acme", Project: project{Name: "prod"}, Tunnel: tunnel{TunnelID: "t2"}},
+		{Org: "acme", Project: project{Name: "prod"}, Tunnel: tunnel{TunnelID: "t3"}},
+	}
+	next, _ := m.Update(overviewLoadedMsg{entries: entries})
+	m = next.(projectsApp)
+	if m.view != viewTunnelOverview || !strings.Contains(m.View(), "acme/prod") {
+		t.Fatalf("overview not shown:\n%s", m.View())
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	next, cmd := next.(projectsApp).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = next.(projectsApp)
+	if len(m.overview) != 0 || cmd == nil {
+		t.Fatalf("bulk delete left %d tunnel(s), cmd = %v", len(m.overview), cmd)
+	}
+
+	next, _ = m.Update(overviewDeletedMsg{deleted: 2, failed: entries[2:], err: errors.New("forbidden")})
+	m = next.(projectsApp)
+	if len(m.overview) != 1 || m.overview[0].Tunnel.TunnelID != "t3" || m.errMsg == "" {
+		t.Fatalf("failed delete not restored: %+v, err = %q", m.overview, m.errMsg)
+	}
+}
+
+func TestProjectsTUILiveTunnelStatus(t *testing.T) {
+	t.Cleanup(func() { storageRoot = "" })
+	storageRoot = t.TempDir()
+	t.Setenv("HUBFLY_TUNNEL_STATUS", tunnelStatusPath("t1", 15432))
+	stats := newTunnelStats()
+	stats.sent.Add(2048)
+	stats.setRetry(3, time.Now().Add(4*time.Second), "gateway connection lost")
+
+	m := newProjectsApp("token", "")
+	plans := []multiTunnelPlan{{tunnel: tunnel{TunnelID: "t1"}, localPort: 15432}}
+	next, cmd := m.Update(multiStartMsg{cmds: []*exec.Cmd{nil}, plans: plans, events: make(chan multiEvent)})
+	m = next.(projectsApp)
+	if !m.liveTicking || cmd == nil || !strings.Contains(m.View(), "starting...") {
+		t.Fatalf("live polling not started:\n%s", m.View())
+	}
+
+	next, cmd = m.Update(tunnelLiveTickMsg(time.Now()))
+	m = next.(projectsApp)
+	view := m.View()
+	if cmd == nil || !strings.Contains(view, "reconnecting, attempt 3 in 4s") || !strings.Contains(view, "sent 2.0 KB") {
+		t.Fatalf("live status not shown:\n%s", view)
+	}
+
+	m.view = viewContainerMenu
+	next, cmd = m.Update(tunnelLiveTickMsg(time.Now()))
+	if next.(projectsApp).liveTicking || cmd != nil {
+		t.Fatal("polling continued after leaving the running view")
+	}
+}
+
+func TestProjectsTUIRunningTunnelsDashboard(t *testing.T) {
+	t.Cleanup(func() { storageRoot = "" })
+	storageRoot = t.TempDir()
+	for _, s := range []tunnelSession{
+		{Name: "api", PID: os.Getpid(), TunnelID: "t1", ProjectID: "p1", Container: "api", LocalPort: 18080, TargetPort: 80},
+		{Name: "db", TunnelID: "t2", ProjectID: "p2", Container: "postgres", LocalPort: 15432, TargetPort: 5432},
+	} {
+		if err := saveTunnelSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HUBFLY_TUNNEL_STATUS", tunnelStatusPath("t1", 18080))
+	stats := newTunnelStats()
+	stats.received.Add(4096)
+	stats.setState(tunnelStateConnected, "")
+
+	m := newProjectsApp("token", "")
+	m.projects = []project{{ID: "p1", Name: "shop"}}
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	m = next.(projectsApp)
+	view := m.View()
+	if m.view != viewTunnelDashboard || cmd == nil || !strings.Contains(view, "localhost:18080 -> api:80 | shop | connected") || !strings.Contains(view, "p2 | exited") {
+		t.Fatalf("dashboard not shown:\n%s", view)
+	}
+
+	m.list.Select(1)
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	next, cmd = next.(projectsApp).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd == nil {
+		t.Fatal("stop was not confirmed")
+	}
+	next, _ = next.(projectsApp).Update(cmd())
+	m = next.(projectsApp)
+	if len(m.dashboard) != 1 || m.dashboard[0].Name != "api" || m.status != "Stopped db" {
+		t.Fatalf("after stop: %+v, status %q", m.dashboard, m.status)
+	}
+}
+
+func TestProjectsTUIRenameContainer(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.containers = []container{{ID: "c1", Name: "web"}}
+	m.selectedContainer = m.containers[0]
+	m.view = viewContainerMenu
+	m.setContainerActionItems()
+	m.list.Select(5)
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewTextInput || m.input.Value() != "web" {
+		t.Fatalf("view = %v, input = %q", m.view, m.input.Value())
+	}
+	m.input.SetValue("Bad Name")
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewTextInput || m.errMsg == "" || cmd != nil {
+		t.Fatalf("invalid name submitted: view = %v, err = %q", m.view, m.errMsg)
+	}
+	m.input.SetValue("storefront")
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewContainerMenu || cmd == nil {
+		t.Fatalf("rename not sent: view = %v", m.view)
+	}
+
+	renamed := container{ID: "c1", Name: "storefront"}
+	next, _ = m.Update(containerUpdatedMsg{container: renamed, what: "name"})
+	m = next.(projectsApp)
+	if m.containers[0].Name != "storefront" || m.selectedContainer.Name != "storefront" {
+		t.Fatalf("rename not applied: %+v", m.containers)
+	}
+}
+
+func TestProjectsTUILogsViewer(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.selectedContainer = container{ID: "c1", Name: "web"}
+	m.view = viewContainerMenu
+	m.setContainerActionItems()
+	m.list.Select(7)
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewLogs || !m.logsFollow || cmd == nil {
+		t.Fatalf("view = %v, follow = %v", m.view, m.logsFollow)
+	}
+
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	next, cmd = m.Update(containerLogsMsg{gen: m.logsGen, logs: containerLogsOutput{Stdout: strings.Join(lines, "\n")}})
+	m = next.(projectsApp)
+	if !m.logs.AtBottom() || !strings.Contains(m.View(), "line 99") || cmd == nil {
+		t.Fatalf("follow did not show the newest lines or keep polling")
+	}
+
+	// A result from before the view was reopened is ignored.
+	next, _ = m.Update(containerLogsMsg{gen: m.logsGen - 1, logs: containerLogsOutput{Stdout: "stale"}})
+	m = next.(projectsApp)
+	if strings.Contains(m.View(), "stale") {
+		t.Fatal("stale logs result applied")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = next.(projectsApp)
+	if m.logsFollow {
+		t.Fatal("scrolling up did not pause follow")
+	}
+	next, cmd = m.Update(logsTickMsg{gen: m.logsGen})
+	m = next.(projectsApp)
+	if cmd != nil {
+		t.Fatal("paused view still polls")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(projectsApp)
+	if m.view != viewContainerMenu {
+		t.Fatalf("esc: view = %v", m.view)
+	}
+}
+
+func TestProjectsTUIContainerLifecycleConfirm(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.containers = []container{{ID: "c1", Name: "web", Status: "running"}}
+	m.selectedContainer = m.containers[0]
+	m.view = viewContainerMenu
+	m.setContainerActionItems()
+	m.list.Select(9)
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.confirm == nil || cmd != nil || !strings.Contains(m.View(), "Stop container web? [y/N]") {
+		t.Fatalf("no confirmation before stopping: %+v", m.confirm)
+	}
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = next.(projectsApp)
+	if m.confirm != nil || cmd != nil {
+		t.Fatal("n did not cancel")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = next.(projectsApp)
+	if m.confirm != nil || cmd == nil {
+		t.Fatal("y did not run the action")
+	}
+
+	stopped := container{ID: "c1", Name: "web", Status: "stopped"}
+	next, _ = m.Update(containerActionMsg{action: containerActionStop, container: stopped})
+	m = next.(projectsApp)
+	if m.containers[0].Status != "stopped" || m.selectedContainer.Status != "stopped" {
+		t.Fatalf("status not refreshed: %+v", m.containers)
+	}
+}
+
+func TestProjectsTUINewContainerWizard(t *testing.T) {
+	m := newProjectsApp("token", "")
+	m.selectedProject = project{ID: "p1", Name: "shop"}
+	m.view = viewProjectMenu
+	m.setProjectActionItems()
+	m.list.Select(2)
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewNewContainer {
+		t.Fatalf("view = %v", m.view)
+	}
+	submit := func(value string) {
+		t.Helper()
+		m.input.SetValue(value)
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = next.(projectsApp)
+	}
+	submit("Bad Name")
+	if m.newContainer.step != createStepName || m.errMsg == "" {
+		t.Fatal("invalid name accepted")
+	}
+	submit("cache")
+	submit("redis:7")
+	submit("shared")
+	submit("6379, 8001:8001")
+	submit("REDIS_ARGS=--appendonly yes")
+	submit("")
+	if m.newContainer.step != createStepReview {
+		t.Fatalf("step = %d, err = %q", m.newContainer.step, m.errMsg)
+	}
+	f := m.newContainer
+	if f.name != "cache" || f.image != "redis:7" || f.tier != "shared" || len(f.ports) != 2 || len(f.env) != 1 || f.env[0].Value != "--appendonly yes" {
+		t.Fatalf("form = %+v", f)
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(projectsApp)
+	if m.view != viewProvisioning || cmd == nil {
+		t.Fatalf("create not sent: view = %v", m.view)
+	}
+	next, cmd = m.Update(containerCreatedMsg{gen: m.createGen, id: "c9"})
+	m = next.(projectsApp)
+	if m.provisionID != "c9" || cmd == nil {
+		t.Fatal("provisioning not followed")
+	}
+	pending := container{ID: "c9", Name: "cache", Status: "provisioning"}
+	next, cmd = m.Update(provisionStatusMsg{gen: m.createGen, container: pending, found: true})
+	m = next.(projectsApp)
+	if m.provisionStatus != "provisioning" || cmd == nil {
+		t.Fatal("polling stopped early")
+	}
+	running := container{ID: "c9", Name: "cache", Status: "running"}
+	gen := m.createGen
+	next, cmd = m.Update(provisionStatusMsg{gen: gen, container: running, found: true})
+	m = next.(projectsApp)
+	if m.createGen == gen || cmd == nil || !strings.Contains(m.status, "running") {
+		t.Fatalf("running container not picked up: status = %q", m.status)
+	}
+}
+
+func TestNewContainerPayload(t *testing.T) {
+	ports, err := parseFormPorts("80, 8443:443, 53/udp")
+	if err != nil || len(ports) != 3 || ports[1].Host != 8443 || ports[2].Protocol != "UDP" {
+		t.Fatalf("parseFormPorts = %+v, %v", ports, err)
+	}
+	if _, err := parseFormPorts("http"); err == nil {
+		t.Error("non-numeric port accepted")
+	}
+	payload := newContainerPayload("p1", newContainerForm{name: "web", image: "nginx:1.27", tier: "shared", ports: ports})
+	resources := payload["resources"].(map[string]any)
+	if payload["tier"] != "shared" || resources["ram"] != 256 {
+		t.Fatalf("shared tier limits not applied: %+v", payload)
+	}
+	if source := payload["source"].(map[string]any); source["dockerImage"] != "nginx:1.27" {
+		t.Fatalf("source = %+v", source)
+	}
+}
+
+func TestHelpBrowserSearchesHelpText(t *testing.T) {
+	tunnel, _ := findCommand("tunnel")
+	flags := strings.Join(commandFlags(tunnel), ", ")
+	for _, want := range []string{"--ttl <duration>", "--project <id|name>", "--compose <file>", "--all"} {
+		if !strings.Contains(flags, want) {
+			t.Errorf("tunnel flags %q lack %q", flags, want)
+		}
+	}
+	update, _ := findCommand("update")
+	if flags := commandFlags(update); !reflect.DeepEqual(flags[:3], []string{"--check", "--force", "--channel stable|beta"}) {
+		t.Errorf("update flags = %q", flags)
+	}
+
+	m := newHelpBrowserModel()
+	m.list.SetFilterText("ttl")
+	var names []string
+	for _, item := range m.list.VisibleItems() {
+		names = append(names, item.(helpTopic).name)
+	}
+	if !reflect.DeepEqual(names, []string{"tunnel"}) {
+		t.Fatalf("searching ttl found %v, want only tunnel", names)
+	}
+	if view := m.View(); !strings.Contains(view, "hubfly tunnel create --container") {
+		t.Fatalf("detail pane does not show the tunnel help:\n%s", view)
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil || cmd() != tea.Quit() {
+		t.Fatal("q did not quit the browser")
+	}
+}
+
+func TestProjectsTUILoadsMoreProjectsNearTheEnd(t *testing.T) {
+	m := newProjectsApp("token", "")
+	var page []project
+	for i := range 8 {
+		page = append(page, project{ID: fmt.Sprintf("p%d", i), Name: fmt.Sprintf("project %d", i)})
+	}
+	next, _ := m.Update(projectsLoadedMsg{projects: page, next: "cursor=2", pages: 1})
+	m = next.(projectsApp)
+	if m.loadMoreProjects() != nil {
+		t.Fatal("next page requested with the cursor at the top")
+	}
+	for range 3 {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = next.(projectsApp)
+	}
+	if !m.loading[tuiDataProjects] {
+		t.Fatal("next page not requested near the end of the list")
+	}
+	next, _ = m.Update(projectsLoadedMsg{projects: []project{{ID: "p8", Name: "project 8"}}, pages: 1, more: true})
+	m = next.(projectsApp)
+	if len(m.list.Items()) != 9 || m.projectsNext != "" || m.projectPages != 2 || m.list.Index() != 3 {
+		t.Fatalf("after the next page: items = %d, next = %q, pages = %d, cursor = %d", len(m.list.Items()), m.projectsNext, m.projectPages, m.list.Index())
+	}
+}
+
+func TestProjectsTUIMultiRunRowDetails(t *testing.T) {
+	cmds := []*exec.Cmd{
+		exec.Command("hubfly", "__connect-tunnel", "t1", "15432", "5432"),
+		exec.Command("hubfly", "__connect-tunnel", "t2", "18080", "80"),
+	}
+	output := &outputTail{}
+	fmt.Fprint(output, "connecting\r\ngateway refused the session\nretry")
+	m := newProjectsApp("token", "")
+	next, _ := m.Update(multiStartMsg{
+		cmds:    cmds,
+		outputs: []*outputTail{{}, output},
+		plans:   []multiTunnelPlan{{tunnel: tunnel{TunnelID: "t1"}, localPort: 15432}, {tunnel: tunnel{TunnelID: "t2"}, localPort: 18080}},
+		events:  make(chan multiEvent, 4),
+	})
+	m = next.(projectsApp)
+
+	next, _ = m.Update(multiEventMsg{event: multiEvent{index: 1, cmd: exec.Command("hubfly"), err: errors.New("stale")}})
+	m = next.(projectsApp)
+	if m.multiRunningState[1] != "running" {
+		t.Fatal("the exit of a replaced process ended the tunnel")
+	}
+	next, _ = m.Update(multiEventMsg{event: multiEvent{index: 1, cmd: cmds[1], err: errors.New("exit status 1")}})
+	m = next.(projectsApp)
+	view := m.View()
+	if m.view != viewRunningMulti || m.errMsg != "" || m.multiExpanded[0] || !m.multiExpanded[1] {
+		t.Fatalf("after t2 failed: view = %v, errMsg = %q, expanded = %v", m.view, m.errMsg, m.multiExpanded)
+	}
+	for _, want := range []string{"error: exit status 1", "      gateway refused the session\n      retry\n", "command: hubfly __connect-tunnel t2 18080 80"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expanded row lacks %q:\n%s", want, view)
+		}
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	next, _ = next.(projectsApp).Update(tea.KeyMsg{Type: tea.KeyDown})
+	next, cmd := next.(projectsApp).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = next.(projectsApp)
+	if !m.multiExpanded[0] || m.multiCursor != 1 || cmd == nil || m.multiRunningState[1] != "restarting" {
+		t.Fatalf("expanded = %v, cursor = %d, state = %q", m.multiExpanded, m.multiCursor, m.multiRunningState[1])
+	}
+	restarted := exec.Command("hubfly")
+	next, _ = m.Update(multiRestartMsg{index: 1, cmd: restarted, output: &outputTail{}})
+	m = next.(projectsApp)
+	if m.multiRunningCmds[1] != restarted || m.multiRunningState[1] != "running" || m.multiRunningErrs[1] != "" {
+		t.Fatalf("after restart: state = %q, err = %q", m.multiRunningState[1], m.multiRunningErrs[1])
+	}
+}
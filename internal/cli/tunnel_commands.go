@@ -0,0 +1,357 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func tunnelCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list", "ls":
+			return tunnelListFlow(args[1:])
+		case "create":
+			return tunnelCreateFlow(args[1:])
+		case "delete", "rm":
+			return tunnelDeleteFlow(args[1:])
+		case "up":
+			return tunnelUpFlow(args[1:])
+		case "reverse":
+			return tunnelReverseFlow(args[1:])
+		case "save":
+			return tunnelSaveFlow(args[1:])
+		case "saved":
+			return tunnelSavedFlow(args[1:])
+		case "down":
+			return tunnelDownFlow(args[1:])
+		case "ps":
+			return tunnelPsFlow(args[1:])
+		case "plan":
+			return tunnelPlanFlow(args[1:])
+		case "check-expiry":
+			return tunnelCheckExpiryFlow(args[1:])
+		case "healthcheck":
+			return tunnelHealthcheckFlow(args[1:])
+		}
+	}
+
+	fs := newFlagSet("tunnel")
+	autoPort := fs.Bool("auto-port", false, "use the next free local port when the chosen one is taken")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) != 3 {
+		return errors.New(tunnelUsage())
+	}
+	targetPort, err := strconv.Atoi(args[2])
+	if err != nil || targetPort <= 0 {
+		return errors.New("invalid target port")
+	}
+	localPort, err := resolveLocalPort(workspaceLocalPort(args[1], args[0], targetPort), targetPort)
+	if err != nil {
+		return err
+	}
+	if localPort, err = ensureLocalPortFree(localPort, *autoPort); err != nil {
+		return err
+	}
+	return tunnelFlow(args[0], localPort, targetPort)
+}
+
+func tunnelUsage() string {
+	return strings.TrimSpace(`
+usage: hubfly tunnel [--auto-port] <containerIdOrName> <localPort|auto> <targetPort>
+       hubfly tunnel list [--project <id|name> | --all-projects]
+       hubfly tunnel create --container <idOrName> --port <targetPort> [--project <id|name>] [--local-port <port>] [--ttl <duration>] [--from-file <path|->]
+       hubfly tunnel delete <tunnelId>
+       hubfly tunnel up [--name <name>] [--auto-port] <containerIdOrName> <localPort|auto> <targetPort>
+       hubfly tunnel up [--name <name>] [--auto-port] <savedName>
+       hubfly tunnel up --compose <file> [--project <id|name>] [--auto-port] [--auto-approve]
+       hubfly tunnel plan --compose <file> [--project <id|name>]
+       hubfly tunnel reverse <containerIdOrName> <remotePort> <localPort>
+       hubfly tunnel save <name> --container <idOrName> --port <remotePort> [--project <id|name>] [--local-port <port>] [--reverse]
+       hubfly tunnel saved [rm <name>]
+       hubfly tunnel ps
+       hubfly tunnel down <name> | --all
+       hubfly tunnel check-expiry [--within <duration>] [--exec <command>] [--skip-expired]
+       hubfly tunnel healthcheck [--probe] [--timeout <duration>]
+`)
+}
+
+type tunnelListEntry struct {
+	TunnelID      string `json:"tunnelId"`
+	Org           string `json:"org,omitempty"`
+	ProjectID     string `json:"projectId"`
+	ProjectName   string `json:"projectName"`
+	ContainerID   string `json:"containerId"`
+	ContainerName string `json:"containerName"`
+	TargetPort    int    `json:"targetPort"`
+	Mode          string `json:"mode"`
+	Direction     string `json:"direction,omitempty"`
+	ExpiresAt     string `json:"expiresAt"`
+	State         string `json:"state"`
+	LocalTicket   bool   `json:"localTicket"`
+}
+
+func tunnelListFlow(args []string) error {
+	fs := newFlagSet("tunnel list")
+	projectQuery := fs.String("project", "", "limit the listing to one project id or name")
+	allProjects := fs.Bool("all-projects", false, "include the projects of every organization")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *allProjects && strings.TrimSpace(*projectQuery) != "" {
+		return errors.New("--project and --all-projects cannot be combined")
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	entries := make([]tunnelListEntry, 0)
+	add := func(org string, p project, t tunnel) {
+		_, ticketErr := loadTunnelTicket(t.TunnelID)
+		entries = append(entries, tunnelListEntry{
+			TunnelID:      t.TunnelID,
+			Org:           org,
+			ProjectID:     p.ID,
+			ProjectName:   p.Name,
+			ContainerID:   t.TargetContainerID,
+			ContainerName: resolveTunnelForwardHost(t),
+			TargetPort:    selectedPrimaryPort(t),
+			Mode:          t.Mode,
+			Direction:     t.Direction,
+			ExpiresAt:     t.ExpiresAt,
+			State:         tunnelState(t.ExpiresAt),
+			LocalTicket:   ticketErr == nil,
+		})
+	}
+	if *allProjects {
+		all, failures, err := fetchAllProjectTunnels(token)
+		if err != nil {
+			return err
+		}
+		for _, failure := range failures {
+			fmt.Fprintf(os.Stderr, "warning: could not list tunnels of %v\n", failure)
+		}
+		for _, e := range all {
+			add(e.Org, e.Project, e.Tunnel)
+		}
+	} else {
+		err = forEachProjectTunnel(token, *projectQuery, func(p project, t tunnel) { add("", p, t) })
+		if err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No tunnels found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	if *allProjects {
+		_, _ = fmt.Fprintln(tw, "Tunnel ID\tOrg\tProject\tTarget\tExpires\tState\tTicket")
+	} else {
+		_, _ = fmt.Fprintln(tw, "Tunnel ID\tProject\tTarget\tExpires\tState\tTicket")
+	}
+	for _, e := range entries {
+		ticket := "missing"
+		if e.LocalTicket {
+			ticket = "ok"
+		}
+		if *allProjects {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t", e.TunnelID, valueOrDash(e.Org))
+		} else {
+			_, _ = fmt.Fprintf(tw, "%s\t", e.TunnelID)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s:%d\t%s\t%s\t%s\n",
+			e.ProjectName, e.ContainerName, e.TargetPort, valueOrDash(e.ExpiresAt), e.State, ticket)
+	}
+	return tw.Flush()
+}
+
+// forEachProjectTunnel calls fn for every tunnel in the project matching
+// projectQuery, or in every project when the query is empty.
+func forEachProjectTunnel(token, projectQuery string, fn func(p project, t tunnel)) error {
+	projects, err := fetchProjects(token)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(projectQuery) != "" {
+		selected, ok := resolveRequestedProject(projects, strings.TrimSpace(projectQuery))
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectQuery)
+		}
+		projects = []project{selected}
+	}
+	for _, p := range projects {
+		tunnels, err := fetchTunnels(token, p.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch tunnels for %s: %w", p.Name, err)
+		}
+		for _, t := range tunnels {
+			fn(p, t)
+		}
+	}
+	return nil
+}
+
+// tunnelCreateFlow creates a tunnel and stores its ticket without connecting,
+// so scripts can create now and `tunnel up` or the service can connect later.
+func tunnelCreateFlow(args []string) error {
+	const usage = "usage: hubfly tunnel create --container <idOrName> --port <targetPort> [--project <id|name>] [--local-port <port>] [--ttl <duration>] [--from-file <path|->]"
+	fs := newFlagSet("tunnel create")
+	containerQuery := fs.String("container", "", "container id or name")
+	targetPort := fs.Int("port", 0, "container port to expose")
+	projectQuery := fs.String("project", "", "project id or name to search")
+	localPort := fs.Int("local-port", 0, "preferred local port recorded on the tunnel")
+	ttl := fs.Duration("ttl", 0, "tunnel lifetime; the server default applies when unset")
+	fromFile := fs.String("from-file", "", "read the create payload from a JSON file, or - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New(usage)
+	}
+	var spec tunnelCreateSpec
+	if *fromFile != "" {
+		if err := readJSONSpec(*fromFile, &spec); err != nil {
+			return err
+		}
+		if err := spec.validate(); err != nil {
+			return err
+		}
+	}
+	// Flags given on the command line override the file.
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "container":
+			spec.ContainerID = *containerQuery
+		case "port":
+			spec.TargetPort = *targetPort
+		case "project":
+			spec.ProjectID = *projectQuery
+		case "local-port":
+			spec.LocalPort = *localPort
+		case "ttl":
+			spec.TTLSeconds = int(ttl.Seconds())
+		}
+	})
+	if *ttl < 0 {
+		return errors.New("--ttl must be positive")
+	}
+	if spec.LocalPort == 0 && spec.Direction != tunnelDirectionReverse {
+		if port, err := strconv.Atoi(workspaceLocalPort("auto", spec.ContainerID, spec.TargetPort)); err == nil {
+			spec.LocalPort = port
+		}
+	}
+	if strings.TrimSpace(spec.ContainerID) == "" || spec.TargetPort <= 0 {
+		return errors.New(usage)
+	}
+	if spec.LocalPort < 0 || spec.LocalPort > 65535 || spec.TargetPort > 65535 {
+		return errors.New("ports must be between 1 and 65535")
+	}
+	if spec.LocalPort > 0 && spec.Direction != tunnelDirectionReverse {
+		if _, err := resolveLocalPort(strconv.Itoa(spec.LocalPort), spec.TargetPort); err != nil {
+			return err
+		}
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	p, c, err := findContainerInProjects(token, spec.ProjectID, strings.TrimSpace(spec.ContainerID))
+	if err != nil {
+		return err
+	}
+	req := spec.createTunnelRequest
+	req.ContainerID = c.ID
+	created, err := createTunnel(token, p.ID, req)
+	if err != nil {
+		return fmt.Errorf("failed to create tunnel: %w", err)
+	}
+	if err := saveTunnelTicket(created); err != nil {
+		return err
+	}
+
+	entry := tunnelListEntry{
+		TunnelID:      created.TunnelID,
+		ProjectID:     p.ID,
+		ProjectName:   p.Name,
+		ContainerID:   c.ID,
+		ContainerName: c.Name,
+		TargetPort:    spec.TargetPort,
+		Mode:          created.Mode,
+		ExpiresAt:     created.ExpiresAt,
+		State:         tunnelState(created.ExpiresAt),
+		LocalTicket:   true,
+	}
+	if jsonOutput {
+		return printJSON(entry)
+	}
+	fmt.Printf("Created tunnel %s -> %s:%d (expires %s)\n", entry.TunnelID, entry.ContainerName, entry.TargetPort, valueOrDash(entry.ExpiresAt))
+	return nil
+}
+
+func tunnelDeleteFlow(args []string) error {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return errors.New("usage: hubfly tunnel delete <tunnelId>")
+	}
+	tunnelID := strings.TrimSpace(args[0])
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	if err := deleteTunnel(token, tunnelID); err != nil {
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || apiErr.Status != 404 {
+			return fmt.Errorf("failed to delete tunnel %s: %w", tunnelID, err)
+		}
+		fmt.Printf("Tunnel %s was already gone on the server.\n", tunnelID)
+	} else {
+		fmt.Printf("Deleted tunnel %s.\n", tunnelID)
+	}
+
+	removed, err := removeLocalTunnelCredentials(tunnelID)
+	if err != nil {
+		return err
+	}
+	for _, path := range removed {
+		fmt.Printf("Removed %s\n", path)
+	}
+	return nil
+}
+
+// removeLocalTunnelCredentials deletes the session ticket for a tunnel plus
+// any key pair left in the keys directory by older CLI versions.
+func removeLocalTunnelCredentials(tunnelID string) ([]string, error) {
+	candidates := []string{
+		tunnelTicketPath(tunnelID),
+		filepath.Join(keysDir(), sanitizeID(tunnelID)),
+		filepath.Join(keysDir(), sanitizeID(tunnelID)+".pub"),
+	}
+	removed := make([]string, 0, len(candidates))
+	for _, path := range candidates {
+		err := os.Remove(path)
+		if err == nil {
+			removed = append(removed, path)
+			continue
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+	}
+	forgetLocalPort(tunnelID)
+	return removed, nil
+}
@@ -0,0 +1,379 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// composeTunnelPlan is one port of a compose service matched to a container.
+type composeTunnelPlan struct {
+	Service     string
+	Container   container
+	LocalPort   int
+	TargetPort  int
+	SessionName string
+}
+
+// planComposeTunnels matches every compose service to a container named
+// like the service, or like the container `hubfly stack` deploys for it, and
+// returns one plan per TCP port. The local port is the published port, or
+// the container port when none is published. Services without a matching
+// container or TCP ports are reported in skipped.
+func planComposeTunnels(spec stackSpec, containers []container) (plans []composeTunnelPlan, skipped []string) {
+	byName := make(map[string]container, len(containers))
+	for _, c := range containers {
+		byName[c.Name] = c
+	}
+	for _, service := range spec.Services {
+		c, ok := byName[service.Name]
+		if !ok {
+			c, ok = byName[service.ContainerName]
+		}
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s: no container named %s or %s", service.Name, service.Name, service.ContainerName))
+			continue
+		}
+		var ports []deployPort
+		for _, p := range service.Ports {
+			if strings.EqualFold(p.Protocol, "UDP") {
+				skipped = append(skipped, fmt.Sprintf("%s: port %d/udp cannot be tunneled", service.Name, p.Container))
+				continue
+			}
+			ports = append(ports, p)
+		}
+		if len(ports) == 0 {
+			if len(service.Ports) == 0 {
+				skipped = append(skipped, fmt.Sprintf("%s: no ports in the compose file", service.Name))
+			}
+			continue
+		}
+		sort.Slice(ports, func(i, j int) bool { return ports[i].Container < ports[j].Container })
+		for _, p := range ports {
+			local := p.Host
+			if local <= 0 {
+				local = p.Container
+			}
+			name := service.Name
+			if len(ports) > 1 {
+				name = fmt.Sprintf("%s-%d", service.Name, p.Container)
+			}
+			plans = append(plans, composeTunnelPlan{
+				Service:     service.Name,
+				Container:   c,
+				LocalPort:   local,
+				TargetPort:  p.Container,
+				SessionName: sanitizeID(name),
+			})
+		}
+	}
+	return plans, skipped
+}
+
+// Changes a compose tunnel plan can make to the running background
+// sessions.
+const (
+	composeChangeStart   = "start"
+	composeChangeKeep    = "keep"
+	composeChangeReplace = "replace"
+	composeChangeStop    = "stop"
+	composeChangeSkip    = "skip"
+)
+
+// composeTunnelChange is one line of `hubfly tunnel plan`.
+type composeTunnelChange struct {
+	Action     string `json:"action"`
+	Session    string `json:"session"`
+	Service    string `json:"service,omitempty"`
+	Container  string `json:"container"`
+	LocalPort  int    `json:"localPort"`
+	TargetPort int    `json:"targetPort"`
+	Reason     string `json:"reason,omitempty"`
+
+	plan    composeTunnelPlan
+	running tunnelSession
+}
+
+// diffComposeTunnels compares the tunnels a compose file asks for with the
+// running background sessions. Sessions started from composeFile that the
+// file no longer asks for are stopped; a session of the same name started
+// some other way is left alone.
+func diffComposeTunnels(plans []composeTunnelPlan, running []tunnelSession, composeFile, projectID string) []composeTunnelChange {
+	byName := make(map[string]tunnelSession, len(running))
+	for _, s := range running {
+		byName[s.Name] = s
+	}
+	changes := make([]composeTunnelChange, 0, len(plans))
+	for _, plan := range plans {
+		change := composeTunnelChange{
+			Action:     composeChangeStart,
+			Session:    plan.SessionName,
+			Service:    plan.Service,
+			Container:  plan.Container.Name,
+			LocalPort:  plan.LocalPort,
+			TargetPort: plan.TargetPort,
+			plan:       plan,
+		}
+		if s, ok := byName[plan.SessionName]; ok {
+			delete(byName, plan.SessionName)
+			change.running = s
+			requested := s.ComposePort
+			if requested == 0 {
+				requested = s.LocalPort
+			}
+			switch {
+			case s.Compose != composeFile:
+				change.Action = composeChangeSkip
+				change.Reason = fmt.Sprintf("session %q is already running and was not started from this file", s.Name)
+			case s.ProjectID != projectID || s.Container != plan.Container.Name || s.TargetPort != plan.TargetPort:
+				change.Action = composeChangeReplace
+				change.Reason = fmt.Sprintf("now %s:%d", s.Container, s.TargetPort)
+			case requested != plan.LocalPort:
+				change.Action = composeChangeReplace
+				change.Reason = fmt.Sprintf("local port %d -> %d", requested, plan.LocalPort)
+			default:
+				change.Action = composeChangeKeep
+				change.LocalPort = s.LocalPort
+			}
+		}
+		changes = append(changes, change)
+	}
+	for _, s := range running {
+		if _, ok := byName[s.Name]; !ok || s.Compose != composeFile {
+			continue
+		}
+		changes = append(changes, composeTunnelChange{
+			Action:     composeChangeStop,
+			Session:    s.Name,
+			Container:  s.Container,
+			LocalPort:  s.LocalPort,
+			TargetPort: s.TargetPort,
+			Reason:     "no longer in the compose file",
+			running:    s,
+		})
+	}
+	return changes
+}
+
+// composeTunnelState is what both `tunnel plan` and `tunnel up --compose`
+// work from.
+type composeTunnelState struct {
+	file    string
+	project project
+	changes []composeTunnelChange
+}
+
+func loadComposeTunnelState(token, composePath, projectQuery string) (composeTunnelState, error) {
+	spec, err := loadStackSpec(composePath)
+	if err != nil {
+		return composeTunnelState{}, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	file, err := filepath.Abs(spec.FilePath)
+	if err != nil {
+		return composeTunnelState{}, err
+	}
+	projects, err := scopedProjects(token, projectQuery)
+	if err != nil {
+		return composeTunnelState{}, err
+	}
+	if len(projects) == 0 {
+		return composeTunnelState{}, errors.New("no projects found")
+	}
+	if strings.TrimSpace(projectQuery) == "" && defaultProjectQuery() == "" && len(projects) > 1 {
+		return composeTunnelState{}, errors.New("several projects are available; pass --project or pick one with `hubfly use project <name>`")
+	}
+	p := projects[0]
+	details, err := fetchProject(token, p.ID)
+	if err != nil {
+		return composeTunnelState{}, fmt.Errorf("failed to fetch containers for %s: %w", p.Name, err)
+	}
+
+	plans, skipped := planComposeTunnels(spec, details.Containers)
+	for _, reason := range skipped {
+		fmt.Fprintf(os.Stderr, "skipping %s\n", reason)
+	}
+	sessions, err := listTunnelSessions()
+	if err != nil {
+		return composeTunnelState{}, err
+	}
+	running := make([]tunnelSession, 0, len(sessions))
+	for _, s := range sessions {
+		if hubflyProcessAlive(s.PID) {
+			running = append(running, s)
+		}
+	}
+	changes := diffComposeTunnels(plans, running, file, p.ID)
+	if len(changes) == 0 {
+		return composeTunnelState{}, fmt.Errorf("no compose service in %s matches a container in project %s", spec.FilePath, p.Name)
+	}
+	return composeTunnelState{file: file, project: p, changes: changes}, nil
+}
+
+func countComposeChanges(changes []composeTunnelChange) map[string]int {
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+	}
+	return counts
+}
+
+func printComposeTunnelPlan(state composeTunnelState) {
+	fmt.Printf("Tunnel plan for %s in project %s:\n", state.file, state.project.Name)
+	symbols := map[string]string{
+		composeChangeStart:   "+",
+		composeChangeKeep:    "=",
+		composeChangeReplace: "~",
+		composeChangeStop:    "-",
+		composeChangeSkip:    "!",
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	for _, c := range state.changes {
+		line := fmt.Sprintf("  %s %s\t%s\tlocalhost:%d -> %s:%d", symbols[c.Action], c.Action, c.Session, c.LocalPort, c.Container, c.TargetPort)
+		if c.Reason != "" {
+			line += "\t(" + c.Reason + ")"
+		}
+		_, _ = fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+	counts := countComposeChanges(state.changes)
+	fmt.Printf("Plan: %d to start, %d to replace, %d to stop, %d unchanged.\n",
+		counts[composeChangeStart], counts[composeChangeReplace], counts[composeChangeStop], counts[composeChangeKeep])
+}
+
+// tunnelPlanFlow shows what `tunnel up --compose` would change without
+// changing anything.
+func tunnelPlanFlow(args []string) error {
+	const usage = "usage: hubfly tunnel plan --compose <file> [--project <id|name>]"
+	fs := newFlagSet("tunnel plan")
+	composePath := fs.String("compose", "", "compose file to plan tunnels for")
+	projectQuery := fs.String("project", "", "project id or name")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || strings.TrimSpace(*composePath) == "" {
+		return errors.New(usage)
+	}
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	state, err := loadComposeTunnelState(token, *composePath, *projectQuery)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(state.changes)
+	}
+	printComposeTunnelPlan(state)
+	return nil
+}
+
+// tunnelUpComposeFlow brings the background sessions in line with a compose
+// file: one tunnel per port of every service that has a same-named container
+// in the selected project, so localhost ports match what `docker compose up`
+// would publish. It shows the plan first and asks before applying it unless
+// autoApprove is set. Without a terminal it applies plans that only start
+// tunnels, but needs autoApprove to stop or replace any.
+func tunnelUpComposeFlow(composePath, projectQuery string, autoPort, autoApprove bool) error {
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	state, err := loadComposeTunnelState(token, composePath, projectQuery)
+	if err != nil {
+		return err
+	}
+	counts := countComposeChanges(state.changes)
+	if counts[composeChangeStart]+counts[composeChangeReplace]+counts[composeChangeStop] == 0 {
+		printComposeTunnelPlan(state)
+		fmt.Println("Nothing to do.")
+		return nil
+	}
+	destructive := counts[composeChangeReplace]+counts[composeChangeStop] > 0
+	if !autoApprove {
+		if isInteractiveShell() {
+			printComposeTunnelPlan(state)
+			ok, err := promptYesNo("Apply this plan", !destructive)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("tunnel plan not applied")
+			}
+		} else if destructive {
+			printComposeTunnelPlan(state)
+			return errors.New("this plan stops or replaces running tunnels; rerun with --auto-approve to apply it")
+		}
+	}
+
+	// Stop first so replaced tunnels free their local ports.
+	for _, c := range state.changes {
+		if c.Action == composeChangeStop || c.Action == composeChangeReplace {
+			if err := stopTunnelSession(c.running); err != nil {
+				return err
+			}
+			if c.Action == composeChangeStop {
+				fmt.Printf("Stopped %s.\n", c.Session)
+			}
+		}
+	}
+
+	type started struct {
+		plan    composeTunnelPlan
+		session tunnelSession
+	}
+	var up []started
+	failures, attempted := 0, 0
+	for _, c := range state.changes {
+		switch c.Action {
+		case composeChangeSkip:
+			fmt.Fprintf(os.Stderr, "skipping %s: %s\n", c.Service, c.Reason)
+			continue
+		case composeChangeStart, composeChangeReplace:
+		default:
+			continue
+		}
+		attempted++
+		plan := c.plan
+		localPort, err := resolveLocalPort(strconv.Itoa(plan.LocalPort), plan.TargetPort)
+		if err == nil {
+			localPort, err = ensureLocalPortFree(localPort, autoPort)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: %v\n", plan.Service, plan.TargetPort, err)
+			failures++
+			continue
+		}
+		s, _, err := startContainerTunnel(token, state.project.ID, plan.Container, plan.SessionName, localPort, plan.TargetPort)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: %v\n", plan.Service, plan.TargetPort, err)
+			failures++
+			continue
+		}
+		// Remember where the session came from so later plans can replace
+		// or stop it.
+		s.Compose, s.ComposePort = state.file, plan.LocalPort
+		if err := saveTunnelSession(s); err != nil {
+			return err
+		}
+		plan.LocalPort = localPort
+		up = append(up, started{plan: plan, session: s})
+	}
+
+	if len(up) > 0 {
+		fmt.Printf("Started %d tunnel(s) in project %s:\n", len(up), state.project.Name)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Session\tLocal\tTarget\tPID")
+		for _, u := range up {
+			_, _ = fmt.Fprintf(tw, "%s\tlocalhost:%d\t%s:%d\t%d\n", u.session.Name, u.plan.LocalPort, u.plan.Container.Name, u.plan.TargetPort, u.session.PID)
+		}
+		_ = tw.Flush()
+		fmt.Println("Stop them with `hubfly tunnel down <session>`.")
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d tunnel(s) failed to start", failures, attempted)
+	}
+	return nil
+}
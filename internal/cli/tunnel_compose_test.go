@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanComposeTunnels(t *testing.T) {
+	spec := stackSpec{Services: []stackServiceSpec{
+		{Name: "db", ContainerName: "shop-db", Ports: []deployPort{{Container: 5432, Host: 15432, Protocol: "TCP"}}},
+		{Name: "web", ContainerName: "shop-web", Ports: []deployPort{
+			{Container: 8080, Protocol: "TCP"},
+			{Container: 3000, Protocol: "TCP"},
+			{Container: 53, Protocol: "UDP"},
+		}},
+		{Name: "worker", ContainerName: "shop-worker"},
+		{Name: "cache", ContainerName: "shop-cache", Ports: []deployPort{{Container: 6379, Protocol: "TCP"}}},
+	}}
+	containers := []container{
+		{ID: "c1", Name: "db"},
+		{ID: "c2", Name: "shop-web"},
+		{ID: "c3", Name: "shop-worker"},
+	}
+
+	plans, skipped := planComposeTunnels(spec, containers)
+	type row struct {
+		Container, Session string
+		Local, Target      int
+	}
+	got := make([]row, 0, len(plans))
+	for _, p := range plans {
+		got = append(got, row{p.Container.ID, p.SessionName, p.LocalPort, p.TargetPort})
+	}
+	want := []row{
+		{"c1", "db", 15432, 5432},
+		{"c2", "web-3000", 3000, 3000},
+		{"c2", "web-8080", 8080, 8080},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("plans = %+v, want %+v", got, want)
+	}
+	if len(skipped) != 3 {
+		t.Fatalf("skipped = %q, want the udp port, worker and cache", skipped)
+	}
+}
+
+func TestDiffComposeTunnels(t *testing.T) {
+	const file = "/work/docker-compose.yml"
+	plans := []composeTunnelPlan{
+		{Service: "db", Container: container{Name: "db"}, LocalPort: 5432, TargetPort: 5432, SessionName: "db"},
+		{Service: "web", Container: container{Name: "web"}, LocalPort: 8080, TargetPort: 8080, SessionName: "web"},
+		{Service: "api", Container: container{Name: "api"}, LocalPort: 3000, TargetPort: 3000, SessionName: "api"},
+		{Service: "cache", Container: container{Name: "cache"}, LocalPort: 6379, TargetPort: 6379, SessionName: "cache"},
+	}
+	running := []tunnelSession{
+		// Moved by --auto-port; still matches what the file asks for.
+		{Name: "db", ProjectID: "p1", Container: "db", LocalPort: 5433, TargetPort: 5432, Compose: file, ComposePort: 5432},
+		{Name: "web", ProjectID: "p1", Container: "web", LocalPort: 80, TargetPort: 8080, Compose: file},
+		{Name: "api", ProjectID: "p1", Container: "api", LocalPort: 3000, TargetPort: 3000},
+		{Name: "old", ProjectID: "p1", Container: "queue", LocalPort: 5672, TargetPort: 5672, Compose: file},
+		{Name: "manual", ProjectID: "p1", Container: "queue", LocalPort: 5673, TargetPort: 5672},
+	}
+
+	got := map[string]string{}
+	for _, c := range diffComposeTunnels(plans, running, file, "p1") {
+		got[c.Session] = c.Action
+	}
+	want := map[string]string{
+		"db":    composeChangeKeep,
+		"web":   composeChangeReplace,
+		"api":   composeChangeSkip,
+		"cache": composeChangeStart,
+		"old":   composeChangeStop,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type expiringTunnel struct {
+	TunnelID  string `json:"tunnelId"`
+	ProjectID string `json:"projectId"`
+	Target    string `json:"target"`
+	ExpiresAt string `json:"expiresAt"`
+	ExpiresIn int64  `json:"expiresInSeconds"`
+	Expired   bool   `json:"expired"`
+}
+
+// expiryLayouts are the ExpiresAt formats seen from the API besides RFC 3339:
+// offsets without a colon or with hours only, a space instead of the T, and
+// no zone at all, which is taken as UTC. Fractional seconds of any precision
+// are accepted after the seconds by every layout.
+var expiryLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05Z07",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05Z07",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// parseExpiry reads an ExpiresAt value in any of expiryLayouts, or as Unix
+// seconds or milliseconds. Everything that decides whether a tunnel has
+// expired goes through it.
+func parseExpiry(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		// Second timestamps stay below 1e12 until the year 33658.
+		if n >= 1e12 {
+			return time.UnixMilli(n).UTC(), true
+		}
+		return time.Unix(n, 0).UTC(), true
+	}
+	for _, layout := range expiryLayouts {
+		if when, err := time.Parse(layout, raw); err == nil {
+			return when, true
+		}
+	}
+	debugf("unrecognised tunnel expiry %q", raw)
+	return time.Time{}, false
+}
+
+// tunnelCheckExpiryFlow is meant for cron: it only reads local tickets, so it
+// needs no network or login, and it exits non-zero when anything is due.
+func tunnelCheckExpiryFlow(args []string) error {
+	fs := newFlagSet("tunnels check-expiry")
+	within := fs.Duration("within", 24*time.Hour, "report tunnels expiring within this window")
+	hook := fs.String("exec", "", "shell command to run once per expiring tunnel")
+	skipExpired := fs.Bool("skip-expired", false, "ignore tunnels that have already expired")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *within <= 0 {
+		return fmt.Errorf("--within must be positive")
+	}
+
+	tickets, err := listTunnelTickets()
+	if err != nil {
+		return err
+	}
+	due := findExpiringTunnels(tickets, serverNow(), *within, *skipExpired)
+
+	if jsonOutput {
+		if err := printJSON(due); err != nil {
+			return err
+		}
+	} else if len(due) == 0 {
+		fmt.Printf("No local tunnels expire within %s.\n", *within)
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Tunnel ID\tTarget\tExpires\tIn")
+		for _, t := range due {
+			in := "expired"
+			if !t.Expired {
+				in = (time.Duration(t.ExpiresIn) * time.Second).String()
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.TunnelID, t.Target, t.ExpiresAt, in)
+		}
+		_ = tw.Flush()
+	}
+
+	hookFailures := 0
+	if strings.TrimSpace(*hook) != "" {
+		for _, t := range due {
+			if err := runExpiryHook(*hook, t); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: hook failed for %s: %v\n", t.TunnelID, err)
+				hookFailures++
+			}
+		}
+	}
+
+	if len(due) > 0 {
+		if hookFailures > 0 {
+			return fmt.Errorf("%d tunnel(s) expire within %s; %d hook run(s) failed", len(due), *within, hookFailures)
+		}
+		return fmt.Errorf("%d tunnel(s) expire within %s", len(due), *within)
+	}
+	return nil
+}
+
+func findExpiringTunnels(tickets []tunnel, now time.Time, within time.Duration, skipExpired bool) []expiringTunnel {
+	due := make([]expiringTunnel, 0)
+	for _, t := range tickets {
+		when, ok := parseExpiry(t.ExpiresAt)
+		if !ok {
+			continue
+		}
+		remaining := when.Sub(now)
+		if remaining > within {
+			continue
+		}
+		if remaining <= 0 && skipExpired {
+			continue
+		}
+		left := remaining
+		if left < 0 {
+			left = 0
+		}
+		due = append(due, expiringTunnel{
+			TunnelID:  t.TunnelID,
+			ProjectID: t.ProjectID,
+			Target:    fmt.Sprintf("%s:%d", resolveTunnelForwardHost(t), selectedPrimaryPort(t)),
+			ExpiresAt: t.ExpiresAt,
+			ExpiresIn: int64(left / time.Second),
+			Expired:   remaining <= 0,
+		})
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ExpiresIn < due[j].ExpiresIn })
+	return due
+}
+
+// runExpiryHook runs the user's command through the platform shell with the
+// tunnel details in HUBFLY_TUNNEL_* variables.
+func runExpiryHook(command string, t expiringTunnel) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"HUBFLY_TUNNEL_ID="+t.TunnelID,
+		"HUBFLY_TUNNEL_PROJECT_ID="+t.ProjectID,
+		"HUBFLY_TUNNEL_TARGET="+t.Target,
+		"HUBFLY_TUNNEL_EXPIRES_AT="+t.ExpiresAt,
+		"HUBFLY_TUNNEL_EXPIRES_IN="+strconv.FormatInt(t.ExpiresIn, 10),
+		"HUBFLY_TUNNEL_EXPIRED="+strconv.FormatBool(t.Expired),
+	)
+	return cmd.Run()
+}
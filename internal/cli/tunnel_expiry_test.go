@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindExpiringTunnels(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tickets := []tunnel{
+		{TunnelID: "soon", ExpiresAt: now.Add(2 * time.Hour).Format(time.RFC3339)},
+		{TunnelID: "later", ExpiresAt: now.Add(48 * time.Hour).Format(time.RFC3339)},
+		{TunnelID: "gone", ExpiresAt: now.Add(-time.Hour).Format(time.RFC3339)},
+		{TunnelID: "unknown", ExpiresAt: ""},
+	}
+
+	due := findExpiringTunnels(tickets, now, 24*time.Hour, false)
+	if len(due) != 2 || due[0].TunnelID != "gone" || !due[0].Expired || due[1].TunnelID != "soon" {
+		t.Fatalf("unexpected due tunnels: %+v", due)
+	}
+	if due[1].ExpiresIn != int64((2 * time.Hour).Seconds()) {
+		t.Fatalf("unexpected ExpiresIn: %d", due[1].ExpiresIn)
+	}
+
+	due = findExpiringTunnels(tickets, now, 24*time.Hour, true)
+	if len(due) != 1 || due[0].TunnelID != "soon" {
+		t.Fatalf("expected only the unexpired tunnel, got %+v", due)
+	}
+}
+
+func TestParseExpiryAcceptsObservedFormats(t *testing.T) {
+	want := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	cases := []string{
+		"2026-03-04T05:06:07Z",
+		"2026-03-04T05:06:07.123Z",
+		"2026-03-04T05:06:07.123456789Z",
+		"2026-03-04T07:06:07+02:00",
+		"2026-03-04T07:06:07.5+0200",
+		"2026-03-04T07:06:07+02",
+		"2026-03-03T23:36:07-05:30",
+		"2026-03-04 05:06:07Z",
+		"2026-03-04 07:06:07 +0200",
+		"2026-03-04T05:06:07",
+		"2026-03-04 05:06:07.250",
+		" 2026-03-04T05:06:07Z ",
+		"1772600767",
+		"1772600767000",
+	}
+	for _, raw := range cases {
+		got, ok := parseExpiry(raw)
+		if !ok {
+			t.Errorf("parseExpiry(%q) failed", raw)
+			continue
+		}
+		if got.Truncate(time.Second).Sub(want) != 0 {
+			t.Errorf("parseExpiry(%q) = %s, want %s", raw, got.UTC(), want)
+		}
+	}
+	for _, raw := range []string{"", "soon", "2026-03-04", "04/03/2026 05:06"} {
+		if _, ok := parseExpiry(raw); ok {
+			t.Errorf("parseExpiry(%q) should fail", raw)
+		}
+	}
+	if got := tunnelState("2026-03-04T05:06:07.123+0000"); got == "unknown" {
+		t.Error("tunnelState does not use parseExpiry")
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// targetFailoverAfter is how many connections in a row the gateway must
+// refuse for a target before the next fallback target is tried.
+const targetFailoverAfter = 3
+
+// streamRejectedError is the gateway refusing to open a stream to a target,
+// typically because it cannot reach the container behind it.
+type streamRejectedError struct {
+	message string
+}
+
+func (e *streamRejectedError) Error() string {
+	return "tunnel stream rejected: " + e.message
+}
+
+// targetFallback is the ordered list of targets a forward tunnel connects
+// to: the primary target first, then the other targets of the ticket on the
+// same port. Host resolution happens on the gateway, so each entry is a
+// target ID and the gateway decides how to reach it.
+type targetFallback struct {
+	mu       sync.Mutex
+	targets  []tunnelTarget
+	current  int
+	failures int
+	// confirmed is set once a connection through the current target worked
+	// after a switch, so the switch is logged with its outcome once.
+	confirmed bool
+	stats     *tunnelStats
+}
+
+func newTargetFallback(t tunnel, primary tunnelTarget, stats *tunnelStats) *targetFallback {
+	f := &targetFallback{targets: []tunnelTarget{primary}, confirmed: true, stats: stats}
+	for _, target := range t.Targets {
+		if target.TargetID != primary.TargetID && target.TargetPort == primary.TargetPort {
+			f.targets = append(f.targets, target)
+		}
+	}
+	return f
+}
+
+// pick returns the target new connections should use.
+func (f *targetFallback) pick() tunnelTarget {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.targets[f.current]
+}
+
+// report records how a connection through target went. Only refusals by the
+// gateway count towards a switch: a local client hanging up says nothing
+// about the target.
+func (f *targetFallback) report(target tunnelTarget, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if target.TargetID != f.targets[f.current].TargetID {
+		return
+	}
+	var rejected *streamRejectedError
+	if err != nil && !errors.As(err, &rejected) {
+		return
+	}
+	if err == nil {
+		f.failures = 0
+		if !f.confirmed {
+			f.confirmed = true
+			fmt.Printf("Connections through fallback target %s work.\n", describeTunnelTarget(target))
+		}
+		return
+	}
+	f.failures++
+	debugf("target %s refused %d connection(s) in a row: %v", describeTunnelTarget(target), f.failures, err)
+	if f.failures < targetFailoverAfter || len(f.targets) < 2 {
+		return
+	}
+	f.current = (f.current + 1) % len(f.targets)
+	f.failures = 0
+	f.confirmed = false
+	next := f.targets[f.current]
+	fmt.Printf("Target %s refused %d connections in a row; trying %s.\n", describeTunnelTarget(target), targetFailoverAfter, describeTunnelTarget(next))
+	f.stats.setTarget(describeTunnelTarget(next))
+}
+
+// describeTunnelTarget names a target the way resolveTunnelForwardHost
+// would, with the target ID so the gateway's choice can be traced.
+func describeTunnelTarget(target tunnelTarget) string {
+	host := "container"
+	for _, candidate := range []string{target.ContainerName, target.ContainerID, target.RuntimeID} {
+		if strings.TrimSpace(candidate) != "" {
+			host = strings.TrimSpace(candidate)
+			break
+		}
+	}
+	return fmt.Sprintf("%s:%d (%s)", host, target.TargetPort, target.TargetID)
+}
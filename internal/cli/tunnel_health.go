@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// healthcheckConcurrency caps how many tunnels are checked at once.
+const healthcheckConcurrency = 8
+
+// Check results in a tunnelHealth row.
+const (
+	healthOK      = "ok"
+	healthFailed  = "failed"
+	healthSkipped = "-"
+)
+
+type tunnelHealth struct {
+	TunnelID  string `json:"tunnelId"`
+	ProjectID string `json:"projectId"`
+	Target    string `json:"target"`
+	ExpiresAt string `json:"expiresAt"`
+	// Gateway is a plain TCP connect to the gateway host. Auth and Stream
+	// are only run with --probe: the gateway handshake with the stored
+	// connect token, then a stream to the tunnel's target.
+	Gateway string `json:"gateway"`
+	Auth    string `json:"auth"`
+	Stream  string `json:"stream"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// tunnelHealthcheckFlow checks every unexpired local ticket in parallel and
+// exits non-zero when any of them fails.
+func tunnelHealthcheckFlow(args []string) error {
+	fs := newFlagSet("tunnel healthcheck")
+	probe := fs.Bool("probe", false, "also authenticate with the gateway and open a stream to the target")
+	timeout := fs.Duration("timeout", 5*time.Second, "time allowed for each tunnel")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || *timeout <= 0 {
+		return errors.New("usage: hubfly tunnel healthcheck [--probe] [--timeout <duration>]")
+	}
+
+	tickets, err := listTunnelTickets()
+	if err != nil {
+		return err
+	}
+	live := make([]tunnel, 0, len(tickets))
+	for _, t := range tickets {
+		if tunnelState(t.ExpiresAt) != "expired" {
+			live = append(live, t)
+		}
+	}
+	results := checkTunnelHealth(live, *probe, *timeout)
+
+	if jsonOutput {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else if len(results) == 0 {
+		fmt.Printf("No unexpired tunnels in %s.\n", tunnelsDir())
+		return nil
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Tunnel ID\tTarget\tGateway\tAuth\tStream\tError")
+		for _, r := range results {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.TunnelID, r.Target, r.Gateway, r.Auth, r.Stream, r.Error)
+		}
+		_ = tw.Flush()
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.Healthy {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tunnel(s) failed the healthcheck", failed, len(results))
+	}
+	return nil
+}
+
+// checkTunnelHealth checks tickets concurrently and returns one row per
+// ticket, sorted by tunnel ID.
+func checkTunnelHealth(tickets []tunnel, probe bool, timeout time.Duration) []tunnelHealth {
+	results := make([]tunnelHealth, len(tickets))
+	sem := make(chan struct{}, healthcheckConcurrency)
+	var wg sync.WaitGroup
+	for i, t := range tickets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = checkOneTunnel(t, probe, timeout)
+		}()
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].TunnelID < results[j].TunnelID })
+	return results
+}
+
+func checkOneTunnel(t tunnel, probe bool, timeout time.Duration) tunnelHealth {
+	result := tunnelHealth{
+		TunnelID:  t.TunnelID,
+		ProjectID: t.ProjectID,
+		Target:    fmt.Sprintf("%s:%d", resolveTunnelForwardHost(t), selectedPrimaryPort(t)),
+		ExpiresAt: t.ExpiresAt,
+		Gateway:   healthSkipped,
+		Auth:      healthSkipped,
+		Stream:    healthSkipped,
+	}
+	fail := func(step *string, err error) tunnelHealth {
+		*step = healthFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	addr, err := gatewayAddress(t.ConnectURL)
+	if err != nil {
+		return fail(&result.Gateway, err)
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fail(&result.Gateway, err)
+	}
+	_ = conn.Close()
+	result.Gateway = healthOK
+	if !probe {
+		result.Healthy = true
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	session, err := dialTunnelSession(ctx, t)
+	if err != nil {
+		return fail(&result.Auth, err)
+	}
+	defer session.Close()
+	result.Auth = healthOK
+
+	target, err := primaryTunnelTarget(t, selectedPrimaryPort(t))
+	if err != nil {
+		return fail(&result.Stream, err)
+	}
+	opened := make(chan error, 1)
+	go func() {
+		stream, _, err := openTargetStream(session, target)
+		if err == nil {
+			_ = stream.Close()
+		}
+		opened <- err
+	}()
+	select {
+	case err = <-opened:
+	case <-ctx.Done():
+		err = fmt.Errorf("no reply from the gateway within %s", timeout)
+	}
+	if err != nil {
+		return fail(&result.Stream, err)
+	}
+	result.Stream = healthOK
+	result.Healthy = true
+	return result
+}
+
+// gatewayAddress returns the host:port a tunnel connect URL dials.
+func gatewayAddress(connectURL string) (string, error) {
+	u, err := url.Parse(connectURL)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid tunnel connect url %q", connectURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "wss" || u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	tunnelStateConnecting   = "connecting"
+	tunnelStateConnected    = "connected"
+	tunnelStateReconnecting = "reconnecting"
+
+	// tunnelReconnectMaxDelay caps the backoff between gateway redials.
+	tunnelReconnectMaxDelay = 30 * time.Second
+	tunnelStatusInterval    = time.Second
+)
+
+// tunnelLiveStatus is what a running `__connect-tunnel` process reports
+// about itself in the file named by HUBFLY_TUNNEL_STATUS. The TUI polls it
+// to show state, retries and traffic while tunnels run.
+type tunnelLiveStatus struct {
+	State     string `json:"state"`
+	Since     string `json:"since"`
+	StartedAt string `json:"startedAt"`
+	RetryAt   string `json:"retryAt,omitempty"`
+	Attempt   int    `json:"attempt,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+	// Target is set once connections moved to a fallback target.
+	Target        string `json:"target,omitempty"`
+	Reconnects    int64  `json:"reconnects"`
+	ActiveStreams int64  `json:"activeStreams"`
+	BytesSent     uint64 `json:"bytesSent"`
+	BytesReceived uint64 `json:"bytesReceived"`
+}
+
+func tunnelStatusPath(tunnelID string, localPort int) string {
+	return filepath.Join(stateDir(), "live", fmt.Sprintf("%s-%d.json", sanitizeID(tunnelID), localPort))
+}
+
+func loadTunnelLiveStatus(path string) (tunnelLiveStatus, bool) {
+	var s tunnelLiveStatus
+	content, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(content, &s) != nil {
+		return tunnelLiveStatus{}, false
+	}
+	return s, true
+}
+
+// removeTunnelLiveStatus cleans up after a tunnel process that was killed
+// before it could remove its own status file.
+func removeTunnelLiveStatus(tunnelID string, localPort int) {
+	_ = os.Remove(tunnelStatusPath(tunnelID, localPort))
+}
+
+// tunnelStats counts a tunnel's traffic and tracks its connection state.
+// Counting always happens; the status file is only written when
+// HUBFLY_TUNNEL_STATUS is set.
+type tunnelStats struct {
+	path      string
+	startedAt time.Time
+
+	mu        sync.Mutex
+	state     string
+	since     time.Time
+	retryAt   time.Time
+	attempt   int
+	lastError string
+	target    string
+
+	reconnects    atomic.Int64
+	activeStreams atomic.Int64
+	sent          atomic.Uint64
+	received      atomic.Uint64
+}
+
+func newTunnelStats() *tunnelStats {
+	now := time.Now()
+	return &tunnelStats{
+		path:      os.Getenv("HUBFLY_TUNNEL_STATUS"),
+		startedAt: now,
+		state:     tunnelStateConnecting,
+		since:     now,
+	}
+}
+
+func (s *tunnelStats) setState(state, lastError string) {
+	s.mu.Lock()
+	if state != s.state {
+		s.since = time.Now()
+	}
+	s.state, s.lastError = state, lastError
+	s.retryAt, s.attempt = time.Time{}, 0
+	s.mu.Unlock()
+	s.write()
+}
+
+// setRetry records that the next redial is attempt number attempt, due at
+// retryAt.
+func (s *tunnelStats) setRetry(attempt int, retryAt time.Time, lastError string) {
+	s.mu.Lock()
+	if s.state != tunnelStateReconnecting {
+		s.since = time.Now()
+	}
+	s.state, s.lastError = tunnelStateReconnecting, lastError
+	s.retryAt, s.attempt = retryAt, attempt
+	s.mu.Unlock()
+	s.write()
+}
+
+// setTarget records the fallback target connections now go to.
+func (s *tunnelStats) setTarget(target string) {
+	s.mu.Lock()
+	s.target = target
+	s.mu.Unlock()
+	s.write()
+}
+
+func (s *tunnelStats) snapshot() tunnelLiveStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := tunnelLiveStatus{
+		State:         s.state,
+		Since:         s.since.UTC().Format(time.RFC3339),
+		StartedAt:     s.startedAt.UTC().Format(time.RFC3339),
+		Attempt:       s.attempt,
+		LastError:     s.lastError,
+		Target:        s.target,
+		Reconnects:    s.reconnects.Load(),
+		ActiveStreams: s.activeStreams.Load(),
+		BytesSent:     s.sent.Load(),
+		BytesReceived: s.received.Load(),
+	}
+	if !s.retryAt.IsZero() {
+		status.RetryAt = s.retryAt.UTC().Format(time.RFC3339Nano)
+	}
+	return status
+}
+
+func (s *tunnelStats) write() {
+	if s.path == "" {
+		return
+	}
+	payload, err := json.Marshal(s.snapshot())
+	if err != nil {
+		return
+	}
+	if err := ensurePrivateDir(filepath.Dir(s.path)); err != nil {
+		return
+	}
+	// Write then rename so a reader never sees half a file.
+	tmp := s.path + ".tmp"
+	if err := writePrivateFile(tmp, payload); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, s.path)
+}
+
+// report writes the status file every tunnelStatusInterval until ctx ends,
+// then removes it: a missing file means the process is gone.
+func (s *tunnelStats) report(ctx context.Context) {
+	if s.path == "" {
+		return
+	}
+	ticker := time.NewTicker(tunnelStatusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = os.Remove(s.path)
+			return
+		case <-ticker.C:
+			s.write()
+		}
+	}
+}
+
+// copyCounted copies src to dst, adding the bytes to counter as they go.
+func copyCounted(dst io.Writer, src io.Reader, counter *atomic.Uint64) {
+	_, _ = io.Copy(&countingWriter{w: dst, n: counter}, src)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(uint64(n))
+	return n, err
+}
+
+// tunnelReconnectDelay doubles from one second up to
+// tunnelReconnectMaxDelay.
+func tunnelReconnectDelay(attempt int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempt && delay < tunnelReconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, tunnelReconnectMaxDelay)
+}
+
+// redialTunnelSession re-establishes a dropped gateway session with
+// exponential backoff. It returns nil, nil once ctx is cancelled and an
+// error only when the gateway rejects the tunnel, which retrying cannot fix.
+func redialTunnelSession(ctx context.Context, t tunnel, stats *tunnelStats) (*yamux.Session, error) {
+	lastError := "gateway connection lost"
+	for attempt := 1; ; attempt++ {
+		delay := tunnelReconnectDelay(attempt)
+		stats.setRetry(attempt, time.Now().Add(delay), lastError)
+		debugf("tunnel %s: reconnect attempt %d in %s", t.TunnelID, attempt, delay)
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(delay):
+		}
+		session, err := dialTunnelSession(ctx, t)
+		if err == nil {
+			stats.reconnects.Add(1)
+			stats.setState(tunnelStateConnected, "")
+			return session, nil
+		}
+		var rejected *tunnelRejectedError
+		if errors.As(err, &rejected) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		lastError = err.Error()
+	}
+}
+
+// tunnelRejectedError is returned when the gateway refuses the tunnel, for
+// example because its connect token expired.
+type tunnelRejectedError struct {
+	message string
+}
+
+func (e *tunnelRejectedError) Error() string {
+	return "tunnel session failed: " + e.message
+}
+
+// describeTunnelLive renders a status line for the running-tunnel views.
+func describeTunnelLive(s tunnelLiveStatus, now time.Time) string {
+	state := s.State
+	switch s.State {
+	case tunnelStateConnected:
+		if since, err := time.Parse(time.RFC3339, s.Since); err == nil {
+			state += " for " + formatElapsed(now.Sub(since))
+		}
+	case tunnelStateReconnecting:
+		if retryAt, err := time.Parse(time.RFC3339Nano, s.RetryAt); err == nil {
+			if wait := retryAt.Sub(now); wait > 0 {
+				state += fmt.Sprintf(", attempt %d in %ds", s.Attempt, int((wait+time.Second-1)/time.Second))
+			} else {
+				state += fmt.Sprintf(", attempt %d now", s.Attempt)
+			}
+		}
+		if s.LastError != "" {
+			state += " (" + s.LastError + ")"
+		}
+	}
+	line := fmt.Sprintf("%s | sent %s, received %s | %d open connection(s)",
+		state, formatBytes(int64(s.BytesSent)), formatBytes(int64(s.BytesReceived)), s.ActiveStreams)
+	if s.Reconnects > 0 {
+		line += fmt.Sprintf(" | %d reconnect(s)", s.Reconnects)
+	}
+	if s.Target != "" {
+		line += " | via " + s.Target
+	}
+	if started, err := time.Parse(time.RFC3339, s.StartedAt); err == nil {
+		line += " | up " + formatElapsed(now.Sub(started))
+	}
+	return line
+}
+
+// formatElapsed renders d as 45s, 3m07s or 2h05m.
+func formatElapsed(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// overviewFetchWorkers bounds the tunnel lookups running at once when
+// listing every project.
+const overviewFetchWorkers = 4
+
+// projectTunnel is a tunnel with the project and organization it belongs to.
+type projectTunnel struct {
+	Org     string
+	Project project
+	Tunnel  tunnel
+}
+
+// orgProject is a project with the organization it was listed under. Org is
+// empty for the user's own projects.
+type orgProject struct {
+	Org     string
+	Project project
+}
+
+// allAccessibleProjects lists the user's own projects followed by the
+// projects of every organization they belong to. A project that shows up in
+// more than one listing is kept once.
+func allAccessibleProjects(token string) ([]orgProject, error) {
+	own, err := fetchProjects(token)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(own))
+	all := make([]orgProject, 0, len(own))
+	for _, p := range own {
+		seen[p.ID] = true
+		all = append(all, orgProject{Project: p})
+	}
+
+	orgs, err := fetchOrganizations(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	for _, org := range orgs {
+		projects, err := fetchProjectsWithOrg(token, org.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects of %s: %w", org.Name, err)
+		}
+		for _, p := range projects {
+			if seen[p.ID] {
+				continue
+			}
+			seen[p.ID] = true
+			all = append(all, orgProject{Org: org.Name, Project: p})
+		}
+	}
+	return all, nil
+}
+
+// fetchAllProjectTunnels lists the tunnels of every accessible project,
+// a few projects at a time. Projects whose tunnels cannot be fetched are
+// reported in failures and left out.
+func fetchAllProjectTunnels(token string) (entries []projectTunnel, failures []error, err error) {
+	projects, err := allAccessibleProjects(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type result struct {
+		index   int
+		tunnels []tunnel
+		err     error
+	}
+	jobs := make(chan int)
+	results := make(chan result, len(projects))
+	var wg sync.WaitGroup
+	for w := 0; w < overviewFetchWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				tunnels, err := fetchTunnels(token, projects[i].Project.ID)
+				results <- result{index: i, tunnels: tunnels, err: err}
+			}
+		}()
+	}
+	for i := range projects {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	byProject := make([][]tunnel, len(projects))
+	for r := range results {
+		if r.err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", projects[r.index].Project.Name, r.err))
+			continue
+		}
+		byProject[r.index] = r.tunnels
+	}
+	for i, p := range projects {
+		for _, t := range byProject[i] {
+			entries = append(entries, projectTunnel{Org: p.Org, Project: p.Project, Tunnel: t})
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Error() < failures[j].Error() })
+	return entries, failures, nil
+}
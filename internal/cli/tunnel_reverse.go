@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/yamux"
+)
+
+// tunnelDirectionReverse marks a tunnel whose streams are opened by the
+// gateway: the container connects to the target port and the CLI dials the
+// local port.
+const tunnelDirectionReverse = "reverse"
+
+const reverseDialTimeout = 5 * time.Second
+
+func tunnelReverseFlow(args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: hubfly tunnel reverse <containerIdOrName> <remotePort> <localPort>")
+	}
+	remotePort, err := strconv.Atoi(args[1])
+	if err != nil || remotePort <= 0 || remotePort > 65535 {
+		return errors.New("invalid remote port")
+	}
+	localPort, err := strconv.Atoi(args[2])
+	if err != nil || localPort <= 0 || localPort > 65535 {
+		return errors.New("invalid local port")
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Searching for container '%s'...\n", args[0])
+	targetContainer, projectID, err := findContainer(token, args[0])
+	if err != nil {
+		return err
+	}
+	t, err := createTunnel(token, projectID, createTunnelRequest{
+		ContainerID: targetContainer.ID,
+		TargetPort:  remotePort,
+		LocalPort:   localPort,
+		Direction:   tunnelDirectionReverse,
+	})
+	if err != nil {
+		return err
+	}
+	if err := saveTunnelTicket(t); err != nil {
+		return err
+	}
+	target, err := primaryTunnelTarget(t, remotePort)
+	if err != nil {
+		return err
+	}
+	if err := checkTunnelConnectable(t, target); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Println("Establishing reverse tunnel...")
+	fmt.Printf("Remote: %s:%d -> Local: localhost:%d\n", resolveTunnelForwardHost(t), target.TargetPort, localPort)
+	fmt.Printf("Gateway: %s\n", t.ConnectURL)
+
+	if err := serveReverseTunnel(ctx, t, target, localPort, newTunnelStats()); err != nil {
+		return err
+	}
+	_ = removeTunnelTicket(t.TunnelID)
+	return nil
+}
+
+// serveReverseTunnel accepts streams the gateway opens for connections made
+// to the target port inside the container and proxies each one to
+// localhost:localPort. It returns when ctx is cancelled or the session ends.
+func serveReverseTunnel(ctx context.Context, t tunnel, target tunnelTarget, localPort int, stats *tunnelStats) error {
+	session, err := dialTunnelSession(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Reverse tunnel connected.")
+	fmt.Println("Press Ctrl+C to stop.")
+	stats.setState(tunnelStateConnected, "")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		stop := context.AfterFunc(ctx, func() { _ = session.Close() })
+		for {
+			stream, err := session.AcceptStream()
+			if err != nil {
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := acceptReverseStream(ctx, stream, target, localPort, stats); err != nil {
+					debugf("reverse tunnel stream error: %v", err)
+				}
+			}()
+		}
+		stop()
+		_ = session.Close()
+		if ctx.Err() != nil {
+			return nil
+		}
+		// The gateway dropped the session: redial and keep serving.
+		session, err = redialTunnelSession(ctx, t, stats)
+		if err != nil || session == nil {
+			return err
+		}
+	}
+}
+
+// acceptReverseStream answers the gateway's connect line for one stream,
+// dials the local port and copies data both ways. Dial failures are reported
+// back to the gateway so the remote client sees the connection refused.
+func acceptReverseStream(ctx context.Context, stream *yamux.Stream, target tunnelTarget, localPort int, stats *tunnelStats) error {
+	defer stream.Close()
+
+	reader := bufio.NewReader(stream)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read reverse stream request: %w", err)
+	}
+	var request tunnelStreamConnectRequest
+	if err := json.Unmarshal(bytesTrimSpace(line), &request); err != nil || request.Type != "connect" {
+		return writeReverseStreamResponse(stream, tunnelStreamConnectResponse{
+			Type: "error", Code: "BAD_REQUEST", Message: "expected a connect request",
+		})
+	}
+	if request.TargetID != "" && request.TargetID != target.TargetID {
+		return writeReverseStreamResponse(stream, tunnelStreamConnectResponse{
+			Type: "error", Code: "TARGET_NOT_FOUND", Message: "unknown target " + request.TargetID,
+		})
+	}
+
+	dialer := net.Dialer{Timeout: reverseDialTimeout}
+	localConn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		_ = writeReverseStreamResponse(stream, tunnelStreamConnectResponse{
+			Type: "error", Code: "LOCAL_UNAVAILABLE", Message: fmt.Sprintf("nothing is listening on localhost:%d", localPort),
+		})
+		return err
+	}
+	defer localConn.Close()
+	if err := writeReverseStreamResponse(stream, tunnelStreamConnectResponse{Type: "connected"}); err != nil {
+		return err
+	}
+
+	stats.activeStreams.Add(1)
+	defer stats.activeStreams.Add(-1)
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		copyCounted(stream, localConn, &stats.sent)
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		copyCounted(localConn, reader, &stats.received)
+		cancel()
+	}()
+	<-copyCtx.Done()
+	_ = localConn.Close()
+	_ = stream.Close()
+	wg.Wait()
+	return nil
+}
+
+func writeReverseStreamResponse(stream *yamux.Stream, response tunnelStreamConnectResponse) error {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = stream.Write(append(payload, '\n'))
+	return err
+}
+
+func isReverseTunnel(t tunnel) bool {
+	return strings.EqualFold(strings.TrimSpace(t.Direction), tunnelDirectionReverse)
+}
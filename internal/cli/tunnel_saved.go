@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// savedTunnel is a named tunnel definition from ~/.hubfly/tunnels.yaml.
+// Starting one creates a fresh tunnel on the server each time, so only what
+// is needed to create it is stored, never a connect token.
+type savedTunnel struct {
+	Name       string `yaml:"-" json:"name"`
+	Container  string `yaml:"container" json:"container"`
+	Project    string `yaml:"project,omitempty" json:"project,omitempty"`
+	RemotePort int    `yaml:"remotePort" json:"remotePort"`
+	LocalPort  int    `yaml:"localPort,omitempty" json:"localPort,omitempty"`
+	Direction  string `yaml:"direction,omitempty" json:"direction,omitempty"`
+}
+
+type savedTunnelsFile struct {
+	Tunnels map[string]savedTunnel `yaml:"tunnels"`
+}
+
+func savedTunnelsPath() string {
+	return filepath.Join(hubflyDir(), "tunnels.yaml")
+}
+
+// loadSavedTunnels reads tunnels.yaml sorted by name. A missing file is an
+// empty list.
+func loadSavedTunnels() ([]savedTunnel, error) {
+	content, err := os.ReadFile(savedTunnelsPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var file savedTunnelsFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", savedTunnelsPath(), err)
+	}
+	saved := make([]savedTunnel, 0, len(file.Tunnels))
+	for name, s := range file.Tunnels {
+		s.Name = name
+		if err := validateSavedTunnel(s); err != nil {
+			return nil, fmt.Errorf("%s: %w", savedTunnelsPath(), err)
+		}
+		saved = append(saved, s)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Name < saved[j].Name })
+	return saved, nil
+}
+
+func writeSavedTunnels(saved []savedTunnel) error {
+	file := savedTunnelsFile{Tunnels: make(map[string]savedTunnel, len(saved))}
+	for _, s := range saved {
+		file.Tunnels[s.Name] = s
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	if err := ensurePrivateDir(hubflyDir()); err != nil {
+		return err
+	}
+	return writePrivateFile(savedTunnelsPath(), buf.Bytes())
+}
+
+func findSavedTunnel(name string) (savedTunnel, bool, error) {
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		return savedTunnel{}, false, err
+	}
+	for _, s := range saved {
+		if s.Name == name {
+			return s, true, nil
+		}
+	}
+	return savedTunnel{}, false, nil
+}
+
+func validateSavedTunnel(s savedTunnel) error {
+	if s.Name == "" || sanitizeID(s.Name) != s.Name {
+		return fmt.Errorf("tunnel name %q may only contain letters, digits, '-' and '_'", s.Name)
+	}
+	if strings.TrimSpace(s.Container) == "" {
+		return fmt.Errorf("tunnel %q: container is required", s.Name)
+	}
+	if s.RemotePort <= 0 || s.RemotePort > 65535 {
+		return fmt.Errorf("tunnel %q: remotePort must be between 1 and 65535", s.Name)
+	}
+	if s.LocalPort < 0 || s.LocalPort > 65535 {
+		return fmt.Errorf("tunnel %q: localPort must be between 1 and 65535", s.Name)
+	}
+	switch s.Direction {
+	case "", "forward":
+	case tunnelDirectionReverse:
+		if s.LocalPort == 0 {
+			return fmt.Errorf("tunnel %q: a reverse tunnel needs localPort", s.Name)
+		}
+	default:
+		return fmt.Errorf("tunnel %q: direction must be forward or reverse", s.Name)
+	}
+	return nil
+}
+
+// savedTunnelLocalPort picks the local port for s: its localPort when set,
+// otherwise a free suggestion. A configured port may still be in use; callers
+// check that. Reverse tunnels dial the port instead of binding it, so it is
+// used as is.
+func savedTunnelLocalPort(s savedTunnel, taken map[int]bool) (int, error) {
+	if s.Direction == tunnelDirectionReverse {
+		return s.LocalPort, nil
+	}
+	if s.LocalPort > 0 {
+		if _, err := checkLocalPort(s.LocalPort); err != nil {
+			return 0, fmt.Errorf("saved tunnel %s: %w", s.Name, err)
+		}
+		return s.LocalPort, nil
+	}
+	port := suggestLocalPort(s.RemotePort, taken)
+	if port == 0 {
+		return 0, fmt.Errorf("saved tunnel %s: no free local port available", s.Name)
+	}
+	return port, nil
+}
+
+// createSavedTunnel resolves the container of s and creates a tunnel for it,
+// saving the ticket. It returns the tunnel and its project and container.
+func createSavedTunnel(token string, s savedTunnel, localPort int) (tunnel, project, container, error) {
+	p, c, err := findContainerInProjects(token, s.Project, s.Container)
+	if err != nil {
+		return tunnel{}, project{}, container{}, fmt.Errorf("saved tunnel %s: %w", s.Name, err)
+	}
+	direction := ""
+	if s.Direction == tunnelDirectionReverse {
+		direction = tunnelDirectionReverse
+	}
+	t, err := createTunnel(token, p.ID, createTunnelRequest{
+		ContainerID: c.ID,
+		TargetPort:  s.RemotePort,
+		LocalPort:   localPort,
+		Direction:   direction,
+	})
+	if err != nil {
+		return tunnel{}, project{}, container{}, fmt.Errorf("saved tunnel %s: %w", s.Name, err)
+	}
+	if err := saveTunnelTicket(t); err != nil {
+		return tunnel{}, project{}, container{}, err
+	}
+	return t, p, c, nil
+}
+
+// tunnelUpSavedFlow starts the saved tunnel name in the background, like
+// `tunnel up` with the definition's container and ports.
+func tunnelUpSavedFlow(name, sessionName string, autoPort bool) error {
+	s, ok, err := findSavedTunnel(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no saved tunnel named %q; list them with `hubfly tunnel saved`", name)
+	}
+	if sessionName == "" {
+		sessionName = s.Name
+	}
+	if existing, err := loadTunnelSession(sessionName); err == nil && hubflyProcessAlive(existing.PID) {
+		return fmt.Errorf("tunnel session %q is already running (pid %d); stop it with `hubfly tunnel down %s`", sessionName, existing.PID, sessionName)
+	}
+	localPort, err := savedTunnelLocalPort(s, nil)
+	if err != nil {
+		return err
+	}
+	if s.Direction != tunnelDirectionReverse {
+		if localPort, err = ensureLocalPortFree(localPort, autoPort); err != nil {
+			return err
+		}
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	t, p, c, err := createSavedTunnel(token, s, localPort)
+	if err != nil {
+		return err
+	}
+	session, err := startDetachedTunnel(sessionName, t, p.ID, c.Name, localPort, s.RemotePort)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Tunnel %q running in the background (pid %d).\n", session.Name, session.PID)
+	if s.Direction == tunnelDirectionReverse {
+		fmt.Printf("%s:%d -> localhost:%d\n", c.Name, s.RemotePort, localPort)
+	} else {
+		fmt.Printf("localhost:%d -> %s:%d\n", localPort, c.Name, s.RemotePort)
+	}
+	fmt.Printf("Logs: %s\n", session.LogPath)
+	return nil
+}
+
+func tunnelSaveFlow(args []string) error {
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	fs := newFlagSet("tunnel save")
+	containerQuery := fs.String("container", "", "container id or name")
+	remotePort := fs.Int("port", 0, "container port")
+	projectQuery := fs.String("project", "", "project id or name to search")
+	localPort := fs.Int("local-port", 0, "preferred local port (picked automatically when unset)")
+	reverse := fs.Bool("reverse", false, "expose the local port inside the container instead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		name = ""
+	}
+	if name == "" {
+		return errors.New("usage: hubfly tunnel save <name> --container <idOrName> --port <remotePort> [--project <id|name>] [--local-port <port>] [--reverse]")
+	}
+
+	s := savedTunnel{
+		Name:       name,
+		Container:  strings.TrimSpace(*containerQuery),
+		Project:    strings.TrimSpace(*projectQuery),
+		RemotePort: *remotePort,
+		LocalPort:  *localPort,
+	}
+	if *reverse {
+		s.Direction = tunnelDirectionReverse
+	}
+	if err := validateSavedTunnel(s); err != nil {
+		return err
+	}
+
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range saved {
+		if saved[i].Name == name {
+			saved[i] = s
+			replaced = true
+		}
+	}
+	if !replaced {
+		saved = append(saved, s)
+	}
+	if err := writeSavedTunnels(saved); err != nil {
+		return err
+	}
+	verb := "Saved"
+	if replaced {
+		verb = "Updated"
+	}
+	fmt.Printf("%s tunnel %q. Start it with `hubfly tunnel up %s`.\n", verb, name, name)
+	return nil
+}
+
+func tunnelSavedFlow(args []string) error {
+	if len(args) > 0 && (args[0] == "rm" || args[0] == "delete") {
+		if len(args) != 2 {
+			return errors.New("usage: hubfly tunnel saved rm <name>")
+		}
+		return removeSavedTunnel(args[1])
+	}
+	if len(args) > 0 {
+		return errors.New("usage: hubfly tunnel saved [rm <name>]")
+	}
+
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if saved == nil {
+			saved = []savedTunnel{}
+		}
+		return printJSON(saved)
+	}
+	if len(saved) == 0 {
+		fmt.Printf("No saved tunnels. Add one with `hubfly tunnel save` or edit %s.\n", savedTunnelsPath())
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Name\tProject\tTarget\tLocal\tDirection")
+	for _, s := range saved {
+		local := "auto"
+		if s.LocalPort > 0 {
+			local = strconv.Itoa(s.LocalPort)
+		}
+		direction := s.Direction
+		if direction == "" {
+			direction = "forward"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s:%d\t%s\t%s\n", s.Name, valueOrDash(s.Project), s.Container, s.RemotePort, local, direction)
+	}
+	return tw.Flush()
+}
+
+func removeSavedTunnel(name string) error {
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		return err
+	}
+	kept := saved[:0]
+	for _, s := range saved {
+		if s.Name != name {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == len(saved) {
+		return fmt.Errorf("no saved tunnel named %q", name)
+	}
+	if err := writeSavedTunnels(kept); err != nil {
+		return err
+	}
+	fmt.Printf("Removed saved tunnel %q.\n", name)
+	return nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSavedTunnels(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if saved, err := loadSavedTunnels(); err != nil || len(saved) != 0 {
+		t.Fatalf("missing file: saved=%v err=%v", saved, err)
+	}
+
+	writeFile := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(savedTunnelsPath()), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(savedTunnelsPath(), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(`
+tunnels:
+  web:
+    container: web
+    remotePort: 3000
+  db:
+    container: postgres
+    project: shop
+    remotePort: 5432
+    localPort: 15432
+`)
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[0].Name != "db" || saved[1].Name != "web" {
+		t.Fatalf("saved = %+v, want db and web sorted by name", saved)
+	}
+	if saved[0].Project != "shop" || saved[0].LocalPort != 15432 {
+		t.Fatalf("db = %+v", saved[0])
+	}
+
+	writeFile(`
+tunnels:
+  hook:
+    container: api
+    remotePort: 9000
+    direction: reverse
+`)
+	if _, err := loadSavedTunnels(); err == nil || !strings.Contains(err.Error(), "needs localPort") {
+		t.Fatalf("err = %v, want missing localPort error", err)
+	}
+}
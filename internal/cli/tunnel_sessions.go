@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// tunnelSession is the on-disk record of a detached tunnel started with
+// `hubfly tunnel up`. The record lives in ~/.hubfly/state/sessions and is the
+// only link between later `ps`/`down` invocations and the background process.
+type tunnelSession struct {
+	Name       string `json:"name"`
+	PID        int    `json:"pid"`
+	TunnelID   string `json:"tunnelId"`
+	ProjectID  string `json:"projectId"`
+	Container  string `json:"container"`
+	LocalPort  int    `json:"localPort"`
+	TargetPort int    `json:"targetPort"`
+	StartedAt  string `json:"startedAt"`
+	LogPath    string `json:"logPath"`
+	// Compose is the compose file a `tunnel up --compose` session was
+	// started from, and ComposePort the local port that file asked for.
+	Compose     string `json:"compose,omitempty"`
+	ComposePort int    `json:"composePort,omitempty"`
+}
+
+// tunnelStartupTimeout bounds how long `tunnel up` waits for a new session
+// to connect before leaving it to connect in the background.
+const tunnelStartupTimeout = 10 * time.Second
+
+func tunnelSessionPath(name string) string {
+	return filepath.Join(sessionsDir(), sanitizeID(name)+".json")
+}
+
+func tunnelSessionLogPath(name string) string {
+	return filepath.Join(sessionsDir(), sanitizeID(name)+".log")
+}
+
+func saveTunnelSession(s tunnelSession) error {
+	if err := ensurePrivateDir(sessionsDir()); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(tunnelSessionPath(s.Name), append(payload, '\n'))
+}
+
+func loadTunnelSession(name string) (tunnelSession, error) {
+	var s tunnelSession
+	content, err := os.ReadFile(tunnelSessionPath(name))
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(content, &s); err != nil {
+		return tunnelSession{}, err
+	}
+	return s, nil
+}
+
+func listTunnelSessions() ([]tunnelSession, error) {
+	entries, err := os.ReadDir(sessionsDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sessions := make([]tunnelSession, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		s, err := loadTunnelSession(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			debugf("skipping unreadable session %s: %v", entry.Name(), err)
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
+	return sessions, nil
+}
+
+func removeTunnelSession(name string) error {
+	for _, path := range []string{tunnelSessionPath(name), tunnelSessionLogPath(name)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func tunnelSessionState(s tunnelSession) string {
+	if hubflyProcessAlive(s.PID) {
+		return "running"
+	}
+	return "exited"
+}
+
+func tunnelUpFlow(args []string) error {
+	fs := newFlagSet("tunnel up")
+	name := fs.String("name", "", "session name used by `tunnel ps` and `tunnel down` (defaults to the container)")
+	autoPort := fs.Bool("auto-port", false, "use the next free local port when the chosen one is taken")
+	composePath := fs.String("compose", "", "open a tunnel for every port in this compose file")
+	autoApprove := fs.Bool("auto-approve", false, "apply the --compose plan without asking")
+	projectQuery := fs.String("project", "", "project id or name to use with --compose")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	rest := fs.Args()
+	if strings.TrimSpace(*composePath) != "" {
+		if len(rest) > 0 || strings.TrimSpace(*name) != "" {
+			return errors.New("usage: hubfly tunnel up --compose <file> [--project <id|name>] [--auto-port] [--auto-approve]")
+		}
+		return tunnelUpComposeFlow(*composePath, *projectQuery, *autoPort, *autoApprove)
+	}
+	if len(rest) == 1 {
+		return tunnelUpSavedFlow(rest[0], strings.TrimSpace(*name), *autoPort)
+	}
+	if len(rest) != 3 {
+		return errors.New("usage: hubfly tunnel up [--name <name>] [--auto-port] <containerIdOrName> <localPort|auto> <targetPort> | <savedName> | --compose <file> [--project <id|name>]")
+	}
+	targetPort, err := strconv.Atoi(rest[2])
+	if err != nil || targetPort <= 0 {
+		return errors.New("invalid target port")
+	}
+	localPort, err := resolveLocalPort(workspaceLocalPort(rest[1], rest[0], targetPort), targetPort)
+	if err != nil {
+		return err
+	}
+	if localPort, err = ensureLocalPortFree(localPort, *autoPort); err != nil {
+		return err
+	}
+	sessionName := strings.TrimSpace(*name)
+	if sessionName == "" {
+		sessionName = rest[0]
+	}
+
+	if existing, err := loadTunnelSession(sessionName); err == nil && hubflyProcessAlive(existing.PID) {
+		return fmt.Errorf("tunnel session %q is already running (pid %d); stop it with `hubfly tunnel down %s`", sessionName, existing.PID, sessionName)
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Searching for container '%s'...\n", rest[0])
+	targetContainer, projectID, err := findContainer(token, rest[0])
+	if err != nil {
+		return err
+	}
+	s, t, err := startContainerTunnel(token, projectID, *targetContainer, sessionName, localPort, targetPort)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Tunnel %q running in the background (pid %d).\n", s.Name, s.PID)
+	fmt.Printf("localhost:%d -> %s:%d\n", localPort, resolveTunnelForwardHost(t), targetPort)
+	fmt.Printf("Logs: %s\n", s.LogPath)
+	return nil
+}
+
+// startContainerTunnel creates a tunnel to c, saves its ticket and connects
+// it in a background session.
+func startContainerTunnel(token, projectID string, c container, sessionName string, localPort, targetPort int) (tunnelSession, tunnel, error) {
+	t, err := createTunnel(token, projectID, createTunnelRequest{
+		ContainerID: c.ID,
+		TargetPort:  targetPort,
+		LocalPort:   localPort,
+	})
+	if err != nil {
+		return tunnelSession{}, tunnel{}, err
+	}
+	if err := saveTunnelTicket(t); err != nil {
+		return tunnelSession{}, tunnel{}, err
+	}
+	s, err := startDetachedTunnel(sessionName, t, projectID, c.Name, localPort, targetPort)
+	if err != nil {
+		return tunnelSession{}, tunnel{}, err
+	}
+	return s, t, nil
+}
+
+func startDetachedTunnel(name string, t tunnel, projectID, containerName string, localPort, targetPort int) (tunnelSession, error) {
+	if err := ensurePrivateDir(sessionsDir()); err != nil {
+		return tunnelSession{}, err
+	}
+	logPath := tunnelSessionLogPath(name)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return tunnelSession{}, err
+	}
+	defer func() { _ = logFile.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return tunnelSession{}, err
+	}
+	cmd := exec.Command(exe, "__connect-tunnel", t.TunnelID, strconv.Itoa(localPort), strconv.Itoa(targetPort))
+	// The status file feeds the running tunnels dashboard in the TUI.
+	cmd.Env = append(os.Environ(), "HUBFLY_TUNNEL_STATUS="+tunnelStatusPath(t.TunnelID, localPort))
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detachCommand(cmd)
+	// A status file left by an earlier process would read as ready.
+	removeTunnelLiveStatus(t.TunnelID, localPort)
+	if err := cmd.Start(); err != nil {
+		return tunnelSession{}, err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	s := tunnelSession{
+		Name:       name,
+		PID:        cmd.Process.Pid,
+		TunnelID:   t.TunnelID,
+		ProjectID:  projectID,
+		Container:  containerName,
+		LocalPort:  localPort,
+		TargetPort: targetPort,
+		StartedAt:  time.Now().UTC().Format(time.RFC3339),
+		LogPath:    logPath,
+	}
+	if err := saveTunnelSession(s); err != nil {
+		_ = terminateProcess(s.PID)
+		return tunnelSession{}, err
+	}
+	rememberLocalPort(t.TunnelID, localPort)
+
+	// Wait for the child to connect so obvious failures surface now instead
+	// of on the next `tunnel ps`. One still retrying the gateway when the
+	// wait ends keeps running, as `tunnel ps` will show.
+	statusPath := tunnelStatusPath(t.TunnelID, localPort)
+	deadline := time.After(tunnelStartupTimeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-exited:
+			_ = os.Remove(tunnelSessionPath(name))
+			return tunnelSession{}, fmt.Errorf("tunnel process exited during startup:\n%s", tailFile(logPath, 10))
+		case <-deadline:
+			return s, nil
+		case <-ticker.C:
+			if status, ok := loadTunnelLiveStatus(statusPath); ok && status.State == tunnelStateConnected {
+				return s, nil
+			}
+		}
+	}
+}
+
+func tunnelPsFlow(_ []string) error {
+	sessions, err := listTunnelSessions()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		type row struct {
+			tunnelSession
+			State string `json:"state"`
+		}
+		rows := make([]row, 0, len(sessions))
+		for _, s := range sessions {
+			rows = append(rows, row{tunnelSession: s, State: tunnelSessionState(s)})
+		}
+		return printJSON(rows)
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No background tunnels.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Name\tPID\tLocal\tTarget\tState\tStarted\tTunnel ID")
+	for _, s := range sessions {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\tlocalhost:%d\t%s:%d\t%s\t%s\t%s\n",
+			s.Name, s.PID, s.LocalPort, valueOrDash(s.Container), s.TargetPort, tunnelSessionState(s), s.StartedAt, s.TunnelID)
+	}
+	return tw.Flush()
+}
+
+func tunnelDownFlow(args []string) error {
+	fs := newFlagSet("tunnel down")
+	all := fs.Bool("all", false, "stop every background tunnel")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var targets []tunnelSession
+	if *all {
+		sessions, err := listTunnelSessions()
+		if err != nil {
+			return err
+		}
+		targets = sessions
+	} else {
+		if len(fs.Args()) != 1 {
+			return errors.New("usage: hubfly tunnel down <name> | --all")
+		}
+		s, err := loadTunnelSession(fs.Args()[0])
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("no background tunnel named %q", fs.Args()[0])
+			}
+			return err
+		}
+		targets = []tunnelSession{s}
+	}
+
+	for _, s := range targets {
+		if err := stopTunnelSession(s); err != nil {
+			return err
+		}
+		fmt.Printf("Stopped %s.\n", s.Name)
+	}
+	return nil
+}
+
+func stopTunnelSession(s tunnelSession) error {
+	if err := terminateProcess(s.PID); err != nil {
+		return fmt.Errorf("failed to stop %s (pid %d): %w", s.Name, s.PID, err)
+	}
+	removeTunnelLiveStatus(s.TunnelID, s.LocalPort)
+	return removeTunnelSession(s.Name)
+}
+
+// restartTunnelSession replaces a session's process with a fresh one on the
+// same ticket and local port, for a tunnel that exited or is stuck.
+func restartTunnelSession(s tunnelSession) (tunnelSession, error) {
+	t, err := loadTunnelTicket(s.TunnelID)
+	if err != nil {
+		return tunnelSession{}, fmt.Errorf("local tunnel ticket not found for tunnel %s", s.TunnelID)
+	}
+	if err := terminateProcess(s.PID); err != nil {
+		return tunnelSession{}, fmt.Errorf("failed to stop %s (pid %d): %w", s.Name, s.PID, err)
+	}
+	removeTunnelLiveStatus(s.TunnelID, s.LocalPort)
+	restarted, err := startDetachedTunnel(s.Name, t, s.ProjectID, s.Container, s.LocalPort, s.TargetPort)
+	if err != nil {
+		return tunnelSession{}, err
+	}
+	if s.Compose != "" {
+		restarted.Compose, restarted.ComposePort = s.Compose, s.ComposePort
+		if err := saveTunnelSession(restarted); err != nil {
+			return tunnelSession{}, err
+		}
+	}
+	return restarted, nil
+}
+
+func tailFile(path string, lines int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	buf := make([]string, 0, lines)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		buf = append(buf, scanner.Text())
+		if len(buf) > lines {
+			buf = buf[1:]
+		}
+	}
+	return strings.Join(buf, "\n")
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestStoppingASessionWithAReusedPIDLeavesTheProcessAlone(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { storageRoot = "" })
+	storageRoot = t.TempDir()
+
+	other := exec.Command("sleep", "5")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = other.Process.Kill(); _ = other.Wait() })
+
+	s := tunnelSession{Name: "db", PID: other.Process.Pid, TunnelID: "tun_1", LocalPort: 15432}
+	if err := saveTunnelSession(s); err != nil {
+		t.Fatal(err)
+	}
+	if state := tunnelSessionState(s); state != "exited" {
+		t.Fatalf("state of a session whose pid runs sleep = %q", state)
+	}
+	if err := stopTunnelSession(s); err != nil {
+		t.Fatal(err)
+	}
+	if !processAlive(other.Process.Pid) {
+		t.Fatal("stopping the session signalled an unrelated process")
+	}
+	if _, err := loadTunnelSession("db"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("session record after stop: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hubfly-cli/internal/testsupport"
+)
+
+func gatewayTicket(gw *testsupport.Gateway, tunnelID string) tunnel {
+	gw.AddTunnel(tunnelID, "connect-"+tunnelID, tunnelID+"-target")
+	return tunnel{
+		TunnelID:        tunnelID,
+		ConnectURL:      gw.URL,
+		ConnectToken:    "connect-" + tunnelID,
+		ProtocolVersion: 1,
+		Targets: []tunnelTarget{{
+			TargetID:      tunnelID + "-target",
+			ContainerName: "db",
+			TargetPort:    5432,
+		}},
+	}
+}
+
+func TestServeTunnelGatewayProxiesStreams(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	ticket := gatewayTicket(gw, "tun_1")
+	port := testsupport.FreePort(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- serveTunnelGateway(ctx, ticket, ticket.Targets[0], port, newTunnelStats()) }()
+
+	first := testsupport.DialLocal(t, port)
+	testsupport.Echo(t, first, "hello")
+	second := testsupport.DialLocal(t, port)
+	testsupport.Echo(t, second, "second stream")
+	testsupport.Echo(t, first, "again")
+
+	if got := gw.Streams(); got != 2 {
+		t.Fatalf("gateway saw %d streams, want 2", got)
+	}
+	cancel()
+	if err := <-done; err != nil && err != context.Canceled {
+		t.Fatalf("serveTunnelGateway returned %v", err)
+	}
+}
+
+func TestServeTunnelGatewayReconnectsAndCounts(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	ticket := gatewayTicket(gw, "tun_1")
+	port := testsupport.FreePort(t)
+	stats := newTunnelStats()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- serveTunnelGateway(ctx, ticket, ticket.Targets[0], port, stats) }()
+
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "before drop")
+	gw.DropSessions()
+	deadline := time.Now().Add(5 * time.Second)
+	for stats.reconnects.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no reconnect, state = %+v", stats.snapshot())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "after reconnect")
+
+	status := stats.snapshot()
+	if status.State != tunnelStateConnected || status.BytesSent == 0 || status.BytesReceived == 0 {
+		t.Fatalf("status = %+v", status)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serveTunnelGateway returned %v", err)
+	}
+}
+
+func TestServeTunnelGatewayReportsRejection(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	ticket := gatewayTicket(gw, "tun_1")
+	gw.Reject("tunnel expired")
+
+	err := serveTunnelGateway(context.Background(), ticket, ticket.Targets[0], testsupport.FreePort(t), newTunnelStats())
+	if err == nil || !strings.Contains(err.Error(), "tunnel expired") {
+		t.Fatalf("err = %v, want gateway rejection", err)
+	}
+}
+
+func TestServeTunnelGatewayFallsBackToNextTarget(t *testing.T) {
+	t.Cleanup(func() { storageRoot = "" })
+	storageRoot = t.TempDir()
+	gw := testsupport.NewGateway(t)
+	ticket := gatewayTicket(gw, "tun_1")
+	ticket.Targets = append([]tunnelTarget{{TargetID: "unreachable", ContainerName: "db-old", TargetPort: 5432}}, ticket.Targets...)
+	port := testsupport.FreePort(t)
+	t.Setenv("HUBFLY_TUNNEL_STATUS", tunnelStatusPath("tun_1", port))
+	stats := newTunnelStats()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = serveTunnelGateway(ctx, ticket, ticket.Targets[0], port, stats) }()
+
+	for i := 0; i < targetFailoverAfter; i++ {
+		conn := testsupport.DialLocal(t, port)
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err == nil {
+			t.Fatal("connection to the unreachable target carried data")
+		}
+	}
+	// The last refusal is counted just after its connection is closed.
+	for deadline := time.Now().Add(5 * time.Second); stats.snapshot().Target == "" && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := stats.snapshot().Target; got != "db:5432 (tun_1-target)" {
+		t.Fatalf("live status target = %q", got)
+	}
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "fallback")
+}
+
+func TestTunnelCreateFlowThenConnect(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HUBFLY_API_URL", "")
+	original := apiHost
+	t.Cleanup(func() { apiHost = original })
+
+	api := testsupport.NewMockAPI(t)
+	gw := testsupport.NewGateway(t)
+	apiHost = api.URL
+	if err := setToken("user-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	created := gatewayTicket(gw, "tun_new")
+	created.ExpiresAt = "2099-01-01T00:00:00Z"
+	api.Handle(http.MethodGet, "/api/v1/auth/me", user{ID: "u1", Name: "Test", Email: "t@example.com"})
+	api.Handle(http.MethodGet, "/api/v1/projects", apiPage[project]{Items: []project{{ID: "p1", Name: "shop"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p1", map[string]any{
+		"containers": []map[string]any{{"id": "c1", "name": "db"}},
+		"volumes":    []any{},
+	})
+	api.Handle(http.MethodPost, "/api/v1/projects/p1/tunnels/create", created)
+
+	if err := tunnelCreateFlow([]string{"--container", "db", "--port", "5432", "--ttl", "2h"}); err != nil {
+		t.Fatalf("tunnelCreateFlow: %v", err)
+	}
+
+	req, ok := api.LastRequest(http.MethodPost, "/api/v1/projects/p1/tunnels/create")
+	if !ok {
+		t.Fatal("tunnel create request was not sent")
+	}
+	if req.Auth != "Bearer user-token" {
+		t.Fatalf("Authorization = %q", req.Auth)
+	}
+	var body createTunnelRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ContainerID != "c1" || body.TargetPort != 5432 || body.TTLSeconds != 7200 {
+		t.Fatalf("create body = %+v", body)
+	}
+
+	ticket, err := loadTunnelTicket("tun_new")
+	if err != nil {
+		t.Fatalf("ticket not saved: %v", err)
+	}
+	target, err := primaryTunnelTarget(ticket, 5432)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := testsupport.FreePort(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = serveTunnelGateway(ctx, ticket, target, port, newTunnelStats()) }()
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "SELECT 1")
+}
+
+func TestServeReverseTunnelProxiesToLocalPort(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	ticket := gatewayTicket(gw, "tun_rev")
+	ticket.Direction = tunnelDirectionReverse
+	localPort := testsupport.EchoServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- serveReverseTunnel(ctx, ticket, ticket.Targets[0], localPort, newTunnelStats()) }()
+
+	conn, err := gw.OpenReverse("tun_rev", ticket.Targets[0].TargetID)
+	if err != nil {
+		t.Fatalf("OpenReverse: %v", err)
+	}
+	testsupport.Echo(t, conn, "POST /webhook")
+	_ = conn.Close()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serveReverseTunnel returned %v", err)
+	}
+}
+
+func TestServeReverseTunnelReportsClosedLocalPort(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	ticket := gatewayTicket(gw, "tun_rev")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = serveReverseTunnel(ctx, ticket, ticket.Targets[0], testsupport.FreePort(t), newTunnelStats())
+	}()
+
+	_, err := gw.OpenReverse("tun_rev", ticket.Targets[0].TargetID)
+	if err == nil || !strings.Contains(err.Error(), "nothing is listening") {
+		t.Fatalf("err = %v, want local port error", err)
+	}
+}
+
+func TestTunnelCreateFlowFromFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HUBFLY_API_URL", "")
+	original := apiHost
+	t.Cleanup(func() { apiHost = original })
+
+	api := testsupport.NewMockAPI(t)
+	apiHost = api.URL
+	if err := setToken("user-token"); err != nil {
+		t.Fatal(err)
+	}
+	api.Handle(http.MethodGet, "/api/v1/auth/me", user{ID: "u1", Name: "Test", Email: "t@example.com"})
+	api.Handle(http.MethodGet, "/api/v1/projects", apiPage[project]{Items: []project{{ID: "p1", Name: "shop"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p1", map[string]any{
+		"containers": []map[string]any{{"id": "c1", "name": "db"}},
+		"volumes":    []any{},
+	})
+	api.Handle(http.MethodPost, "/api/v1/projects/p1/tunnels/create", tunnel{TunnelID: "tun_file"})
+
+	spec := filepath.Join(t.TempDir(), "tunnel.json")
+	if err := os.WriteFile(spec, []byte(`{"projectId": "shop", "containerId": "db", "targetPort": 5432, "ttlSeconds": 60}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// --ttl on the command line wins over the file.
+	if err := tunnelCreateFlow([]string{"--from-file", spec, "--ttl", "2h"}); err != nil {
+		t.Fatalf("tunnelCreateFlow: %v", err)
+	}
+	req, ok := api.LastRequest(http.MethodPost, "/api/v1/projects/p1/tunnels/create")
+	if !ok {
+		t.Fatal("tunnel create request was not sent")
+	}
+	var body createTunnelRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ContainerID != "c1" || body.TargetPort != 5432 || body.TTLSeconds != 7200 {
+		t.Fatalf("create body = %+v", body)
+	}
+
+	if err := os.WriteFile(spec, []byte(`{"containerId": "db", "targetPort": 5432, "ttl": 60}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := tunnelCreateFlow([]string{"--from-file", spec}); err == nil || !strings.Contains(err.Error(), `unknown field "ttl"`) {
+		t.Fatalf("unknown field: err = %v", err)
+	}
+}
+
+func TestTunnelHealthcheckReportsEachStep(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	healthy := gatewayTicket(gw, "tun_ok")
+	badToken := gatewayTicket(gw, "tun_token")
+	badToken.ConnectToken = "wrong"
+	badTarget := gatewayTicket(gw, "tun_target")
+	badTarget.Targets[0].TargetID = "gone"
+	down := gatewayTicket(gw, "tun_down")
+	down.ConnectURL = fmt.Sprintf("ws://127.0.0.1:%d/tunnel", testsupport.FreePort(t))
+
+	results := checkTunnelHealth([]tunnel{healthy, badToken, badTarget, down}, true, 5*time.Second)
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.TunnelID] = fmt.Sprintf("%s/%s/%s/%v", r.Gateway, r.Auth, r.Stream, r.Healthy)
+	}
+	want := map[string]string{
+		"tun_ok":     "ok/ok/ok/true",
+		"tun_token":  "ok/failed/-/false",
+		"tun_target": "ok/ok/failed/false",
+		"tun_down":   "failed/-/-/false",
+	}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("%s = %s, want %s", id, got[id], w)
+		}
+	}
+
+	// Without --probe only the gateway is dialled.
+	quick := checkTunnelHealth([]tunnel{badToken}, false, 5*time.Second)
+	if len(quick) != 1 || !quick[0].Healthy || quick[0].Auth != healthSkipped {
+		t.Errorf("quick check = %+v", quick)
+	}
+}
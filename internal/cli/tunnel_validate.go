@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// normalizeAPIRecord is called by decodeAPIResponse on every tunnel the API
+// returns. Suspect records are reported once per run rather than failing the
+// command, since the rest of a listing is still useful.
+func (t *tunnel) normalizeAPIRecord() {
+	for _, problem := range normalizeTunnel(t) {
+		warnOnce("tunnel-record-"+t.TunnelID+"-"+problem, fmt.Sprintf("warning: tunnel %s from the API: %s", tunnelLabel(*t), problem))
+	}
+}
+
+// normalizeTunnel trims the string fields of a tunnel read from the API or a
+// ticket, so call sites can compare them as they are, and returns what looks
+// wrong with the record.
+func normalizeTunnel(t *tunnel) []string {
+	for _, field := range []*string{
+		&t.TunnelID, &t.ID, &t.ProjectID, &t.ProjectName, &t.TargetContainer,
+		&t.TargetContainerID, &t.ConnectURL, &t.ConnectToken, &t.Mode,
+		&t.Direction, &t.Status, &t.ExpiresAt, &t.CreatedAt,
+	} {
+		*field = strings.TrimSpace(*field)
+	}
+	var problems []string
+	if t.TargetPort < 0 || t.TargetPort > 65535 {
+		problems = append(problems, fmt.Sprintf("target port %d is out of range", t.TargetPort))
+	}
+	for i := range t.Targets {
+		target := &t.Targets[i]
+		for _, field := range []*string{&target.TargetID, &target.ContainerID, &target.ContainerName, &target.RuntimeID} {
+			*field = strings.TrimSpace(*field)
+		}
+		if err := validateTunnelTarget(*target); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if t.ConnectURL != "" {
+		if err := validateConnectURL(t.ConnectURL); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if t.ExpiresAt != "" {
+		if _, ok := parseExpiry(t.ExpiresAt); !ok {
+			problems = append(problems, fmt.Sprintf("expiry %q is not a recognised time", t.ExpiresAt))
+		}
+	}
+	return problems
+}
+
+func validateTunnelTarget(target tunnelTarget) error {
+	switch {
+	case target.TargetID == "":
+		return fmt.Errorf("a target on port %d has no target id", target.TargetPort)
+	case target.TargetPort <= 0 || target.TargetPort > 65535:
+		return fmt.Errorf("target %s has port %d, outside 1-65535", target.TargetID, target.TargetPort)
+	case target.LocalPort < 0 || target.LocalPort > 65535:
+		return fmt.Errorf("target %s has local port %d, outside 1-65535", target.TargetID, target.LocalPort)
+	}
+	return nil
+}
+
+func validateConnectURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("connect url %q has no host", raw)
+	}
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+		return nil
+	}
+	return fmt.Errorf("connect url %q is not a websocket url", raw)
+}
+
+// checkTunnelConnectable is the last check before dialing the gateway for
+// target, so a malformed record fails with a readable error instead of a
+// websocket or stream error.
+func checkTunnelConnectable(t tunnel, target tunnelTarget) error {
+	if t.ConnectURL == "" {
+		return fmt.Errorf("tunnel %s has no connect url; create a new tunnel", tunnelLabel(t))
+	}
+	if err := validateConnectURL(t.ConnectURL); err != nil {
+		return fmt.Errorf("tunnel %s: %w", tunnelLabel(t), err)
+	}
+	if err := validateTunnelTarget(target); err != nil {
+		return fmt.Errorf("tunnel %s: %w", tunnelLabel(t), err)
+	}
+	return nil
+}
+
+func tunnelLabel(t tunnel) string {
+	if t.TunnelID != "" {
+		return t.TunnelID
+	}
+	if t.ID != "" {
+		return t.ID
+	}
+	return "(no id)"
+}
@@ -2,17 +2,65 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
-var apiHost = getAPIHost()
+const defaultAPIHost = "https://api.hubfly.space"
 
+// apiHost is resolved in Run once --profile has been parsed.
+var apiHost = defaultAPIHost
+
+// configureAPIHost strips --api-host and exports it as HUBFLY_API_URL, so it
+// wins over the profile here and in background tunnels started from here.
+func configureAPIHost(args []string) ([]string, error) {
+	host := ""
+	filtered := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--api-host":
+			if i+1 >= len(args) || strings.TrimSpace(args[i+1]) == "" {
+				return nil, errors.New("--api-host requires a URL")
+			}
+			host = strings.TrimSpace(args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--api-host="):
+			host = strings.TrimSpace(strings.TrimPrefix(arg, "--api-host="))
+		default:
+			filtered = append(filtered, arg)
+		}
+	}
+	if host == "" {
+		return filtered, nil
+	}
+	parsed, err := url.Parse(host)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("--api-host %q is not an http(s) URL", host)
+	}
+	_ = os.Setenv("HUBFLY_API_URL", strings.TrimRight(host, "/"))
+	return filtered, nil
+}
+
+// getAPIHost resolves the API base URL: the demo fixture server when in demo
+// mode, else HUBFLY_API_URL, then the active profile's apiHost, then the
+// public API.
 func getAPIHost() string {
-	if url := os.Getenv("HUBFLY_API_URL"); url != "" {
-		return url
+	if demoAPIHost != "" {
+		return demoAPIHost
 	}
-	return "https://api.hubfly.space"
+	if host := os.Getenv("HUBFLY_API_URL"); host != "" {
+		return host
+	}
+	if host := strings.TrimSpace(activeProfile().APIHost); host != "" {
+		return strings.TrimRight(host, "/")
+	}
+	return defaultAPIHost
 }
 
 type apiError struct {
@@ -21,21 +69,78 @@ type apiError struct {
 	Message   string
 	RequestID string
 	ErrorID   string
+	// Details is the error's details object, such as a quota's limit or
+	// the fields that failed validation.
+	Details map[string]any
+	// RetryAfter is how long the API asked the client to wait, if it did.
+	RetryAfter time.Duration
 }
 
 func (e *apiError) Error() string {
-	traceID := e.ErrorID
-	if traceID == "" {
-		traceID = e.RequestID
+	parts := []string{fmt.Sprintf("status %d", e.Status)}
+	if e.RequestID != "" {
+		parts = append(parts, "request id: "+e.RequestID)
 	}
-	if traceID != "" {
-		return fmt.Sprintf("%s (status %d, trace %s)", e.Message, e.Status, traceID)
+	if e.ErrorID != "" && e.ErrorID != e.RequestID {
+		parts = append(parts, "error id: "+e.ErrorID)
 	}
-	return fmt.Sprintf("%s (status %d)", e.Message, e.Status)
+	return fmt.Sprintf("%s (%s)", e.Message, strings.Join(parts, ", "))
 }
 
 type storeConfig struct {
-	Token string `json:"token"`
+	CurrentProfile string                    `json:"currentProfile,omitempty"`
+	Profiles       map[string]*profileConfig `json:"profiles,omitempty"`
+	// Token is the pre-profile layout; migration 2 moves it into
+	// profiles.default.
+	Token string `json:"token,omitempty"`
+	// Keymap rebinds keys in `hubfly projects`: action name to keys,
+	// separated by commas.
+	Keymap map[string]string `json:"keymap,omitempty"`
+}
+
+type profileConfig struct {
+	Token          string `json:"token,omitempty"`
+	APIHost        string `json:"apiHost,omitempty"`
+	DefaultProject string `json:"defaultProject,omitempty"`
+	// DefaultProjectName is recorded by `hubfly use project` next to the
+	// project's ID, so commands can scope to it without listing projects.
+	DefaultProjectName string          `json:"defaultProjectName,omitempty"`
+	SSH                *sshDefaults    `json:"ssh,omitempty"`
+	Tunnels            *tunnelDefaults `json:"tunnels,omitempty"`
+	Update             *updateDefaults `json:"update,omitempty"`
+	TUI                *tuiDefaults    `json:"tui,omitempty"`
+	Tips               *tipsDefaults   `json:"tips,omitempty"`
+	// Locale picks the language of messages, overriding LC_ALL and LANG.
+	Locale string `json:"locale,omitempty"`
+}
+
+type sshDefaults struct {
+	ExecTimeout string `json:"execTimeout,omitempty"`
+}
+
+type tuiDefaults struct {
+	// RefreshInterval is how often `hubfly projects` reloads the list on
+	// screen while idle; "0" turns it off.
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+type tunnelDefaults struct {
+	// LocalPortRange is "low-high"; auto-assigned local ports stay inside it.
+	LocalPortRange string `json:"localPortRange,omitempty"`
+}
+
+type tipsDefaults struct {
+	// Track set to false stops counting usage for `hubfly tips`.
+	Track *bool `json:"track,omitempty"`
+}
+
+type updateDefaults struct {
+	// ReleaseURL is a self-hosted release source used instead of GitHub.
+	ReleaseURL string `json:"releaseURL,omitempty"`
+	// Channel is the release channel `hubfly update` follows by default.
+	Channel string `json:"channel,omitempty"`
+	// Notify set to false turns off the "new version available" notice.
+	Notify *bool `json:"notify,omitempty"`
 }
 
 type user struct {
@@ -65,19 +170,17 @@ type project struct {
 	Region    region `json:"region"`
 }
 
-type projectsResponse struct {
-	Projects []project `json:"items"`
-}
-
 type container struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Tier    string `json:"tier"`
-	Status  string `json:"status"`
-	Created string `json:"createdAt"`
-	Updated string `json:"updatedAt"`
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Tier    string   `json:"tier"`
+	Status  string   `json:"status"`
+	Tags    []string `json:"tags"`
+	Created string   `json:"createdAt"`
+	Updated string   `json:"updatedAt"`
 	Source  struct {
-		Type string `json:"type"`
+		Type  string `json:"type"`
+		Image string `json:"image"`
 	} `json:"source"`
 	Resources struct {
 		CPU     float64 `json:"cpu"`
@@ -123,6 +226,7 @@ type tunnel struct {
 	ConnectToken      string         `json:"connectToken,omitempty"`
 	ProtocolVersion   int            `json:"protocolVersion"`
 	Mode              string         `json:"mode"`
+	Direction         string         `json:"direction,omitempty"`
 	Status            string         `json:"status"`
 	Targets           []tunnelTarget `json:"targets"`
 	Limits            tunnelLimits   `json:"limits"`
@@ -131,6 +235,10 @@ type tunnel struct {
 	StreamsOpened     int            `json:"streamsOpened"`
 	CloseReason       string         `json:"closeReason"`
 	ExpiresAt         string         `json:"expiresAt"`
+	CreatedAt         string         `json:"createdAt,omitempty"`
+	// CreatedBy is a user id/email string or a user object depending on the
+	// API version; see tunnelCreatorName.
+	CreatedBy json.RawMessage `json:"createdBy,omitempty"`
 }
 
 type createTunnelRequest struct {
@@ -138,6 +246,9 @@ type createTunnelRequest struct {
 	TargetPort  int    `json:"targetPort"`
 	LocalPort   int    `json:"localPort,omitempty"`
 	TTLSeconds  int    `json:"ttlSeconds,omitempty"`
+	// Direction is "reverse" for a tunnel that exposes a local port inside the
+	// container; forward tunnels leave it empty.
+	Direction string `json:"direction,omitempty"`
 }
 
 type tunnelTarget struct {
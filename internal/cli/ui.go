@@ -6,6 +6,10 @@ import (
 )
 
 func clearScreen() {
+	if !terminalSupportsVT() {
+		clearScreenFallback()
+		return
+	}
 	fmt.Print("\033[H\033[2J")
 }
 
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"hubfly-cli/internal/service"
+)
+
+type uninstallOptions struct {
+	Revoke     bool
+	KeepData   bool
+	KeepBinary bool
+	Yes        bool
+}
+
+func parseUninstallOptions(args []string) (uninstallOptions, error) {
+	var opts uninstallOptions
+	fs := newFlagSet("uninstall")
+	fs.BoolVar(&opts.Revoke, "revoke", false, "delete tunnels with a local ticket on the server")
+	fs.BoolVar(&opts.KeepData, "keep-data", false, "leave ~/.hubfly in place")
+	fs.BoolVar(&opts.KeepBinary, "keep-binary", false, "leave the hubfly executable in place")
+	fs.BoolVar(&opts.Yes, "yes", false, "skip the confirmation prompt")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if len(fs.Args()) > 0 {
+		return opts, errors.New("usage: hubfly uninstall [--revoke] [--keep-data] [--keep-binary] [--yes]")
+	}
+	return opts, nil
+}
+
+// uninstallFlow offboards a machine: background tunnels and the local service
+// are stopped first so nothing keeps writing into ~/.hubfly while it is
+// being removed.
+func uninstallFlow(args []string) error {
+	opts, err := parseUninstallOptions(args)
+	if err != nil {
+		return err
+	}
+
+	exePath, err := os.Executable()
+	if err == nil {
+		if resolved, evalErr := filepath.EvalSymlinks(exePath); evalErr == nil {
+			exePath = resolved
+		}
+	}
+
+	fmt.Println("This will:")
+	fmt.Println("  - stop background tunnels and the local tunnel service")
+	fmt.Println("  - remove the tunnel service systemd/launchd unit, if installed")
+	if opts.Revoke {
+		fmt.Println("  - delete tunnels with a local ticket on the server")
+	}
+	if !opts.KeepData {
+		fmt.Printf("  - delete %s (login token, tickets, logs)\n", hubflyDir())
+	}
+	if !opts.KeepBinary && exePath != "" {
+		fmt.Printf("  - delete %s\n", exePath)
+	}
+	if !opts.Yes {
+		if !isInteractiveShell() {
+			return errors.New("refusing to uninstall without confirmation; pass --yes")
+		}
+		ok, err := promptYesNo("Continue", false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Uninstall cancelled.")
+			return nil
+		}
+	}
+
+	var failures []string
+	fail := func(step string, err error) {
+		fmt.Fprintf(os.Stderr, "warning: %s: %v\n", step, err)
+		failures = append(failures, step)
+	}
+
+	sessions, err := listTunnelSessions()
+	if err != nil {
+		fail("list background tunnels", err)
+	}
+	for _, s := range sessions {
+		if err := stopTunnelSession(s); err != nil {
+			fail("stop "+s.Name, err)
+			continue
+		}
+		fmt.Printf("Stopped background tunnel %s.\n", s.Name)
+	}
+
+	if info, err := service.ReadInfo(); err == nil {
+		// After a crash or reboot the pid in service.json may belong to
+		// another program; then only the stale file goes.
+		if !hubflyProcessAlive(info.PID) {
+			if err := os.Remove(service.InfoPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fail("remove "+service.InfoPath(), err)
+			}
+		} else if err := terminateProcess(info.PID); err != nil {
+			fail("stop tunnel service", err)
+		} else {
+			fmt.Printf("Stopped tunnel service (pid %d).\n", info.PID)
+		}
+	}
+
+	removedUnits, err := removeServiceUnits()
+	if err != nil {
+		fail("remove service unit", err)
+	}
+	for _, path := range removedUnits {
+		fmt.Printf("Removed %s\n", path)
+	}
+
+	if opts.Revoke {
+		if err := revokeLocalTunnels(); err != nil {
+			fail("revoke tunnels", err)
+		}
+	}
+
+	if !opts.KeepData {
+		if err := os.RemoveAll(hubflyDir()); err != nil {
+			fail("remove "+hubflyDir(), err)
+		} else {
+			fmt.Printf("Removed %s\n", hubflyDir())
+		}
+	}
+
+	if !opts.KeepBinary && exePath != "" {
+		if err := removeExecutable(exePath); err != nil {
+			fail("remove "+exePath, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("uninstall finished with errors: %s", strings.Join(failures, ", "))
+	}
+	fmt.Println("Hubfly CLI uninstalled.")
+	return nil
+}
+
+func revokeLocalTunnels() error {
+	token, err := getToken()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(token) == "" {
+		return errors.New("not logged in; skipping server-side revocation")
+	}
+	tickets, err := listTunnelTickets()
+	if err != nil {
+		return err
+	}
+	for _, t := range tickets {
+		id := t.TunnelID
+		if err := deleteTunnel(token, id); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) || apiErr.Status != 404 {
+				fmt.Fprintf(os.Stderr, "warning: failed to revoke tunnel %s: %v\n", id, err)
+				continue
+			}
+		}
+		fmt.Printf("Revoked tunnel %s.\n", id)
+	}
+	return nil
+}
+
+func removeExecutable(path string) error {
+	if runtime.GOOS == "windows" {
+		// A running executable cannot be deleted on Windows; rename it out of
+		// the way so the install directory is free and tell the user.
+		stale := path + ".old"
+		_ = os.Remove(stale)
+		if err := os.Rename(path, stale); err != nil {
+			return err
+		}
+		fmt.Printf("Moved %s to %s; delete it after this process exits.\n", path, stale)
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("%w (try with sudo if it was installed system-wide)", err)
+	}
+	fmt.Printf("Removed %s\n", path)
+	return nil
+}
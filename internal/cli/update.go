@@ -4,14 +4,16 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,25 +23,98 @@ import (
 )
 
 type githubRelease struct {
-	TagName string `json:"tag_name"`
-	Name    string `json:"name"`
-	Assets  []struct {
-		Name string `json:"name"`
-		URL  string `json:"browser_download_url"`
-	} `json:"assets"`
+	TagName    string        `json:"tag_name"`
+	Name       string        `json:"name"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []githubAsset `json:"assets"`
 }
 
-func showVersion() {
-	fmt.Printf("hubfly version %s\n", version.Version)
-	fmt.Printf("commit: %s\n", version.Commit)
-	fmt.Printf("date:   %s\n", version.Date)
-	fmt.Printf("os/arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+// updateChannels are the release channels `hubfly update` can follow.
+// stable is GitHub's latest release; beta also includes prereleases.
+var updateChannels = []string{"stable", "beta"}
+
+func validUpdateChannel(channel string) bool {
+	for _, c := range updateChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
 }
 
-func updateFlow(checkOnly bool) error {
-	rel, err := fetchLatestRelease()
-	if err != nil {
-		return fmt.Errorf("failed to fetch latest release: %w", err)
+// updateChannel is the channel from --channel, HUBFLY_UPDATE_CHANNEL or the
+// profile's update.channel, defaulting to stable.
+func updateChannel(flagValue string) (string, error) {
+	channel := strings.ToLower(strings.TrimSpace(flagValue))
+	if channel == "" {
+		channel = strings.ToLower(strings.TrimSpace(os.Getenv("HUBFLY_UPDATE_CHANNEL")))
+	}
+	if channel == "" {
+		if p := activeProfile(); p.Update != nil {
+			channel = p.Update.Channel
+		}
+	}
+	if channel == "" {
+		return "stable", nil
+	}
+	if !validUpdateChannel(channel) {
+		return "", fmt.Errorf("unknown update channel %q (expected one of: %s)", channel, strings.Join(updateChannels, ", "))
+	}
+	return channel, nil
+}
+
+type githubAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func showVersion(args []string) error {
+	fs := newFlagSet("version")
+	verify := fs.Bool("verify", false, "check this binary against the checksums of its release")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: hubfly version [--verify]")
+	}
+	if *verify {
+		return verifyRunningBinary()
+	}
+	info := currentBuildInfo()
+	if jsonOutput {
+		return printJSON(info)
+	}
+	fmt.Printf("hubfly version %s\n", info.Version)
+	fmt.Printf("commit: %s\n", info.Commit)
+	fmt.Printf("date:   %s\n", info.Date)
+	fmt.Printf("go:     %s\n", info.GoVersion)
+	fmt.Printf("os/arch: %s/%s\n", info.OS, info.Arch)
+	return nil
+}
+
+// updateFlow installs the newest release on channel, or the release pinned
+// by version, which may be older than the running one. When background
+// tunnels or the tunnel service run from the current binary, the verified
+// update is queued unless force is set or an interactive user agrees to
+// replace it now.
+func updateFlow(checkOnly, force bool, channel, pinned string) error {
+	var (
+		rel githubRelease
+		err error
+	)
+	pinned = strings.TrimSpace(pinned)
+	if pinned != "" {
+		tag := normalizeVersion(pinned)
+		if !semver.IsValid(tag) {
+			return fmt.Errorf("--version must be a release tag such as v1.4.2, got %q", pinned)
+		}
+		rel, err = fetchReleaseByTag(tag)
+		if err != nil {
+			return fmt.Errorf("failed to fetch release %s: %w", tag, err)
+		}
+	} else {
+		rel, err = fetchLatestRelease(channel)
+		if err != nil {
+			return fmt.Errorf("failed to fetch latest %s release: %w", channel, err)
+		}
 	}
 
 	latest := normalizeVersion(rel.TagName)
@@ -52,7 +127,11 @@ func updateFlow(checkOnly bool) error {
 		}
 	} else {
 		cmp := semver.Compare(current, latest)
-		if cmp >= 0 {
+		if pinned != "" && cmp == 0 {
+			fmt.Printf("Already on %s\n", latest)
+			return nil
+		}
+		if pinned == "" && cmp >= 0 {
 			fmt.Printf("Already up to date (%s)\n", version.Version)
 			return nil
 		}
@@ -82,33 +161,87 @@ func updateFlow(checkOnly bool) error {
 		exePath = filepath.Clean(exePath)
 	}
 
-	newBinary, err := downloadAndExtractBinary(assetURL)
+	newBinary, cleanup, err := downloadVerifiedBinary(rel, assetName, assetURL)
 	if err != nil {
-		return err
+		return fmt.Errorf("refusing to update to %s: %w", latest, err)
 	}
-	defer func() { _ = os.Remove(newBinary) }()
+	defer cleanup()
 
+	if err := checkUpdatedBinary(newBinary, latest); err != nil {
+		return err
+	}
+	if users := binaryUsers(); len(users) > 0 && !force {
+		fmt.Println("These processes run from the current binary:")
+		for _, u := range users {
+			fmt.Printf("  - %s\n", u)
+		}
+		replaceNow := false
+		if isInteractiveShell() && !jsonOutput {
+			if replaceNow, err = promptYesNo("Replace the binary now anyway", false); err != nil {
+				return err
+			}
+		}
+		if !replaceNow {
+			if err := queuePendingUpdate(newBinary, exePath, latest); err != nil {
+				return fmt.Errorf("failed to queue the update: %w", err)
+			}
+			fmt.Printf("Update to %s is queued and will be installed by the first hubfly command after they stop.\n", latest)
+			fmt.Println("Run `hubfly update --force` to install it now.")
+			return nil
+		}
+	}
 	if err := replaceExecutable(exePath, newBinary); err != nil {
 		return err
 	}
 
+	clearPendingUpdate()
 	fmt.Println("Update successful. Re-run `hubfly version` to confirm.")
 	return nil
 }
 
-func fetchLatestRelease() (githubRelease, error) {
+func fetchLatestRelease(channel string) (githubRelease, error) {
+	if base := updateReleaseURL(); base != "" {
+		return fetchMirrorRelease(base, channel)
+	}
+	if channel == "beta" {
+		return fetchNewestReleaseForRepo(version.RepoOwner, version.RepoName)
+	}
 	return fetchLatestReleaseForRepo(version.RepoOwner, version.RepoName)
 }
 
-func fetchLatestReleaseForRepo(owner, repo string) (githubRelease, error) {
-	var rel githubRelease
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
+// updateReleaseURL is the self-hosted release source, from HUBFLY_UPDATE_URL
+// or the profile's update.releaseURL. Empty means GitHub.
+func updateReleaseURL() string {
+	if raw := strings.TrimSpace(os.Getenv("HUBFLY_UPDATE_URL")); raw != "" {
+		return strings.TrimRight(raw, "/")
+	}
+	if p := activeProfile(); p.Update != nil {
+		return strings.TrimRight(p.Update.ReleaseURL, "/")
+	}
+	return ""
+}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// fetchMirrorRelease reads a release from a mirror laid out as
+//
+//	<base>/latest                   the release tag, for example v1.4.0
+//	<base>/latest-beta              the newest tag on the beta channel
+//	<base>/<tag>/<asset>            the archives from the GitHub release
+//	<base>/<tag>/<asset>.sha256     and their checksums
+//	<base>/<tag>/checksums.txt      all checksums, optionally with
+//	<base>/<tag>/checksums.txt.minisig
+//
+// so copying a GitHub release into a bucket is enough. The result lists the
+// archive and checksum for this platform and is verified like a GitHub one.
+func fetchMirrorRelease(base, channel string) (githubRelease, error) {
+	var rel githubRelease
+	pointer := base + "/latest"
+	if channel == "beta" {
+		pointer += "-beta"
+	}
+	req, err := http.NewRequest(http.MethodGet, pointer, nil)
 	if err != nil {
 		return rel, err
 	}
-	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("User-Agent", "hubfly-cli/"+version.Version)
 
 	client := &http.Client{Timeout: 20 * time.Second}
@@ -118,18 +251,106 @@ func fetchLatestReleaseForRepo(owner, repo string) (githubRelease, error) {
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return rel, fmt.Errorf("github API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return rel, fmt.Errorf("release mirror %s returned %d: %s", base, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	tag := strings.TrimSpace(string(body))
+	if normalizeVersion(tag) == "" {
+		return rel, fmt.Errorf("release mirror %s does not contain a version tag, got %q", pointer, tag)
 	}
+	return mirrorRelease(base, tag), nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
-		return rel, err
+func mirrorRelease(base, tag string) githubRelease {
+	rel := githubRelease{TagName: tag}
+	asset := expectedAssetName(runtime.GOOS, runtime.GOARCH)
+	for _, name := range []string{asset, asset + ".sha256", releaseChecksumsAsset, releaseSignatureAsset} {
+		rel.Assets = append(rel.Assets, githubAsset{Name: name, URL: base + "/" + tag + "/" + name})
+	}
+	return rel
+}
+
+// fetchReleaseByTag returns the hubfly release tag from the configured
+// mirror or from GitHub.
+func fetchReleaseByTag(tag string) (githubRelease, error) {
+	if base := updateReleaseURL(); base != "" {
+		return mirrorRelease(base, tag), nil
+	}
+	return fetchGitHubReleaseForRepo(tag, version.RepoOwner, version.RepoName)
+}
+
+func fetchLatestReleaseForRepo(owner, repo string) (githubRelease, error) {
+	return fetchGitHubReleaseAtURL(fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo))
+}
+
+// fetchNewestReleaseForRepo lists recent releases and returns the highest
+// version, prereleases included. /releases/latest never returns those.
+func fetchNewestReleaseForRepo(owner, repo string) (githubRelease, error) {
+	var releases []githubRelease
+	if err := fetchGitHubJSON(fmt.Sprintf("https://api.github.com/repos/%s/%s/releases?per_page=30", owner, repo), &releases); err != nil {
+		return githubRelease{}, err
+	}
+	return newestRelease(releases)
+}
+
+func newestRelease(releases []githubRelease) (githubRelease, error) {
+	var best githubRelease
+	for _, rel := range releases {
+		tag := normalizeVersion(rel.TagName)
+		if rel.Draft || !semver.IsValid(tag) {
+			continue
+		}
+		if best.TagName == "" || semver.Compare(tag, normalizeVersion(best.TagName)) > 0 {
+			best = rel
+		}
+	}
+	if best.TagName == "" {
+		return best, errors.New("no published releases found")
+	}
+	return best, nil
+}
+
+// githubToken is sent to api.github.com to raise the rate limit from 60
+// requests an hour per IP, which a shared NAT exhausts quickly.
+func githubToken() string {
+	if token := strings.TrimSpace(os.Getenv("HUBFLY_UPDATE_TOKEN")); token != "" {
+		return token
+	}
+	return strings.TrimSpace(os.Getenv("GITHUB_TOKEN"))
+}
+
+// newGitHubAPIRequest builds a GitHub API request. Proxies come from
+// HTTPS_PROXY/HTTP_PROXY/NO_PROXY through the default transport.
+func newGitHubAPIRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "hubfly-cli/"+version.Version)
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
-	if strings.TrimSpace(rel.TagName) == "" {
-		return rel, errors.New("latest release has no tag_name")
+	return req, nil
+}
+
+// githubAPIError explains a failed GitHub API response, calling out rate
+// limits with when they reset and how to raise them.
+func githubAPIError(resp *http.Response, body []byte) error {
+	limited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0")
+	if !limited {
+		return fmt.Errorf("github API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
-	return rel, nil
+	reset := ""
+	if epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = fmt.Sprintf(" until %s", time.Unix(epoch, 0).Local().Format("15:04"))
+	}
+	if githubToken() != "" {
+		return fmt.Errorf("github API rate limit reached for the configured token%s", reset)
+	}
+	return fmt.Errorf("github API rate limit reached%s; set GITHUB_TOKEN or HUBFLY_UPDATE_TOKEN to a GitHub token to raise it", reset)
 }
 
 func normalizeVersion(v string) string {
@@ -163,11 +384,86 @@ func findAssetURL(rel githubRelease, name string) (string, error) {
 	return "", fmt.Errorf("release %s does not contain asset %q for %s/%s", rel.TagName, name, runtime.GOOS, runtime.GOARCH)
 }
 
-func downloadAndExtractBinary(assetURL string) (string, error) {
-	return downloadAndExtractNamedBinary(
-		assetURL,
-		[]string{"hubfly", "hubfly-cli", "hubfly.exe"},
-	)
+// downloadVerifiedBinary fetches the release archive and its checksum at the
+// same time and only extracts the binary once the archive matches, so a
+// truncated or tampered download fails here instead of replacing the install.
+func downloadVerifiedBinary(rel githubRelease, assetName, assetURL string) (string, func(), error) {
+	type archiveResult struct {
+		path    string
+		cleanup func()
+		err     error
+	}
+	archiveCh := make(chan archiveResult, 1)
+	go func() {
+		path, cleanup, err := downloadAssetToTemp(assetURL, ".archive")
+		archiveCh <- archiveResult{path, cleanup, err}
+	}()
+	expectedChecksum, checksumErr := releaseChecksum(rel, assetName)
+	archive := <-archiveCh
+	if archive.err != nil {
+		return "", nil, fmt.Errorf("failed to download %s: %w", assetName, archive.err)
+	}
+	if checksumErr != nil {
+		archive.cleanup()
+		return "", nil, fmt.Errorf("could not verify %s: %w", assetName, checksumErr)
+	}
+	if err := verifyFileSHA256(archive.path, expectedChecksum, assetName); err != nil {
+		archive.cleanup()
+		return "", nil, err
+	}
+
+	binaryPath := filepath.Join(filepath.Dir(archive.path), "hubfly")
+	candidates := []string{"hubfly", "hubfly-cli", "hubfly.exe"}
+	var err error
+	if strings.HasSuffix(assetName, ".zip") {
+		err = extractBinaryFromZipByNames(archive.path, binaryPath, candidates...)
+	} else {
+		err = extractBinaryFromTarGzByNames(archive.path, binaryPath, candidates...)
+	}
+	if err == nil {
+		err = os.Chmod(binaryPath, 0o755)
+	}
+	if err != nil {
+		archive.cleanup()
+		return "", nil, err
+	}
+	return binaryPath, archive.cleanup, nil
+}
+
+// checkUpdatedBinary runs `<binary> --version` before the swap and expects
+// it to report want. The run gets an empty HOME so the new binary cannot
+// migrate or otherwise touch ~/.hubfly before it is installed.
+func checkUpdatedBinary(binaryPath, want string) error {
+	sandbox, err := os.MkdirTemp("", "hubfly-update-check-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(sandbox) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binaryPath, "--version")
+	cmd.Dir = sandbox
+	cmd.Env = []string{"HOME=" + sandbox, "USERPROFILE=" + sandbox, "PATH=" + os.Getenv("PATH")}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("downloaded binary failed to run (%v); keeping the current install:\n%s", err, strings.TrimSpace(string(output)))
+	}
+	got := versionFromOutput(string(output))
+	if got != want {
+		return fmt.Errorf("downloaded binary reports version %q, expected %s; keeping the current install", got, want)
+	}
+	return nil
+}
+
+// versionFromOutput reads the version from `hubfly --version` output.
+func versionFromOutput(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "hubfly version "); ok {
+			return normalizeVersion(v)
+		}
+	}
+	return ""
 }
 
 func downloadAndExtractNamedBinary(assetURL string, binaryCandidates []string) (string, error) {
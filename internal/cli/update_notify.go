@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/term"
+
+	"hubfly-cli/internal/version"
+)
+
+const updateCheckInterval = 24 * time.Hour
+
+// updateCheck caches the newest release seen on a channel so the notice
+// costs at most one release lookup a day.
+type updateCheck struct {
+	CheckedAt string `json:"checkedAt"`
+	Channel   string `json:"channel"`
+	Latest    string `json:"latest"`
+}
+
+func updateCheckPath() string {
+	return filepath.Join(stateDir(), "update-check.json")
+}
+
+func loadUpdateCheck() (updateCheck, bool) {
+	var c updateCheck
+	content, err := os.ReadFile(updateCheckPath())
+	if err != nil {
+		return c, false
+	}
+	if err := json.Unmarshal(content, &c); err != nil {
+		return c, false
+	}
+	return c, true
+}
+
+func saveUpdateCheck(c updateCheck) error {
+	if err := ensurePrivateDir(stateDir()); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(updateCheckPath(), append(payload, '\n'))
+}
+
+// updateNotifierEnabled reports whether command may print an update notice.
+// Scripts, CI, JSON output, demo mode and development builds never get one,
+// and update.notify=false or HUBFLY_NO_UPDATE_NOTIFIER turn it off.
+func updateNotifierEnabled(command string) bool {
+	switch command {
+	case "update", "version", "help", "service", "__update-check", "__connect-tunnel":
+		return false
+	}
+	if jsonOutput || demoMode || os.Getenv("CI") != "" || os.Getenv("HUBFLY_NO_UPDATE_NOTIFIER") != "" {
+		return false
+	}
+	if !semver.IsValid(normalizeVersion(version.Version)) {
+		return false
+	}
+	if p := activeProfile(); p.Update != nil && p.Update.Notify != nil && !*p.Update.Notify {
+		return false
+	}
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// startUpdateNotifier returns a function to call once the command is done,
+// which prints the notice from the cached check. When the cache is older than
+// a day, a detached `hubfly __update-check` refreshes it for the next command,
+// so the lookup never delays this one.
+func startUpdateNotifier(command string) func() {
+	if !updateNotifierEnabled(command) {
+		return func() {}
+	}
+	channel, err := updateChannel("")
+	if err != nil {
+		return func() {}
+	}
+	cached, ok := loadUpdateCheck()
+	if ok && cached.Channel != channel {
+		cached, ok = updateCheck{}, false
+	}
+	checkedAt, err := time.Parse(time.RFC3339, cached.CheckedAt)
+	if !ok || err != nil || time.Since(checkedAt) >= updateCheckInterval {
+		// Record the attempt first so commands run in quick succession do
+		// not each start a check.
+		cached.Channel = channel
+		cached.CheckedAt = time.Now().UTC().Format(time.RFC3339)
+		if err := saveUpdateCheck(cached); err == nil {
+			if err := startUpdateCheck(channel); err != nil {
+				debugf("failed to start background update check: %v", err)
+			}
+		}
+	}
+	return func() { printUpdateNotice(cached.Latest) }
+}
+
+func startUpdateCheck(channel string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "__update-check", channel)
+	detachCommand(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// updateCheckCommand looks up the newest release on channel and caches it.
+// It runs detached from the command that started it.
+func updateCheckCommand(channel string) error {
+	rel, err := fetchLatestRelease(channel)
+	if err != nil {
+		return err
+	}
+	return saveUpdateCheck(updateCheck{
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		Channel:   channel,
+		Latest:    normalizeVersion(rel.TagName),
+	})
+}
+
+func printUpdateNotice(latest string) {
+	current := normalizeVersion(version.Version)
+	if latest == "" || semver.Compare(latest, current) <= 0 {
+		return
+	}
+	if p, ok := loadPendingUpdate(); ok && strings.EqualFold(p.Version, latest) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nA new version of hubfly is available: %s -> %s. Run `hubfly update` to install it.\n", current, latest)
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hubfly-cli/internal/service"
+)
+
+// pendingUpdate is a verified binary waiting for the background processes
+// of the current one to stop. It is recorded in
+// ~/.hubfly/state/pending-update.json and installed by the next command that
+// finds them gone.
+type pendingUpdate struct {
+	Binary   string `json:"binary"`
+	Target   string `json:"target"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	StagedAt string `json:"stagedAt"`
+}
+
+func pendingUpdatePath() string {
+	return filepath.Join(stateDir(), "pending-update.json")
+}
+
+func pendingUpdateBinaryPath() string {
+	return filepath.Join(stateDir(), "pending-update", "hubfly")
+}
+
+// binaryUsers describes the background processes running the installed
+// binary: detached tunnel sessions and the tunnel service. Swapping the
+// binary under them leaves teammates with old and new versions mixed.
+func binaryUsers() []string {
+	var users []string
+	sessions, _ := listTunnelSessions()
+	for _, s := range sessions {
+		if hubflyProcessAlive(s.PID) {
+			users = append(users, fmt.Sprintf("tunnel session %s (pid %d)", s.Name, s.PID))
+		}
+	}
+	if info, err := service.ReadInfo(); err == nil && hubflyProcessAlive(info.PID) {
+		users = append(users, fmt.Sprintf("tunnel service on port %d (pid %d)", info.Port, info.PID))
+	}
+	return users
+}
+
+// queuePendingUpdate copies the verified binary into the state directory
+// and records where it goes, with its checksum so that what is installed
+// later is still the binary that was verified.
+func queuePendingUpdate(binaryPath, target, version string) error {
+	sum, err := fileSHA256(binaryPath)
+	if err != nil {
+		return err
+	}
+	if err := ensurePrivateDir(filepath.Dir(pendingUpdateBinaryPath())); err != nil {
+		return err
+	}
+	if err := copyFile(pendingUpdateBinaryPath(), binaryPath); err != nil {
+		return err
+	}
+	if err := os.Chmod(pendingUpdateBinaryPath(), 0o700); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(pendingUpdate{
+		Binary:   pendingUpdateBinaryPath(),
+		Target:   target,
+		Version:  version,
+		SHA256:   sum,
+		StagedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(pendingUpdatePath(), append(payload, '\n'))
+}
+
+func loadPendingUpdate() (pendingUpdate, bool) {
+	var p pendingUpdate
+	content, err := os.ReadFile(pendingUpdatePath())
+	if err != nil {
+		return p, false
+	}
+	if err := json.Unmarshal(content, &p); err != nil || p.Binary == "" || p.Target == "" {
+		return p, false
+	}
+	return p, true
+}
+
+func clearPendingUpdate() {
+	_ = os.Remove(pendingUpdatePath())
+	_ = os.RemoveAll(filepath.Dir(pendingUpdateBinaryPath()))
+}
+
+// applyPendingUpdate installs a queued update once nothing runs from the
+// current binary. It only touches the binary the update was queued for, and
+// failures are warnings so the command the user typed still runs.
+func applyPendingUpdate() {
+	p, ok := loadPendingUpdate()
+	if !ok {
+		return
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	if filepath.Clean(exePath) != filepath.Clean(p.Target) {
+		return
+	}
+	if len(binaryUsers()) > 0 {
+		return
+	}
+	if _, err := os.Stat(p.Binary); errors.Is(err, os.ErrNotExist) {
+		clearPendingUpdate()
+		return
+	}
+	if err := verifyPendingUpdate(p); err != nil {
+		clearPendingUpdate()
+		fmt.Fprintf(os.Stderr, "warning: dropped the queued update to %s: %v; run `hubfly update` again\n", p.Version, err)
+		return
+	}
+	if err := replaceExecutable(p.Target, p.Binary); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not install the queued update to %s: %v\n", p.Version, err)
+		return
+	}
+	clearPendingUpdate()
+	fmt.Fprintf(os.Stderr, "note: installed the queued update to %s now that no background tunnels are running\n", p.Version)
+}
+
+// verifyPendingUpdate checks that the staged binary is the one that was
+// verified and queued, in the place it was queued.
+func verifyPendingUpdate(p pendingUpdate) error {
+	if !sameFilePath(p.Binary, pendingUpdateBinaryPath()) {
+		return fmt.Errorf("%s is not the staged update", p.Binary)
+	}
+	if p.SHA256 == "" {
+		return errors.New("no checksum was recorded for it")
+	}
+	sum, err := fileSHA256(p.Binary)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, p.SHA256) {
+		return fmt.Errorf("%s changed since it was verified", p.Binary)
+	}
+	return nil
+}
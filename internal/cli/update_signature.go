@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"hubfly-cli/internal/version"
+)
+
+const (
+	releaseChecksumsAsset = "checksums.txt"
+	releaseSignatureAsset = "checksums.txt.minisig"
+)
+
+// updatePublicKey is the minisign key release checksums must be signed
+// with, from HUBFLY_UPDATE_PUBKEY or the key built into release binaries.
+// Empty means signatures are not checked.
+func updatePublicKey() string {
+	if key := strings.TrimSpace(os.Getenv("HUBFLY_UPDATE_PUBKEY")); key != "" {
+		return key
+	}
+	return strings.TrimSpace(version.UpdatePublicKey)
+}
+
+// releaseChecksum returns the expected sha256 of assetName. It prefers the
+// release's checksums.txt, whose minisign signature is required when a
+// public key is configured. Without a key it falls back to the per-asset
+// .sha256 that older releases and plain mirrors carry.
+func releaseChecksum(rel githubRelease, assetName string) (string, error) {
+	publicKey := updatePublicKey()
+	var list []byte
+	listURL, err := findAssetURL(rel, releaseChecksumsAsset)
+	if err == nil {
+		list, err = downloadReleaseFile(listURL)
+		if err != nil {
+			err = fmt.Errorf("failed to download %s: %w", releaseChecksumsAsset, err)
+		}
+	}
+	if err != nil {
+		if publicKey != "" {
+			return "", fmt.Errorf("release %s has no signed %s and a signing key is configured: %w", rel.TagName, releaseChecksumsAsset, err)
+		}
+		checksumURL, shaErr := findAssetURL(rel, assetName+".sha256")
+		if shaErr != nil {
+			return "", fmt.Errorf("release %s has neither %s nor %s", rel.TagName, releaseChecksumsAsset, assetName+".sha256")
+		}
+		return downloadReleaseChecksum(checksumURL)
+	}
+
+	if publicKey != "" {
+		sigURL, err := findAssetURL(rel, releaseSignatureAsset)
+		if err != nil {
+			return "", fmt.Errorf("release %s is missing %s", rel.TagName, releaseSignatureAsset)
+		}
+		sig, err := downloadReleaseFile(sigURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s: %w", releaseSignatureAsset, err)
+		}
+		if err := verifyMinisign(publicKey, list, sig); err != nil {
+			return "", fmt.Errorf("%s of release %s: %w", releaseChecksumsAsset, rel.TagName, err)
+		}
+	}
+	return checksumFromList(list, assetName)
+}
+
+func downloadReleaseFile(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	req.Header.Set("User-Agent", "hubfly-cli/"+version.Version)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// checksumFromList finds assetName in sha256sum output. Names may carry a
+// directory or sha256sum's binary-mode "*".
+func checksumFromList(list []byte, assetName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(list))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		name := path.Base(strings.TrimPrefix(fields[1], "*"))
+		if name == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no entry for %s", releaseChecksumsAsset, assetName)
+}
+
+// verifyMinisign checks a minisign signature of message, including the
+// signed trusted comment. Only the legacy, non-prehashed "Ed" algorithm is
+// supported, which is what `minisign -S -l` produces.
+func verifyMinisign(publicKey string, message, signature []byte) error {
+	keyID, pub, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(signature), "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment:") {
+		return errors.New("signature is not in minisign format")
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("signature is not in minisign format")
+	}
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		return errors.New("prehashed minisign signatures are not supported; sign with `minisign -S -l`")
+	default:
+		return fmt.Errorf("unknown minisign algorithm %q", sig[:2])
+	}
+	if !bytes.Equal(sig[2:10], keyID) {
+		return fmt.Errorf("signed with key %X, expected %X", reverseBytes(sig[2:10]), reverseBytes(keyID))
+	}
+	if !ed25519.Verify(pub, message, sig[10:]) {
+		return errors.New("signature does not match: the checksums were altered or signed by someone else")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment:")
+	trusted = strings.TrimPrefix(trusted, " ")
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("signature has a malformed trusted comment signature")
+	}
+	if !ed25519.Verify(pub, append(append([]byte{}, sig[10:]...), trusted...), globalSig) {
+		return errors.New("signature's trusted comment was altered")
+	}
+	return nil
+}
+
+// parseMinisignPublicKey accepts a minisign.pub file or just its base64 line.
+func parseMinisignPublicKey(text string) ([]byte, ed25519.PublicKey, error) {
+	encoded := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			encoded = line
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, nil, errors.New("public key is not a minisign public key")
+	}
+	return raw[2:10], ed25519.PublicKey(raw[10:]), nil
+}
+
+// reverseBytes renders a minisign key ID the way `minisign` prints it.
+func reverseBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
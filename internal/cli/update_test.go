@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMirrorReleaseDownloadIsVerified(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	content := []byte("#!/bin/sh\necho hubfly version v1.2.3\n")
+	_ = tw.WriteHeader(&tar.Header{Name: "hubfly", Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(content)
+	_ = tw.Close()
+	_ = gz.Close()
+	sum := sha256.Sum256(archive.Bytes())
+
+	name := expectedAssetName(runtime.GOOS, runtime.GOARCH)
+	var truncate atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			_, _ = w.Write([]byte("v1.2.3\n"))
+		case "/v1.2.3/" + name + ".sha256":
+			_, _ = w.Write([]byte(hex.EncodeToString(sum[:]) + "  dist/" + name + "\n"))
+		case "/v1.2.3/" + name:
+			if truncate.Load() {
+				_, _ = w.Write(archive.Bytes()[:archive.Len()/2])
+				return
+			}
+			_, _ = w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	rel, err := fetchMirrorRelease(srv.URL, "stable")
+	if err != nil {
+		t.Fatalf("fetchMirrorRelease: %v", err)
+	}
+	assetURL, err := findAssetURL(rel, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, cleanup, err := downloadVerifiedBinary(rel, name, assetURL)
+	if err != nil {
+		t.Fatalf("downloadVerifiedBinary: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	cleanup()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("extracted binary = %q, %v", got, err)
+	}
+
+	truncate.Store(true)
+	if _, _, err := downloadVerifiedBinary(rel, name, assetURL); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("truncated download: err = %v, want checksum mismatch", err)
+	}
+}
+
+func TestVersionFromOutput(t *testing.T) {
+	output := "hubfly version v1.4.0\ncommit: abc\ndate:   2026-10-01\n"
+	if got := versionFromOutput(output); got != "v1.4.0" {
+		t.Fatalf("versionFromOutput = %q", got)
+	}
+	if got := versionFromOutput("segmentation fault"); got != "" {
+		t.Fatalf("versionFromOutput on garbage = %q", got)
+	}
+}
+
+func TestGitHubAPIErrorExplainsRateLimit(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("HUBFLY_UPDATE_TOKEN", "")
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", "1790000000")
+	err := githubAPIError(resp, []byte(`{"message":"API rate limit exceeded"}`))
+	if err == nil || !strings.Contains(err.Error(), "rate limit") || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Fatalf("rate limited error = %v", err)
+	}
+
+	resp = &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	if err := githubAPIError(resp, []byte("forbidden")); strings.Contains(err.Error(), "rate limit") {
+		t.Fatalf("plain 403 reported as rate limit: %v", err)
+	}
+}
+
+func TestPendingUpdateWaitsForBackgroundSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { storageRoot = "" })
+	storageRoot = t.TempDir()
+
+	target := filepath.Join(t.TempDir(), "hubfly")
+	if err := os.WriteFile(target, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	staged := filepath.Join(t.TempDir(), "new")
+	if err := os.WriteFile(staged, []byte("new"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := queuePendingUpdate(staged, target, "v9.9.9"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A live session keeps the queued update waiting.
+	if err := saveTunnelSession(tunnelSession{Name: "db", PID: os.Getpid()}); err != nil {
+		t.Fatal(err)
+	}
+	if users := binaryUsers(); len(users) != 1 {
+		t.Fatalf("binaryUsers = %q, want the db session", users)
+	}
+	if err := os.Remove(tunnelSessionPath("db")); err != nil {
+		t.Fatal(err)
+	}
+	if users := binaryUsers(); len(users) != 0 {
+		t.Fatalf("binaryUsers after stop = %q", users)
+	}
+
+	// A session whose pid now belongs to another program does not count.
+	if runtime.GOOS != "windows" {
+		other := exec.Command("sleep", "5")
+		if err := other.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = other.Process.Kill(); _ = other.Wait() })
+		if err := saveTunnelSession(tunnelSession{Name: "stale", PID: other.Process.Pid}); err != nil {
+			t.Fatal(err)
+		}
+		if users := binaryUsers(); len(users) != 0 {
+			t.Fatalf("binaryUsers with a reused pid = %q", users)
+		}
+		if err := os.Remove(tunnelSessionPath("stale")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, ok := loadPendingUpdate()
+	if !ok || p.Target != target || p.Version != "v9.9.9" {
+		t.Fatalf("pending update = %+v, %v", p, ok)
+	}
+	if err := verifyPendingUpdate(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyPendingUpdate(pendingUpdate{Binary: staged, SHA256: p.SHA256}); err == nil {
+		t.Fatal("a binary outside the staging directory was accepted")
+	}
+	if err := os.WriteFile(p.Binary, []byte("tampered"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyPendingUpdate(p); err == nil {
+		t.Fatal("a staged binary changed after it was verified was accepted")
+	}
+	if err := os.WriteFile(p.Binary, []byte("new"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceExecutable(p.Target, p.Binary); err != nil {
+		t.Fatal(err)
+	}
+	clearPendingUpdate()
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Fatalf("target = %q after install", got)
+	}
+	if _, ok := loadPendingUpdate(); ok {
+		t.Fatal("pending update still recorded after install")
+	}
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	publicKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)) + "\n"
+
+	list := []byte("abc123  hubfly_linux_amd64.tar.gz\ndef456  dist/hubfly_darwin_arm64.tar.gz\n")
+	sign := func(message []byte, trusted string) []byte {
+		sig := ed25519.Sign(priv, message)
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+		return []byte("untrusted comment: signature\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), sig...)) + "\n" +
+			"trusted comment: " + trusted + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+
+	signature := sign(list, "timestamp:1790000000")
+	if err := verifyMinisign(publicKey, list, signature); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	tampered := bytes.Replace(list, []byte("abc123"), []byte("fff123"), 1)
+	if err := verifyMinisign(publicKey, tampered, signature); err == nil {
+		t.Fatal("tampered checksums accepted")
+	}
+	altered := bytes.Replace(signature, []byte("timestamp:1790000000"), []byte("timestamp:1"), 1)
+	if err := verifyMinisign(publicKey, list, altered); err == nil {
+		t.Fatal("altered trusted comment accepted")
+	}
+
+	if sum, err := checksumFromList(list, "hubfly_darwin_arm64.tar.gz"); err != nil || sum != "def456" {
+		t.Fatalf("checksumFromList = %q, %v", sum, err)
+	}
+	if _, err := checksumFromList(list, "hubfly_windows_amd64.zip"); err == nil {
+		t.Fatal("missing asset found in checksums.txt")
+	}
+}
+
+func TestNewestReleaseIncludesPrereleases(t *testing.T) {
+	releases := []githubRelease{
+		{TagName: "v1.4.1"},
+		{TagName: "v1.5.0-beta.2", Prerelease: true},
+		{TagName: "v1.6.0", Draft: true},
+		{TagName: "nightly", Prerelease: true},
+		{TagName: "v1.5.0-beta.1", Prerelease: true},
+	}
+	rel, err := newestRelease(releases)
+	if err != nil || rel.TagName != "v1.5.0-beta.2" {
+		t.Fatalf("newestRelease = %q, %v", rel.TagName, err)
+	}
+
+	// A stable release newer than every beta is the newest beta too.
+	rel, err = newestRelease(append(releases, githubRelease{TagName: "v1.5.0"}))
+	if err != nil || rel.TagName != "v1.5.0" {
+		t.Fatalf("newestRelease with stable = %q, %v", rel.TagName, err)
+	}
+}
@@ -0,0 +1,389 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// When a command line is wrong in a way that can be guessed, such as a
+// misspelt command, flag or container, or a tunnel's ports given in the
+// wrong order, the error names the corrected command line instead of the
+// command's whole usage. Container names are matched against the ones this
+// machine already knows: saved tunnels, tunnel tickets and usage counts.
+
+// usageSuggestionError is a usage error with the command line it was
+// probably meant to be.
+type usageSuggestionError struct {
+	err        error
+	suggestion []string
+}
+
+func (e *usageSuggestionError) Error() string {
+	command := e.suggestion[0]
+	reason, _, _ := strings.Cut(e.err.Error(), "\n")
+	if strings.HasPrefix(reason, "usage: ") {
+		reason = "wrong arguments for hubfly " + command
+	}
+	return fmt.Sprintf("%s\ndid you mean: hubfly %s\nRun `hubfly help %s` for all of its usage.", reason, quoteArgs(e.suggestion), command)
+}
+
+func (e *usageSuggestionError) Unwrap() error { return e.err }
+
+// withUsageSuggestion returns err with a suggested command line when err is
+// a usage error and a correction of args can be guessed.
+func withUsageSuggestion(args []string, err error) error {
+	if err == nil || !isUsageError(err) {
+		return err
+	}
+	suggestion := suggestInvocation(args)
+	if suggestion == nil {
+		return err
+	}
+	return &usageSuggestionError{err: err, suggestion: suggestion}
+}
+
+var usageErrorPrefixes = []string{
+	"usage: ",
+	"unknown command: ",
+	"flag provided but not defined: ",
+	"invalid target port",
+	"invalid local port",
+}
+
+func isUsageError(err error) bool {
+	msg := err.Error()
+	for _, prefix := range usageErrorPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestInvocation corrects args, the command line without "hubfly", or
+// returns nil when it finds nothing to correct.
+func suggestInvocation(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		name := closestWord(args[0], commandNames())
+		if name == "" {
+			return nil
+		}
+		cmd, _ = findCommand(name)
+	}
+	out := []string{cmd.name}
+	rest := args[1:]
+
+	sub := ""
+	if subs := subcommandNames(cmd.name); len(rest) > 0 && len(subs) > 0 && !strings.HasPrefix(rest[0], "-") {
+		switch {
+		case contains(subs, rest[0]):
+			sub = rest[0]
+		case cmd.name == "tunnel" && looksLikeTunnelTarget(rest):
+			// `hubfly tunnel <container> ...` has no subcommand.
+		default:
+			sub = closestWord(rest[0], subs)
+		}
+		if sub != "" {
+			out = append(out, sub)
+			rest = rest[1:]
+		}
+	}
+
+	fixed, flags, positional := correctSuggestionArgs(cmd, sub, rest)
+	if cmd.name == "tunnel" && (sub == "" || sub == "up") && !hasFlag(flags, "compose") {
+		if target := suggestTunnelTarget(positional); target != nil {
+			_, after, _ := cutArgs(fixed, "--")
+			fixed = append(append(flags, target...), after...)
+		}
+	}
+	out = append(out, fixed...)
+	if strings.Join(out, "\x00") == strings.Join(args, "\x00") {
+		return nil
+	}
+	return out
+}
+
+// cutArgs splits args around the first sep, which stays with after.
+func cutArgs(args []string, sep string) (before, after []string, found bool) {
+	for i, arg := range args {
+		if arg == sep {
+			return args[:i], args[i:], true
+		}
+	}
+	return args, nil, false
+}
+
+// correctSuggestionArgs corrects misspelt flag names in args, keeping their
+// order, and also returns the flags and the positional arguments apart.
+// Anything from "--" on is left as it is.
+func correctSuggestionArgs(cmd cliCommand, sub string, args []string) (fixed, flags, positional []string) {
+	known := map[string]bool{}
+	takesValue := map[string]bool{}
+	var names []string
+	for _, f := range subcommandFlags(cmd, sub) {
+		name, value, _ := strings.Cut(f, " ")
+		name = strings.TrimLeft(name, "-")
+		known[name] = true
+		takesValue[name] = value != ""
+		names = append(names, name)
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(fixed, args[i:]...), flags, positional
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			fixed = append(fixed, arg)
+			positional = append(positional, arg)
+			continue
+		}
+		dashes := arg[:len(arg)-len(strings.TrimLeft(arg, "-"))]
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if target, ok := flagAliases[name]; ok && known[target] {
+			name = target
+		} else if !known[name] && !known[strings.TrimPrefix(name, "no-")] {
+			if correct := closestWord(name, names); correct != "" {
+				name = correct
+				arg = dashes + name
+				if hasValue {
+					arg += "=" + value
+				}
+			}
+		}
+		fixed = append(fixed, arg)
+		flags = append(flags, arg)
+		if takesValue[name] && !hasValue && i+1 < len(args) {
+			i++
+			fixed = append(fixed, args[i])
+			flags = append(flags, args[i])
+		}
+	}
+	return fixed, flags, positional
+}
+
+// subcommandFlags lists the flags in the synopses of cmd's subcommand sub,
+// or of all of cmd when sub is "" or has no synopsis.
+func subcommandFlags(cmd cliCommand, sub string) []string {
+	if sub == "" {
+		return commandFlags(cmd)
+	}
+	prefix := cmd.name + " " + sub
+	var usage []string
+	matched := false
+	for _, line := range cmd.usage {
+		if !strings.HasPrefix(line, " ") {
+			matched = line == prefix || strings.HasPrefix(line, prefix+" ")
+		}
+		if matched {
+			usage = append(usage, line)
+		}
+	}
+	if len(usage) == 0 {
+		return commandFlags(cmd)
+	}
+	return commandFlags(cliCommand{usage: usage})
+}
+
+// looksLikeTunnelTarget reports whether args read as a container and ports
+// rather than a subcommand.
+func looksLikeTunnelTarget(args []string) bool {
+	for _, arg := range args[1:] {
+		if isPortArg(arg) {
+			return true
+		}
+	}
+	return strings.Contains(args[0], ":")
+}
+
+// suggestTunnelTarget puts a tunnel's container and ports in the order
+// <container> <localPort|auto> <targetPort>. It splits container:port and
+// container:local:target, and fills in auto for a missing local port.
+func suggestTunnelTarget(positional []string) []string {
+	var names, ports []string
+	for _, arg := range positional {
+		for _, part := range strings.Split(arg, ":") {
+			if isPortArg(part) {
+				ports = append(ports, part)
+			} else if part != "" {
+				names = append(names, part)
+			}
+		}
+	}
+	if len(names) != 1 {
+		return nil
+	}
+	name := closestEntityName(names[0])
+	switch {
+	case len(ports) == 1 && ports[0] != "auto":
+		return []string{name, "auto", ports[0]}
+	case len(ports) == 2 && ports[1] != "auto":
+		return []string{name, ports[0], ports[1]}
+	}
+	return nil
+}
+
+func isPortArg(arg string) bool {
+	if arg == "auto" {
+		return true
+	}
+	port, err := strconv.Atoi(arg)
+	return err == nil && port > 0 && port <= 65535
+}
+
+func commandNames() []string {
+	var names []string
+	for _, cmd := range cliCommands() {
+		if !cmd.hidden {
+			names = append(names, cmd.name)
+		}
+	}
+	return names
+}
+
+// subcommandNames lists a command's subcommands, from explainedCommands and
+// their aliases.
+func subcommandNames(command string) []string {
+	var subs []string
+	for key := range explainedCommands {
+		if name, sub, ok := strings.Cut(key, " "); ok && name == command {
+			subs = append(subs, sub)
+		}
+	}
+	for alias := range explainAliases {
+		if name, sub, ok := strings.Cut(alias, " "); ok && name == command {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Strings(subs)
+	return subs
+}
+
+// knownEntityNames are the container names this machine has seen.
+func knownEntityNames() []string {
+	seen := map[string]bool{}
+	saved, err := loadSavedTunnels()
+	if err != nil {
+		debugf("suggestions: %v", err)
+	}
+	for _, s := range saved {
+		seen[s.Container] = true
+	}
+	tickets, err := listTunnelTickets()
+	if err != nil {
+		debugf("suggestions: %v", err)
+	}
+	for _, t := range tickets {
+		seen[t.TargetContainer] = true
+	}
+	for flow := range loadUsageLog().Flows {
+		if subject, ok := strings.CutPrefix(flow, "tunnel:"); ok {
+			container, _, _ := strings.Cut(subject, ":")
+			seen[container] = true
+		}
+	}
+	delete(seen, "")
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func closestEntityName(name string) string {
+	known := knownEntityNames()
+	if contains(known, name) {
+		return name
+	}
+	if fixed := closestWord(name, known); fixed != "" {
+		return fixed
+	}
+	return name
+}
+
+// closestWord returns the one candidate within a typo of word: one edit for
+// words up to four letters, two for longer ones. It returns "" when none or
+// several are that close.
+func closestWord(word string, candidates []string) string {
+	limit := 1
+	if len(word) > 4 {
+		limit = 2
+	}
+	best, bestDistance, tied := "", limit+1, false
+	for _, candidate := range candidates {
+		d := editDistance(strings.ToLower(word), strings.ToLower(candidate))
+		switch {
+		case d < bestDistance:
+			best, bestDistance, tied = candidate, d, false
+		case d == bestDistance && candidate != best:
+			tied = true
+		}
+	}
+	if tied || best == "" {
+		return ""
+	}
+	return best
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent letters that turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func hasFlag(flags []string, name string) bool {
+	for _, f := range flags {
+		if f, _, _ = strings.Cut(strings.TrimLeft(f, "-"), "="); f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteArgs joins args for display, quoting the ones a shell would split.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"$`\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// useContext is what `hubfly use` prints: the project commands run against
+// when they are not given --project, and where that choice comes from.
+type useContext struct {
+	Profile     string `json:"profile"`
+	ProjectID   string `json:"projectId,omitempty"`
+	ProjectName string `json:"projectName,omitempty"`
+	Source      string `json:"source,omitempty"`
+}
+
+func useCommand(args []string) error {
+	if len(args) == 0 {
+		return useShowFlow()
+	}
+	switch args[0] {
+	case "project":
+		return useProjectFlow(args[1:])
+	default:
+		return fmt.Errorf("unknown use target: %s (only project is supported)", args[0])
+	}
+}
+
+func useShowFlow() error {
+	cfg, err := loadStoreConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	ctx := useContext{Profile: currentProfileName(cfg)}
+	p := activeProfile()
+	if ws := currentWorkspace(); ws.Project != "" {
+		ctx.ProjectName, ctx.Source = ws.Project, ws.Path
+	} else if p.DefaultProjectName != "" {
+		ctx.ProjectID, ctx.ProjectName, ctx.Source = p.DefaultProject, p.DefaultProjectName, "profile "+ctx.Profile
+	} else if p.DefaultProject != "" {
+		// Set with `config set defaultProject`, which takes an ID or a name.
+		ctx.ProjectName, ctx.Source = p.DefaultProject, "profile "+ctx.Profile
+	}
+	if jsonOutput {
+		return printJSON(ctx)
+	}
+	switch {
+	case ctx.ProjectName == "":
+		fmt.Printf("No project selected for profile %s; commands search every project. Pick one with `hubfly use project <name>`.\n", ctx.Profile)
+	case ctx.ProjectID != "":
+		fmt.Printf("Using project %s (%s) from %s.\n", ctx.ProjectName, ctx.ProjectID, ctx.Source)
+	default:
+		fmt.Printf("Using project %s from %s.\n", ctx.ProjectName, ctx.Source)
+	}
+	return nil
+}
+
+// useProjectFlow makes a project the active profile's default. It stores
+// the project's ID and name, so later commands scope to it without looking
+// it up again.
+func useProjectFlow(args []string) error {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return errors.New("usage: hubfly use project <id|name> | hubfly use project --clear")
+	}
+	var profileName string
+	if args[0] == "--clear" {
+		err := updateStoreConfig(func(cfg *storeConfig) error {
+			profileName = currentProfileName(*cfg)
+			p := profileFor(cfg, profileName)
+			p.DefaultProject, p.DefaultProjectName = "", ""
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(useContext{Profile: profileName})
+		}
+		fmt.Printf("Cleared the project for profile %s; commands search every project again.\n", profileName)
+		return nil
+	}
+
+	token, err := ensureAuth(true)
+	if err != nil {
+		return err
+	}
+	projects, err := fetchProjects(token)
+	if err != nil {
+		return err
+	}
+	selected, ok := resolveRequestedProject(projects, args[0])
+	if !ok {
+		return fmt.Errorf("project '%s' not found", args[0])
+	}
+	err = updateStoreConfig(func(cfg *storeConfig) error {
+		profileName = currentProfileName(*cfg)
+		p := profileFor(cfg, profileName)
+		p.DefaultProject, p.DefaultProjectName = selected.ID, selected.Name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(useContext{Profile: profileName, ProjectID: selected.ID, ProjectName: selected.Name, Source: "profile " + profileName})
+	}
+	fmt.Printf("Using project %s (%s) for profile %s.\n", selected.Name, selected.ID, profileName)
+	if ws := currentWorkspace(); ws.Project != "" {
+		fmt.Printf("%s sets project %s, which still wins in this directory.\n", filepath.Base(ws.Path), ws.Project)
+	}
+	return nil
+}
+
+// resolveDefaultProject returns the project commands use when no --project
+// is given. One picked with `hubfly use project` is known by ID and name and
+// costs no API call; a name from a workspace file or `config set` is looked
+// up in the project list. A default that matches no project is ignored.
+func resolveDefaultProject(token string) (project, bool, error) {
+	query := defaultProjectQuery()
+	if query == "" {
+		return project{}, false, nil
+	}
+	if p := activeProfile(); p.DefaultProjectName != "" && query == p.DefaultProject {
+		return project{ID: p.DefaultProject, Name: p.DefaultProjectName}, true, nil
+	}
+	projects, err := fetchProjects(token)
+	if err != nil {
+		return project{}, false, err
+	}
+	selected, ok := resolveRequestedProject(projects, query)
+	if !ok {
+		warnOnce("default-project", fmt.Sprintf("Default project %q not found; searching every project.", query))
+		return project{}, false, nil
+	}
+	return selected, true, nil
+}
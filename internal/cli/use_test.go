@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+
+	"hubfly-cli/internal/testsupport"
+)
+
+func TestUseProjectScopesLookupsWithoutListingProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HUBFLY_API_URL", "")
+	t.Chdir(t.TempDir())
+	original := apiHost
+	t.Cleanup(func() { apiHost, containerSearchUnavailable = original, false })
+
+	api := testsupport.NewMockAPI(t)
+	apiHost = api.URL
+	if err := setToken("user-token"); err != nil {
+		t.Fatal(err)
+	}
+	api.Handle(http.MethodGet, "/api/v1/auth/me", user{ID: "u1", Name: "Test", Email: "t@example.com"})
+	api.Handle(http.MethodGet, "/api/v1/projects", apiPage[project]{Items: []project{{ID: "p1", Name: "shop"}, {ID: "p2", Name: "billing"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p1", map[string]any{"containers": []map[string]any{{"id": "c1", "name": "web"}}})
+	api.Handle(http.MethodGet, "/api/v1/projects/p2", map[string]any{"containers": []map[string]any{{"id": "c2", "name": "ledger"}}})
+
+	if err := useProjectFlow([]string{"billing"}); err != nil {
+		t.Fatal(err)
+	}
+	if p := activeProfile(); p.DefaultProject != "p2" || p.DefaultProjectName != "billing" {
+		t.Fatalf("profile after use = %+v", p)
+	}
+
+	countProjectLists := func() int {
+		n := 0
+		for _, r := range api.Requests() {
+			if r.Method == http.MethodGet && r.Path == "/api/v1/projects" {
+				n++
+			}
+		}
+		return n
+	}
+	before := countProjectLists()
+	p, c, err := findContainerInProjects("user-token", "", "ledger")
+	if err != nil || p.ID != "p2" || c.ID != "c2" {
+		t.Fatalf("lookup in the default project = %+v %+v %v", p, c, err)
+	}
+	if n := countProjectLists() - before; n != 0 {
+		t.Errorf("lookup in the default project listed projects %d time(s)", n)
+	}
+	if projects, err := scopedProjects("user-token", ""); err != nil || len(projects) != 1 || projects[0].Name != "billing" {
+		t.Errorf("scopedProjects = %+v, %v", projects, err)
+	}
+
+	// A container outside the default project is still found.
+	if p, _, err := findContainerInProjects("user-token", "", "web"); err != nil || p.ID != "p1" {
+		t.Fatalf("fallback lookup = %+v, %v", p, err)
+	}
+
+	if err := useProjectFlow([]string{"--clear"}); err != nil {
+		t.Fatal(err)
+	}
+	if projects, err := scopedProjects("user-token", ""); err != nil || len(projects) != 2 {
+		t.Errorf("scopedProjects after --clear = %+v, %v", projects, err)
+	}
+}
@@ -0,0 +1,362 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workspaceFileNames are looked up in the working directory and then in each
+// parent, like git does with .git, so commands run anywhere in a checkout
+// pick up the same defaults.
+var workspaceFileNames = []string{"hubfly.yaml", "hubfly.yml"}
+
+// workspaceConfig is a hubfly.yaml file. Everything in it is a default: flags
+// and arguments given on the command line always win.
+//
+//	project: shop
+//	containers:
+//	  api: shop-api-7f3c
+//	ports:
+//	  api: ["8080:80"]
+//	branches:
+//	  main:
+//	    project: shop-production
+//	  "feature/*":
+//	    project: shop-staging
+type workspaceConfig struct {
+	workspaceEnvironment `yaml:",inline"`
+	// Branches overrides the settings above while the named git branch is
+	// checked out. Keys may be path.Match patterns.
+	Branches map[string]workspaceEnvironment `yaml:"branches" json:"branches,omitempty"`
+
+	Path string `yaml:"-" json:"path"`
+	// Branch is the checked-out branch, and BranchMatch the Branches key
+	// applied for it, if any.
+	Branch      string `yaml:"-" json:"branch,omitempty"`
+	BranchMatch string `yaml:"-" json:"branchMatch,omitempty"`
+	// localPorts is Ports parsed: container -> target port -> local port.
+	localPorts map[string]map[int]int
+}
+
+type workspaceEnvironment struct {
+	// Project is used wherever --project is optional, ahead of the
+	// profile's defaultProject.
+	Project string `yaml:"project" json:"project,omitempty"`
+	// Containers maps short aliases to container ids or names.
+	Containers map[string]string `yaml:"containers" json:"containers,omitempty"`
+	// Ports maps a container, or an alias, to "local:target" pairs used
+	// when a tunnel's local port is auto.
+	Ports map[string][]string `yaml:"ports" json:"ports,omitempty"`
+}
+
+// findWorkspaceFile walks up from dir and returns the first workspace file,
+// or "" when there is none.
+func findWorkspaceFile(dir string) string {
+	for {
+		for _, name := range workspaceFileNames {
+			file := filepath.Join(dir, name)
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				return file
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadWorkspace returns the workspace around the working directory. found is
+// false when no workspace file exists.
+func loadWorkspace() (cfg workspaceConfig, found bool, err error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return workspaceConfig{}, false, err
+	}
+	file := findWorkspaceFile(cwd)
+	if file == "" {
+		return workspaceConfig{}, false, nil
+	}
+	cfg, err = parseWorkspaceFile(file)
+	if err != nil {
+		return workspaceConfig{}, true, err
+	}
+	cfg.applyBranch(currentGitBranch(filepath.Dir(file)))
+	if err := cfg.parsePorts(); err != nil {
+		return workspaceConfig{}, true, err
+	}
+	return cfg, true, nil
+}
+
+func parseWorkspaceFile(file string) (workspaceConfig, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return workspaceConfig{}, err
+	}
+	var cfg workspaceConfig
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return workspaceConfig{}, fmt.Errorf("invalid workspace file %s: %w", file, err)
+	}
+	cfg.Path = file
+	for pattern := range cfg.Branches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return workspaceConfig{}, fmt.Errorf("invalid workspace file %s: branches: bad pattern %q", file, pattern)
+		}
+	}
+	return cfg, nil
+}
+
+// applyBranch merges the Branches entry for branch over the top-level
+// settings. An exact key wins over patterns; among patterns the longest,
+// then the first in sort order, wins.
+func (cfg *workspaceConfig) applyBranch(branch string) {
+	cfg.Branch = branch
+	if branch == "" || len(cfg.Branches) == 0 {
+		return
+	}
+	match := ""
+	if _, ok := cfg.Branches[branch]; ok {
+		match = branch
+	} else {
+		patterns := make([]string, 0, len(cfg.Branches))
+		for pattern := range cfg.Branches {
+			patterns = append(patterns, pattern)
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			if len(patterns[i]) != len(patterns[j]) {
+				return len(patterns[i]) > len(patterns[j])
+			}
+			return patterns[i] < patterns[j]
+		})
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, branch); ok {
+				match = pattern
+				break
+			}
+		}
+	}
+	if match == "" {
+		return
+	}
+	cfg.BranchMatch = match
+	env := cfg.Branches[match]
+	if strings.TrimSpace(env.Project) != "" {
+		cfg.Project = env.Project
+	}
+	cfg.Containers = mergeWorkspaceMap(cfg.Containers, env.Containers)
+	cfg.Ports = mergeWorkspaceMap(cfg.Ports, env.Ports)
+}
+
+func mergeWorkspaceMap[V any](base, override map[string]V) map[string]V {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]V, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+func (cfg *workspaceConfig) parsePorts() error {
+	cfg.Project = strings.TrimSpace(cfg.Project)
+	cfg.localPorts = map[string]map[int]int{}
+	for name, mappings := range cfg.Ports {
+		ports := map[int]int{}
+		for _, mapping := range mappings {
+			local, target, ok := parseWorkspacePort(mapping)
+			if !ok {
+				return fmt.Errorf("invalid workspace file %s: ports.%s: %q is not <local>:<target>", cfg.Path, name, mapping)
+			}
+			ports[target] = local
+		}
+		cfg.localPorts[name] = ports
+	}
+	return nil
+}
+
+// currentGitBranch returns the branch checked out in the git repository
+// around dir, or "" outside a repository or on a detached HEAD.
+// HUBFLY_BRANCH overrides it, for CI jobs that check out a bare commit.
+func currentGitBranch(dir string) string {
+	if branch := strings.TrimSpace(os.Getenv("HUBFLY_BRANCH")); branch != "" {
+		return branch
+	}
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				// Worktrees and submodules use a .git file pointing at
+				// the real git directory.
+				content, err := os.ReadFile(dotGit)
+				if err != nil {
+					return ""
+				}
+				target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+				if !ok {
+					return ""
+				}
+				gitDir = strings.TrimSpace(target)
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+			}
+			head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			branch, _ := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+			if branch == strings.TrimSpace(string(head)) {
+				return ""
+			}
+			return branch
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func parseWorkspacePort(value string) (local, target int, ok bool) {
+	left, right, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return 0, 0, false
+	}
+	local, err := strconv.Atoi(strings.TrimSpace(left))
+	if err != nil || local <= 0 || local > 65535 {
+		return 0, 0, false
+	}
+	target, err = strconv.Atoi(strings.TrimSpace(right))
+	if err != nil || target <= 0 || target > 65535 {
+		return 0, 0, false
+	}
+	return local, target, true
+}
+
+// currentWorkspace is loadWorkspace for callers that only want defaults,
+// laid over the installed team presets. A broken workspace file or preset
+// is reported once and then ignored.
+func currentWorkspace() workspaceConfig {
+	cfg, _, err := loadWorkspace()
+	if err != nil {
+		warnOnce("workspace", "warning: "+err.Error()+"; ignoring it")
+		cfg = workspaceConfig{}
+	}
+	presets, err := loadPresetDefaults()
+	if err != nil {
+		warnOnce("presets", "warning: "+err.Error()+"; ignoring presets")
+		return cfg
+	}
+	if len(presets.Containers) == 0 && len(presets.Ports) == 0 {
+		return cfg
+	}
+	cfg.Containers = mergeWorkspaceMap(presets.Containers, cfg.Containers)
+	cfg.Ports = mergeWorkspaceMap(presets.Ports, cfg.Ports)
+	_ = cfg.parsePorts()
+	return cfg
+}
+
+// defaultProjectQuery is the project to use when none was given: the
+// workspace's, then the active profile's.
+func defaultProjectQuery() string {
+	ws := currentWorkspace()
+	if ws.Project != "" {
+		if ws.BranchMatch != "" && !jsonOutput {
+			warnOnce("workspace-branch", fmt.Sprintf("Using project %s for branch %s (%s).", ws.Project, ws.Branch, filepath.Base(ws.Path)))
+		}
+		return ws.Project
+	}
+	return strings.TrimSpace(activeProfile().DefaultProject)
+}
+
+// resolveContainerAlias maps a workspace alias to the container it names.
+// Anything else is returned unchanged.
+func resolveContainerAlias(query string) string {
+	if target := strings.TrimSpace(currentWorkspace().Containers[query]); target != "" {
+		return target
+	}
+	return query
+}
+
+// workspaceLocalPort returns raw, or the workspace's local port for
+// container:targetPort when raw is "auto" and one is configured.
+func workspaceLocalPort(raw, container string, targetPort int) string {
+	if !strings.EqualFold(strings.TrimSpace(raw), "auto") {
+		return raw
+	}
+	ws := currentWorkspace()
+	for _, name := range []string{container, resolveContainerAlias(container)} {
+		if local := ws.localPorts[name][targetPort]; local > 0 {
+			return strconv.Itoa(local)
+		}
+	}
+	return raw
+}
+
+// configWorkspaceFlow prints the workspace file in effect.
+func configWorkspaceFlow(args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: hubfly config workspace")
+	}
+	cfg, found, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if !found {
+			return printJSON(map[string]any{"path": nil})
+		}
+		return printJSON(cfg)
+	}
+	if !found {
+		fmt.Printf("No %s found in this directory or its parents.\n", workspaceFileNames[0])
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "File:\t%s\n", cfg.Path)
+	if cfg.Branch != "" {
+		branch := cfg.Branch
+		if cfg.BranchMatch != "" {
+			branch += " (matches " + cfg.BranchMatch + ")"
+		}
+		_, _ = fmt.Fprintf(tw, "Branch:\t%s\n", branch)
+	}
+	_, _ = fmt.Fprintf(tw, "Project:\t%s\n", valueOrDash(cfg.Project))
+	aliases := make([]string, 0, len(cfg.Containers))
+	for alias := range cfg.Containers {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		_, _ = fmt.Fprintf(tw, "Alias:\t%s -> %s\n", alias, cfg.Containers[alias])
+	}
+	names := make([]string, 0, len(cfg.Ports))
+	for name := range cfg.Ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(tw, "Ports:\t%s %s\n", name, strings.Join(cfg.Ports[name], ", "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceFoundFromSubdirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	root := t.TempDir()
+	content := "project: shop\ncontainers:\n  api: shop-api-7f3c\nports:\n  api: [\"8080:80\"]\n"
+	if err := os.WriteFile(filepath.Join(root, "hubfly.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(nested)
+
+	if got := defaultProjectQuery(); got != "shop" {
+		t.Errorf("defaultProjectQuery = %q, want shop", got)
+	}
+	if got := resolveContainerAlias("api"); got != "shop-api-7f3c" {
+		t.Errorf("resolveContainerAlias(api) = %q", got)
+	}
+	if got := resolveContainerAlias("web"); got != "web" {
+		t.Errorf("resolveContainerAlias(web) = %q", got)
+	}
+	if got := workspaceLocalPort("auto", "api", 80); got != "8080" {
+		t.Errorf("workspaceLocalPort(auto) = %q, want 8080", got)
+	}
+	if got := workspaceLocalPort("9000", "api", 80); got != "9000" {
+		t.Errorf("explicit port replaced: %q", got)
+	}
+
+	branches := content + "branches:\n  main:\n    project: shop-production\n  \"feature/*\":\n    project: shop-staging\n    containers:\n      api: staging-api\n"
+	if err := os.WriteFile(filepath.Join(root, "hubfly.yaml"), []byte(branches), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte("ref: refs/heads/feature/login\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ws, _, err := loadWorkspace()
+	if err != nil || ws.Project != "shop-staging" || ws.Containers["api"] != "staging-api" || ws.localPorts["api"][80] != 8080 {
+		t.Fatalf("feature branch workspace = %+v, %v", ws, err)
+	}
+	t.Setenv("HUBFLY_BRANCH", "main")
+	if got := defaultProjectQuery(); got != "shop-production" {
+		t.Errorf("defaultProjectQuery on main = %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "hubfly.yaml"), []byte("ports:\n  api: [\"80\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadWorkspace(); err == nil {
+		t.Error("port without a target accepted")
+	}
+}
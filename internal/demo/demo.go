@@ -0,0 +1,336 @@
+// Package demo serves the Hubfly API from built-in fixtures so the CLI can be
+// tried, demoed and tested end to end without an account or network access.
+//
+// The server answers the same {ok, data} envelope as the real API, hosts a
+// tunnel gateway whose targets echo whatever they receive, and a terminal
+// endpoint backed by a tiny fake shell. Tunnels created in demo mode are
+// persisted next to the demo storage so separate invocations (and detached
+// `tunnel up` children) agree on what exists.
+package demo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Server is a running fixture API.
+type Server struct {
+	// URL is the http base URL, suitable for apiHost.
+	URL string
+
+	srv       *http.Server
+	listener  net.Listener
+	statePath string
+	mu        sync.Mutex
+	// statuses holds container statuses changed by lifecycle actions. Unlike
+	// tunnels they only last as long as the server.
+	statuses map[string]string
+}
+
+// Start listens on a random loopback port and serves fixtures. Tunnel state is
+// kept in stateDir so it survives across processes.
+func Start(stateDir string) (*Server, error) {
+	if err := os.MkdirAll(stateDir, 0o700); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		URL:       "http://" + listener.Addr().String(),
+		listener:  listener,
+		statePath: filepath.Join(stateDir, "demo-tunnels.json"),
+		statuses:  map[string]string{},
+	}
+	s.srv = &http.Server{Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = s.srv.Serve(listener) }()
+	return s, nil
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// RebaseURL points a websocket URL handed out by an earlier demo server at the
+// server base. Demo servers live only as long as the process that started
+// them, so stored tunnel tickets must be re-targeted before use.
+func RebaseURL(raw, base string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return raw
+	}
+	u.Host = b.Host
+	u.Scheme = "ws"
+	if b.Scheme == "https" {
+		u.Scheme = "wss"
+	}
+	return u.String()
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	api := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, requireBearer(h))
+	}
+	api("GET /api/v1/auth/me", func(w http.ResponseWriter, r *http.Request) {
+		writeData(w, fixtureUser)
+	})
+	api("GET /api/v1/organizations", func(w http.ResponseWriter, r *http.Request) {
+		writeData(w, fixtureOrganizations)
+	})
+	api("GET /api/v1/regions", func(w http.ResponseWriter, r *http.Request) {
+		writeData(w, fixtureRegions)
+	})
+	api("GET /api/v1/projects", s.handleProjects)
+	api("GET /api/v1/projects/{id}", s.handleProject)
+	api("GET /api/v1/projects/{id}/containers/{cid}/logs", s.handleLogs)
+	api("POST /api/v1/projects/{id}/containers/{cid}/exec", s.handleExec)
+	api("POST /api/v1/projects/{id}/containers/{cid}/{action}", s.handleContainerAction)
+	api("POST /api/v1/projects/{id}/containers/{cid}/terminal/session", s.handleTerminalSession)
+	api("GET /api/v1/projects/{id}/tunnels", s.handleListTunnels)
+	api("POST /api/v1/projects/{id}/tunnels/create", s.handleCreateTunnel)
+	api("DELETE /api/v1/tunnels/{id}", s.handleDeleteTunnel)
+	api("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotImplemented, "DEMO_UNSUPPORTED",
+			fmt.Sprintf("%s %s is not available in demo mode", r.Method, r.URL.Path))
+	})
+	mux.Handle("/demo/tunnel", websocket.Handler(s.serveTunnelGateway))
+	mux.Handle("/demo/terminal", websocket.Handler(s.serveTerminal))
+	return mux
+}
+
+func requireBearer(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) == "" {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	writeEnvelope(w, http.StatusOK, map[string]any{"ok": true, "data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeEnvelope(w, status, map[string]any{
+		"ok":    false,
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
+
+func writeEnvelope(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	org := r.URL.Query().Get("organizationId")
+	items := make([]map[string]any, 0, len(fixtureProjects))
+	for _, p := range fixtureProjects {
+		if org != "" && p.Org != org {
+			continue
+		}
+		items = append(items, projectJSON(p))
+	}
+	writeData(w, map[string]any{"items": items})
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	p, ok := findProject(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "project not found")
+		return
+	}
+	containers := make([]map[string]any, 0, len(p.Containers))
+	for _, c := range p.Containers {
+		containers = append(containers, containerJSON(s.withStatus(c)))
+	}
+	volumes := p.Volumes
+	if volumes == nil {
+		volumes = []map[string]any{}
+	}
+	writeData(w, map[string]any{"containers": containers, "volumes": volumes})
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	_, c, ok := findContainer(r.PathValue("id"), r.PathValue("cid"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "container not found")
+		return
+	}
+	writeData(w, map[string]string{"stdout": c.Logs, "stderr": ""})
+}
+
+func (s *Server) handleContainerAction(w http.ResponseWriter, r *http.Request) {
+	_, c, ok := findContainer(r.PathValue("id"), r.PathValue("cid"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "container not found")
+		return
+	}
+	status := ""
+	switch r.PathValue("action") {
+	case "start", "restart":
+		status = "running"
+	case "stop":
+		status = "stopped"
+	default:
+		writeError(w, http.StatusNotImplemented, "DEMO_UNSUPPORTED",
+			fmt.Sprintf("%s %s is not available in demo mode", r.Method, r.URL.Path))
+		return
+	}
+	s.mu.Lock()
+	s.statuses[c.ID] = status
+	s.mu.Unlock()
+	writeData(w, containerJSON(s.withStatus(c)))
+}
+
+func (s *Server) withStatus(c fixtureContainer) fixtureContainer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, ok := s.statuses[c.ID]; ok {
+		c.Status = status
+	}
+	return c
+}
+
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+	_, c, ok := findContainer(r.PathValue("id"), r.PathValue("cid"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "container not found")
+		return
+	}
+	var body struct {
+		Command []string `json:"command"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Command) == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "command is required")
+		return
+	}
+	if c.Status != "running" {
+		writeError(w, http.StatusConflict, "CONTAINER_NOT_RUNNING", "container is not running")
+		return
+	}
+	out, code := runShellCommand(c, strings.Join(body.Command, " "))
+	result := map[string]any{"exitCode": code, "stdout": out, "stderr": ""}
+	if code != 0 {
+		result["stdout"], result["stderr"] = "", out
+	}
+	writeData(w, result)
+}
+
+func (s *Server) handleTerminalSession(w http.ResponseWriter, r *http.Request) {
+	p, c, ok := findContainer(r.PathValue("id"), r.PathValue("cid"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "container not found")
+		return
+	}
+	if c.Status != "running" {
+		writeError(w, http.StatusConflict, "CONTAINER_NOT_RUNNING", "container is not running")
+		return
+	}
+	writeData(w, map[string]any{
+		"sessionId":    p.ID + ":" + c.ID,
+		"connectToken": randomID(),
+		"connectUrl":   s.wsURL("/demo/terminal"),
+		"shell":        "/bin/sh",
+		"expiresAt":    time.Now().UTC().Add(time.Hour).Format(time.RFC3339),
+	})
+}
+
+func (s *Server) wsURL(path string) string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + path
+}
+
+func projectJSON(p fixtureProject) map[string]any {
+	reg := map[string]any{}
+	for _, candidate := range fixtureRegions {
+		if candidate["id"] == p.Region {
+			reg = candidate
+		}
+	}
+	return map[string]any{
+		"id":          p.ID,
+		"name":        p.Name,
+		"status":      p.Status,
+		"role":        p.Role,
+		"createdAt":   "2025-02-03T10:00:00Z",
+		"spentAmount": p.Spent,
+		"monthlyCost": p.Monthly,
+		"region":      reg,
+	}
+}
+
+func containerJSON(c fixtureContainer) map[string]any {
+	ports := make([]map[string]any, 0, len(c.Ports))
+	for _, port := range c.Ports {
+		ports = append(ports, map[string]any{"protocol": "tcp", "container": port, "tunnelUrl": ""})
+	}
+	return map[string]any{
+		"id":        c.ID,
+		"name":      c.Name,
+		"tier":      c.Tier,
+		"status":    c.Status,
+		"createdAt": "2025-02-03T10:05:00Z",
+		"updatedAt": "2025-03-01T08:30:00Z",
+		"source":    map[string]string{"type": c.Source, "image": c.Image},
+		"resources": map[string]float64{"cpu": c.CPU, "ram": c.RAM, "storage": c.Disk},
+		"networking": map[string]any{
+			"ports": ports,
+		},
+		"primaryNetworkAlias": c.Name,
+	}
+}
+
+func findProject(id string) (fixtureProject, bool) {
+	for _, p := range fixtureProjects {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return fixtureProject{}, false
+}
+
+func findContainer(projectID, containerID string) (fixtureProject, fixtureContainer, bool) {
+	p, ok := findProject(projectID)
+	if !ok {
+		return p, fixtureContainer{}, false
+	}
+	for _, c := range p.Containers {
+		if c.ID == containerID {
+			return p, c, true
+		}
+	}
+	return p, fixtureContainer{}, false
+}
+
+func randomID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+var errNotFound = errors.New("not found")
@@ -0,0 +1,95 @@
+package demo
+
+import "time"
+
+// Token is the credential the demo server accepts. Any bearer token works;
+// this one is written into the demo profile so ensureAuth succeeds without a
+// login.
+const Token = "hubfly-demo-token"
+
+type fixtureContainer struct {
+	ID     string
+	Name   string
+	Tier   string
+	Status string
+	Source string
+	Image  string
+	CPU    float64
+	RAM    float64
+	Disk   float64
+	Ports  []int
+	Logs   string
+}
+
+type fixtureProject struct {
+	ID         string
+	Name       string
+	Status     string
+	Role       string
+	Monthly    string
+	Spent      string
+	Region     string
+	Org        string
+	Containers []fixtureContainer
+	Volumes    []map[string]any
+}
+
+var fixtureUser = map[string]any{
+	"id":    "usr_demo",
+	"name":  "Demo User",
+	"email": "demo@hubfly.space",
+	"image": "",
+}
+
+var fixtureOrganizations = []map[string]any{
+	{"id": "org_demo", "name": "Demo Org", "slug": "demo", "role": "owner", "createdAt": "2025-01-06T09:00:00Z"},
+}
+
+var fixtureRegions = []map[string]any{
+	{"id": "reg_fra", "name": "Frankfurt", "location": "eu-central", "available": true, "primaryIP": "192.0.2.10", "primaryProvider": "demo"},
+	{"id": "reg_nyc", "name": "New York", "location": "us-east", "available": true, "primaryIP": "192.0.2.20", "primaryProvider": "demo"},
+	{"id": "reg_sgp", "name": "Singapore", "location": "ap-southeast", "available": false, "primaryIP": "192.0.2.30", "primaryProvider": "demo"},
+}
+
+var fixtureProjects = []fixtureProject{
+	{
+		ID:      "prj_shop",
+		Name:    "demo-shop",
+		Status:  "active",
+		Role:    "owner",
+		Monthly: "18.40",
+		Spent:   "6.12",
+		Region:  "reg_fra",
+		Org:     "org_demo",
+		Containers: []fixtureContainer{
+			{ID: "ctr_web", Name: "web", Tier: "standard", Status: "running", Source: "image", Image: "node:20-alpine", CPU: 1, RAM: 1024, Disk: 10, Ports: []int{3000},
+				Logs: "> demo-shop@1.0.0 start\n> node server.js\nlistening on :3000\nGET / 200 4ms\nGET /api/cart 200 11ms\n"},
+			{ID: "ctr_api", Name: "api", Tier: "standard", Status: "running", Source: "git", CPU: 1, RAM: 512, Disk: 5, Ports: []int{8080},
+				Logs: "api starting (build 4f2c9e1)\nconnected to postgres\nserving http on :8080\n"},
+			{ID: "ctr_db", Name: "postgres", Tier: "database", Status: "running", Source: "image", Image: "postgres:16", CPU: 2, RAM: 2048, Disk: 20, Ports: []int{5432},
+				Logs: "database system is ready to accept connections\n"},
+		},
+		Volumes: []map[string]any{
+			{"id": "vol_pgdata", "name": "pgdata", "description": "postgres data", "sizeGb": "20", "performanceMode": "standard", "status": "attached", "readOnly": false, "hourlyCost": "0.003", "monthlyCost": "2.00"},
+		},
+	},
+	{
+		ID:      "prj_blog",
+		Name:    "demo-blog",
+		Status:  "active",
+		Role:    "developer",
+		Monthly: "4.20",
+		Spent:   "1.05",
+		Region:  "reg_nyc",
+		Org:     "org_demo",
+		Containers: []fixtureContainer{
+			{ID: "ctr_ghost", Name: "ghost", Tier: "standard", Status: "running", Source: "image", Image: "ghost:5", CPU: 0.5, RAM: 512, Disk: 5, Ports: []int{2368},
+				Logs: "Ghost boot 1.2s\nYour site is now available on http://localhost:2368/\n"},
+			{ID: "ctr_worker", Name: "worker", Tier: "standard", Status: "stopped", Source: "git", CPU: 0.5, RAM: 256, Disk: 1,
+				Logs: "worker exited with code 0\n"},
+		},
+	},
+}
+
+// fixtureTunnelTTL is how long fixture and newly created tunnels stay valid.
+const fixtureTunnelTTL = 8 * time.Hour
@@ -0,0 +1,137 @@
+package demo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+type terminalMessage struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionId,omitempty"`
+	Data      string `json:"data,omitempty"`
+	ExitCode  int    `json:"exitCode,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// serveTerminal drives the terminal protocol with a line-buffered fake shell.
+// The client runs in raw mode, so input is echoed back here like a pty would.
+func (s *Server) serveTerminal(conn *websocket.Conn) {
+	defer conn.Close()
+	var auth terminalMessage
+	if err := websocket.JSON.Receive(conn, &auth); err != nil || auth.Type != "authenticate" {
+		return
+	}
+	projectID, containerID, _ := strings.Cut(auth.SessionID, ":")
+	_, c, ok := findContainer(projectID, containerID)
+	if !ok {
+		_ = websocket.JSON.Send(conn, terminalMessage{Type: "error", Message: "unknown terminal session"})
+		return
+	}
+	send := func(msg terminalMessage) bool {
+		return websocket.JSON.Send(conn, msg) == nil
+	}
+	output := func(text string) bool {
+		return send(terminalMessage{Type: "output", Data: strings.ReplaceAll(text, "\n", "\r\n")})
+	}
+	prompt := fmt.Sprintf("root@%s:/app# ", c.Name)
+
+	if !send(terminalMessage{Type: "authenticated"}) ||
+		!output(fmt.Sprintf("Connected to %s (hubfly demo). Try `ls`, `hostname` or `exit`.\n%s", c.Name, prompt)) {
+		return
+	}
+
+	var line strings.Builder
+	for {
+		var msg terminalMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "ping":
+			send(terminalMessage{Type: "pong"})
+			continue
+		case "input":
+		default:
+			continue
+		}
+		for _, r := range msg.Data {
+			switch r {
+			case '\r', '\n':
+				cmd := strings.TrimSpace(line.String())
+				line.Reset()
+				if cmd == "exit" || cmd == "logout" {
+					output("\nlogout\n")
+					send(terminalMessage{Type: "exit"})
+					return
+				}
+				out := ""
+				if cmd != "" {
+					out, _ = runShellCommand(c, cmd)
+				}
+				if !output("\n" + out + prompt) {
+					return
+				}
+			case 0x7f, '\b':
+				if line.Len() > 0 {
+					typed := []rune(line.String())
+					line.Reset()
+					line.WriteString(string(typed[:len(typed)-1]))
+					output("\b \b")
+				}
+			case 0x03:
+				line.Reset()
+				output("^C\n" + prompt)
+			case 0x04:
+				if line.Len() == 0 {
+					output("\nlogout\n")
+					send(terminalMessage{Type: "exit"})
+					return
+				}
+			default:
+				line.WriteRune(r)
+				output(string(r))
+			}
+		}
+	}
+}
+
+// runShellCommand answers a handful of commands the way a small container
+// would. Output always ends with a newline when non-empty.
+func runShellCommand(c fixtureContainer, cmdline string) (string, int) {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return "", 0
+	}
+	if (fields[0] == "sh" || fields[0] == "bash") && len(fields) >= 3 && fields[1] == "-c" {
+		return runShellCommand(c, strings.Join(fields[2:], " "))
+	}
+	switch fields[0] {
+	case "hostname":
+		return c.Name + "\n", 0
+	case "whoami":
+		return "root\n", 0
+	case "pwd":
+		return "/app\n", 0
+	case "ls":
+		return "Dockerfile  README.md  node_modules  package.json  src\n", 0
+	case "uname":
+		return "Linux\n", 0
+	case "date":
+		return time.Now().UTC().Format(time.UnixDate) + "\n", 0
+	case "echo":
+		return strings.Join(fields[1:], " ") + "\n", 0
+	case "cat":
+		if len(fields) > 1 && fields[1] == "/etc/hostname" {
+			return c.Name + "\n", 0
+		}
+		return "cat: " + strings.Join(fields[1:], " ") + ": No such file or directory\n", 1
+	case "true":
+		return "", 0
+	case "false":
+		return "", 1
+	}
+	return "sh: " + fields[0] + ": not found\n", 127
+}
@@ -0,0 +1,271 @@
+package demo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"golang.org/x/net/websocket"
+)
+
+type demoTunnel struct {
+	TunnelID      string `json:"tunnelId"`
+	ProjectID     string `json:"projectId"`
+	ContainerID   string `json:"containerId"`
+	ContainerName string `json:"containerName"`
+	TargetPort    int    `json:"targetPort"`
+	LocalPort     int    `json:"localPort"`
+	ConnectToken  string `json:"connectToken"`
+	CreatedAt     string `json:"createdAt"`
+	ExpiresAt     string `json:"expiresAt"`
+}
+
+type tunnelState struct {
+	Seeded  bool         `json:"seeded"`
+	Tunnels []demoTunnel `json:"tunnels"`
+}
+
+// loadState reads tunnel state from disk, seeding the fixture tunnels the
+// first time. The file is re-read on every request because another demo
+// process may have created or deleted tunnels since. Callers hold s.mu.
+func (s *Server) loadState() (tunnelState, error) {
+	var state tunnelState
+	content, err := os.ReadFile(s.statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return state, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &state); err != nil {
+			return state, err
+		}
+	}
+	if !state.Seeded {
+		now := time.Now().UTC()
+		state.Seeded = true
+		state.Tunnels = append(state.Tunnels,
+			newDemoTunnel("prj_shop", "ctr_web", "web", 3000, now, fixtureTunnelTTL),
+			newDemoTunnel("prj_shop", "ctr_db", "postgres", 5432, now, 3*time.Hour),
+		)
+		if err := s.saveState(state); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+func (s *Server) saveState(state tunnelState) error {
+	payload, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.statePath, append(payload, '\n'), 0o600)
+}
+
+func newDemoTunnel(projectID, containerID, containerName string, port int, now time.Time, ttl time.Duration) demoTunnel {
+	return demoTunnel{
+		TunnelID:      "tun_" + randomID(),
+		ProjectID:     projectID,
+		ContainerID:   containerID,
+		ContainerName: containerName,
+		TargetPort:    port,
+		ConnectToken:  randomID(),
+		CreatedAt:     now.Format(time.RFC3339),
+		ExpiresAt:     now.Add(ttl).Format(time.RFC3339),
+	}
+}
+
+func (s *Server) tunnelJSON(t demoTunnel) map[string]any {
+	p, _ := findProject(t.ProjectID)
+	return map[string]any{
+		"tunnelId":            t.TunnelID,
+		"id":                  t.TunnelID,
+		"projectId":           t.ProjectID,
+		"projectName":         p.Name,
+		"targetContainerName": t.ContainerName,
+		"targetContainerId":   t.ContainerID,
+		"targetPort":          t.TargetPort,
+		"connectUrl":          s.wsURL("/demo/tunnel"),
+		"connectToken":        t.ConnectToken,
+		"protocolVersion":     1,
+		"mode":                "tcp",
+		"status":              "active",
+		"targets": []map[string]any{{
+			"targetId":      t.TunnelID + "-0",
+			"containerId":   t.ContainerID,
+			"containerName": t.ContainerName,
+			"runtimeId":     t.ContainerID,
+			"targetPort":    t.TargetPort,
+			"localPort":     t.LocalPort,
+		}},
+		"limits":        map[string]int{"maxStreams": 32, "idleTimeoutSeconds": 900, "maxDurationSeconds": int(fixtureTunnelTTL.Seconds())},
+		"bytesSent":     "0",
+		"bytesReceived": "0",
+		"expiresAt":     t.ExpiresAt,
+		"createdAt":     t.CreatedAt,
+		"createdBy":     fixtureUser,
+	}
+}
+
+func (s *Server) handleListTunnels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.loadState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "DEMO_STATE", err.Error())
+		return
+	}
+	items := make([]map[string]any, 0)
+	for _, t := range state.Tunnels {
+		if t.ProjectID == r.PathValue("id") {
+			items = append(items, s.tunnelJSON(t))
+		}
+	}
+	writeData(w, items)
+}
+
+func (s *Server) handleCreateTunnel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ContainerID string `json:"containerId"`
+		TargetPort  int    `json:"targetPort"`
+		LocalPort   int    `json:"localPort"`
+		TTLSeconds  int    `json:"ttlSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TargetPort <= 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "containerId and targetPort are required")
+		return
+	}
+	_, c, ok := findContainer(r.PathValue("id"), body.ContainerID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "container not found")
+		return
+	}
+	if c.Status != "running" {
+		writeError(w, http.StatusConflict, "CONTAINER_NOT_RUNNING", "container is not running")
+		return
+	}
+	ttl := fixtureTunnelTTL
+	if body.TTLSeconds > 0 {
+		ttl = time.Duration(body.TTLSeconds) * time.Second
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.loadState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "DEMO_STATE", err.Error())
+		return
+	}
+	t := newDemoTunnel(r.PathValue("id"), c.ID, c.Name, body.TargetPort, time.Now().UTC(), ttl)
+	t.LocalPort = body.LocalPort
+	state.Tunnels = append(state.Tunnels, t)
+	if err := s.saveState(state); err != nil {
+		writeError(w, http.StatusInternalServerError, "DEMO_STATE", err.Error())
+		return
+	}
+	writeData(w, s.tunnelJSON(t))
+}
+
+func (s *Server) handleDeleteTunnel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.loadState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "DEMO_STATE", err.Error())
+		return
+	}
+	for i, t := range state.Tunnels {
+		if t.TunnelID != r.PathValue("id") {
+			continue
+		}
+		state.Tunnels = append(state.Tunnels[:i], state.Tunnels[i+1:]...)
+		if err := s.saveState(state); err != nil {
+			writeError(w, http.StatusInternalServerError, "DEMO_STATE", err.Error())
+			return
+		}
+		writeData(w, map[string]bool{"deleted": true})
+		return
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "tunnel not found")
+}
+
+func (s *Server) findTunnel(id, token string) (demoTunnel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.loadState()
+	if err != nil {
+		return demoTunnel{}, err
+	}
+	for _, t := range state.Tunnels {
+		if t.TunnelID == id && t.ConnectToken == token {
+			return t, nil
+		}
+	}
+	return demoTunnel{}, errNotFound
+}
+
+// serveTunnelGateway speaks the gateway handshake and then serves yamux
+// streams whose "target" simply echoes bytes back.
+func (s *Server) serveTunnelGateway(conn *websocket.Conn) {
+	defer conn.Close()
+	var auth struct {
+		Type         string `json:"type"`
+		TunnelID     string `json:"tunnelId"`
+		ConnectToken string `json:"connectToken"`
+	}
+	if err := websocket.JSON.Receive(conn, &auth); err != nil || auth.Type != "authenticate" {
+		return
+	}
+	_ = websocket.JSON.Send(conn, map[string]any{"type": "hello", "protocolVersion": 1})
+	t, err := s.findTunnel(auth.TunnelID, auth.ConnectToken)
+	if err != nil {
+		_ = websocket.JSON.Send(conn, map[string]string{"type": "error", "code": "TUNNEL_NOT_FOUND", "message": "unknown tunnel or connect token"})
+		return
+	}
+	if expires, err := time.Parse(time.RFC3339, t.ExpiresAt); err == nil && time.Now().After(expires) {
+		_ = websocket.JSON.Send(conn, map[string]string{"type": "error", "code": "TUNNEL_EXPIRED", "message": "tunnel has expired"})
+		return
+	}
+	if err := websocket.JSON.Send(conn, map[string]string{"type": "authenticated", "tunnelId": t.TunnelID}); err != nil {
+		return
+	}
+
+	session, err := yamux.Server(conn, nil)
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	for {
+		stream, err := session.AcceptStream()
+		if err != nil {
+			return
+		}
+		go serveEchoStream(stream, t.TunnelID+"-0")
+	}
+}
+
+func serveEchoStream(stream io.ReadWriteCloser, targetID string) {
+	defer stream.Close()
+	reader := bufio.NewReader(stream)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req struct {
+		Type     string `json:"type"`
+		TargetID string `json:"targetId"`
+	}
+	reply := map[string]string{"type": "connected"}
+	if json.Unmarshal(line, &req) != nil || req.Type != "connect" || req.TargetID != targetID {
+		reply = map[string]string{"type": "error", "code": "TARGET_NOT_FOUND", "message": "unknown target"}
+	}
+	payload, _ := json.Marshal(reply)
+	if _, err := stream.Write(append(payload, '\n')); err != nil || reply["type"] != "connected" {
+		return
+	}
+	_, _ = io.Copy(stream, reader)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor systemd passes (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// activation holds the sockets systemd passed through LISTEN_FDS. The one
+// named "control" (FileDescriptorName=control), or else the one on the
+// control port, serves the API; every other socket is a tunnel listener keyed
+// by its local port.
+type activation struct {
+	control net.Listener
+	tunnels map[int]*reusableListener
+}
+
+// socketActivation is set by Run when the service was socket-activated.
+var socketActivation *activation
+
+// activationFromEnv reads LISTEN_PID/LISTEN_FDS/LISTEN_FDNAMES. It returns
+// nil when the process was not socket-activated.
+func activationFromEnv(controlPort int) (*activation, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	// Child processes must not think the sockets are theirs.
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	a := &activation{tunnels: make(map[int]*reusableListener)}
+	var unnamed []*net.TCPListener
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			a.close()
+			return nil, fmt.Errorf("socket activation fd %d: %w", listenFDsStart+i, err)
+		}
+		tcp, ok := listener.(*net.TCPListener)
+		if !ok {
+			_ = listener.Close()
+			a.close()
+			return nil, fmt.Errorf("socket activation fd %d is not a TCP socket", listenFDsStart+i)
+		}
+		port := tcp.Addr().(*net.TCPAddr).Port
+		switch {
+		case name == "control" || (a.control == nil && name != "tunnel" && port == controlPort):
+			a.control = tcp
+		default:
+			unnamed = append(unnamed, tcp)
+		}
+	}
+	for _, tcp := range unnamed {
+		a.tunnels[tcp.Addr().(*net.TCPAddr).Port] = &reusableListener{TCPListener: tcp, activated: true}
+	}
+	return a, nil
+}
+
+// listener returns the activated socket for a tunnel's local port.
+func (a *activation) listener(port int) (*reusableListener, bool) {
+	if a == nil {
+		return nil, false
+	}
+	l, ok := a.tunnels[port]
+	return l, ok
+}
+
+func (a *activation) close() {
+	if a.control != nil {
+		_ = a.control.Close()
+	}
+	for _, l := range a.tunnels {
+		_ = l.TCPListener.Close()
+	}
+}
+
+// reusableListener is a forward tunnel's local socket. Closing it only
+// stops Accept, so the next run on the same port can use it again: a
+// restarted tunnel, or any later tunnel on a socket-activated port. release
+// closes the socket for good, except systemd's, which stay open for the life
+// of the process.
+type reusableListener struct {
+	*net.TCPListener
+	closed atomic.Bool
+	// activated marks a socket passed by systemd.
+	activated bool
+	released  atomic.Bool
+}
+
+func (l *reusableListener) Accept() (net.Conn, error) {
+	conn, err := l.TCPListener.Accept()
+	if err != nil && l.closed.Load() {
+		return nil, fmt.Errorf("%w: %v", net.ErrClosed, err)
+	}
+	return conn, err
+}
+
+func (l *reusableListener) Close() error {
+	l.closed.Store(true)
+	return l.SetDeadline(time.Now())
+}
+
+func (l *reusableListener) reopen() {
+	l.closed.Store(false)
+	_ = l.SetDeadline(time.Time{})
+}
+
+func (l *reusableListener) release() {
+	_ = l.Close()
+	if !l.activated {
+		l.released.Store(true)
+		_ = l.TCPListener.Close()
+	}
+}
+
+// listenLocal returns the listener for a forward tunnel's local port: the
+// socket-activated one when systemd passed it, whatever its address,
+// otherwise a new bind on ip.
+func listenLocal(ip net.IP, port int) (*reusableListener, error) {
+	if l, ok := socketActivation.listener(port); ok {
+		l.reopen()
+		return l, nil
+	}
+	addr := &net.TCPAddr{IP: ip, Port: port}
+	listener, err := net.ListenTCP("tcp", addr)
+	if err != nil {
+		if IsAddrInUse(err) {
+			return nil, fmt.Errorf("local port %d is %w", port, errLocalPortInUse)
+		}
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return &reusableListener{TCPListener: listener}, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"net/http"
+
+	"golang.org/x/net/websocket"
+)
+
+// lifecycleEvents are the event types pushed on the /ws control channel.
+// Per-stream and stats events stay on the log streams.
+var lifecycleEvents = map[string]bool{
+	"starting":         true,
+	"active":           true,
+	"reconnecting":     true,
+	"reconnect-failed": true,
+	"reconnected":      true,
+	"error":            true,
+	"restarting":       true,
+	"expired":          true,
+	"closed":           true,
+	"stopped":          true,
+}
+
+// The first frame on the /ws control channel is a "status" with every
+// tunnel, as /status returns them; each later one is an "event" carrying one
+// lifecycle event.
+type controlStatus struct {
+	Type    string         `json:"type"`
+	Tunnels []TunnelStatus `json:"tunnels"`
+}
+
+type controlEvent struct {
+	Type  string `json:"type"`
+	Event Event  `json:"event"`
+}
+
+// handleControl upgrades to a WebSocket that pushes tunnel lifecycle events,
+// so clients can react without polling /status. ?tunnel=<id> limits the
+// events to one tunnel. Messages from the client are ignored.
+func (m *manager) handleControl(w http.ResponseWriter, r *http.Request) {
+	tunnelID := r.URL.Query().Get("tunnel")
+	server := websocket.Server{
+		// The token already authenticated the request; any origin, including
+		// browser extensions, may connect with it.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			m.serveControl(conn, tunnelID)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (m *manager) serveControl(conn *websocket.Conn, tunnelID string) {
+	defer conn.Close()
+	// Subscribe before taking the snapshot so no change falls in between.
+	sub, _ := m.events.subscribe(tunnelID)
+	defer m.events.unsubscribe(sub)
+
+	statuses := m.statuses()
+	if tunnelID != "" {
+		kept := statuses[:0]
+		for _, s := range statuses {
+			if s.ID == tunnelID {
+				kept = append(kept, s)
+			}
+		}
+		statuses = kept
+	}
+	if err := websocket.JSON.Send(conn, controlStatus{Type: "status", Tunnels: statuses}); err != nil {
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-conn.Request().Context().Done():
+			return
+		case ev := <-sub.ch:
+			if !lifecycleEvents[ev.Type] {
+				continue
+			}
+			if err := websocket.JSON.Send(conn, controlEvent{Type: "event", Event: ev}); err != nil {
+				return
+			}
+		}
+	}
+}
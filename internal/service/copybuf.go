@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Tunnel copies start with a small buffer and double it while reads keep
+// filling it, so a database session or a shell holds a few KiB per direction
+// and a file transfer moves up to maxCopyBuffer per read.
+const (
+	minCopyBuffer = 4 << 10
+	maxCopyBuffer = 256 << 10
+	// growAfter full reads in a row double the buffer. shrinkAfter reads in
+	// a row that use under a quarter of it halve it again.
+	growAfter   = 2
+	shrinkAfter = 8
+)
+
+var errInvalidWrite = errors.New("invalid write result")
+
+// copyBufferPools holds one pool per buffer size, minCopyBuffer << i.
+var copyBufferPools = func() []*sync.Pool {
+	var pools []*sync.Pool
+	for size := minCopyBuffer; size <= maxCopyBuffer; size <<= 1 {
+		pools = append(pools, &sync.Pool{New: func() any {
+			buf := make([]byte, size)
+			return &buf
+		}})
+	}
+	return pools
+}()
+
+// copyBuffer is a pooled buffer that resizes itself from the reads made
+// into it. held, when set, tracks the bytes it currently takes.
+type copyBuffer struct {
+	class int
+	buf   *[]byte
+	full  int
+	short int
+	held  *atomic.Int64
+}
+
+func newCopyBuffer(held *atomic.Int64) *copyBuffer {
+	b := &copyBuffer{held: held}
+	b.take(0)
+	return b
+}
+
+func (b *copyBuffer) take(class int) {
+	b.class = class
+	b.buf = copyBufferPools[class].Get().(*[]byte)
+	if b.held != nil {
+		b.held.Add(int64(len(*b.buf)))
+	}
+}
+
+func (b *copyBuffer) give() {
+	if b.held != nil {
+		b.held.Add(-int64(len(*b.buf)))
+	}
+	copyBufferPools[b.class].Put(b.buf)
+	b.buf = nil
+}
+
+func (b *copyBuffer) bytes() []byte {
+	return *b.buf
+}
+
+// observe records a read of n bytes and moves to the next size up or down
+// once the pattern has held for long enough.
+func (b *copyBuffer) observe(n int) {
+	size := len(*b.buf)
+	switch {
+	case n == size:
+		b.full++
+		b.short = 0
+	case n < size/4:
+		b.short++
+		b.full = 0
+	default:
+		b.full, b.short = 0, 0
+	}
+	switch {
+	case b.full >= growAfter && b.class < len(copyBufferPools)-1:
+		b.resize(b.class + 1)
+	case b.short >= shrinkAfter && b.class > 0:
+		b.resize(b.class - 1)
+	}
+}
+
+func (b *copyBuffer) resize(class int) {
+	b.give()
+	b.take(class)
+	b.full, b.short = 0, 0
+}
+
+// copyAdaptive is io.Copy with a buffer sized to the traffic. It never
+// hands off to ReaderFrom or WriterTo, which would copy through a fixed
+// 32 KiB buffer of their own. held may be nil.
+func copyAdaptive(dst io.Writer, src io.Reader, held *atomic.Int64) (written int64, err error) {
+	buf := newCopyBuffer(held)
+	defer buf.give()
+	for {
+		nr, readErr := src.Read(buf.bytes())
+		if nr > 0 {
+			nw, writeErr := dst.Write(buf.bytes()[:nr])
+			if nw < 0 || nw > nr {
+				nw = 0
+				if writeErr == nil {
+					writeErr = errInvalidWrite
+				}
+			}
+			written += int64(nw)
+			if writeErr != nil {
+				return written, writeErr
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		if readErr != nil {
+			if readErr == io.EOF {
+				return written, nil
+			}
+			return written, readErr
+		}
+		buf.observe(nr)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	eventHistorySize   = 200
+	eventSubscriberBuf = 64
+	statsInterval      = 2 * time.Second
+)
+
+// Event is a single entry on the service log stream. Byte and stream
+// counters are snapshots taken when the event was published.
+type Event struct {
+	Time            string `json:"time"`
+	TunnelID        string `json:"tunnel_id,omitempty"`
+	Type            string `json:"type"`
+	Message         string `json:"message,omitempty"`
+	ActiveStreams   int64  `json:"active_streams"`
+	StreamsOpened   int64  `json:"streams_opened"`
+	BytesSent       uint64 `json:"bytes_sent"`
+	BytesReceived   uint64 `json:"bytes_received"`
+	StreamsStalled  int64  `json:"streams_stalled"`
+	StreamsRejected int64  `json:"streams_rejected"`
+}
+
+type subscriber struct {
+	tunnelID string
+	ch       chan Event
+}
+
+// broker fans events out to log stream subscribers and keeps a short history
+// so a client that connects after a failure can still see what happened.
+type broker struct {
+	mu          sync.Mutex
+	history     []Event
+	subscribers map[*subscriber]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (b *broker) publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.history = append(b.history, ev)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+	for sub := range b.subscribers {
+		if sub.tunnelID != "" && sub.tunnelID != ev.TunnelID {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			// Slow readers drop events rather than stall the tunnels.
+		}
+	}
+}
+
+func (b *broker) subscribe(tunnelID string) (*subscriber, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub := &subscriber{tunnelID: tunnelID, ch: make(chan Event, eventSubscriberBuf)}
+	b.subscribers[sub] = struct{}{}
+	backlog := make([]Event, 0, len(b.history))
+	for _, ev := range b.history {
+		if tunnelID == "" || ev.TunnelID == tunnelID {
+			backlog = append(backlog, ev)
+		}
+	}
+	return sub, backlog
+}
+
+func (b *broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, sub)
+}
+
+// emit logs a tunnel event to stdout and publishes it to stream subscribers.
+func (t *ActiveTunnel) emit(eventType, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("[tunnel] %s %s | %s", eventType, t.Req.ID, message)
+	if t.events == nil {
+		return
+	}
+	t.events.publish(t.snapshot(eventType, message))
+}
+
+func (t *ActiveTunnel) snapshot(eventType, message string) Event {
+	return Event{
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		TunnelID:        t.Req.ID,
+		Type:            eventType,
+		Message:         message,
+		ActiveStreams:   t.ActiveStreams.Load(),
+		StreamsOpened:   t.StreamsOpened.Load(),
+		BytesSent:       t.BytesSent.Load(),
+		BytesReceived:   t.BytesReceived.Load(),
+		StreamsStalled:  t.StreamsStalled.Load(),
+		StreamsRejected: t.StreamsRejected.Load(),
+	}
+}
+
+// publishStats emits a counters-only event whenever traffic moved since the
+// previous tick, until the tunnel finishes.
+func (t *ActiveTunnel) publishStats() {
+	if t.events == nil {
+		return
+	}
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+	var lastSent, lastReceived uint64
+	for {
+		select {
+		case <-t.Done:
+			return
+		case <-ticker.C:
+			sent, received := t.BytesSent.Load(), t.BytesReceived.Load()
+			if sent == lastSent && received == lastReceived {
+				continue
+			}
+			lastSent, lastReceived = sent, received
+			t.events.publish(t.snapshot("stats", ""))
+		}
+	}
+}
+
+func (m *manager) handleLogs(w http.ResponseWriter, r *http.Request) {
+	m.streamEvents(w, r, "")
+}
+
+func (m *manager) handleTunnelLogs(w http.ResponseWriter, r *http.Request) {
+	m.streamEvents(w, r, r.PathValue("id"))
+}
+
+// streamEvents writes events as Server-Sent Events until the client goes away.
+func (m *manager) streamEvents(w http.ResponseWriter, r *http.Request, tunnelID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, backlog := m.events.subscribe(tunnelID)
+	defer m.events.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range backlog {
+		if err := writeEvent(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-sub.ch:
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload)
+	return err
+}
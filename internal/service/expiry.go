@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// expiredRetention is how long an expired tunnel stays in /status, marked
+// "expired", before it is removed.
+var expiredRetention = time.Minute
+
+// expiryCheckInterval is how often a tunnel's idle time and TTL are checked.
+var expiryCheckInterval = time.Second
+
+// limits parses the optional idle_timeout and ttl of req. Zero means no limit.
+func (req TunnelRequest) limits() (idle, ttl time.Duration, err error) {
+	if req.IdleTimeout != "" {
+		idle, err = time.ParseDuration(req.IdleTimeout)
+		if err != nil || idle <= 0 {
+			return 0, 0, fmt.Errorf("Invalid idle_timeout %q (use a duration such as 15m)", req.IdleTimeout)
+		}
+	}
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			return 0, 0, fmt.Errorf("Invalid ttl %q (use a duration such as 2h)", req.TTL)
+		}
+	}
+	return idle, ttl, nil
+}
+
+// watchExpiry closes active once it has had no open connections for its idle
+// timeout or has run past its TTL. It returns when ctx is done.
+func (m *manager) watchExpiry(ctx context.Context, active *ActiveTunnel) {
+	idle, ttl, err := active.Req.limits()
+	if err != nil || (idle == 0 && ttl == 0) {
+		return
+	}
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+	lastUsed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if active.ActiveStreams.Load() > 0 {
+				lastUsed = now
+			}
+			switch {
+			case ttl > 0 && now.Sub(active.StartedAt) >= ttl:
+				m.expireTunnel(active, fmt.Sprintf("ttl of %s reached", ttl))
+				return
+			case idle > 0 && now.Sub(lastUsed) >= idle:
+				m.expireTunnel(active, fmt.Sprintf("no connections for %s", idle))
+				return
+			}
+		}
+	}
+}
+
+// expireTunnel marks active "expired" and closes it. The entry stays in
+// /status for expiredRetention so clients polling it see why it went away.
+func (m *manager) expireTunnel(active *ActiveTunnel, reason string) {
+	m.mu.Lock()
+	if m.tunnels[active.Req.ID] != active {
+		m.mu.Unlock()
+		return
+	}
+	active.Status = "expired"
+	active.LastError = reason
+	m.mu.Unlock()
+	forgetTunnel(active.Req.ID)
+
+	active.emit("expired", "%s", reason)
+	if active.Cancel != nil {
+		active.Cancel()
+	}
+	<-active.Done
+	time.AfterFunc(expiredRetention, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.tunnels[active.Req.ID] == active {
+			delete(m.tunnels, active.Req.ID)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var errServiceClosing = errors.New("tunnel service is shutting down; start it again with `hubfly service start`")
+
+// idleTracker records authenticated API activity. A request counts from the
+// moment it arrives until its handler returns, so an open log stream keeps
+// the service busy.
+type idleTracker struct {
+	last     atomic.Int64
+	inflight atomic.Int64
+}
+
+func newIdleTracker() *idleTracker {
+	t := &idleTracker{}
+	t.last.Store(time.Now().UnixNano())
+	return t
+}
+
+func (t *idleTracker) track(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t.inflight.Add(1)
+		defer func() {
+			t.last.Store(time.Now().UnixNano())
+			t.inflight.Add(-1)
+		}()
+		next(w, r)
+	}
+}
+
+// idleFor returns how long no request has been running, or 0 while one is.
+func (t *idleTracker) idleFor(now time.Time) time.Duration {
+	if t.inflight.Load() > 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, t.last.Load()))
+}
+
+// idleCheckInterval is how often watchIdle looks at the service, bounded so
+// short test timeouts react quickly and long ones do not spin.
+func idleCheckInterval(timeout time.Duration) time.Duration {
+	interval := timeout / 10
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	return interval
+}
+
+// watchIdle calls shutdown once the service has had no tunnels and no API
+// calls for timeout. From then on /start is refused so a request racing the
+// shutdown cannot start a tunnel that is about to be torn down.
+func (m *manager) watchIdle(timeout time.Duration, shutdown func()) {
+	ticker := time.NewTicker(idleCheckInterval(timeout))
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.mu.Lock()
+		idle := len(m.tunnels) == 0 && m.activity.idleFor(now) >= timeout
+		if idle {
+			m.closing = true
+		}
+		m.mu.Unlock()
+		if idle {
+			shutdown()
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Info describes a running service instance. It is written to
+// ~/.hubfly/service.json with owner-only permissions so local clients can
+// discover the port and the shared secret required by the control API.
+type Info struct {
+	Port      int    `json:"port"`
+	Token     string `json:"token"`
+	PID       int    `json:"pid"`
+	StartedAt string `json:"started_at"`
+}
+
+func InfoPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".hubfly", "service.json")
+}
+
+func ReadInfo() (Info, error) {
+	var info Info
+	content, err := os.ReadFile(InfoPath())
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(content, &info); err != nil {
+		return Info{}, err
+	}
+	if strings.TrimSpace(info.Token) == "" || info.Port <= 0 {
+		return Info{}, errors.New("service info is incomplete")
+	}
+	return info, nil
+}
+
+func writeInfo(info Info) error {
+	if err := os.MkdirAll(filepath.Dir(InfoPath()), 0o700); err != nil {
+		return err
+	}
+	payload, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(InfoPath(), append(payload, '\n'), 0o600)
+}
+
+func removeInfo(token string) {
+	current, err := ReadInfo()
+	if err != nil || current.Token != token {
+		return
+	}
+	_ = os.Remove(InfoPath())
+}
+
+func newServiceToken() (string, error) {
+	if token := strings.TrimSpace(os.Getenv("HUBFLY_SERVICE_TOKEN")); token != "" {
+		return token, nil
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func newInfo(port int, token string) Info {
+	return Info{
+		Port:      port,
+		Token:     token,
+		PID:       os.Getpid(),
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// requireToken checks the bearer token in the Authorization header.
+func requireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return checkToken(token, false, next)
+}
+
+// requireTokenOrQuery also accepts ?token=, for the streams that browser
+// EventSource and WebSocket clients open without being able to set headers.
+// Everything else keeps the token out of URLs, where it would end up in
+// shell history and proxy logs.
+func requireTokenOrQuery(token string, next http.HandlerFunc) http.HandlerFunc {
+	return checkToken(token, true, next)
+}
+
+func checkToken(token string, allowQuery bool, next http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		provided := []byte(r.Header.Get("Authorization"))
+		if allowQuery && len(provided) == 0 && r.URL.Query().Has("token") {
+			provided = []byte("Bearer " + r.URL.Query().Get("token"))
+		}
+		if subtle.ConstantTimeCompare(provided, expected) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultConnectionRate is how many new connections per second the service
+// accepts across all tunnels unless --max-connection-rate says otherwise.
+const DefaultConnectionRate = 200
+
+// connectionLimiter is a token bucket shared by every tunnel. It refills at
+// rate tokens per second and holds at most one second's worth, so a burst of
+// up to rate connections is let through at once.
+type connectionLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newConnectionLimiter returns nil, which admits everything, for a rate of 0.
+func newConnectionLimiter(rate int) *connectionLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &connectionLimiter{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+func (l *connectionLimiter) allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// admit decides whether a new connection may be served. On success the
+// caller must call the returned release once the connection ends. On
+// refusal, admit counts and logs the rejection and returns a reason.
+func (t *ActiveTunnel) admit() (release func(), reason string) {
+	n := t.admitted.Add(1)
+	release = func() { t.admitted.Add(-1) }
+	switch max := int64(t.Req.MaxConnections); {
+	case max > 0 && n > max:
+		reason = fmt.Sprintf("connection limit of %d reached", max)
+	case !t.limiter.allow(time.Now()):
+		reason = fmt.Sprintf("service connection rate of %.0f/s exceeded", t.limiter.rate)
+	default:
+		return release, ""
+	}
+	release()
+	t.StreamsRejected.Add(1)
+	t.emit("stream-rejected", "%s", reason)
+	return nil, reason
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// Log levels understood by SetLogLevel and the /log-level endpoint.
+const (
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+)
+
+// debugLogging turns on per-stream traces. It can change while tunnels are
+// running: through /log-level, or with SIGUSR1 on unix.
+var debugLogging atomic.Bool
+
+// LogLevel reports the current log level.
+func LogLevel() string {
+	if debugLogging.Load() {
+		return LogLevelDebug
+	}
+	return LogLevelInfo
+}
+
+// SetLogLevel switches between info and debug logging.
+func SetLogLevel(level string) error {
+	switch level {
+	case LogLevelInfo, LogLevelDebug:
+	default:
+		return fmt.Errorf("unknown log level %q (use info or debug)", level)
+	}
+	if debugLogging.Swap(level == LogLevelDebug) != (level == LogLevelDebug) {
+		log.Printf("Log level set to %s", level)
+	}
+	return nil
+}
+
+func toggleLogLevel() {
+	if debugLogging.Load() {
+		_ = SetLogLevel(LogLevelInfo)
+		return
+	}
+	_ = SetLogLevel(LogLevelDebug)
+}
+
+// debug emits a "debug" event while debug logging is on.
+func (t *ActiveTunnel) debug(format string, args ...any) {
+	if !debugLogging.Load() {
+		return
+	}
+	t.emit("debug", format, args...)
+}
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+func handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body logLevelBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := SetLogLevel(body.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(logLevelBody{Level: LogLevel()})
+}
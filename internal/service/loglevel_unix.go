@@ -0,0 +1,26 @@
+//go:build !windows
+
+package service
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchLogLevelSignal toggles debug logging on every SIGUSR1, until done is
+// closed.
+func watchLogLevelSignal(done <-chan struct{}) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-sigs:
+			toggleLogLevel()
+		}
+	}
+}
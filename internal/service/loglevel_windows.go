@@ -0,0 +1,8 @@
+//go:build windows
+
+package service
+
+// Windows has no SIGUSR1; use `hubfly service set-log-level` instead.
+func watchLogLevelSignal(done <-chan struct{}) {
+	<-done
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package service
+
+import (
+	"errors"
+	"syscall"
+)
+
+// IsAddrInUse reports whether a listen failed because the address is taken.
+func IsAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+// IsAccessDenied reports whether a listen failed for lack of permission, as
+// binding a privileged port without root does.
+func IsAccessDenied(err error) bool {
+	return errors.Is(err, syscall.EACCES)
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package service
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// IsAddrInUse reports whether a listen failed because the address is taken.
+// Winsock reports it as WSAEADDRINUSE, not the EADDRINUSE that package
+// syscall defines for portability.
+func IsAddrInUse(err error) bool {
+	return errors.Is(err, windows.WSAEADDRINUSE) || errors.Is(err, syscall.EADDRINUSE)
+}
+
+// IsAccessDenied reports whether a listen failed for lack of permission.
+// Winsock reports it as WSAEACCES.
+func IsAccessDenied(err error) bool {
+	return errors.Is(err, windows.WSAEACCES) || errors.Is(err, syscall.EACCES)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// resourceCheckInterval is how often watchResources looks for leaks.
+var resourceCheckInterval = 30 * time.Second
+
+// A tunnel runs a fixed set of goroutines (the run itself, the gateway
+// supervisor, the accept loop, stats and expiry) plus three per open
+// connection: the handler and one copy in each direction. The slack covers
+// connections that are still being set up or torn down.
+const (
+	tunnelBaseGoroutines = 6
+	goroutinesPerStream  = 3
+	goroutineSlack       = 4
+	// fdSlack covers the control listener and log files, on top of two
+	// descriptors per open connection and two per tunnel (its listener and
+	// gateway socket).
+	fdSlack = 64
+)
+
+// TunnelResources is what one tunnel holds. /status?verbose=1 adds it to
+// each tunnel.
+type TunnelResources struct {
+	Goroutines      int64  `json:"goroutines"`
+	OpenConnections int64  `json:"open_connections"`
+	BufferBytes     int64  `json:"buffer_bytes"`
+	Warning         string `json:"warning,omitempty"`
+}
+
+// ProcessResources is what the whole service holds. OpenFDs is -1 where the
+// platform cannot count them.
+type ProcessResources struct {
+	Goroutines        int    `json:"goroutines"`
+	TrackedGoroutines int64  `json:"tracked_goroutines"`
+	OpenFDs           int    `json:"open_fds"`
+	HeapBytes         uint64 `json:"heap_bytes"`
+	SysBytes          uint64 `json:"sys_bytes"`
+	Warning           string `json:"warning,omitempty"`
+}
+
+// TunnelMetrics is one tunnel's entry in /metrics.
+type TunnelMetrics struct {
+	ID string `json:"id"`
+	TunnelResources
+	StreamsOpened   int64  `json:"streams_opened"`
+	StreamsStalled  int64  `json:"streams_stalled"`
+	StreamsRejected int64  `json:"streams_rejected"`
+	BytesSent       uint64 `json:"bytes_sent"`
+	BytesReceived   uint64 `json:"bytes_received"`
+}
+
+// Metrics is the body of GET /metrics.
+type Metrics struct {
+	Process ProcessResources `json:"process"`
+	Tunnels []TunnelMetrics  `json:"tunnels"`
+}
+
+// spawn runs f on a goroutine counted against t.
+func (t *ActiveTunnel) spawn(f func()) {
+	t.goroutines.Add(1)
+	go func() {
+		defer t.goroutines.Add(-1)
+		f()
+	}()
+}
+
+func (t *ActiveTunnel) resources() TunnelResources {
+	return TunnelResources{
+		Goroutines:      t.goroutines.Load(),
+		OpenConnections: t.ActiveStreams.Load(),
+		BufferBytes:     t.bufferBytes.Load(),
+		Warning:         t.resourceWarning(),
+	}
+}
+
+func (t *ActiveTunnel) resourceWarning() string {
+	if w, ok := t.leakWarning.Load().(string); ok {
+		return w
+	}
+	return ""
+}
+
+// goroutineLeak describes t's goroutines when there are more than its open
+// connections account for.
+func (t *ActiveTunnel) goroutineLeak() string {
+	streams := t.ActiveStreams.Load()
+	limit := tunnelBaseGoroutines + goroutineSlack + goroutinesPerStream*streams
+	if n := t.goroutines.Load(); n > limit {
+		return fmt.Sprintf("%d goroutines for %d open connection(s), expected at most %d", n, streams, limit)
+	}
+	return ""
+}
+
+func (m *manager) metrics() Metrics {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	out := Metrics{
+		Process: ProcessResources{
+			Goroutines: runtime.NumGoroutine(),
+			OpenFDs:    openFDs(),
+			HeapBytes:  mem.HeapInuse,
+			SysBytes:   mem.Sys,
+		},
+		Tunnels: []TunnelMetrics{},
+	}
+
+	m.mu.Lock()
+	for id, t := range m.tunnels {
+		out.Process.TrackedGoroutines += t.goroutines.Load()
+		out.Tunnels = append(out.Tunnels, TunnelMetrics{
+			ID:              id,
+			TunnelResources: t.resources(),
+			StreamsOpened:   t.StreamsOpened.Load(),
+			StreamsStalled:  t.StreamsStalled.Load(),
+			BytesSent:       t.BytesSent.Load(),
+			BytesReceived:   t.BytesReceived.Load(),
+		})
+	}
+	out.Process.Warning = m.processWarning
+	m.mu.Unlock()
+
+	sort.Slice(out.Tunnels, func(i, j int) bool { return out.Tunnels[i].ID < out.Tunnels[j].ID })
+	return out
+}
+
+func (m *manager) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(m.metrics())
+}
+
+// watchResources checks every tunnel and the process for leaks until ctx is
+// done. A count has to stay over its limit for two checks in a row before it
+// is reported, and each leak is logged once, when it is first seen.
+func (m *manager) watchResources(ctx context.Context) {
+	ticker := time.NewTicker(resourceCheckInterval)
+	defer ticker.Stop()
+	suspect := make(map[*ActiveTunnel]bool)
+	processSuspect := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		tunnels := make([]*ActiveTunnel, 0, len(m.tunnels))
+		for _, t := range m.tunnels {
+			tunnels = append(tunnels, t)
+		}
+		m.mu.Unlock()
+
+		seen := make(map[*ActiveTunnel]bool, len(tunnels))
+		var streams int64
+		for _, t := range tunnels {
+			seen[t] = true
+			streams += t.ActiveStreams.Load()
+			leak := t.goroutineLeak()
+			switch {
+			case leak == "":
+				suspect[t] = false
+				t.leakWarning.Store("")
+			case suspect[t] && t.resourceWarning() == "":
+				t.leakWarning.Store(leak)
+				log.Printf("Possible leak in tunnel %s: %s", t.Req.ID, leak)
+				t.emit("resource-warning", "%s", leak)
+			default:
+				suspect[t] = true
+			}
+		}
+		for t := range suspect {
+			if !seen[t] {
+				delete(suspect, t)
+			}
+		}
+
+		leak := ""
+		if fds := openFDs(); fds >= 0 && int64(fds) > 2*streams+2*int64(len(tunnels))+fdSlack {
+			leak = fmt.Sprintf("%d open file descriptors for %d open connection(s)", fds, streams)
+		}
+		m.mu.Lock()
+		switch {
+		case leak == "":
+			processSuspect = false
+			m.processWarning = ""
+		case processSuspect && m.processWarning == "":
+			m.processWarning = leak
+			log.Printf("Possible descriptor leak: %s", leak)
+		default:
+			processSuspect = true
+		}
+		m.mu.Unlock()
+	}
+}
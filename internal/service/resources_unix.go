@@ -0,0 +1,16 @@
+//go:build !windows
+
+package service
+
+import "os"
+
+// openFDs counts the descriptors the process has open, or returns -1 when
+// /dev/fd cannot be read.
+func openFDs() int {
+	entries, err := os.ReadDir("/dev/fd")
+	if err != nil {
+		return -1
+	}
+	// Reading the directory opened one more.
+	return len(entries) - 1
+}
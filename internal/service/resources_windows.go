@@ -0,0 +1,8 @@
+//go:build windows
+
+package service
+
+// openFDs cannot count handles on Windows; /metrics reports -1.
+func openFDs() int {
+	return -1
+}
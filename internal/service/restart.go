@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+var errTunnelNotFound = errors.New("tunnel not found")
+
+// handleRestart rebuilds a tunnel from its current request: a new gateway
+// session, the same local port.
+func (m *manager) handleRestart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req, ok := m.tunnelRequest(body.ID)
+	if !ok {
+		http.Error(w, errTunnelNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	m.replaceAndRespond(w, r, req)
+}
+
+// handleUpdateTunnel applies the fields in the body to a tunnel's request and
+// restarts it with the result. Fields left out keep their value, so a body
+// of {"target_port": 6379} re-points the tunnel and {"connect_url": ...,
+// "connect_token": ...} rebuilds it on a new ticket.
+func (m *manager) handleUpdateTunnel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	req, ok := m.tunnelRequest(id)
+	if !ok {
+		http.Error(w, errTunnelNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	direction, ticketID := req.Direction, req.TicketID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.TicketID != ticketID {
+		if err := m.applyTicket(&req); err != nil {
+			http.Error(w, err.Error(), ticketErrorStatus(err))
+			return
+		}
+	}
+	if req.ID != id {
+		http.Error(w, "The tunnel id cannot be changed", http.StatusBadRequest)
+		return
+	}
+	if req.Direction != direction {
+		http.Error(w, "The tunnel direction cannot be changed; stop it and start a new one", http.StatusBadRequest)
+		return
+	}
+	if err := validateTunnelRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.replaceAndRespond(w, r, req)
+}
+
+func (m *manager) replaceAndRespond(w http.ResponseWriter, r *http.Request, req TunnelRequest) {
+	active, err := m.replaceTunnel(req)
+	switch {
+	case errors.Is(err, errServiceClosing):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case errors.Is(err, errTunnelNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	m.respondStarted(w, r, active)
+}
+
+func (m *manager) tunnelRequest(id string) (TunnelRequest, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tunnels[id]
+	if !ok {
+		return TunnelRequest{}, false
+	}
+	return t.Req, true
+}
+
+// replaceTunnel swaps the tunnel registered under req.ID for a new run of
+// req. The ID stays registered throughout, so no /start can claim it in
+// between. A forward tunnel that keeps its local port hands its socket to
+// the new run.
+func (m *manager) replaceTunnel(req TunnelRequest) (*ActiveTunnel, error) {
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		return nil, errServiceClosing
+	}
+	old, ok := m.tunnels[req.ID]
+	if !ok {
+		m.mu.Unlock()
+		return nil, errTunnelNotFound
+	}
+	samePort := req.LocalPort == old.Req.LocalPort
+	keepPort := req.Direction == directionForward && samePort && req.bindIP().Equal(old.Req.bindIP())
+	// A new bind address on the same port is bound once the old run has
+	// let go of the port, so only a new port is checked here.
+	if req.Direction == directionForward && !samePort {
+		if err := m.checkLocalPortLocked(req); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+	}
+	ctx, next := m.newActiveTunnel(req)
+	m.tunnels[req.ID] = next
+	old.keepListener.Store(keepPort)
+	m.mu.Unlock()
+	persistTunnel(req)
+
+	old.emit("restarting", "%s", describeRoute(req))
+	if old.Cancel != nil {
+		old.Cancel()
+	}
+	<-old.Done
+	// The old run may have ended on its own and closed the socket before it
+	// was told to keep it; the new run then binds the port again.
+	if l := old.listener; keepPort && l != nil && !l.released.Load() {
+		next.handoff = l
+	}
+	next.emit("starting", "%s | gateway=%s", describeRoute(req), req.ConnectURL)
+	next.spawn(func() { m.runTunnel(ctx, next) })
+	return next, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	directionForward = "forward"
+	directionReverse = "reverse"
+
+	reverseDialTimeout = 5 * time.Second
+)
+
+// serveReverseGateway is the reverse counterpart of serveTunnelGateway. The
+// gateway opens a stream for every connection made to the target port inside
+// the container, and each stream is proxied to localhost:LocalPort. Dropped
+// sessions are re-dialed by superviseGateway exactly as for forward tunnels.
+func serveReverseGateway(
+	ctx context.Context,
+	active *ActiveTunnel,
+	target TunnelTarget,
+	onReady func(),
+	onStatus func(status, lastError string),
+) error {
+	session, closeSession, err := dialGateway(ctx, active.Req)
+	if err != nil {
+		return err
+	}
+	holder := newSessionHolder()
+	holder.set(session)
+	if onReady != nil {
+		onReady()
+	}
+	close(active.Ready)
+
+	superviseErrCh := make(chan error, 1)
+	active.spawn(func() {
+		superviseErrCh <- superviseGateway(ctx, active, holder, session, closeSession, onStatus)
+	})
+
+	loopCtx, stopLoop := context.WithCancel(ctx)
+	defer stopLoop()
+	var wg sync.WaitGroup
+	acceptDone := make(chan struct{})
+	active.spawn(func() {
+		defer close(acceptDone)
+		for {
+			current, err := holder.wait(loopCtx, reconnectWaitTimeout)
+			if err != nil {
+				if loopCtx.Err() != nil {
+					return
+				}
+				continue
+			}
+			stream, err := current.AcceptStream()
+			if err != nil {
+				// The session dropped; wait for the supervisor to replace it.
+				continue
+			}
+			release, reason := active.admit()
+			if reason != "" {
+				_ = writeStreamResponse(stream, tunnelStreamConnectResponse{Type: "error", Code: "LIMIT_REACHED", Message: reason})
+				_ = stream.Close()
+				continue
+			}
+			wg.Add(1)
+			active.spawn(func() {
+				defer wg.Done()
+				defer release()
+				if err := proxyReverseStream(loopCtx, active, target, stream); err != nil {
+					active.emit("stream-error", "%v", err)
+				}
+			})
+		}
+	})
+
+	var result error
+	select {
+	case <-ctx.Done():
+	case result = <-superviseErrCh:
+	}
+	stopLoop()
+	<-acceptDone
+	wg.Wait()
+	if result != nil {
+		return result
+	}
+	return ctx.Err()
+}
+
+func proxyReverseStream(ctx context.Context, active *ActiveTunnel, target TunnelTarget, stream *yamux.Stream) error {
+	defer stream.Close()
+	streamNumber := active.StreamsOpened.Add(1)
+	active.ActiveStreams.Add(1)
+	active.emit("stream-open", "#%d %s -> localhost:%d", streamNumber, describeTarget(active.Req), active.Req.LocalPort)
+	// The local end is dialed below; until then nothing can stall.
+	local := &stallWriter{}
+	defer func() {
+		active.ActiveStreams.Add(-1)
+		if local.stalled.Load() {
+			active.StreamsStalled.Add(1)
+		}
+		active.emit(
+			"stream-close",
+			"#%d active=%d sent=%dB recv=%dB%s",
+			streamNumber,
+			active.ActiveStreams.Load(),
+			active.BytesSent.Load(),
+			active.BytesReceived.Load(),
+			local.closeReason(),
+		)
+	}()
+
+	reader := bufio.NewReader(stream)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read reverse stream request: %w", err)
+	}
+	var request tunnelStreamConnectRequest
+	if err := json.Unmarshal(bytesTrimSpace(line), &request); err != nil || request.Type != "connect" {
+		_ = writeStreamResponse(stream, tunnelStreamConnectResponse{Type: "error", Code: "BAD_REQUEST", Message: "expected a connect request"})
+		return errors.New("invalid reverse stream request")
+	}
+	if request.TargetID != "" && request.TargetID != target.TargetID {
+		_ = writeStreamResponse(stream, tunnelStreamConnectResponse{Type: "error", Code: "TARGET_NOT_FOUND", Message: "unknown target " + request.TargetID})
+		return fmt.Errorf("reverse stream for unknown target %s", request.TargetID)
+	}
+
+	dialer := net.Dialer{Timeout: reverseDialTimeout}
+	localConn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", active.Req.LocalPort))
+	if err != nil {
+		_ = writeStreamResponse(stream, tunnelStreamConnectResponse{
+			Type:    "error",
+			Code:    "LOCAL_UNAVAILABLE",
+			Message: fmt.Sprintf("nothing is listening on localhost:%d", active.Req.LocalPort),
+		})
+		return err
+	}
+	defer localConn.Close()
+	local.conn = localConn
+	if err := writeStreamResponse(stream, tunnelStreamConnectResponse{Type: "connected"}); err != nil {
+		return err
+	}
+
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	active.spawn(func() {
+		defer wg.Done()
+		n, _ := copyAdaptive(stream, localConn, &active.bufferBytes)
+		if n > 0 {
+			active.BytesSent.Add(uint64(n))
+		}
+		cancel()
+	})
+	active.spawn(func() {
+		defer wg.Done()
+		n, _ := copyAdaptive(local, reader, &active.bufferBytes)
+		if n > 0 {
+			active.BytesReceived.Add(uint64(n))
+		}
+		cancel()
+	})
+	<-copyCtx.Done()
+	_ = localConn.Close()
+	_ = stream.Close()
+	wg.Wait()
+	return nil
+}
+
+func writeStreamResponse(stream *yamux.Stream, response tunnelStreamConnectResponse) error {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = stream.Write(append(payload, '\n'))
+	return err
+}
@@ -6,30 +6,78 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/hashicorp/yamux"
 	"golang.org/x/net/websocket"
 )
 
-const tunnelDialTimeout = 10 * time.Second
+// startTimeout bounds how long /start waits for the gateway handshake and
+// the local bind before it gives up on the tunnel.
+var startTimeout = tunnelDialTimeout + 5*time.Second
+
+// errLocalPortInUse marks a local port another program already holds.
+var errLocalPortInUse = errors.New("already in use by another program")
+
+const (
+	tunnelDialTimeout    = 10 * time.Second
+	reconnectMaxDelay    = 30 * time.Second
+	reconnectWaitTimeout = 15 * time.Second
+)
 
 type TunnelRequest struct {
-	ID              string         `json:"id"`
+	ID string `json:"id"`
+	// TicketID names a tunnel ticket stored by the CLI or loaded through
+	// POST /tickets. The connect URL and token then come from the ticket.
+	TicketID        string         `json:"ticket_id,omitempty"`
 	ConnectURL      string         `json:"connect_url"`
 	ConnectToken    string         `json:"connect_token"`
 	ProtocolVersion int            `json:"protocol_version"`
 	LocalPort       int            `json:"local_port"`
 	TargetPort      int            `json:"target_port"`
 	Targets         []TunnelTarget `json:"targets"`
+	// Direction is "forward" (the default) or "reverse". A reverse tunnel
+	// makes localhost:LocalPort reachable on TargetPort inside the container.
+	Direction string `json:"direction,omitempty"`
+	// IdleTimeout and TTL are optional Go durations ("15m", "2h"). The
+	// tunnel expires after IdleTimeout without open connections, or TTL after
+	// it started, whichever comes first.
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+	TTL         string `json:"ttl,omitempty"`
+	// Persist keeps the tunnel in ~/.hubfly/service-state.json so the
+	// service restores it when it next starts, for example after a reboot.
+	Persist bool `json:"persist,omitempty"`
+	// BindAddress is the IP a forward tunnel listens on, 127.0.0.1 when
+	// empty. An address other machines can reach, such as 0.0.0.0 or a LAN
+	// IP, is only accepted with AllowLAN set.
+	BindAddress string `json:"bind_address,omitempty"`
+	AllowLAN    bool   `json:"allow_lan,omitempty"`
+	// MaxConnections caps the tunnel's concurrent connections; 0 means no
+	// cap. Connections over it are refused.
+	MaxConnections int `json:"max_connections,omitempty"`
+}
+
+// bindIP is the address a forward tunnel listens on.
+func (req TunnelRequest) bindIP() net.IP {
+	if ip := net.ParseIP(req.BindAddress); ip != nil {
+		return ip
+	}
+	return net.IPv4(127, 0, 0, 1)
+}
+
+// localAddress is the host:port a forward tunnel listens on.
+func (req TunnelRequest) localAddress() string {
+	return net.JoinHostPort(req.bindIP().String(), strconv.Itoa(req.LocalPort))
 }
 
 type TunnelTarget struct {
@@ -43,15 +91,24 @@ type TunnelTarget struct {
 type TunnelStatus struct {
 	ID            string `json:"id"`
 	LocalPort     int    `json:"local_port"`
+	BindAddress   string `json:"bind_address,omitempty"`
 	Target        string `json:"target"`
+	Direction     string `json:"direction"`
 	Status        string `json:"status"`
 	Gateway       string `json:"gateway"`
 	ActiveStreams int64  `json:"active_streams"`
 	StreamsOpened int64  `json:"streams_opened"`
 	BytesSent     uint64 `json:"bytes_sent"`
 	BytesReceived uint64 `json:"bytes_received"`
-	StartedAt     string `json:"started_at,omitempty"`
-	Error         string `json:"error,omitempty"`
+	Reconnects    int64  `json:"reconnects"`
+	// StreamsStalled counts connections closed because the local client
+	// stopped reading, StreamsRejected those refused by a limit.
+	StreamsStalled  int64  `json:"streams_stalled"`
+	StreamsRejected int64  `json:"streams_rejected"`
+	StartedAt       string `json:"started_at,omitempty"`
+	Error           string `json:"error,omitempty"`
+	// Resources is only filled for /status?verbose=1.
+	Resources *TunnelResources `json:"resources,omitempty"`
 }
 
 type ActiveTunnel struct {
@@ -66,11 +123,56 @@ type ActiveTunnel struct {
 	StreamsOpened atomic.Int64
 	BytesSent     atomic.Uint64
 	BytesReceived atomic.Uint64
+	Reconnects    atomic.Int64
+	// StreamsStalled counts connections closed because the local client
+	// stopped reading for stallTimeout. StreamsRejected counts connections
+	// refused by MaxConnections or the service's connection rate.
+	StreamsStalled  atomic.Int64
+	StreamsRejected atomic.Int64
+
+	events *broker
+	// failure is the error that ended the tunnel, kept so /start can tell
+	// auth, port and network failures apart.
+	failure error
+	// listener is the local socket of a forward tunnel. handoff is the one
+	// a restart passes on from the run it replaces, and keepListener tells
+	// that run to leave it open.
+	listener     *reusableListener
+	handoff      *reusableListener
+	keepListener atomic.Bool
+	// goroutines counts the goroutines started through spawn, bufferBytes
+	// the copy buffers held by its connections. leakWarning holds the last
+	// string watchResources reported for the tunnel.
+	goroutines  atomic.Int64
+	bufferBytes atomic.Int64
+	leakWarning atomic.Value
+	// admitted counts connections let through by admit, and limiter is the
+	// service's shared rate limit.
+	admitted atomic.Int64
+	limiter  *connectionLimiter
+	// retryConnect is set on restored tunnels; see launchTunnel.
+	retryConnect bool
+	// drain is closed when the service shuts down, so the tunnel stops
+	// accepting connections while in-flight ones finish.
+	drain     chan struct{}
+	drainOnce sync.Once
 }
 
 type manager struct {
-	mu      sync.Mutex
-	tunnels map[string]*ActiveTunnel
+	mu       sync.Mutex
+	tunnels  map[string]*ActiveTunnel
+	events   *broker
+	activity *idleTracker
+	// tickets were loaded through POST /tickets and live only in memory.
+	tickets map[string]tunnelTicket
+	// processWarning is the leak watchResources last found for the whole
+	// process.
+	processWarning string
+	// limiter caps new connections per second across all tunnels.
+	limiter *connectionLimiter
+	// closing is set once the service has decided to shut down, either
+	// after being idle or on a signal.
+	closing bool
 }
 
 type tunnelClientMessage struct {
@@ -99,17 +201,128 @@ type tunnelStreamConnectResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-func Run(port int) error {
-	m := &manager{tunnels: make(map[string]*ActiveTunnel)}
+// Run serves the control API on port. A positive idleTimeout makes the
+// service exit once it has had no tunnels and no API calls for that long.
+// connectionRate caps new connections per second across all tunnels; 0
+// disables the cap.
+func Run(port int, idleTimeout time.Duration, connectionRate int) error {
+	if managed, err := runAsManagedService(port, idleTimeout, connectionRate); managed {
+		return err
+	}
+	return RunContext(context.Background(), port, idleTimeout, connectionRate)
+}
+
+// RunContext is Run for a service manager that stops the service by
+// cancelling ctx rather than with a signal. Cancelling it shuts the service
+// down the same way SIGTERM does.
+func RunContext(parent context.Context, port int, idleTimeout time.Duration, connectionRate int) error {
+	token, err := newServiceToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate service token: %w", err)
+	}
+
+	act, err := activationFromEnv(port)
+	if err != nil {
+		return err
+	}
+	socketActivation = act
+	var listener net.Listener
+	if act != nil && act.control != nil {
+		listener = act.control
+		port = listener.Addr().(*net.TCPAddr).Port
+	} else {
+		listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writeInfo(newInfo(port, token)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", InfoPath(), err)
+	}
+	defer removeInfo(token)
+
+	m := &manager{
+		tunnels:  make(map[string]*ActiveTunnel),
+		events:   newBroker(),
+		activity: newIdleTracker(),
+		limiter:  newConnectionLimiter(connectionRate),
+	}
+	track := m.activity.track
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", enableCORS(handleHealth))
-	mux.HandleFunc("/start", enableCORS(m.handleStart))
-	mux.HandleFunc("/stop", enableCORS(m.handleStop))
-	mux.HandleFunc("/status", enableCORS(m.handleStatus))
+	mux.HandleFunc("/start", enableCORS(requireToken(token, track(m.handleStart))))
+	mux.HandleFunc("/stop", enableCORS(requireToken(token, track(m.handleStop))))
+	mux.HandleFunc("/status", enableCORS(requireToken(token, track(m.handleStatus))))
+	mux.HandleFunc("/metrics", enableCORS(requireToken(token, track(m.handleMetrics))))
+	mux.HandleFunc("/logs", enableCORS(requireTokenOrQuery(token, track(m.handleLogs))))
+	mux.HandleFunc("/restart", enableCORS(requireToken(token, track(m.handleRestart))))
+	mux.HandleFunc("/tunnels/{id}", enableCORS(requireToken(token, track(m.handleUpdateTunnel))))
+	mux.HandleFunc("/tunnels/{id}/logs", enableCORS(requireTokenOrQuery(token, track(m.handleTunnelLogs))))
+	mux.HandleFunc("/tickets", enableCORS(requireToken(token, track(m.handleTickets))))
+	mux.HandleFunc("/tickets/{id}", enableCORS(requireToken(token, track(m.handleDeleteTicket))))
+	mux.HandleFunc("/ws", enableCORS(requireTokenOrQuery(token, track(m.handleControl))))
+	mux.HandleFunc("/log-level", enableCORS(requireToken(token, track(handleLogLevel))))
+
+	log.Printf("Tunnel Service running on %s", listener.Addr())
+	log.Printf("Control API token written to %s", InfoPath())
+	if act != nil {
+		log.Printf("Socket-activated with %d tunnel socket(s)", len(act.tunnels))
+	}
+	if connectionRate > 0 {
+		log.Printf("Accepting at most %d new connections per second", connectionRate)
+	}
+	m.restorePersistedTunnels()
+
+	signalsDone := make(chan struct{})
+	defer close(signalsDone)
+	go watchLogLevelSignal(signalsDone)
+
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Requests run under baseCtx so streaming endpoints (/logs, /ws) end
+	// when the service shuts down instead of holding Shutdown open.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	server := &http.Server{
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+	if idleTimeout > 0 {
+		log.Printf("Exiting after %s without tunnels or API calls", idleTimeout)
+		go m.watchIdle(idleTimeout, func() {
+			log.Printf("Idle for %s, shutting down", idleTimeout)
+			stop()
+		})
+	}
+	go m.watchResources(baseCtx)
 
-	addr := fmt.Sprintf(":%d", port)
-	log.Printf("Tunnel Service running on %s", addr)
-	return http.ListenAndServe(addr, mux)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+	select {
+	case err := <-serveErr:
+		_ = m.shutdown(0)
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, draining tunnels for up to %s", tunnelDrainTimeout)
+	drainErr := m.shutdown(tunnelDrainTimeout)
+	cancelRequests()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancelShutdown()
+	var shutdownErr error
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		shutdownErr = fmt.Errorf("failed to stop control API: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		shutdownErr = errors.Join(shutdownErr, err)
+	}
+	if err := errors.Join(drainErr, shutdownErr); err != nil {
+		return fmt.Errorf("tunnel service did not shut down cleanly: %w", err)
+	}
+	log.Printf("Tunnel Service stopped")
+	return nil
 }
 
 func enableCORS(next http.HandlerFunc) http.HandlerFunc {
@@ -142,67 +355,185 @@ func (m *manager) handleStart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if strings.TrimSpace(req.ConnectURL) == "" || strings.TrimSpace(req.ConnectToken) == "" || req.LocalPort <= 0 {
-		http.Error(w, "Missing required fields (connect_url, connect_token, local_port)", http.StatusBadRequest)
+	if err := m.applyTicket(&req); err != nil {
+		http.Error(w, err.Error(), ticketErrorStatus(err))
 		return
 	}
-	if len(req.Targets) == 0 {
-		http.Error(w, "Missing required tunnel targets", http.StatusBadRequest)
+	if err := validateTunnelRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	if req.ID == "" {
 		req.ID = fmt.Sprintf("tunnel-%d", req.LocalPort)
 	}
 
-	m.mu.Lock()
-	if _, exists := m.tunnels[req.ID]; exists {
-		m.mu.Unlock()
-		http.Error(w, fmt.Sprintf("Tunnel with ID %s already exists", req.ID), http.StatusConflict)
+	active, err := m.startTunnel(req)
+	if errors.Is(err, errServiceClosing) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	active := &ActiveTunnel{
-		Req:       req,
-		Cancel:    cancel,
-		Done:      make(chan struct{}),
-		Ready:     make(chan struct{}),
-		Status:    "starting",
-		StartedAt: time.Now().UTC(),
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
 	}
-	m.tunnels[req.ID] = active
-	m.mu.Unlock()
-	log.Printf("[tunnel] starting %s | localhost:%d -> %s | gateway=%s", req.ID, req.LocalPort, describeTarget(req), req.ConnectURL)
+	m.respondStarted(w, r, active)
+}
 
-	go m.runTunnel(ctx, active)
+// validateTunnelRequest checks the fields /start and PUT /tunnels/{id}
+// require and fills in the default direction.
+func validateTunnelRequest(req *TunnelRequest) error {
+	if strings.TrimSpace(req.ConnectURL) == "" || strings.TrimSpace(req.ConnectToken) == "" || req.LocalPort <= 0 {
+		return errors.New("Missing required fields (connect_url, connect_token, local_port)")
+	}
+	if len(req.Targets) == 0 {
+		return errors.New("Missing required tunnel targets")
+	}
+	switch req.Direction {
+	case "":
+		req.Direction = directionForward
+	case directionForward, directionReverse:
+	default:
+		return fmt.Errorf("Unknown direction %q (use forward or reverse)", req.Direction)
+	}
+	if err := validateBindAddress(*req); err != nil {
+		return err
+	}
+	if req.MaxConnections < 0 {
+		return fmt.Errorf("Invalid max_connections %d (use 0 for no limit)", req.MaxConnections)
+	}
+	_, _, err := req.limits()
+	return err
+}
 
+func validateBindAddress(req TunnelRequest) error {
+	if req.BindAddress == "" {
+		return nil
+	}
+	if req.Direction == directionReverse {
+		return errors.New("bind_address only applies to forward tunnels")
+	}
+	ip := net.ParseIP(req.BindAddress)
+	if ip == nil {
+		return fmt.Errorf("Invalid bind_address %q (use an IP address such as 127.0.0.1)", req.BindAddress)
+	}
+	if !ip.IsLoopback() && !req.AllowLAN {
+		return fmt.Errorf("bind_address %s makes the tunnel reachable from other machines; set allow_lan to confirm", req.BindAddress)
+	}
+	return nil
+}
+
+// respondStarted answers once the gateway accepted active and its local
+// port is bound, or with the reason it could not be.
+func (m *manager) respondStarted(w http.ResponseWriter, r *http.Request, active *ActiveTunnel) {
+	ctx, cancel := context.WithTimeout(r.Context(), startTimeout)
+	defer cancel()
 	select {
 	case <-active.Ready:
-	case <-time.After(3 * time.Second):
 	case <-active.Done:
+	case <-ctx.Done():
+		_ = m.stopTunnel(active.Req.ID)
+		http.Error(w, fmt.Sprintf("Tunnel %s did not connect within %s", active.Req.ID, startTimeout), http.StatusGatewayTimeout)
+		return
 	}
 
 	m.mu.Lock()
-	current, ok := m.tunnels[req.ID]
 	status := active.Status
 	lastError := active.LastError
-	if ok {
-		status = current.Status
-		lastError = current.LastError
-	}
+	failure := active.failure
 	m.mu.Unlock()
 
 	if status == "error" {
-		http.Error(w, lastError, http.StatusInternalServerError)
+		http.Error(w, lastError, startFailureStatus(failure))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{
 		"status": status,
-		"id":     req.ID,
+		"id":     active.Req.ID,
 	})
 }
 
+// startFailureStatus maps the error that stopped a tunnel during /start to
+// a status code: 403 when the gateway refused the connect token, 409 when
+// the local port is taken and 502 when the gateway could not be reached.
+func startFailureStatus(err error) int {
+	var rejected *gatewayRejectedError
+	switch {
+	case errors.As(err, &rejected):
+		return http.StatusForbidden
+	case errors.Is(err, errLocalPortInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// startTunnel registers req and runs it in the background. It fails when the
+// id or the local port is already in use.
+func (m *manager) startTunnel(req TunnelRequest) (*ActiveTunnel, error) {
+	return m.launchTunnel(req, false)
+}
+
+// launchTunnel is startTunnel. With retryConnect set, a first gateway dial
+// that fails is retried with backoff instead of ending the tunnel.
+func (m *manager) launchTunnel(req TunnelRequest, retryConnect bool) (*ActiveTunnel, error) {
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		return nil, errServiceClosing
+	}
+	if existing, exists := m.tunnels[req.ID]; exists && existing.Status != "expired" {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("Tunnel with ID %s already exists", req.ID)
+	}
+	if req.Direction == directionForward {
+		if err := m.checkLocalPortLocked(req); err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+	}
+	ctx, active := m.newActiveTunnel(req)
+	active.retryConnect = retryConnect
+	m.tunnels[req.ID] = active
+	m.mu.Unlock()
+	persistTunnel(req)
+	active.emit("starting", "%s | gateway=%s", describeRoute(req), req.ConnectURL)
+
+	active.spawn(func() { m.runTunnel(ctx, active) })
+	return active, nil
+}
+
+func (m *manager) newActiveTunnel(req TunnelRequest) (context.Context, *ActiveTunnel) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return ctx, &ActiveTunnel{
+		Req:       req,
+		Cancel:    cancel,
+		Done:      make(chan struct{}),
+		Ready:     make(chan struct{}),
+		drain:     make(chan struct{}),
+		Status:    "starting",
+		StartedAt: time.Now().UTC(),
+		events:    m.events,
+		limiter:   m.limiter,
+	}
+}
+
+// restorePersistedTunnels restarts the tunnels a previous run persisted.
+// They keep retrying until the gateway is reachable, since the network may
+// not be up yet after a reboot. Tunnels whose connect token has expired fail
+// on the gateway handshake and are dropped from the list.
+func (m *manager) restorePersistedTunnels() {
+	for _, req := range loadPersistedTunnels() {
+		if _, err := m.launchTunnel(req, true); err != nil {
+			log.Printf("Not restoring tunnel %s: %v", req.ID, err)
+			forgetTunnel(req.ID)
+			continue
+		}
+		log.Printf("Restored tunnel %s (%s)", req.ID, describeRoute(req))
+	}
+}
+
 func (m *manager) handleStop(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -226,60 +557,148 @@ func (m *manager) handleStop(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("Tunnel stopped"))
 }
 
-func (m *manager) handleStatus(w http.ResponseWriter, _ *http.Request) {
+func (m *manager) handleStatus(w http.ResponseWriter, r *http.Request) {
+	verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose"))
+	statuses := m.statuses()
+	if verbose {
+		m.addResources(statuses)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(statuses)
+}
+
+func (m *manager) addResources(statuses []TunnelStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range statuses {
+		if t, ok := m.tunnels[statuses[i].ID]; ok {
+			resources := t.resources()
+			statuses[i].Resources = &resources
+		}
+	}
+}
+
+func (m *manager) statuses() []TunnelStatus {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	statuses := make([]TunnelStatus, 0, len(m.tunnels))
 	for id, t := range m.tunnels {
 		statuses = append(statuses, TunnelStatus{
-			ID:            id,
-			LocalPort:     t.Req.LocalPort,
-			Target:        describeTarget(t.Req),
-			Gateway:       t.Req.ConnectURL,
-			Status:        t.Status,
-			ActiveStreams: t.ActiveStreams.Load(),
-			StreamsOpened: t.StreamsOpened.Load(),
-			BytesSent:     t.BytesSent.Load(),
-			BytesReceived: t.BytesReceived.Load(),
-			StartedAt:     t.StartedAt.Format(time.RFC3339),
-			Error:         t.LastError,
+			ID:              id,
+			LocalPort:       t.Req.LocalPort,
+			BindAddress:     t.Req.BindAddress,
+			Target:          describeTarget(t.Req),
+			Direction:       t.Req.Direction,
+			Gateway:         t.Req.ConnectURL,
+			Status:          t.Status,
+			ActiveStreams:   t.ActiveStreams.Load(),
+			StreamsOpened:   t.StreamsOpened.Load(),
+			BytesSent:       t.BytesSent.Load(),
+			BytesReceived:   t.BytesReceived.Load(),
+			Reconnects:      t.Reconnects.Load(),
+			StreamsStalled:  t.StreamsStalled.Load(),
+			StreamsRejected: t.StreamsRejected.Load(),
+			StartedAt:       t.StartedAt.Format(time.RFC3339),
+			Error:           t.LastError,
 		})
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(statuses)
+	return statuses
 }
 
 func (m *manager) runTunnel(ctx context.Context, active *ActiveTunnel) {
 	defer close(active.Done)
 	target, err := primaryTarget(active.Req, active.Req.TargetPort)
 	if err != nil {
-		m.failTunnel(active.Req.ID, err)
+		m.failTunnel(active, err)
 		return
 	}
-	err = serveTunnelGateway(
-		ctx,
-		active,
-		target,
-		func() {
-			m.setTunnelStatus(active.Req.ID, "active", "")
-			log.Printf("[tunnel] active %s | localhost:%d -> %s", active.Req.ID, active.Req.LocalPort, describeTarget(active.Req))
-		},
-	)
+	active.spawn(active.publishStats)
+	active.spawn(func() { m.watchExpiry(ctx, active) })
+	serve := serveTunnelGateway
+	if active.Req.Direction == directionReverse {
+		serve = serveReverseGateway
+	}
+	for attempt := 1; ; attempt++ {
+		err = serve(
+			ctx,
+			active,
+			target,
+			func() {
+				m.setTunnelStatus(active, "active", "")
+				active.emit("active", "%s", describeRoute(active.Req))
+			},
+			func(status, lastError string) {
+				m.setTunnelStatus(active, status, lastError)
+			},
+		)
+		if !m.retryFirstConnect(ctx, active, err, attempt) {
+			break
+		}
+	}
 	if err != nil && !errors.Is(err, context.Canceled) {
-		m.failTunnel(active.Req.ID, err)
+		m.failTunnel(active, err)
 		return
 	}
-	log.Printf(
-		"[tunnel] closed %s | streams=%d active=%d sent=%dB recv=%dB",
-		active.Req.ID,
+	active.emit(
+		"closed",
+		"streams=%d active=%d sent=%dB recv=%dB",
 		active.StreamsOpened.Load(),
 		active.ActiveStreams.Load(),
 		active.BytesSent.Load(),
 		active.BytesReceived.Load(),
 	)
-	m.finishTunnel(active.Req.ID, "closed", "")
+	m.finishTunnel(active, "closed", "")
+}
+
+// retryFirstConnect waits out the backoff before another attempt at a
+// restored tunnel's first gateway dial. It reports false when the tunnel
+// should not be retried: it connected before, was cancelled, or failed for
+// a reason a retry cannot fix.
+func (m *manager) retryFirstConnect(ctx context.Context, active *ActiveTunnel, err error, attempt int) bool {
+	var rejected *gatewayRejectedError
+	if err == nil || !active.retryConnect || ctx.Err() != nil ||
+		errors.As(err, &rejected) || errors.Is(err, errLocalPortInUse) {
+		return false
+	}
+	select {
+	case <-active.Ready:
+		return false
+	default:
+	}
+	delay := reconnectDelay(attempt)
+	m.setTunnelStatus(active, "reconnecting", err.Error())
+	active.emit("reconnect-failed", "%v; retrying in %s", err, delay)
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// checkLocalPortLocked reports a forward tunnel's local port that another
+// tunnel of this service or another program already holds, so /start fails
+// with a clear conflict instead of a bind error. m.mu must be held.
+func (m *manager) checkLocalPortLocked(req TunnelRequest) error {
+	port := req.LocalPort
+	for id, t := range m.tunnels {
+		if t.Req.Direction == directionForward && t.Req.LocalPort == port && t.Status != "expired" {
+			return fmt.Errorf("local port %d is already used by tunnel %s", port, id)
+		}
+	}
+	if _, activated := socketActivation.listener(port); activated {
+		return nil
+	}
+	l, err := net.Listen("tcp", req.localAddress())
+	if err != nil {
+		if IsAddrInUse(err) {
+			return fmt.Errorf("local port %d is %w", port, errLocalPortInUse)
+		}
+		return nil
+	}
+	_ = l.Close()
+	return nil
 }
 
 func (m *manager) stopTunnel(id string) error {
@@ -287,19 +706,20 @@ func (m *manager) stopTunnel(id string) error {
 	t, exists := m.tunnels[id]
 	if !exists {
 		m.mu.Unlock()
-		return fmt.Errorf("tunnel not found")
+		return errTunnelNotFound
 	}
 	delete(m.tunnels, id)
 	t.Status = "closed"
 	m.mu.Unlock()
+	forgetTunnel(id)
 
 	if t.Cancel != nil {
 		t.Cancel()
 	}
 	<-t.Done
-	log.Printf(
-		"[tunnel] stopped %s | streams=%d active=%d sent=%dB recv=%dB",
-		id,
+	t.emit(
+		"stopped",
+		"streams=%d active=%d sent=%dB recv=%dB",
 		t.StreamsOpened.Load(),
 		t.ActiveStreams.Load(),
 		t.BytesSent.Load(),
@@ -308,33 +728,41 @@ func (m *manager) stopTunnel(id string) error {
 	return nil
 }
 
-func (m *manager) failTunnel(id string, err error) {
-	log.Printf("[tunnel] error %s | %v", id, err)
-	m.finishTunnel(id, "error", err.Error())
+func (m *manager) failTunnel(active *ActiveTunnel, err error) {
+	m.mu.Lock()
+	active.failure = err
+	m.mu.Unlock()
+	active.emit("error", "%v", err)
+	m.finishTunnel(active, "error", err.Error())
 }
 
-func (m *manager) setTunnelStatus(id, status, lastError string) {
+// setTunnelStatus and finishTunnel only touch t while it is still the
+// tunnel registered under its ID, so a run being replaced by a restart
+// cannot overwrite or remove its successor.
+func (m *manager) setTunnelStatus(t *ActiveTunnel, status, lastError string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	t, ok := m.tunnels[id]
-	if !ok {
+	if m.tunnels[t.Req.ID] != t || t.Status == "expired" {
 		return
 	}
 	t.Status = status
 	t.LastError = lastError
 }
 
-func (m *manager) finishTunnel(id, status, lastError string) {
+func (m *manager) finishTunnel(t *ActiveTunnel, status, lastError string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	t, ok := m.tunnels[id]
-	if !ok {
+	if m.tunnels[t.Req.ID] != t || t.Status == "expired" {
+		// expireTunnel removes expired tunnels after expiredRetention.
 		return
 	}
 	t.Status = status
 	t.LastError = lastError
 	if status != "active" {
-		delete(m.tunnels, id)
+		delete(m.tunnels, t.Req.ID)
+		if !m.closing {
+			forgetTunnel(t.Req.ID)
+		}
 	}
 }
 
@@ -343,20 +771,124 @@ func serveTunnelGateway(
 	active *ActiveTunnel,
 	target TunnelTarget,
 	onReady func(),
+	onStatus func(status, lastError string),
 ) error {
 	req := active.Req
+	session, closeSession, err := dialGateway(ctx, req)
+	if err != nil {
+		if active.handoff != nil {
+			active.handoff.release()
+		}
+		return err
+	}
+	holder := newSessionHolder()
+	holder.set(session)
+
+	// A restarted tunnel takes over its predecessor's socket, so the port is
+	// never free for another program to grab.
+	listener := active.handoff
+	if listener != nil {
+		listener.reopen()
+	} else if listener, err = listenLocal(req.bindIP(), req.LocalPort); err != nil {
+		closeSession()
+		return err
+	}
+	active.listener = listener
+	closeListener := func() {
+		if active.keepListener.Load() {
+			_ = listener.Close()
+		} else {
+			listener.release()
+		}
+	}
+	defer closeListener()
+	if onReady != nil {
+		onReady()
+	}
+	close(active.Ready)
+
+	superviseErrCh := make(chan error, 1)
+	active.spawn(func() {
+		superviseErrCh <- superviseGateway(ctx, active, holder, session, closeSession, onStatus)
+	})
+	active.spawn(func() {
+		select {
+		case <-ctx.Done():
+		case <-active.drain:
+		}
+		closeListener()
+	})
+
+	var wg sync.WaitGroup
+	acceptErrCh := make(chan error, 1)
+	active.spawn(func() {
+		for {
+			clientConn, err := listener.Accept()
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+					acceptErrCh <- nil
+					return
+				}
+				acceptErrCh <- err
+				return
+			}
+			release, reason := active.admit()
+			if reason != "" {
+				_ = clientConn.Close()
+				continue
+			}
+			wg.Add(1)
+			active.spawn(func() {
+				defer wg.Done()
+				defer release()
+				if err := proxyTunnelConnection(ctx, active, holder, target, clientConn); err != nil {
+					active.emit("stream-error", "%v", err)
+				}
+			})
+		}
+	})
+
+	var result error
+	select {
+	case <-ctx.Done():
+	case err := <-acceptErrCh:
+		result = err
+	case err := <-superviseErrCh:
+		result = err
+	}
+	closeListener()
+
+	wg.Wait()
+	if result != nil {
+		return result
+	}
+	return ctx.Err()
+}
+
+// gatewayRejectedError is returned when the gateway explicitly refuses the
+// session (expired or revoked connect token). Reconnecting cannot fix it.
+type gatewayRejectedError struct {
+	message string
+}
+
+func (e *gatewayRejectedError) Error() string {
+	return "tunnel session failed: " + e.message
+}
+
+// dialGateway opens the websocket, authenticates the tunnel and starts a
+// yamux client on top of it. The returned func closes both.
+func dialGateway(ctx context.Context, req TunnelRequest) (*yamux.Session, func(), error) {
 	wsConfig, err := websocket.NewConfig(req.ConnectURL, apiHost())
 	if err != nil {
-		return fmt.Errorf("invalid tunnel connect url: %w", err)
+		return nil, nil, fmt.Errorf("invalid tunnel connect url: %w", err)
 	}
 
 	dialCtx, cancelDial := context.WithTimeout(ctx, tunnelDialTimeout)
 	defer cancelDial()
 	conn, err := wsConfig.DialContext(dialCtx)
 	if err != nil {
-		return fmt.Errorf("failed to connect to tunnel gateway: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect to tunnel gateway: %w", err)
 	}
-	defer conn.Close()
 
 	if err := sendTunnelMessage(conn, tunnelClientMessage{
 		Type:            "authenticate",
@@ -364,13 +896,15 @@ func serveTunnelGateway(
 		TunnelID:        req.ID,
 		ConnectToken:    req.ConnectToken,
 	}); err != nil {
-		return fmt.Errorf("failed to authenticate tunnel session: %w", err)
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to authenticate tunnel session: %w", err)
 	}
 
-	for {
+	for authenticated := false; !authenticated; {
 		var raw []byte
 		if err := websocket.Message.Receive(conn, &raw); err != nil {
-			return fmt.Errorf("tunnel handshake failed: %w", err)
+			conn.Close()
+			return nil, nil, fmt.Errorf("tunnel handshake failed: %w", err)
 		}
 		var msg tunnelServerMessage
 		if err := json.Unmarshal(raw, &msg); err != nil {
@@ -379,104 +913,182 @@ func serveTunnelGateway(
 		switch msg.Type {
 		case "hello":
 		case "authenticated":
-			goto authenticated
+			authenticated = true
 		case "error":
-			return fmt.Errorf("tunnel session failed: %s", msg.Message)
+			conn.Close()
+			return nil, nil, &gatewayRejectedError{message: msg.Message}
 		}
 	}
 
-authenticated:
-	session, err := yamux.Client(conn, nil)
-	if err != nil {
-		return fmt.Errorf("failed to initialize tunnel session: %w", err)
-	}
-	defer session.Close()
-
-	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", req.LocalPort))
+	session, err := yamux.Client(conn, tunnelYamuxConfig())
 	if err != nil {
-		return fmt.Errorf("failed to listen on localhost:%d: %w", req.LocalPort, err)
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to initialize tunnel session: %w", err)
 	}
-	defer listener.Close()
-	if onReady != nil {
-		onReady()
+	closeFn := func() {
+		_ = session.Close()
+		_ = conn.Close()
 	}
-	close(active.Ready)
+	return session, closeFn, nil
+}
 
-	go func() {
-		<-ctx.Done()
-		_ = listener.Close()
-		_ = session.Close()
-	}()
+// superviseGateway waits for the gateway session to drop and re-dials it with
+// exponential backoff while the local listener keeps accepting. It returns
+// nil when ctx is cancelled and an error only when the gateway rejects us.
+func superviseGateway(
+	ctx context.Context,
+	active *ActiveTunnel,
+	holder *sessionHolder,
+	session *yamux.Session,
+	closeSession func(),
+	onStatus func(status, lastError string),
+) error {
+	for {
+		select {
+		case <-ctx.Done():
+			closeSession()
+			return nil
+		case <-session.CloseChan():
+		}
+		active.debug("gateway session closed with %d active stream(s)", active.ActiveStreams.Load())
+		closeSession()
+		holder.set(nil)
 
-	var wg sync.WaitGroup
-	acceptErrCh := make(chan error, 1)
-	go func() {
-		for {
-			clientConn, err := listener.Accept()
+		for attempt := 1; ; attempt++ {
+			delay := reconnectDelay(attempt)
+			if onStatus != nil {
+				onStatus("reconnecting", "gateway connection lost")
+			}
+			active.emit("reconnecting", "attempt %d in %s", attempt, delay)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+
+			next, closeNext, err := dialGateway(ctx, active.Req)
 			if err != nil {
-				if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
-					acceptErrCh <- nil
-					return
+				var rejected *gatewayRejectedError
+				if errors.As(err, &rejected) {
+					return err
 				}
-				acceptErrCh <- err
-				return
-			}
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				if err := proxyTunnelConnection(ctx, active, session, target, clientConn); err != nil {
-					log.Printf("Tunnel proxy error for %s: %v", req.ID, err)
+				if ctx.Err() != nil {
+					return nil
 				}
-			}()
+				if onStatus != nil {
+					onStatus("reconnecting", err.Error())
+				}
+				active.emit("reconnect-failed", "%v", err)
+				continue
+			}
+			session, closeSession = next, closeNext
+			holder.set(session)
+			active.Reconnects.Add(1)
+			if onStatus != nil {
+				onStatus("active", "")
+			}
+			active.emit("reconnected", "after %d attempt(s)", attempt)
+			break
 		}
-	}()
+	}
+}
 
-	select {
-	case <-ctx.Done():
-	case err := <-acceptErrCh:
-		if err != nil {
-			return err
-		}
+// reconnectDelay doubles from one second up to reconnectMaxDelay.
+func reconnectDelay(attempt int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempt && delay < reconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > reconnectMaxDelay {
+		delay = reconnectMaxDelay
 	}
+	return delay
+}
 
-	wg.Wait()
-	return ctx.Err()
+// sessionHolder publishes the current gateway session to connection handlers
+// and lets them wait briefly while a reconnect is in progress.
+type sessionHolder struct {
+	mu      sync.Mutex
+	session *yamux.Session
+	changed chan struct{}
+}
+
+func newSessionHolder() *sessionHolder {
+	return &sessionHolder{changed: make(chan struct{})}
+}
+
+func (h *sessionHolder) set(session *yamux.Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.session = session
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+func (h *sessionHolder) wait(ctx context.Context, timeout time.Duration) (*yamux.Session, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		h.mu.Lock()
+		session, changed := h.session, h.changed
+		h.mu.Unlock()
+		if session != nil && !session.IsClosed() {
+			return session, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, errors.New("tunnel gateway is reconnecting; connection dropped")
+		}
+	}
 }
 
 func proxyTunnelConnection(
 	ctx context.Context,
 	active *ActiveTunnel,
-	session *yamux.Session,
+	holder *sessionHolder,
 	target TunnelTarget,
 	clientConn net.Conn,
 ) error {
 	defer clientConn.Close()
+	local := &stallWriter{conn: clientConn}
 	streamNumber := active.StreamsOpened.Add(1)
 	active.ActiveStreams.Add(1)
-	log.Printf(
-		"[tunnel] stream-open %s#%d | %s -> %s",
-		active.Req.ID,
+	active.emit(
+		"stream-open",
+		"#%d %s -> %s",
 		streamNumber,
 		clientConn.RemoteAddr().String(),
 		describeTarget(active.Req),
 	)
 	defer func() {
 		active.ActiveStreams.Add(-1)
-		log.Printf(
-			"[tunnel] stream-close %s#%d | active=%d sent=%dB recv=%dB",
-			active.Req.ID,
+		if local.stalled.Load() {
+			active.StreamsStalled.Add(1)
+		}
+		active.emit(
+			"stream-close",
+			"#%d active=%d sent=%dB recv=%dB%s",
 			streamNumber,
 			active.ActiveStreams.Load(),
 			active.BytesSent.Load(),
 			active.BytesReceived.Load(),
+			local.closeReason(),
 		)
 	}()
 
+	session, err := holder.wait(ctx, reconnectWaitTimeout)
+	if err != nil {
+		return err
+	}
 	stream, err := session.OpenStream()
 	if err != nil {
 		return err
 	}
 	defer stream.Close()
+	active.debug("#%d opened gateway stream %d for target %s", streamNumber, stream.StreamID(), target.TargetID)
 
 	header, err := json.Marshal(tunnelStreamConnectRequest{
 		Type:     "connect",
@@ -505,32 +1117,35 @@ func proxyTunnelConnection(
 		}
 		return fmt.Errorf("tunnel stream rejected: %s", message)
 	}
+	active.debug("#%d gateway connected the stream", streamNumber)
 
 	copyCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	var wg sync.WaitGroup
+	var sent, received int64
 	wg.Add(2)
-	go func() {
+	active.spawn(func() {
 		defer wg.Done()
-		n, _ := io.Copy(stream, clientConn)
-		if n > 0 {
-			active.BytesSent.Add(uint64(n))
+		sent, _ = copyAdaptive(stream, clientConn, &active.bufferBytes)
+		if sent > 0 {
+			active.BytesSent.Add(uint64(sent))
 		}
 		cancel()
-	}()
-	go func() {
+	})
+	active.spawn(func() {
 		defer wg.Done()
-		n, _ := io.Copy(clientConn, reader)
-		if n > 0 {
-			active.BytesReceived.Add(uint64(n))
+		received, _ = copyAdaptive(local, reader, &active.bufferBytes)
+		if received > 0 {
+			active.BytesReceived.Add(uint64(received))
 		}
 		cancel()
-	}()
+	})
 	<-copyCtx.Done()
 	_ = clientConn.Close()
 	_ = stream.Close()
 	wg.Wait()
+	active.debug("#%d finished: sent=%dB recv=%dB", streamNumber, sent, received)
 	return nil
 }
 
@@ -571,6 +1186,17 @@ func describeTarget(req TunnelRequest) string {
 	return fmt.Sprintf("%s:%d", host, target.TargetPort)
 }
 
+// describeRoute shows which way traffic flows, for log events.
+func describeRoute(req TunnelRequest) string {
+	if req.Direction == directionReverse {
+		return fmt.Sprintf("%s -> localhost:%d", describeTarget(req), req.LocalPort)
+	}
+	if req.BindAddress != "" {
+		return fmt.Sprintf("%s -> %s", req.localAddress(), describeTarget(req))
+	}
+	return fmt.Sprintf("localhost:%d -> %s", req.LocalPort, describeTarget(req))
+}
+
 func bytesTrimSpace(raw []byte) []byte {
 	return []byte(strings.TrimSpace(string(raw)))
 }
@@ -0,0 +1,928 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"hubfly-cli/internal/testsupport"
+)
+
+// TestMain points HOME at a scratch directory, since stopping a tunnel
+// rewrites the persisted tunnel list in ~/.hubfly.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "hubfly-service-test")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv("HOME", home)
+	_ = os.Setenv("USERPROFILE", home)
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func newTestTunnel(gw *testsupport.Gateway, port int) *ActiveTunnel {
+	gw.AddTunnel("tun_1", "connect-token", "target-1")
+	return &ActiveTunnel{
+		Req: TunnelRequest{
+			ID:              "tun_1",
+			ConnectURL:      gw.URL,
+			ConnectToken:    "connect-token",
+			ProtocolVersion: 1,
+			LocalPort:       port,
+			TargetPort:      5432,
+			Targets:         []TunnelTarget{{TargetID: "target-1", ContainerName: "db", TargetPort: 5432}},
+		},
+		Done:   make(chan struct{}),
+		Ready:  make(chan struct{}),
+		events: newBroker(),
+	}
+}
+
+func TestServeTunnelGatewayReconnectsAfterDrop(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	active := newTestTunnel(gw, port)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statuses := make(chan string, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveTunnelGateway(ctx, active, active.Req.Targets[0], nil, func(status, _ string) {
+			statuses <- status
+		})
+	}()
+
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "before drop")
+
+	gw.DropSessions()
+	waitForStatus(t, statuses, "reconnecting")
+	waitForStatus(t, statuses, "active")
+
+	// The listener stayed up, so new connections use the new session.
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "after reconnect")
+	if got := active.Reconnects.Load(); got != 1 {
+		t.Fatalf("Reconnects = %d, want 1", got)
+	}
+	if got := gw.Sessions(); got != 2 {
+		t.Fatalf("gateway sessions = %d, want 2", got)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil && err != context.Canceled {
+			t.Fatalf("serveTunnelGateway returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveTunnelGateway did not stop after cancel")
+	}
+}
+
+func TestServeTunnelGatewayStopsWhenRejectedOnReconnect(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	active := newTestTunnel(gw, port)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- serveTunnelGateway(context.Background(), active, active.Req.Targets[0], nil, nil)
+	}()
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "ok")
+
+	gw.Reject("tunnel revoked")
+	gw.DropSessions()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "tunnel revoked") {
+			t.Fatalf("err = %v, want rejection", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("serveTunnelGateway kept reconnecting after a rejection")
+	}
+}
+
+func TestServeReverseGatewayProxiesToLocalPort(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	active := newTestTunnel(gw, testsupport.EchoServer(t))
+	active.Req.Direction = directionReverse
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statuses := make(chan string, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveReverseGateway(ctx, active, active.Req.Targets[0], nil, func(status, _ string) {
+			statuses <- status
+		})
+	}()
+
+	conn, err := gw.OpenReverse("tun_1", "target-1")
+	if err != nil {
+		t.Fatalf("OpenReverse: %v", err)
+	}
+	testsupport.Echo(t, conn, "webhook payload")
+	_ = conn.Close()
+
+	gw.DropSessions()
+	waitForStatus(t, statuses, "active")
+	conn, err = gw.OpenReverse("tun_1", "target-1")
+	if err != nil {
+		t.Fatalf("OpenReverse after reconnect: %v", err)
+	}
+	testsupport.Echo(t, conn, "after reconnect")
+	_ = conn.Close()
+	if got := active.StreamsOpened.Load(); got != 2 {
+		t.Fatalf("StreamsOpened = %d, want 2", got)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil && err != context.Canceled {
+			t.Fatalf("serveReverseGateway returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveReverseGateway did not stop after cancel")
+	}
+}
+
+func waitForStatus(t *testing.T, statuses <-chan string, want string) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case got := <-statuses:
+			if got == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("status %q never reported", want)
+		}
+	}
+}
+
+func TestActivatedListenerIsReusedAcrossTunnels(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	tcp, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	socketActivation = &activation{tunnels: map[int]*reusableListener{
+		port: {TCPListener: tcp.(*net.TCPListener), activated: true},
+	}}
+	t.Cleanup(func() {
+		socketActivation.close()
+		socketActivation = nil
+	})
+
+	// The port is held by the activated socket, so each run must adopt it
+	// rather than bind, and stopping a run must leave it usable.
+	for run := 0; run < 2; run++ {
+		active := newTestTunnel(gw, port)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- serveTunnelGateway(ctx, active, active.Req.Targets[0], nil, nil) }()
+		testsupport.Echo(t, testsupport.DialLocal(t, port), fmt.Sprintf("run %d", run))
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("run %d did not stop", run)
+		}
+	}
+}
+
+func TestWatchIdleWaitsForTunnelsAndRequests(t *testing.T) {
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	m.tunnels["busy"] = &ActiveTunnel{}
+	release := make(chan struct{})
+	go func() {
+		m.activity.track(func(http.ResponseWriter, *http.Request) { <-release })(nil, nil)
+	}()
+
+	shutdown := make(chan struct{})
+	go m.watchIdle(200*time.Millisecond, func() { close(shutdown) })
+
+	select {
+	case <-shutdown:
+		t.Fatal("shut down while a tunnel was active")
+	case <-time.After(500 * time.Millisecond):
+	}
+	m.mu.Lock()
+	delete(m.tunnels, "busy")
+	m.mu.Unlock()
+	select {
+	case <-shutdown:
+		t.Fatal("shut down while a request was running")
+	case <-time.After(500 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-shutdown:
+	case <-time.After(2 * time.Second):
+		t.Fatal("service did not shut down once idle")
+	}
+	if _, err := m.startTunnel(TunnelRequest{ID: "late"}); !errors.Is(err, errServiceClosing) {
+		t.Fatalf("startTunnel after shutdown = %v, want errServiceClosing", err)
+	}
+}
+
+func TestLogLevelChangesAtRuntime(t *testing.T) {
+	t.Cleanup(func() { debugLogging.Store(false) })
+	active := &ActiveTunnel{Req: TunnelRequest{ID: "tun_1"}, events: newBroker()}
+	sub, _ := active.events.subscribe("")
+
+	active.debug("hidden at info")
+	rec := httptest.NewRecorder()
+	handleLogLevel(rec, httptest.NewRequest(http.MethodPost, "/log-level", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"debug"`) {
+		t.Fatalf("POST /log-level = %d %s", rec.Code, rec.Body)
+	}
+	active.debug("shown at debug")
+	select {
+	case ev := <-sub.ch:
+		if ev.Type != "debug" || ev.Message != "shown at debug" {
+			t.Fatalf("event = %+v", ev)
+		}
+	default:
+		t.Fatal("no debug event after switching to debug")
+	}
+
+	toggleLogLevel()
+	if LogLevel() != LogLevelInfo {
+		t.Errorf("LogLevel after toggle = %s", LogLevel())
+	}
+	rec = httptest.NewRecorder()
+	handleLogLevel(rec, httptest.NewRequest(http.MethodPost, "/log-level", strings.NewReader(`{"level":"trace"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown level accepted: %d", rec.Code)
+	}
+}
+
+func TestControlChannelPushesLifecycleEvents(t *testing.T) {
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	m.tunnels["tun_1"] = &ActiveTunnel{Req: TunnelRequest{ID: "tun_1", LocalPort: 15432}, Status: "active"}
+	server := httptest.NewServer(http.HandlerFunc(m.handleControl))
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var status controlStatus
+	if err := websocket.JSON.Receive(conn, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Type != "status" || len(status.Tunnels) != 1 || status.Tunnels[0].LocalPort != 15432 {
+		t.Fatalf("first frame = %+v", status)
+	}
+
+	m.events.publish(Event{TunnelID: "tun_1", Type: "stats"})
+	m.events.publish(Event{TunnelID: "tun_1", Type: "reconnecting", Message: "attempt 1 in 1s"})
+	var ev controlEvent
+	if err := websocket.JSON.Receive(conn, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != "event" || ev.Event.Type != "reconnecting" || ev.Event.Message != "attempt 1 in 1s" {
+		t.Fatalf("event frame = %+v", ev)
+	}
+}
+
+func TestShutdownDrainsInFlightConnections(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	active, err := m.startTunnel(newTestTunnel(gw, port).Req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-active.Ready
+
+	inFlight := testsupport.DialLocal(t, port)
+	testsupport.Echo(t, inFlight, "before shutdown")
+
+	done := make(chan error, 1)
+	go func() { done <- m.shutdown(5 * time.Second) }()
+
+	// New connections are refused while the open one keeps working.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("listener still accepting after shutdown started")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	testsupport.Echo(t, inFlight, "while draining")
+	if _, err := m.startTunnel(TunnelRequest{ID: "late"}); !errors.Is(err, errServiceClosing) {
+		t.Fatalf("startTunnel during shutdown = %v, want errServiceClosing", err)
+	}
+
+	inFlight.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("shutdown = %v, want nil after streams drained", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not return after the last stream closed")
+	}
+	if len(m.statuses()) != 0 {
+		t.Fatalf("tunnels left after shutdown: %+v", m.statuses())
+	}
+}
+
+func TestShutdownCutsStreamsAfterTimeout(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	active, err := m.startTunnel(newTestTunnel(gw, port).Req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-active.Ready
+	conn := testsupport.DialLocal(t, port)
+	defer conn.Close()
+	testsupport.Echo(t, conn, "stuck")
+
+	err = m.shutdown(100 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "still open") {
+		t.Fatalf("shutdown = %v, want a still-open stream error", err)
+	}
+	select {
+	case <-active.Done:
+	default:
+		t.Fatal("tunnel not closed after shutdown")
+	}
+}
+
+func TestIdleTunnelExpiresAndIsRemoved(t *testing.T) {
+	interval, retention := expiryCheckInterval, expiredRetention
+	expiryCheckInterval, expiredRetention = 20*time.Millisecond, 300*time.Millisecond
+	t.Cleanup(func() { expiryCheckInterval, expiredRetention = interval, retention })
+
+	if _, _, err := (TunnelRequest{TTL: "soon"}).limits(); err == nil {
+		t.Fatal("invalid ttl accepted")
+	}
+
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	req := newTestTunnel(gw, port).Req
+	req.IdleTimeout = "200ms"
+	active, err := m.startTunnel(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-active.Ready
+	// An open connection keeps the tunnel alive past its idle timeout.
+	conn := testsupport.DialLocal(t, port)
+	testsupport.Echo(t, conn, "busy")
+	time.Sleep(400 * time.Millisecond)
+	if s := m.statuses(); len(s) != 1 || s[0].Status == "expired" {
+		t.Fatalf("tunnel expired while in use: %+v", s)
+	}
+	conn.Close()
+
+	waitFor := func(what string, cond func([]TunnelStatus) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond(m.statuses()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: %+v", what, m.statuses())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("expired status", func(s []TunnelStatus) bool {
+		return len(s) == 1 && s[0].Status == "expired" && strings.Contains(s[0].Error, "no connections")
+	})
+	select {
+	case <-active.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expired tunnel still running")
+	}
+	waitFor("removal", func(s []TunnelStatus) bool { return len(s) == 0 })
+}
+
+func TestStartReportsWhyTheTunnelFailed(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	t.Cleanup(func() { _ = m.shutdown(0) })
+	start := func(req TunnelRequest) (int, string) {
+		t.Helper()
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		m.handleStart(rec, httptest.NewRequest(http.MethodPost, "/start", bytes.NewReader(body)))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	ok := newTestTunnel(gw, testsupport.FreePort(t)).Req
+	if code, body := start(ok); code != http.StatusOK || !strings.Contains(body, `"active"`) {
+		t.Fatalf("valid start = %d %s", code, body)
+	}
+
+	taken := ok
+	taken.ID = "tun_2"
+	if code, body := start(taken); code != http.StatusConflict || !strings.Contains(body, "already used") {
+		t.Fatalf("start on a taken port = %d %s", code, body)
+	}
+
+	unreachable := ok
+	unreachable.ID = "tun_3"
+	unreachable.LocalPort = testsupport.FreePort(t)
+	unreachable.ConnectURL = fmt.Sprintf("ws://127.0.0.1:%d/tunnel", testsupport.FreePort(t))
+	if code, body := start(unreachable); code != http.StatusBadGateway || !strings.Contains(body, "failed to connect") {
+		t.Fatalf("start with an unreachable gateway = %d %s", code, body)
+	}
+
+	gw.Reject("connect token expired")
+	rejected := ok
+	rejected.ID = "tun_4"
+	rejected.LocalPort = testsupport.FreePort(t)
+	if code, body := start(rejected); code != http.StatusForbidden || !strings.Contains(body, "connect token expired") {
+		t.Fatalf("start with a rejected token = %d %s", code, body)
+	}
+}
+
+func TestRestartAndUpdateKeepTheLocalSocket(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	t.Cleanup(func() { _ = m.shutdown(0) })
+	req := newTestTunnel(gw, port).Req
+	gw.AddTunnel("tun_1", "connect-token", "target-1", "target-2")
+	if err := validateTunnelRequest(&req); err != nil {
+		t.Fatal(err)
+	}
+	first, err := m.startTunnel(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-first.Ready
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "before restart")
+
+	call := func(method, path, body string) (int, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/tunnels/") {
+			r.SetPathValue("id", strings.TrimPrefix(path, "/tunnels/"))
+			m.handleUpdateTunnel(rec, r)
+		} else {
+			m.handleRestart(rec, r)
+		}
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	if code, body := call(http.MethodPost, "/restart", `{"id":"tun_1"}`); code != http.StatusOK {
+		t.Fatalf("restart = %d %s", code, body)
+	}
+	second := m.tunnels["tun_1"]
+	if second == first || second.listener != first.listener {
+		t.Fatal("restart did not hand the local socket to the new run")
+	}
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "after restart")
+
+	update := `{"target_port": 6379, "targets": [{"target_id": "target-2", "container_name": "cache", "target_port": 6379}]}`
+	if code, body := call(http.MethodPut, "/tunnels/tun_1", update); code != http.StatusOK {
+		t.Fatalf("update = %d %s", code, body)
+	}
+	if s := m.statuses(); len(s) != 1 || s[0].Target != "cache:6379" || s[0].Status != "active" {
+		t.Fatalf("status after update = %+v", s)
+	}
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "after update")
+	if got := gw.Sessions(); got != 3 {
+		t.Errorf("gateway sessions = %d, want 3", got)
+	}
+
+	if code, _ := call(http.MethodPut, "/tunnels/tun_1", `{"direction": "reverse"}`); code != http.StatusBadRequest {
+		t.Errorf("direction change = %d, want 400", code)
+	}
+	if code, _ := call(http.MethodPut, "/tunnels/nope", `{}`); code != http.StatusNotFound {
+		t.Errorf("unknown tunnel = %d, want 404", code)
+	}
+}
+
+// scriptedReader answers each Read with the next size in sizes, or with a
+// full buffer for a size of -1, and records the buffer sizes it was offered.
+type scriptedReader struct {
+	sizes   []int
+	offered []int
+}
+
+func (r *scriptedReader) Read(p []byte) (int, error) {
+	if len(r.sizes) == 0 {
+		return 0, io.EOF
+	}
+	r.offered = append(r.offered, len(p))
+	n := r.sizes[0]
+	r.sizes = r.sizes[1:]
+	if n < 0 || n > len(p) {
+		n = len(p)
+	}
+	return n, nil
+}
+
+func TestCopyAdaptiveGrowsForBulkReadsAndShrinksForChattyOnes(t *testing.T) {
+	var sizes []int
+	for i := 0; i < 20; i++ {
+		sizes = append(sizes, -1)
+	}
+	for i := 0; i < 60; i++ {
+		sizes = append(sizes, 10)
+	}
+	src := &scriptedReader{sizes: sizes}
+	var dst bytes.Buffer
+	written, err := copyAdaptive(struct{ io.Writer }{&dst}, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(dst.Len()) {
+		t.Fatalf("written = %d, copied %d", written, dst.Len())
+	}
+
+	largest := 0
+	for _, size := range src.offered {
+		largest = max(largest, size)
+	}
+	if src.offered[0] != minCopyBuffer || largest != maxCopyBuffer {
+		t.Errorf("buffer started at %d and peaked at %d, want %d and %d", src.offered[0], largest, minCopyBuffer, maxCopyBuffer)
+	}
+	if last := src.offered[len(src.offered)-1]; last != minCopyBuffer {
+		t.Errorf("buffer ended at %d after small reads, want %d", last, minCopyBuffer)
+	}
+}
+
+// benchmarkCopy sends total bytes over loopback TCP in writes of chunk bytes
+// and copies them out with copyFn, the way a tunnel stream does.
+func benchmarkCopy(b *testing.B, chunk, total int, copyFn func(io.Writer, io.Reader) (int64, error)) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer ln.Close()
+	payload := make([]byte, chunk)
+	b.SetBytes(int64(total))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		go func() {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for sent := 0; sent < total; sent += chunk {
+				if _, err := conn.Write(payload); err != nil {
+					return
+				}
+			}
+		}()
+		conn, err := ln.Accept()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n, err := copyFn(struct{ io.Writer }{io.Discard}, conn); err != nil || n != int64(total) {
+			b.Fatalf("copied %d bytes, err %v", n, err)
+		}
+		_ = conn.Close()
+	}
+}
+
+// copyFixed copies through one 32 KiB buffer, as io.Copy from a TCP
+// connection does.
+func copyFixed(dst io.Writer, src io.Reader) (int64, error) {
+	return io.CopyBuffer(dst, struct{ io.Reader }{src}, make([]byte, 32<<10))
+}
+
+func BenchmarkTunnelCopy(b *testing.B) {
+	cases := []struct {
+		name         string
+		chunk, total int
+	}{
+		{"bulk", 256 << 10, 32 << 20},
+		{"chatty", 64, 64 << 10},
+	}
+	for _, c := range cases {
+		b.Run(c.name+"/fixed", func(b *testing.B) { benchmarkCopy(b, c.chunk, c.total, copyFixed) })
+		b.Run(c.name+"/adaptive", func(b *testing.B) {
+			benchmarkCopy(b, c.chunk, c.total, func(dst io.Writer, src io.Reader) (int64, error) {
+				return copyAdaptive(dst, src, nil)
+			})
+		})
+	}
+}
+
+func TestPersistedTunnelIsSealedAndRestored(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	req := newTestTunnel(gw, port).Req
+	req.Direction = directionForward
+
+	persistTunnel(req)
+	if _, err := os.Stat(serviceStatePath()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("tunnel without persist was written to %s", serviceStatePath())
+	}
+	req.Persist = true
+	persistTunnel(req)
+	content, err := os.ReadFile(serviceStatePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(content, []byte(req.ConnectToken)) {
+		t.Fatalf("connect token stored in the clear:\n%s", content)
+	}
+
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	t.Cleanup(func() { _ = m.shutdown(0) })
+	m.restorePersistedTunnels()
+	restored := m.tunnels["tun_1"]
+	if restored == nil {
+		t.Fatal("persisted tunnel was not restored")
+	}
+	<-restored.Ready
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "after restore")
+
+	if err := m.stopTunnel("tun_1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(serviceStatePath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("stopped tunnel is still in %s", serviceStatePath())
+	}
+}
+
+func TestStartByTicketReference(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	gw := testsupport.NewGateway(t)
+	gw.AddTunnel("tun_1", "connect-token", "target-1")
+	gw.AddTunnel("tun_2", "other-token", "target-1")
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	t.Cleanup(func() { _ = m.shutdown(0) })
+
+	stored := fmt.Sprintf(`{"tunnelId": "tun_1", "connectUrl": %q, "connectToken": "connect-token", "protocolVersion": 1,
+		"targetPort": 5432, "targets": [{"targetId": "target-1", "containerName": "db", "targetPort": 5432}]}`, gw.URL)
+	if err := os.MkdirAll(ticketsDir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ticketsDir(), "tun_1.json"), []byte(stored), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded := strings.ReplaceAll(strings.ReplaceAll(stored, "tun_1", "tun_2"), "connect-token", "other-token")
+	rec := httptest.NewRecorder()
+	m.handleTickets(rec, httptest.NewRequest(http.MethodPost, "/tickets", strings.NewReader(loaded)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /tickets = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	m.handleTickets(rec, httptest.NewRequest(http.MethodGet, "/tickets", nil))
+	if body := rec.Body.String(); strings.Contains(body, "token") || !strings.Contains(body, `"source":"stored"`) || !strings.Contains(body, `"source":"loaded"`) {
+		t.Fatalf("GET /tickets = %s", body)
+	}
+
+	for _, ticketID := range []string{"tun_1", "tun_2"} {
+		port := testsupport.FreePort(t)
+		rec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"ticket_id": %q, "local_port": %d}`, ticketID, port)
+		m.handleStart(rec, httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("start %s = %d %s", ticketID, rec.Code, rec.Body)
+		}
+		testsupport.Echo(t, testsupport.DialLocal(t, port), "by reference")
+	}
+
+	rec = httptest.NewRecorder()
+	m.handleStart(rec, httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(`{"ticket_id": "tun_9", "local_port": 1}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown ticket = %d, want 404", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	m.handleStart(rec, httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(`{"ticket_id": "../config", "local_port": 1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("path in ticket_id = %d, want 400", rec.Code)
+	}
+}
+
+func TestResourceAccountingAndLeakWarning(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	m := &manager{tunnels: make(map[string]*ActiveTunnel), events: newBroker(), activity: newIdleTracker()}
+	t.Cleanup(func() { _ = m.shutdown(0) })
+	active, err := m.startTunnel(newTestTunnel(gw, port).Req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-active.Ready
+
+	idle := active.goroutines.Load()
+	conn := testsupport.DialLocal(t, port)
+	testsupport.Echo(t, conn, "counted")
+	rec := httptest.NewRecorder()
+	m.handleStatus(rec, httptest.NewRequest(http.MethodGet, "/status?verbose=1", nil))
+	var statuses []TunnelStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil || len(statuses) != 1 || statuses[0].Resources == nil {
+		t.Fatalf("verbose status = %s", rec.Body)
+	}
+	if r := statuses[0].Resources; r.Goroutines != idle+goroutinesPerStream || r.BufferBytes != 2*minCopyBuffer {
+		t.Errorf("resources with one connection = %+v, idle goroutines %d", r, idle)
+	}
+
+	_ = conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for active.goroutines.Load() != idle || active.bufferBytes.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("after close: %d goroutines (idle %d), %d buffer bytes", active.goroutines.Load(), idle, active.bufferBytes.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	for i := 0; i < 20; i++ {
+		active.spawn(func() { <-release })
+	}
+	resourceCheckInterval = 10 * time.Millisecond
+	defer func() { resourceCheckInterval = 30 * time.Second }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.watchResources(ctx)
+	for m.metrics().Tunnels[0].Warning == "" {
+		if time.Now().After(deadline.Add(2 * time.Second)) {
+			t.Fatal("no leak warning for 20 stuck goroutines")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBindAddressNeedsOptInBeyondLoopback(t *testing.T) {
+	base := newTestTunnel(testsupport.NewGateway(t), 15432).Req
+	cases := []struct {
+		bind      string
+		allowLAN  bool
+		direction string
+		wantErr   string
+	}{
+		{bind: ""},
+		{bind: "127.0.0.1"},
+		{bind: "::1"},
+		{bind: "0.0.0.0", wantErr: "set allow_lan"},
+		{bind: "192.168.1.20", wantErr: "set allow_lan"},
+		{bind: "0.0.0.0", allowLAN: true},
+		{bind: "localhost", wantErr: "Invalid bind_address"},
+		{bind: "127.0.0.1", direction: directionReverse, wantErr: "only applies to forward"},
+	}
+	for _, c := range cases {
+		req := base
+		req.BindAddress, req.AllowLAN, req.Direction = c.bind, c.allowLAN, c.direction
+		err := validateTunnelRequest(&req)
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Errorf("bind %q allow_lan=%v: %v", c.bind, c.allowLAN, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Errorf("bind %q allow_lan=%v: error %v, want %q", c.bind, c.allowLAN, err, c.wantErr)
+		}
+	}
+	if got := base.localAddress(); got != "127.0.0.1:15432" {
+		t.Errorf("default local address = %s", got)
+	}
+}
+
+func TestStalledLocalClientIsClosed(t *testing.T) {
+	stallTimeout = 200 * time.Millisecond
+	defer func() { stallTimeout = time.Minute }()
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	active := newTestTunnel(gw, port)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = serveTunnelGateway(ctx, active, active.Req.Targets[0], nil, nil) }()
+	<-active.Ready
+
+	// The client sends far more than the socket buffers hold and never reads
+	// the echo, so the tunnel's writes to it block.
+	conn := testsupport.DialLocal(t, port)
+	_ = conn.(*net.TCPConn).SetReadBuffer(4 << 10)
+	go func() {
+		chunk := make([]byte, 64<<10)
+		for i := 0; i < 1024; i++ {
+			if _, err := conn.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for active.StreamsStalled.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stalled connection was not closed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := active.ActiveStreams.Load(); n != 0 {
+		t.Errorf("active streams after stall = %d", n)
+	}
+}
+
+func TestConnectionLimits(t *testing.T) {
+	gw := testsupport.NewGateway(t)
+	port := testsupport.FreePort(t)
+	active := newTestTunnel(gw, port)
+	active.Req.MaxConnections = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = serveTunnelGateway(ctx, active, active.Req.Targets[0], nil, nil) }()
+	<-active.Ready
+
+	first := testsupport.DialLocal(t, port)
+	testsupport.Echo(t, first, "first")
+
+	second := testsupport.DialLocal(t, port)
+	_ = second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := second.Read(make([]byte, 1)); err == nil {
+		t.Fatal("second connection was served past max_connections")
+	}
+	if n := active.StreamsRejected.Load(); n != 1 {
+		t.Errorf("streams rejected = %d, want 1", n)
+	}
+
+	_ = first.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for active.ActiveStreams.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("first connection was not released")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	testsupport.Echo(t, testsupport.DialLocal(t, port), "third")
+
+	limiter := newConnectionLimiter(2)
+	now := time.Now()
+	if !limiter.allow(now) || !limiter.allow(now) || limiter.allow(now) {
+		t.Error("limiter did not stop at its burst")
+	}
+	if !limiter.allow(now.Add(500 * time.Millisecond)) {
+		t.Error("limiter did not refill")
+	}
+	if !newConnectionLimiter(0).allow(now) {
+		t.Error("a rate of 0 should not limit")
+	}
+}
+
+func TestQueryTokenOnlyOnStreams(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+		header  string
+		want    int
+	}{
+		{"header", requireToken("secret", ok), "/stop", "Bearer secret", http.StatusOK},
+		{"query on a command", requireToken("secret", ok), "/stop?token=secret", "", http.StatusUnauthorized},
+		{"query on a stream", requireTokenOrQuery("secret", ok), "/logs?token=secret", "", http.StatusOK},
+		{"wrong query on a stream", requireTokenOrQuery("secret", ok), "/logs?token=nope", "", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		tc.handler(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// tunnelDrainTimeout bounds how long shutdown waits for in-flight streams
+	// to finish on their own before cutting them.
+	tunnelDrainTimeout = 10 * time.Second
+	// serverShutdownTimeout bounds how long the control API waits for open
+	// requests once the tunnels are gone.
+	serverShutdownTimeout = 5 * time.Second
+)
+
+// drainPollInterval is how often shutdown checks for open streams.
+const drainPollInterval = 50 * time.Millisecond
+
+// stopAccepting closes a forward tunnel's local listener so it takes no new
+// connections, while the ones already proxied keep running.
+func (t *ActiveTunnel) stopAccepting() {
+	t.drainOnce.Do(func() {
+		if t.drain != nil {
+			close(t.drain)
+		}
+	})
+}
+
+// drained reports whether t has no open streams left.
+func (t *ActiveTunnel) drained() bool {
+	select {
+	case <-t.Done:
+		return true
+	default:
+	}
+	return t.ActiveStreams.Load() == 0
+}
+
+// shutdown refuses new tunnels, stops every tunnel from accepting
+// connections, gives in-flight streams up to timeout to finish and then
+// closes all tunnels. Tunnels that still had open streams are reported in the
+// returned error. Persisted tunnels are kept so a socket-activated restart
+// restores them.
+func (m *manager) shutdown(timeout time.Duration) error {
+	m.mu.Lock()
+	m.closing = true
+	tunnels := make([]*ActiveTunnel, 0, len(m.tunnels))
+	for _, t := range m.tunnels {
+		tunnels = append(tunnels, t)
+	}
+	m.mu.Unlock()
+
+	for _, t := range tunnels {
+		t.stopAccepting()
+	}
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for time.Now().Before(deadline) && !allDrained(tunnels) {
+		<-ticker.C
+	}
+
+	var errs []error
+	for _, t := range tunnels {
+		if open := t.ActiveStreams.Load(); !t.drained() {
+			errs = append(errs, fmt.Errorf("tunnel %s: closed %d stream(s) still open after %s", t.Req.ID, open, timeout))
+		}
+		if t.Cancel != nil {
+			t.Cancel()
+		}
+		<-t.Done
+		t.emit(
+			"stopped",
+			"streams=%d active=%d sent=%dB recv=%dB",
+			t.StreamsOpened.Load(),
+			t.ActiveStreams.Load(),
+			t.BytesSent.Load(),
+			t.BytesReceived.Load(),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+func allDrained(tunnels []*ActiveTunnel) bool {
+	for _, t := range tunnels {
+		if !t.drained() {
+			return false
+		}
+	}
+	return true
+}
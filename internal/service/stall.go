@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/yamux"
+)
+
+// stallTimeout is how long a write to a local connection may block before
+// the connection is closed as stalled. Until then the gateway can have at
+// most streamWindowSize of the stream's data waiting on this side.
+var stallTimeout = time.Minute
+
+// streamWindowSize caps the unread data yamux buffers per stream, and so how
+// much a client that stops reading can make the gateway hold for it.
+const streamWindowSize = 256 << 10
+
+var errStalled = errors.New("local connection stopped reading")
+
+func tunnelYamuxConfig() *yamux.Config {
+	config := yamux.DefaultConfig()
+	config.MaxStreamWindowSize = streamWindowSize
+	return config
+}
+
+// stallWriter writes to a local connection with a fresh deadline for each
+// write, and remembers whether one ran out.
+type stallWriter struct {
+	conn    net.Conn
+	stalled atomic.Bool
+}
+
+func (w *stallWriter) Write(p []byte) (int, error) {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(stallTimeout))
+	n, err := w.conn.Write(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		w.stalled.Store(true)
+		return n, errStalled
+	}
+	return n, err
+}
+
+// closeReason is appended to a stream-close event.
+func (w *stallWriter) closeReason() string {
+	if w.stalled.Load() {
+		return " closed due to stall"
+	}
+	return ""
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// serviceStatePath lists the tunnels the service restores when it starts:
+// those started with "persist": true, and under socket activation every
+// tunnel. Connect tokens in it are sealed with the key at serviceStateKeyPath,
+// so the file alone does not grant access to a tunnel.
+func serviceStatePath() string {
+	return filepath.Join(filepath.Dir(InfoPath()), "service-state.json")
+}
+
+func serviceStateKeyPath() string {
+	return filepath.Join(filepath.Dir(InfoPath()), "service-state.key")
+}
+
+// legacyStatePath is where socket-activated services kept their tunnels,
+// with connect tokens in the clear. It is read until the first write to
+// serviceStatePath, which removes it.
+func legacyStatePath() string {
+	return filepath.Join(filepath.Dir(InfoPath()), "service-tunnels.json")
+}
+
+type serviceState struct {
+	Tunnels []persistedTunnel `json:"tunnels"`
+}
+
+type persistedTunnel struct {
+	TunnelRequest
+	// ConnectToken hides the embedded request's token from JSON; only the
+	// sealed copy is written.
+	ConnectToken       string `json:"connect_token,omitempty"`
+	SealedConnectToken string `json:"sealed_connect_token"`
+}
+
+var persistMu sync.Mutex
+
+// shouldPersist reports whether req is restored after a service restart.
+func shouldPersist(req TunnelRequest) bool {
+	return req.Persist || socketActivation != nil
+}
+
+// loadPersistedTunnels returns the tunnels to restore with their connect
+// tokens unsealed. Entries that cannot be unsealed, for example because the
+// key file was removed, are logged and skipped.
+func loadPersistedTunnels() []TunnelRequest {
+	content, err := os.ReadFile(serviceStatePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return loadLegacyTunnels()
+	}
+	if err != nil {
+		log.Printf("Could not read %s: %v", serviceStatePath(), err)
+		return nil
+	}
+	var state serviceState
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Printf("Ignoring %s: %v", serviceStatePath(), err)
+		return nil
+	}
+	if len(state.Tunnels) == 0 {
+		return nil
+	}
+	key, err := loadStateKey(false)
+	if err != nil {
+		log.Printf("Cannot unseal persisted tunnels: %v", err)
+		return nil
+	}
+	reqs := make([]TunnelRequest, 0, len(state.Tunnels))
+	for _, t := range state.Tunnels {
+		token, err := openConnectToken(key, t.ID, t.SealedConnectToken)
+		if err != nil {
+			log.Printf("Dropping persisted tunnel %s: %v", t.ID, err)
+			continue
+		}
+		req := t.TunnelRequest
+		req.ConnectToken = token
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+func loadLegacyTunnels() []TunnelRequest {
+	content, err := os.ReadFile(legacyStatePath())
+	if err != nil {
+		return nil
+	}
+	var reqs []TunnelRequest
+	if err := json.Unmarshal(content, &reqs); err != nil {
+		return nil
+	}
+	return reqs
+}
+
+func writePersistedTunnels(reqs []TunnelRequest) error {
+	if err := os.Remove(legacyStatePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(reqs) == 0 {
+		err := os.Remove(serviceStatePath())
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(serviceStatePath()), 0o700); err != nil {
+		return err
+	}
+	key, err := loadStateKey(true)
+	if err != nil {
+		return err
+	}
+	state := serviceState{Tunnels: make([]persistedTunnel, 0, len(reqs))}
+	for _, req := range reqs {
+		sealed, err := sealConnectToken(key, req.ID, req.ConnectToken)
+		if err != nil {
+			return err
+		}
+		state.Tunnels = append(state.Tunnels, persistedTunnel{TunnelRequest: req, SealedConnectToken: sealed})
+	}
+	payload, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(serviceStatePath(), append(payload, '\n'), 0o600)
+}
+
+// persistTunnel records req when it should survive a restart, and drops any
+// earlier record of its ID when it should not.
+func persistTunnel(req TunnelRequest) {
+	if !shouldPersist(req) {
+		forgetTunnel(req.ID)
+		return
+	}
+	persistMu.Lock()
+	defer persistMu.Unlock()
+	reqs := loadPersistedTunnels()
+	kept := reqs[:0]
+	for _, r := range reqs {
+		if r.ID != req.ID {
+			kept = append(kept, r)
+		}
+	}
+	if err := writePersistedTunnels(append(kept, req)); err != nil {
+		log.Printf("Could not persist tunnel %s: %v", req.ID, err)
+	}
+}
+
+// forgetTunnel drops id from the persisted list once it is stopped or fails.
+func forgetTunnel(id string) {
+	persistMu.Lock()
+	defer persistMu.Unlock()
+	reqs := loadPersistedTunnels()
+	kept := reqs[:0]
+	for _, r := range reqs {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) != len(reqs) {
+		if err := writePersistedTunnels(kept); err != nil {
+			log.Printf("Could not forget tunnel %s: %v", id, err)
+		}
+	}
+}
+
+// loadStateKey reads the AES-256 key that seals persisted connect tokens,
+// generating it on first use when create is set.
+func loadStateKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(serviceStateKeyPath())
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("%s is not a 32-byte key", serviceStateKeyPath())
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, err
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(serviceStateKeyPath(), key, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// sealConnectToken encrypts token with AES-GCM, bound to the tunnel ID so a
+// sealed token cannot be moved to another entry.
+func sealConnectToken(key []byte, id, token string) (string, error) {
+	aead, err := stateCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(token), []byte(id))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openConnectToken(key []byte, id, sealed string) (string, error) {
+	aead, err := stateCipher(key)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return "", errors.New("sealed connect token is malformed")
+	}
+	token, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", errors.New("sealed connect token does not match the state key")
+	}
+	return string(token), nil
+}
+
+func stateCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
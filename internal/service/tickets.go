@@ -0,0 +1,212 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var errTicketNotFound = errors.New("ticket not found")
+
+// tunnelTicket is a tunnel ticket as the Hubfly API returns it and the CLI
+// stores it in ~/.hubfly/tunnels/<id>.json. A /start that names one with
+// ticket_id takes its connect URL and token from it, so the token does not
+// have to be sent to the service with every request.
+type tunnelTicket struct {
+	TunnelID        string         `json:"tunnelId"`
+	ConnectURL      string         `json:"connectUrl"`
+	ConnectToken    string         `json:"connectToken"`
+	ProtocolVersion int            `json:"protocolVersion"`
+	TargetPort      int            `json:"targetPort"`
+	Direction       string         `json:"direction,omitempty"`
+	ExpiresAt       string         `json:"expiresAt"`
+	Targets         []ticketTarget `json:"targets"`
+}
+
+type ticketTarget struct {
+	TargetID      string `json:"targetId"`
+	ContainerID   string `json:"containerId"`
+	ContainerName string `json:"containerName"`
+	TargetPort    int    `json:"targetPort"`
+}
+
+// ticketSummary is what GET /tickets lists; it never includes the token.
+type ticketSummary struct {
+	ID        string `json:"id"`
+	Source    string `json:"source"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+func ticketsDir() string {
+	return filepath.Join(filepath.Dir(InfoPath()), "tunnels")
+}
+
+// validTicketID accepts the file names the CLI gives tickets, so an ID can
+// never point outside ticketsDir.
+func validTicketID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, ch := range id {
+		if (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') && ch != '-' && ch != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupTicket returns a ticket loaded through POST /tickets, or else the
+// one the CLI stored on disk.
+func (m *manager) lookupTicket(id string) (tunnelTicket, error) {
+	if !validTicketID(id) {
+		return tunnelTicket{}, fmt.Errorf("Invalid ticket_id %q", id)
+	}
+	m.mu.Lock()
+	ticket, ok := m.tickets[id]
+	m.mu.Unlock()
+	if ok {
+		return ticket, nil
+	}
+	content, err := os.ReadFile(filepath.Join(ticketsDir(), id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return tunnelTicket{}, fmt.Errorf("%w: %s", errTicketNotFound, id)
+	}
+	if err != nil {
+		return tunnelTicket{}, err
+	}
+	if err := json.Unmarshal(content, &ticket); err != nil {
+		return tunnelTicket{}, fmt.Errorf("ticket %s is not valid JSON: %w", id, err)
+	}
+	return ticket, nil
+}
+
+// applyTicket fills req from the ticket it names. The ticket's connect URL,
+// token and protocol version always win. ID, targets, target port and
+// direction are only taken when req leaves them out.
+func (m *manager) applyTicket(req *TunnelRequest) error {
+	if req.TicketID == "" {
+		return nil
+	}
+	ticket, err := m.lookupTicket(req.TicketID)
+	if err != nil {
+		return err
+	}
+	req.ConnectURL = ticket.ConnectURL
+	req.ConnectToken = ticket.ConnectToken
+	req.ProtocolVersion = ticket.ProtocolVersion
+	if req.ID == "" {
+		req.ID = ticket.TunnelID
+	}
+	if req.TargetPort == 0 {
+		req.TargetPort = ticket.TargetPort
+	}
+	if req.Direction == "" {
+		req.Direction = ticket.Direction
+	}
+	if len(req.Targets) == 0 {
+		for _, t := range ticket.Targets {
+			req.Targets = append(req.Targets, TunnelTarget{
+				TargetID:      t.TargetID,
+				ContainerID:   t.ContainerID,
+				ContainerName: t.ContainerName,
+				TargetPort:    t.TargetPort,
+			})
+		}
+	}
+	return nil
+}
+
+// ticketErrorStatus maps an applyTicket error to its HTTP status.
+func ticketErrorStatus(err error) int {
+	if errors.Is(err, errTicketNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// handleTickets lists the tickets /start can reference (GET) or loads one
+// into memory for the life of the service (POST).
+func (m *manager) handleTickets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		m.listTickets(w)
+	case http.MethodPost:
+		var ticket tunnelTicket
+		if err := json.NewDecoder(r.Body).Decode(&ticket); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if !validTicketID(ticket.TunnelID) {
+			http.Error(w, fmt.Sprintf("Invalid tunnelId %q", ticket.TunnelID), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(ticket.ConnectURL) == "" || strings.TrimSpace(ticket.ConnectToken) == "" {
+			http.Error(w, "Missing required fields (connectUrl, connectToken)", http.StatusBadRequest)
+			return
+		}
+		m.mu.Lock()
+		if m.tickets == nil {
+			m.tickets = make(map[string]tunnelTicket)
+		}
+		m.tickets[ticket.TunnelID] = ticket
+		m.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "loaded", "id": ticket.TunnelID})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDeleteTicket drops a ticket loaded through POST /tickets. Tickets
+// on disk belong to the CLI and are left alone.
+func (m *manager) handleDeleteTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.PathValue("id")
+	m.mu.Lock()
+	_, ok := m.tickets[id]
+	delete(m.tickets, id)
+	m.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("%v: %s", errTicketNotFound, id), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "removed", "id": id})
+}
+
+func (m *manager) listTickets(w http.ResponseWriter) {
+	seen := make(map[string]bool)
+	summaries := []ticketSummary{}
+	m.mu.Lock()
+	for id, ticket := range m.tickets {
+		seen[id] = true
+		summaries = append(summaries, ticketSummary{ID: id, Source: "loaded", ExpiresAt: ticket.ExpiresAt})
+	}
+	m.mu.Unlock()
+
+	entries, _ := os.ReadDir(ticketsDir())
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || seen[id] || !validTicketID(id) {
+			continue
+		}
+		ticket, err := m.lookupTicket(id)
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, ticketSummary{ID: id, Source: "stored", ExpiresAt: ticket.ExpiresAt})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(summaries)
+}
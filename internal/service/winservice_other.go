@@ -0,0 +1,11 @@
+//go:build !windows
+
+package service
+
+import "time"
+
+// runAsManagedService is only needed on Windows; systemd and launchd run
+// the service as an ordinary process and stop it with SIGTERM.
+func runAsManagedService(int, time.Duration, int) (bool, error) {
+	return false, nil
+}
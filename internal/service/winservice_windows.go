@@ -0,0 +1,77 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// WindowsServiceName is the name `hubfly service install` registers the
+// service under with the service control manager.
+const WindowsServiceName = "HubflyService"
+
+// runAsManagedService runs the service under the service control manager
+// when it started this process, and reports false otherwise. Stop and
+// shutdown requests drain tunnels the same way SIGTERM does elsewhere.
+func runAsManagedService(port int, idleTimeout time.Duration, connectionRate int) (bool, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false, nil
+	}
+	// A service has no console, so log to the file `service start` uses.
+	logDir := filepath.Join(filepath.Dir(InfoPath()), "logs")
+	if err := os.MkdirAll(logDir, 0o700); err == nil {
+		if f, err := os.OpenFile(filepath.Join(logDir, "service.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600); err == nil {
+			defer func() { _ = f.Close() }()
+			log.SetOutput(f)
+		}
+	}
+	h := &windowsService{run: func(ctx context.Context) error {
+		return RunContext(ctx, port, idleTimeout, connectionRate)
+	}}
+	if err := svc.Run(WindowsServiceName, h); err != nil {
+		return true, err
+	}
+	return true, h.err
+}
+
+type windowsService struct {
+	run func(context.Context) error
+	err error
+}
+
+func (s *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.run(ctx) }()
+
+	status <- svc.Status{State: svc.StartPending}
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case s.err = <-done:
+			if s.err != nil {
+				log.Print(s.err)
+				// A non-zero exit code lets the recovery actions set by
+				// `hubfly service install` restart the service.
+				return false, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+			}
+		}
+	}
+}
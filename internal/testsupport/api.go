@@ -0,0 +1,124 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// APIRequest is a request the mock API received.
+type APIRequest struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   []byte
+}
+
+// MockAPI answers registered routes with the {ok, data} envelope used by the
+// Hubfly API and records every request. Unregistered routes get a 404 error
+// envelope.
+type MockAPI struct {
+	// URL is the base URL, suitable for the CLI's apiHost.
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []APIRequest
+}
+
+// NewMockAPI starts a mock API that is closed when the test ends.
+func NewMockAPI(t testing.TB) *MockAPI {
+	t.Helper()
+	m := &MockAPI{routes: make(map[string]http.HandlerFunc)}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serve))
+	m.URL = m.server.URL
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// Handle answers method+path with data wrapped in the success envelope.
+func (m *MockAPI) Handle(method, path string, data any) {
+	m.HandleFunc(method, path, func(w http.ResponseWriter, _ *http.Request) {
+		WriteData(w, data)
+	})
+}
+
+// HandleError answers method+path with the error envelope.
+func (m *MockAPI) HandleError(method, path string, status int, code, message string) {
+	m.HandleFunc(method, path, func(w http.ResponseWriter, _ *http.Request) {
+		WriteError(w, status, code, message)
+	})
+}
+
+// HandleFunc registers a custom handler. The request body has already been
+// read and recorded, and is available again through r.Body.
+func (m *MockAPI) HandleFunc(method, path string, fn http.HandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes[method+" "+path] = fn
+}
+
+// Requests returns the requests received so far.
+func (m *MockAPI) Requests() []APIRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]APIRequest(nil), m.requests...)
+}
+
+// LastRequest returns the most recent request for method+path.
+func (m *MockAPI) LastRequest(method, path string) (APIRequest, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.requests) - 1; i >= 0; i-- {
+		if m.requests[i].Method == method && m.requests[i].Path == path {
+			return m.requests[i], true
+		}
+	}
+	return APIRequest{}, false
+}
+
+func (m *MockAPI) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	m.mu.Lock()
+	m.requests = append(m.requests, APIRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Auth:   r.Header.Get("Authorization"),
+		Body:   body,
+	})
+	fn := m.routes[r.Method+" "+r.URL.Path]
+	m.mu.Unlock()
+
+	if fn == nil {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "no mock for "+r.Method+" "+r.URL.Path)
+		return
+	}
+	fn(w, r)
+}
+
+// WriteData writes data in the API success envelope.
+func WriteData(w http.ResponseWriter, data any) {
+	writeEnvelope(w, http.StatusOK, map[string]any{"ok": true, "data": data})
+}
+
+// WriteError writes the API error envelope.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	writeEnvelope(w, status, map[string]any{
+		"ok":    false,
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
+
+func writeEnvelope(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,243 @@
+// Package testsupport provides in-process stand-ins for the Hubfly API and
+// tunnel gateway so tunnel code can be tested end to end. It is imported only
+// from _test.go files.
+package testsupport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"golang.org/x/net/websocket"
+)
+
+// Gateway speaks the tunnel gateway protocol: a JSON authenticate handshake
+// over a websocket, then a yamux session whose streams open with a
+// {"type":"connect","targetId":...} line. Streams for a known target echo
+// whatever they receive. OpenReverse opens a stream in the other direction,
+// the way the gateway serves a reverse tunnel.
+type Gateway struct {
+	// URL is the ws:// address to use as a tunnel connectUrl.
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	tunnels  map[string]gatewayTunnel
+	reject   string
+	conns    map[*websocket.Conn]struct{}
+	live     map[string]*yamux.Session
+	sessions int
+	streams  int
+}
+
+type gatewayTunnel struct {
+	token   string
+	targets map[string]bool
+}
+
+// NewGateway starts a gateway that is closed when the test ends.
+func NewGateway(t testing.TB) *Gateway {
+	t.Helper()
+	g := &Gateway{
+		tunnels: make(map[string]gatewayTunnel),
+		conns:   make(map[*websocket.Conn]struct{}),
+		live:    make(map[string]*yamux.Session),
+	}
+	g.server = httptest.NewServer(websocket.Handler(g.serve))
+	g.URL = "ws" + strings.TrimPrefix(g.server.URL, "http") + "/tunnel"
+	t.Cleanup(g.Close)
+	return g
+}
+
+// AddTunnel registers a tunnel, its connect token and the target ids that
+// streams may connect to.
+func (g *Gateway) AddTunnel(tunnelID, token string, targetIDs ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	targets := make(map[string]bool, len(targetIDs))
+	for _, id := range targetIDs {
+		targets[id] = true
+	}
+	g.tunnels[tunnelID] = gatewayTunnel{token: token, targets: targets}
+}
+
+// Reject makes every later handshake fail with message, the way the real
+// gateway answers an expired or revoked tunnel. An empty message clears it.
+func (g *Gateway) Reject(message string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reject = message
+}
+
+// DropSessions closes every open websocket, simulating a network drop.
+func (g *Gateway) DropSessions() {
+	g.mu.Lock()
+	conns := make([]*websocket.Conn, 0, len(g.conns))
+	for conn := range g.conns {
+		conns = append(conns, conn)
+	}
+	g.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// Sessions is the number of successful handshakes so far.
+func (g *Gateway) Sessions() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.sessions
+}
+
+// Streams is the number of streams that connected to a target.
+func (g *Gateway) Streams() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.streams
+}
+
+// OpenReverse opens a stream to the client connected for tunnelID, as the
+// gateway does when something in the container connects to a reverse
+// tunnel's port. It waits up to five seconds for the client to connect and
+// returns the stream once the client answers "connected".
+func (g *Gateway) OpenReverse(tunnelID, targetID string) (net.Conn, error) {
+	var session *yamux.Session
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(25 * time.Millisecond) {
+		g.mu.Lock()
+		session = g.live[tunnelID]
+		g.mu.Unlock()
+		if session != nil && !session.IsClosed() {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("no client connected for tunnel %s", tunnelID)
+		}
+	}
+
+	stream, err := session.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(map[string]string{"type": "connect", "targetId": targetID})
+	if _, err := stream.Write(append(header, '\n')); err != nil {
+		stream.Close()
+		return nil, err
+	}
+	// Read the reply a byte at a time so no payload is buffered away from
+	// the caller.
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		if _, err := stream.Read(buf); err != nil {
+			stream.Close()
+			return nil, err
+		}
+		if buf[0] == '\n' {
+			break
+		}
+		line = append(line, buf[0])
+	}
+	var reply struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(line, &reply); err != nil || reply.Type != "connected" {
+		stream.Close()
+		return nil, fmt.Errorf("reverse stream rejected: %s", reply.Message)
+	}
+	return stream, nil
+}
+
+// Close drops all sessions and stops the server.
+func (g *Gateway) Close() {
+	g.DropSessions()
+	g.server.Close()
+}
+
+func (g *Gateway) serve(conn *websocket.Conn) {
+	defer conn.Close()
+	var auth struct {
+		Type         string `json:"type"`
+		TunnelID     string `json:"tunnelId"`
+		ConnectToken string `json:"connectToken"`
+	}
+	if err := websocket.JSON.Receive(conn, &auth); err != nil || auth.Type != "authenticate" {
+		return
+	}
+	_ = websocket.JSON.Send(conn, map[string]any{"type": "hello", "protocolVersion": 1})
+
+	g.mu.Lock()
+	tunnel, known := g.tunnels[auth.TunnelID]
+	reject := g.reject
+	g.mu.Unlock()
+	if reject == "" && (!known || tunnel.token != auth.ConnectToken) {
+		reject = "invalid tunnel credentials"
+	}
+	if reject != "" {
+		_ = websocket.JSON.Send(conn, map[string]string{"type": "error", "code": "TUNNEL_REJECTED", "message": reject})
+		return
+	}
+	if err := websocket.JSON.Send(conn, map[string]string{"type": "authenticated", "tunnelId": auth.TunnelID}); err != nil {
+		return
+	}
+
+	session, err := yamux.Server(conn, nil)
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	g.mu.Lock()
+	g.sessions++
+	g.conns[conn] = struct{}{}
+	g.live[auth.TunnelID] = session
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		delete(g.conns, conn)
+		if g.live[auth.TunnelID] == session {
+			delete(g.live, auth.TunnelID)
+		}
+		g.mu.Unlock()
+	}()
+
+	for {
+		stream, err := session.AcceptStream()
+		if err != nil {
+			return
+		}
+		go g.serveStream(stream, tunnel.targets)
+	}
+}
+
+func (g *Gateway) serveStream(stream *yamux.Stream, targets map[string]bool) {
+	defer stream.Close()
+	reader := bufio.NewReader(stream)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req struct {
+		Type     string `json:"type"`
+		TargetID string `json:"targetId"`
+	}
+	if json.Unmarshal(line, &req) != nil || req.Type != "connect" || !targets[req.TargetID] {
+		_, _ = stream.Write([]byte(`{"type":"error","code":"TARGET_NOT_FOUND","message":"unknown target"}` + "\n"))
+		return
+	}
+	if _, err := stream.Write([]byte(`{"type":"connected"}` + "\n")); err != nil {
+		return
+	}
+	g.mu.Lock()
+	g.streams++
+	g.mu.Unlock()
+	_, _ = io.Copy(stream, reader)
+}
@@ -4,6 +4,10 @@ var (
 	Version = "dev"
 	Commit  = "none"
 	Date    = "unknown"
+
+	// UpdatePublicKey is the minisign public key release checksums are
+	// signed with. When set, `hubfly update` refuses unsigned releases.
+	UpdatePublicKey = ""
 )
 
 const (
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"hubfly-cli/internal/cli"
 	"hubfly-cli/internal/service"
@@ -11,17 +14,17 @@ import (
 
 func main() {
 	args := os.Args[1:]
-	if len(args) > 0 && args[0] == "service" {
-		port := 5600
-		if len(args) >= 3 && args[1] == "--port" {
-			parsed, err := strconv.Atoi(args[2])
-			if err != nil || parsed <= 0 {
-				fmt.Fprintln(os.Stderr, "invalid service port")
-				os.Exit(1)
-			}
-			port = parsed
+	// `hubfly service [--port N] [--idle-timeout D] [--max-connection-rate R]`
+	// runs the server;
+	// subcommands such as `hubfly service status` are handled by the CLI as
+	// clients, and so is `hubfly service --help`.
+	if len(args) > 0 && args[0] == "service" && (len(args) == 1 || strings.HasPrefix(args[1], "-") && !isHelpFlag(args[1])) {
+		port, idleTimeout, connectionRate, err := parseServiceArgs(args[1:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-		if err := service.Run(port); err != nil {
+		if err := service.Run(port, idleTimeout, connectionRate); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -30,3 +33,59 @@ func main() {
 
 	os.Exit(cli.Run(args))
 }
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// parseServiceArgs reads the server flags. HUBFLY_SERVICE_IDLE_TIMEOUT and
+// HUBFLY_SERVICE_CONNECTION_RATE apply when --idle-timeout and
+// --max-connection-rate are not given.
+func parseServiceArgs(args []string) (int, time.Duration, int, error) {
+	port := 5600
+	var idleTimeout time.Duration
+	connectionRate := service.DefaultConnectionRate
+	if raw := strings.TrimSpace(os.Getenv("HUBFLY_SERVICE_IDLE_TIMEOUT")); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return 0, 0, 0, fmt.Errorf("invalid HUBFLY_SERVICE_IDLE_TIMEOUT %q", raw)
+		}
+		idleTimeout = parsed
+	}
+	if raw := strings.TrimSpace(os.Getenv("HUBFLY_SERVICE_CONNECTION_RATE")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return 0, 0, 0, fmt.Errorf("invalid HUBFLY_SERVICE_CONNECTION_RATE %q", raw)
+		}
+		connectionRate = parsed
+	}
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return 0, 0, 0, fmt.Errorf("missing value for %s", args[i])
+		}
+		switch args[i] {
+		case "--port":
+			parsed, err := strconv.Atoi(args[i+1])
+			if err != nil || parsed <= 0 {
+				return 0, 0, 0, errors.New("invalid service port")
+			}
+			port = parsed
+		case "--idle-timeout":
+			parsed, err := time.ParseDuration(args[i+1])
+			if err != nil || parsed < 0 {
+				return 0, 0, 0, errors.New("invalid idle timeout (use a duration such as 10m, or 0 to disable)")
+			}
+			idleTimeout = parsed
+		case "--max-connection-rate":
+			parsed, err := strconv.Atoi(args[i+1])
+			if err != nil || parsed < 0 {
+				return 0, 0, 0, errors.New("invalid connection rate (use new connections per second, or 0 to disable)")
+			}
+			connectionRate = parsed
+		default:
+			return 0, 0, 0, fmt.Errorf("unknown service flag: %s", args[i])
+		}
+		i++
+	}
+	return port, idleTimeout, connectionRate, nil
+}